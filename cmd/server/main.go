@@ -2,31 +2,65 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
+	"github.com/kerbatek/url-shortener/internal/assets"
+	"github.com/kerbatek/url-shortener/internal/botdetect"
+	"github.com/kerbatek/url-shortener/internal/codegen"
+	"github.com/kerbatek/url-shortener/internal/dashboard"
+	"github.com/kerbatek/url-shortener/internal/events"
+	"github.com/kerbatek/url-shortener/internal/grpcserver"
 	"github.com/kerbatek/url-shortener/internal/handler"
+	"github.com/kerbatek/url-shortener/internal/linkcheck"
 	"github.com/kerbatek/url-shortener/internal/middleware"
 	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/openapi"
+	"github.com/kerbatek/url-shortener/internal/replica"
 	"github.com/kerbatek/url-shortener/internal/repository"
 	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/kerbatek/url-shortener/internal/sso"
+	"github.com/kerbatek/url-shortener/internal/telemetry"
+	"github.com/kerbatek/url-shortener/internal/threat"
+	"github.com/kerbatek/url-shortener/internal/unfurl"
+	"github.com/kerbatek/url-shortener/internal/webhook"
+	"github.com/kerbatek/url-shortener/migrations"
+	"github.com/kerbatek/url-shortener/static"
 )
 
+// shutdownTimeout bounds how long requests in flight get to finish once
+// the server is told to stop.
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
 	log.Logger = logger
+	zerolog.DefaultContextLogger = &logger
 
-	var ctx = context.Background()
+	// ctx stops the background workers once the servers have shut down;
+	// workers waits for those that flush on the way out.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var workers sync.WaitGroup
 	var cfg model.Config
 	var err error
 
@@ -34,6 +68,10 @@ func main() {
 	if err != nil || cfg.AppPort == 0 {
 		cfg.AppPort = 8080 // default port
 	}
+	cfg.GRPCPort, err = strconv.Atoi(os.Getenv("GRPC_PORT"))
+	if err != nil || cfg.GRPCPort == 0 {
+		cfg.GRPCPort = 9090 // default gRPC port
+	}
 	cfg.DBName = os.Getenv("DB_NAME")
 	cfg.DBUser = os.Getenv("DB_USER")
 	cfg.DBPassword = os.Getenv("DB_PASSWORD")
@@ -42,19 +80,98 @@ func main() {
 	if err != nil || cfg.DBPort == 0 {
 		cfg.DBPort = 5432 // default PostgreSQL port
 	}
+	if v := os.Getenv("DB_REPLICAS"); v != "" {
+		cfg.DBReplicas = strings.Split(v, ",")
+	}
+	cfg.DBReplicaMaxLag = 5 * time.Second // default lag tolerance
+	if v := os.Getenv("DB_REPLICA_MAX_LAG"); v != "" {
+		if cfg.DBReplicaMaxLag, err = time.ParseDuration(v); err != nil {
+			logger.Fatal().Err(err).Msg("Invalid DB_REPLICA_MAX_LAG")
+		}
+	}
+	cfg.DBReplicaCheckInterval, err = time.ParseDuration(os.Getenv("DB_REPLICA_CHECK_INTERVAL"))
+	if err != nil || cfg.DBReplicaCheckInterval <= 0 {
+		cfg.DBReplicaCheckInterval = 5 * time.Second // default check interval
+	}
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, strings.TrimSpace(p))
+		}
+	}
+	if v := os.Getenv("DEFAULT_HOSTS"); v != "" {
+		for _, h := range strings.Split(v, ",") {
+			cfg.DefaultHosts = append(cfg.DefaultHosts, strings.TrimSpace(h))
+		}
+	}
+	cfg.CodeStrategy = os.Getenv("CODE_STRATEGY")
+	if cfg.CodeStrategy == "" {
+		cfg.CodeStrategy = codegen.Random // default strategy
+	}
+	if !codegen.Valid(cfg.CodeStrategy) {
+		logger.Fatal().Str("strategy", cfg.CodeStrategy).Msg("Invalid CODE_STRATEGY")
+	}
+	cfg.CodeSalt = os.Getenv("CODE_SALT")
+	cfg.CaseInsensitiveCodes, _ = strconv.ParseBool(os.Getenv("CASE_INSENSITIVE_CODES"))
+	cfg.ThreatListDir = os.Getenv("THREAT_LIST_DIR")
+	cfg.ThreatRescanInterval, err = time.ParseDuration(os.Getenv("THREAT_RESCAN_INTERVAL"))
+	if err != nil || cfg.ThreatRescanInterval <= 0 {
+		cfg.ThreatRescanInterval = time.Hour // default rescan interval
+	}
+	cfg.LinkCheckInterval = 24 * time.Hour // default recheck interval
+	if v := os.Getenv("LINK_CHECK_INTERVAL"); v != "" {
+		if cfg.LinkCheckInterval, err = time.ParseDuration(v); err != nil {
+			logger.Fatal().Err(err).Msg("Invalid LINK_CHECK_INTERVAL")
+		}
+	}
+	cfg.LinkCheckAllowPrivate, _ = strconv.ParseBool(os.Getenv("LINK_CHECK_ALLOW_PRIVATE"))
+	cfg.WebhookAllowPrivate, _ = strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE"))
+	cfg.AdminAPIKey = os.Getenv("ADMIN_API_KEY")
+	cfg.OIDCIssuer = os.Getenv("OIDC_ISSUER")
+	cfg.OIDCClientID = os.Getenv("OIDC_CLIENT_ID")
+	cfg.OIDCClientSecret = os.Getenv("OIDC_CLIENT_SECRET")
+	cfg.OIDCRedirectURL = os.Getenv("OIDC_REDIRECT_URL")
+	cfg.OIDCAudience = os.Getenv("OIDC_AUDIENCE")
+	cfg.OIDCGroupsClaim = os.Getenv("OIDC_GROUPS_CLAIM")
+	cfg.OIDCRoles = os.Getenv("OIDC_ROLES")
+	cfg.TracesExporter = os.Getenv("OTEL_TRACES_EXPORTER")
+	cfg.BotIPRangesFile = os.Getenv("BOT_IP_RANGES_FILE")
+	cfg.StaticDir = os.Getenv("STATIC_DIR")
+	cfg.MigrationsDir = os.Getenv("MIGRATIONS_DIR")
+	cfg.EventsFileDir = os.Getenv("EVENTS_FILE_DIR")
+	cfg.EventsHTTPURL = os.Getenv("EVENTS_HTTP_URL")
+	cfg.EventsHTTPAuthorization = os.Getenv("EVENTS_HTTP_AUTHORIZATION")
+	if v := os.Getenv("EVENTS_KAFKA_BROKERS"); v != "" {
+		cfg.EventsKafkaBrokers = strings.Split(v, ",")
+	}
+	cfg.EventsKafkaTopic = os.Getenv("EVENTS_KAFKA_TOPIC")
+	if cfg.EventsKafkaTopic == "" {
+		cfg.EventsKafkaTopic = "clicks" // default topic
+	}
+	cfg.EventsBuffer, err = strconv.Atoi(os.Getenv("EVENTS_BUFFER"))
+	if err != nil || cfg.EventsBuffer <= 0 {
+		cfg.EventsBuffer = 10000 // default buffer per sink
+	}
+	cfg.EventsPolicy = os.Getenv("EVENTS_POLICY")
+	switch events.Policy(cfg.EventsPolicy) {
+	case "":
+		cfg.EventsPolicy = string(events.PolicyDrop)
+	case events.PolicyDrop, events.PolicyBlock:
+	default:
+		logger.Fatal().Str("policy", cfg.EventsPolicy).Msg("Invalid EVENTS_POLICY")
+	}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-	config, err := pgxpool.ParseConfig(connStr)
+	// Logs own stdout, so the stdout exporter's spans go to stderr.
+	shutdownTracing, err := telemetry.Setup(ctx, cfg.TracesExporter, os.Stderr)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Config parse failed")
+		logger.Fatal().Err(err).Msg("Tracing setup failed")
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("Flushing traces failed")
+		}
+	}()
 
-	config.MaxConns = 20
-	config.MinConns = 5
-	config.MaxConnLifetime = time.Hour
-	config.MaxConnIdleTime = 30 * time.Minute
-
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+	pool, err := newPool(ctx, cfg, cfg.DBHost, cfg.DBPort)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Pool creation failed")
 	}
@@ -64,43 +181,337 @@ func main() {
 		logger.Warn().Err(err).Msg("Database unreachable")
 	}
 
-	if err := runMigrations(ctx, pool, logger); err != nil {
+	dbs := replica.NewSet(pool, logger)
+	dbs.MaxLag = cfg.DBReplicaMaxLag
+	dbs.Interval = cfg.DBReplicaCheckInterval
+	for _, addr := range cfg.DBReplicas {
+		host, port := strings.TrimSpace(addr), cfg.DBPort
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host = h
+			if port, err = strconv.Atoi(p); err != nil || port <= 0 {
+				logger.Fatal().Str("replica", addr).Msg("Invalid DB_REPLICAS")
+			}
+		}
+		replicaPool, err := newPool(ctx, cfg, host, port)
+		if err != nil {
+			logger.Fatal().Err(err).Str("replica", addr).Msg("Replica pool creation failed")
+		}
+		defer replicaPool.Close()
+		dbs.Add(net.JoinHostPort(host, strconv.Itoa(port)), replicaPool)
+	}
+	go dbs.Run(ctx)
+
+	var migrationFS fs.FS = migrations.Files
+	if cfg.MigrationsDir != "" {
+		migrationFS = os.DirFS(cfg.MigrationsDir)
+	}
+	if err := runMigrations(ctx, pool, migrationFS, logger); err != nil {
 		logger.Fatal().Err(err).Msg("Migration failed")
 	}
 
-	repo := repository.NewPostgresURLRepository(pool)
-	svc := service.NewURLService(repo)
+	repoOpts := []repository.URLRepositoryOption{repository.WithReplicas(dbs)}
+	if cfg.CaseInsensitiveCodes {
+		collisions, err := repository.EnableCaseInsensitiveCodes(ctx, pool)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Case-insensitive code index failed")
+		}
+		for _, c := range collisions {
+			logger.Error().Str("domain", c.Domain).Strs("codes", c.Codes).Msg("Codes differ only in case")
+		}
+		if len(collisions) > 0 {
+			logger.Fatal().Int("collisions", len(collisions)).
+				Msg("CASE_INSENSITIVE_CODES needs the colliding codes renamed or deleted")
+		}
+		repoOpts = append(repoOpts, repository.WithCaseInsensitiveCodes())
+	}
+	repo := repository.NewPostgresURLRepository(pool, repoOpts...)
+	domainRepo := repository.NewPostgresDomainRepository(pool)
+	clickRepo := repository.NewPostgresClickRepository(pool)
+	accountRepo := repository.NewPostgresAccountRepository(pool)
+	auditRepo := repository.NewPostgresAuditRepository(pool)
+	opts := []service.Option{
+		service.WithDomains(domainRepo), service.WithClicks(clickRepo), service.WithAccounts(accountRepo),
+		service.WithAudit(auditRepo), service.WithDefaultHosts(cfg.DefaultHosts...),
+		service.WithCodeStrategy(cfg.CodeStrategy, codegen.Config{
+			Salt: cfg.CodeSalt, CaseInsensitive: cfg.CaseInsensitiveCodes,
+		}),
+	}
+	domainOpts := []service.DomainOption{service.WithReservedHosts(cfg.DefaultHosts...)}
+	if len(cfg.DefaultHosts) == 0 {
+		logger.Warn().Msg("DEFAULT_HOSTS not set, the service's own host can be registered as a custom domain")
+	}
+	if cfg.LinkCheckAllowPrivate {
+		domainOpts = append(domainOpts, service.WithPrivateFallbacks())
+	}
+	var threats *threat.Watcher
+	if cfg.ThreatListDir != "" {
+		threats, err = threat.NewWatcher(cfg.ThreatListDir, logger)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Threat lists failed to load")
+		}
+		opts = append(opts, service.WithScreener(threats))
+		domainOpts = append(domainOpts, service.WithFallbackScreener(threats))
+	}
+	bots := botdetect.New()
+	if cfg.BotIPRangesFile != "" {
+		bots, err = bots.LoadRanges(cfg.BotIPRangesFile)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Bot IP ranges failed to load")
+		}
+	}
+	opts = append(opts, service.WithBotClassifier(bots))
+	previews := unfurl.NewFetcher()
+	previews.AllowPrivate = cfg.LinkCheckAllowPrivate
+	opts = append(opts, service.WithPreviewFetcher(previews))
+	var emitters events.Emitters
+	addSink := func(name string, sink events.Sink) {
+		e := events.NewEmitter(name, sink, cfg.EventsBuffer, logger)
+		e.Policy = events.Policy(cfg.EventsPolicy)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			e.Run(ctx)
+		}()
+		emitters = append(emitters, e)
+	}
+	if cfg.EventsFileDir != "" {
+		sink, err := events.NewFileSink(cfg.EventsFileDir)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Event file sink failed")
+		}
+		addSink("file", sink)
+	}
+	if cfg.EventsHTTPURL != "" {
+		sink := events.NewHTTPSink(cfg.EventsHTTPURL)
+		if cfg.EventsHTTPAuthorization != "" {
+			sink.Header.Set("Authorization", cfg.EventsHTTPAuthorization)
+		}
+		addSink("http", sink)
+	}
+	if len(cfg.EventsKafkaBrokers) > 0 {
+		addSink("kafka", events.NewKafkaSink(cfg.EventsKafkaBrokers, cfg.EventsKafkaTopic))
+	}
+	if len(emitters) > 0 {
+		opts = append(opts, service.WithEvents(emitters))
+	}
+
+	svc := service.NewURLService(repo, opts...)
+	if threats != nil {
+		rescanner := threat.NewRescanner(svc, logger)
+		rescanner.Interval = cfg.ThreatRescanInterval
+		threats.OnReload = rescanner.Trigger
+		rescanner.Trigger() // lists may have changed while we were down
+		go threats.Run(ctx)
+		go rescanner.Run(ctx)
+	}
+	var provider *sso.Provider
+	var accountOpts []service.AccountOption
+	if cfg.AdminAPIKey != "" {
+		accountOpts = append(accountOpts, service.WithAdminKey(cfg.AdminAPIKey))
+	}
+	if cfg.OIDCIssuer != "" {
+		roles, err := sso.ParseRoleMap(cfg.OIDCRoles)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid OIDC_ROLES")
+		}
+		provider, err = sso.New(ctx, sso.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Audience:     cfg.OIDCAudience,
+			GroupsClaim:  cfg.OIDCGroupsClaim,
+			Roles:        roles,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("OIDC provider failed")
+		}
+		accountOpts = append(accountOpts, service.WithTokenVerifier(provider))
+	}
+	accounts := service.NewAccountService(accountRepo, accountOpts...)
 	h := handler.NewURLHandler(svc)
-	hh := handler.NewHealthHandler(pool)
+	ah := handler.NewAccountHandler(accounts)
+	dh := handler.NewDomainHandler(service.NewDomainService(domainRepo, domainOpts...))
+	webhookRepo := repository.NewPostgresWebhookRepository(pool)
+	var webhookOpts []service.WebhookOption
+	if cfg.WebhookAllowPrivate {
+		webhookOpts = append(webhookOpts, service.WithPrivateWebhookTargets())
+	}
+	wh := handler.NewWebhookHandler(service.NewWebhookService(webhookRepo, accountRepo, webhookOpts...))
+
+	dispatcher := webhook.NewDispatcher(webhookRepo, logger)
+	dispatcher.AllowPrivate = cfg.WebhookAllowPrivate
+	go dispatcher.Run(ctx)
+	if cfg.LinkCheckInterval > 0 {
+		checker := linkcheck.NewChecker(repo, logger)
+		checker.Recheck = cfg.LinkCheckInterval
+		checker.AllowPrivate = cfg.LinkCheckAllowPrivate
+		go checker.Run(ctx)
+	}
+	hh := handler.NewHealthHandler(dbs)
+
+	spec, err := openapi.Load()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("OpenAPI spec invalid")
+	}
+	validator, err := openapi.Validator(spec)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("OpenAPI validator failed")
+	}
+
+	var staticFS fs.FS = static.Files
+	if cfg.StaticDir != "" {
+		staticFS = os.DirFS(cfg.StaticDir)
+	}
+	staticFiles, err := assets.NewServer(staticFS, cfg.StaticDir != "")
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Static files failed to load")
+	}
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// Client IPs go into the audit log and decide bot ranges, so
+	// X-Forwarded-For is only believed from the configured proxies.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+	router.Use(otelgin.Middleware(telemetry.ServiceName))
+	router.Use(middleware.RequestID(logger))
 	router.Use(middleware.Logger(logger))
 	router.Use(gin.Recovery())
+	router.Use(middleware.Errors(logger))
+	router.Use(middleware.Origin())
+	router.Use(validator)
+	router.Use(middleware.Authenticate(accounts))
+	router.GET("/openapi.json", openapi.Handler)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	router.GET("/health", hh.Liveness)
 	router.GET("/ready", hh.Readiness)
-	router.StaticFile("/", "./static/index.html")
-	router.Static("/static", "./static")
+	router.GET("/", staticFiles.File("index.html"))
+	router.HEAD("/", staticFiles.File("index.html"))
+	router.GET("/static/*filepath", staticFiles.Dir)
+	router.HEAD("/static/*filepath", staticFiles.Dir)
 	router.POST("/shorten", h.ShortenURL)
 	router.GET("/:code", h.RedirectURL)
+	router.GET("/urls", h.ListURLs)
+	router.GET("/export", h.ExportURLs)
+	router.POST("/import", h.ImportURLs)
+	router.GET("/url/:id", h.GetURL)
+	router.GET("/url/:id/stats", h.URLStats)
+	router.PATCH("/url/:id", h.UpdateURL)
 	router.DELETE("/url/:id", h.DeleteURL)
+	router.POST("/url/:id/restore", h.RestoreURL)
+	router.GET("/audit", h.Audit)
+	router.GET("/tags", h.ListTags)
+	router.GET("/tags/:name/stats", h.TagStats)
+	router.GET("/campaigns", h.ListCampaigns)
+	router.GET("/campaigns/:name/stats", h.CampaignStats)
+	router.POST("/domains", dh.RegisterDomain)
+	router.GET("/domains", dh.ListDomains)
+	router.POST("/webhooks", wh.RegisterWebhook)
+	router.GET("/webhooks", wh.ListWebhooks)
+	router.GET("/webhooks/:id/deliveries", wh.ListDeliveries)
+	router.POST("/users", ah.SignUp)
+	router.POST("/workspaces", ah.CreateWorkspace)
+	router.GET("/workspaces", ah.ListWorkspaces)
+	router.GET("/workspaces/:id/members", ah.ListMembers)
+	router.PATCH("/workspaces/:id/members/:user_id", ah.SetRole)
+	router.DELETE("/workspaces/:id/members/:user_id", ah.RemoveMember)
+	router.POST("/workspaces/:id/invites", ah.CreateInvite)
+	router.POST("/invites/accept", ah.AcceptInvite)
+
+	if cfg.AdminAPIKey != "" {
+		d := dashboard.New(svc, cfg.AdminAPIKey, logger)
+		if provider != nil && cfg.OIDCRedirectURL != "" {
+			d.SSO, d.Accounts = provider, accounts
+		} else if provider != nil {
+			logger.Warn().Msg("OIDC_REDIRECT_URL not set, dashboard single sign-on disabled")
+		}
+		d.Register(router)
+	} else {
+		logger.Warn().Msg("ADMIN_API_KEY not set, admin dashboard disabled")
+	}
+
+	grpcAddr := fmt.Sprintf(":%d", cfg.GRPCPort)
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("gRPC listen failed")
+	}
+	grpcSrv := grpcserver.New(svc, accounts, dbs, logger)
+	go func() {
+		logger.Info().Str("addr", grpcAddr).Msg("gRPC server starting")
+		if err := grpcSrv.Serve(lis); err != nil {
+			logger.Fatal().Err(err).Msg("gRPC server failed")
+		}
+	}()
 
 	addr := fmt.Sprintf(":%d", cfg.AppPort)
-	logger.Info().Str("addr", addr).Msg("Server starting")
-	if err := router.Run(addr); err != nil {
-		logger.Fatal().Err(err).Msg("Server failed")
+	srv := &http.Server{Addr: addr, Handler: router.Handler()}
+	go func() {
+		logger.Info().Str("addr", addr).Msg("Server starting")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal().Err(err).Msg("Server failed")
+		}
+	}()
+
+	// On SIGINT or SIGTERM, stop taking requests and let those in flight
+	// finish, then stop the workers so the emitters flush their buffered
+	// events; the deferred shutdownTracing flushes spans last.
+	stopped, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-stopped.Done()
+	logger.Info().Msg("Shutting down")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("HTTP shutdown failed")
+	}
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		logger.Error().Msg("gRPC shutdown timed out")
+		grpcSrv.Stop()
 	}
+
+	cancel()
+	workers.Wait()
+	logger.Info().Msg("Server stopped")
+}
+
+// newPool opens a connection pool to the database on host and port with the
+// credentials in cfg.
+func newPool(ctx context.Context, cfg model.Config, host string, port int) (*pgxpool.Pool, error) {
+	connStr := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable",
+		cfg.DBUser, cfg.DBPassword, net.JoinHostPort(host, strconv.Itoa(port)), cfg.DBName)
+	config, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	config.MaxConns = 20
+	config.MinConns = 5
+	config.MaxConnLifetime = time.Hour
+	config.MaxConnIdleTime = 30 * time.Minute
+	config.ConnConfig.Tracer = telemetry.QueryTracer{}
+
+	return pgxpool.NewWithConfig(ctx, config)
 }
 
-func runMigrations(ctx context.Context, pool *pgxpool.Pool, logger zerolog.Logger) error {
-	files, err := filepath.Glob("./migrations/*.up.sql")
+func runMigrations(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS, logger zerolog.Logger) error {
+	files, err := fs.Glob(fsys, "*.up.sql")
 	if err != nil {
 		return fmt.Errorf("finding migrations: %w", err)
 	}
 	sort.Strings(files)
 
 	for _, f := range files {
-		sql, err := os.ReadFile(f)
+		sql, err := fs.ReadFile(fsys, f)
 		if err != nil {
 			return fmt.Errorf("reading %s: %w", f, err)
 		}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/pkg/client"
+)
+
+type app struct {
+	client *client.Client
+	stdin  io.Reader
+	stderr io.Writer
+	out    *printer
+}
+
+func (a *app) run(cmd string, args []string) error {
+	ctx := context.Background()
+	switch cmd {
+	case "create":
+		return a.create(ctx, args)
+	case "bulk":
+		return a.bulk(ctx, args)
+	case "get":
+		return a.get(ctx, args)
+	case "list":
+		return a.list(ctx, args)
+	case "update":
+		return a.update(ctx, args)
+	case "delete":
+		return a.delete(ctx, args)
+	case "restore":
+		return a.restore(ctx, args)
+	case "stats":
+		return a.stats(ctx, args)
+	case "tags":
+		return a.groups(ctx, args, "tags TAG", a.client.Tags, a.client.TagStats)
+	case "campaigns":
+		return a.groups(ctx, args, "campaigns CAMPAIGN", a.client.Campaigns, a.client.CampaignStats)
+	case "workspaces":
+		return a.workspaces(ctx, args)
+	case "export":
+		return a.export(ctx, args)
+	case "import":
+		return a.importLinks(ctx, args)
+	default:
+		return usageError("unknown command %q", cmd)
+	}
+}
+
+// paramsFlag collects repeated -utm key=value flags.
+type paramsFlag map[string]string
+
+func (p paramsFlag) String() string { return formatParams(p) }
+
+func (p paramsFlag) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	p[k] = val
+	return nil
+}
+
+// metadataFlag collects repeated key=value flags into link metadata, with
+// every value a string.
+type metadataFlag map[string]any
+
+func (m metadataFlag) String() string { return "" }
+
+func (m metadataFlag) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("want key=value, got %q", v)
+	}
+	m[k] = val
+	return nil
+}
+
+const codeStrategyUsage = "how the code is made: random, friendly, sequential, obfuscated or words"
+
+// listFlag collects repeated string flags such as -tag.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func (a *app) create(ctx context.Context, args []string) error {
+	fs := newFlagSet("create URL")
+	req := client.ShortenRequest{UTMParams: paramsFlag{}, Metadata: metadataFlag{}}
+	fs.BoolVar(&req.ForwardQuery, "forward-query", false, "forward incoming query parameters")
+	fs.StringVar(&req.QueryPrecedence, "precedence", "", "query precedence: incoming or destination")
+	fs.StringVar(&req.BotPolicy, "bot-policy", "", "what bots are served: redirect or preview")
+	fs.StringVar(&req.Domain, "domain", "", "custom short domain")
+	fs.StringVar(&req.Workspace, "workspace", "", "workspace ID to create the link in")
+	fs.Var(paramsFlag(req.UTMParams), "utm", "UTM parameter key=value (repeatable)")
+	fs.StringVar(&req.Campaign, "campaign", "", "campaign the link belongs to")
+	fs.Var((*listFlag)(&req.Tags), "tag", "tag (repeatable)")
+	fs.StringVar(&req.Title, "title", "", "title noting what the link is for")
+	fs.StringVar(&req.Description, "description", "", "why the link exists and who asked for it")
+	fs.Var(metadataFlag(req.Metadata), "meta", "metadata key=value (repeatable)")
+	fs.Int64Var(&req.MaxClicks, "max-clicks", 0, "stop redirecting after this many clicks (0 for no limit)")
+	fs.StringVar(&req.CodeStrategy, "code", "", codeStrategyUsage)
+	var preview model.LinkPreview
+	previewFlags(fs, &preview)
+	fs.BoolVar(&req.FetchPreview, "fetch-preview", false, "fill empty preview fields from the destination")
+	dest, err := parseWithArg(fs, args)
+	if err != nil {
+		return err
+	}
+	req.URL = dest
+	if preview != (model.LinkPreview{}) {
+		req.Preview = &preview
+	}
+
+	u, err := a.client.Shorten(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.out.url(u)
+}
+
+// bulk shortens one link per input line. Lines are either a bare URL or a
+// JSON shorten request; blank lines and # comments are skipped. Failures
+// are reported per line and do not stop the run.
+func (a *app) bulk(ctx context.Context, args []string) error {
+	fs := newFlagSet("bulk FILE")
+	domain := fs.String("domain", "", "custom short domain for lines that do not set one")
+	strategy := fs.String("code", "", codeStrategyUsage+" for lines that do not set one")
+	path, err := parseWithArg(fs, args)
+	if err != nil {
+		return err
+	}
+
+	in := a.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	var created []model.URL
+	failed, total := 0, 0
+	scanner := bufio.NewScanner(in)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		total++
+
+		req := client.ShortenRequest{URL: line}
+		if strings.HasPrefix(line, "{") {
+			req = client.ShortenRequest{}
+			if err := json.Unmarshal([]byte(line), &req); err != nil {
+				_, _ = fmt.Fprintf(a.stderr, "line %d: %v\n", n, err)
+				failed++
+				continue
+			}
+		}
+		if req.Domain == "" {
+			req.Domain = *domain
+		}
+		if req.CodeStrategy == "" {
+			req.CodeStrategy = *strategy
+		}
+
+		u, err := a.client.Shorten(ctx, req)
+		if err != nil {
+			_, _ = fmt.Fprintf(a.stderr, "line %d: %v\n", n, err)
+			failed++
+			continue
+		}
+		created = append(created, *u)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := a.out.urls(created); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d links failed", failed, total)
+	}
+	return nil
+}
+
+func (a *app) get(ctx context.Context, args []string) error {
+	id, err := parseWithArg(newFlagSet("get ID"), args)
+	if err != nil {
+		return err
+	}
+	u, err := a.client.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.out.url(u)
+}
+
+func (a *app) list(ctx context.Context, args []string) error {
+	fs := newFlagSet("list")
+	limit := fs.Int("limit", 20, "links per page")
+	cursor := fs.String("cursor", "", "cursor from a previous page")
+	all := fs.Bool("all", false, "fetch every page")
+	var filter client.ListFilter
+	fs.BoolVar(&filter.Broken, "broken", false, "only links whose destination check failed")
+	fs.Var((*listFlag)(&filter.Tags), "tag", "only links with this tag (repeatable, all must match)")
+	fs.StringVar(&filter.Campaign, "campaign", "", "only links in this campaign")
+	fs.StringVar(&filter.Search, "q", "", "search codes, destinations, titles, descriptions and metadata")
+	filter.Metadata = paramsFlag{}
+	fs.Var(paramsFlag(filter.Metadata), "meta", "only links with metadata key=value (repeatable, all must match)")
+	fs.StringVar(&filter.Workspace, "workspace", "", "list the links of this workspace ID")
+	if rest, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("usage: shortctl list [-limit N] [-cursor C] [-all] [-broken] [-q TEXT] [-tag T]... [-campaign C] [-meta K=V]... [-workspace ID]")
+	}
+
+	var urls []model.URL
+	next := *cursor
+	for {
+		page, err := a.client.ListFiltered(ctx, filter, *limit, next)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, page.URLs...)
+		next = page.NextCursor
+		if !*all || next == "" {
+			break
+		}
+	}
+
+	if err := a.out.urls(urls); err != nil {
+		return err
+	}
+	if next != "" && a.out.format == "table" {
+		_, _ = fmt.Fprintf(a.stderr, "more results: -cursor %s\n", next)
+	}
+	return nil
+}
+
+// previewFlags registers the flags setting a link's custom preview.
+func previewFlags(fs *flag.FlagSet, p *model.LinkPreview) {
+	fs.StringVar(&p.Title, "preview-title", "", "title shown by link unfurlers")
+	fs.StringVar(&p.Description, "preview-description", "", "description shown by link unfurlers")
+	fs.StringVar(&p.ImageURL, "preview-image", "", "image URL shown by link unfurlers")
+}
+
+func (a *app) update(ctx context.Context, args []string) error {
+	fs := newFlagSet("update ID")
+	dest := fs.String("url", "", "new destination URL")
+	forward := fs.Bool("forward-query", false, "forward incoming query parameters")
+	precedence := fs.String("precedence", "", "query precedence: incoming or destination")
+	botPolicy := fs.String("bot-policy", "", "what bots are served: redirect or preview")
+	utm := paramsFlag{}
+	fs.Var(utm, "utm", "UTM parameter key=value (repeatable, replaces all)")
+	clearUTM := fs.Bool("clear-utm", false, "remove all UTM parameters")
+	campaign := fs.String("campaign", "", "move to this campaign (empty to leave it)")
+	var tags listFlag
+	fs.Var(&tags, "tag", "tag (repeatable, replaces all)")
+	clearTags := fs.Bool("clear-tags", false, "remove all tags")
+	title := fs.String("title", "", "new title (empty to clear)")
+	description := fs.String("description", "", "new description (empty to clear)")
+	metadata := metadataFlag{}
+	fs.Var(metadata, "meta", "metadata key=value (repeatable, replaces all)")
+	clearMetadata := fs.Bool("clear-meta", false, "remove all metadata")
+	maxClicks := fs.Int64("max-clicks", 0, "new click limit (0 for no limit)")
+	var preview model.LinkPreview
+	previewFlags(fs, &preview)
+	clearPreview := fs.Bool("clear-preview", false, "remove the custom preview")
+	id, err := parseWithArg(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// Only send what was set on the command line.
+	var req client.UpdateRequest
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "url":
+			req.URL = dest
+		case "forward-query":
+			req.ForwardQuery = forward
+		case "precedence":
+			req.QueryPrecedence = precedence
+		case "bot-policy":
+			req.BotPolicy = botPolicy
+		case "utm":
+			m := map[string]string(utm)
+			req.UTMParams = &m
+		case "clear-utm":
+			if *clearUTM {
+				m := map[string]string{}
+				req.UTMParams = &m
+			}
+		case "campaign":
+			req.Campaign = campaign
+		case "tag":
+			t := []string(tags)
+			req.Tags = &t
+		case "clear-tags":
+			if *clearTags {
+				req.Tags = &[]string{}
+			}
+		case "title":
+			req.Title = title
+		case "description":
+			req.Description = description
+		case "meta":
+			m := map[string]any(metadata)
+			req.Metadata = &m
+		case "clear-meta":
+			if *clearMetadata {
+				req.Metadata = &map[string]any{}
+			}
+		case "max-clicks":
+			req.MaxClicks = maxClicks
+		case "preview-title", "preview-description", "preview-image":
+			// The preview is replaced as a whole.
+			req.Preview = &preview
+		case "clear-preview":
+			if *clearPreview {
+				req.Preview = &model.LinkPreview{}
+			}
+		}
+	})
+	if req == (client.UpdateRequest{}) {
+		return usageError("update: nothing to change")
+	}
+
+	u, err := a.client.Update(ctx, id, req)
+	if err != nil {
+		return err
+	}
+	return a.out.url(u)
+}
+
+func (a *app) delete(ctx context.Context, args []string) error {
+	ids, err := parseArgs(newFlagSet("delete ID..."), args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usageError("usage: shortctl delete ID...")
+	}
+	for _, id := range ids {
+		if err := a.client.Delete(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		_, _ = fmt.Fprintf(a.stderr, "deleted %s\n", id)
+	}
+	return nil
+}
+
+func (a *app) restore(ctx context.Context, args []string) error {
+	id, err := parseWithArg(newFlagSet("restore ID"), args)
+	if err != nil {
+		return err
+	}
+	u, err := a.client.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.out.url(u)
+}
+
+func (a *app) stats(ctx context.Context, args []string) error {
+	fs := newFlagSet("stats ID")
+	bots := fs.Bool("bots", false, "count bot clicks in the totals")
+	id, err := parseWithArg(fs, args)
+	if err != nil {
+		return err
+	}
+	stats := a.client.Stats
+	if *bots {
+		stats = a.client.StatsWithBots
+	}
+	s, err := stats(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.out.stats(s)
+}
+
+// groups lists the tags or campaigns in use or, given a name, shows the
+// click statistics summed over that group's links.
+func (a *app) groups(ctx context.Context, args []string, usage string,
+	list func(context.Context, string) ([]model.LinkGroup, error),
+	stats func(context.Context, string, string, bool) (*model.GroupStats, error),
+) error {
+	fs := newFlagSet(usage)
+	bots := fs.Bool("bots", false, "count bot clicks in the totals")
+	workspace := fs.String("workspace", "", "only the links of this workspace ID")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	switch len(rest) {
+	case 0:
+		groups, err := list(ctx, *workspace)
+		if err != nil {
+			return err
+		}
+		return a.out.groups(groups)
+	case 1:
+		s, err := stats(ctx, rest[0], *workspace, *bots)
+		if err != nil {
+			return err
+		}
+		return a.out.groupStats(s)
+	default:
+		return usageError("usage: shortctl %s", usage)
+	}
+}
+
+// workspaces lists the workspaces the API key's user is a member of.
+func (a *app) workspaces(ctx context.Context, args []string) error {
+	fs := newFlagSet("workspaces")
+	if rest, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("usage: shortctl workspaces")
+	}
+
+	workspaces, err := a.client.ListWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+	return a.out.workspaces(workspaces)
+}
+
+// export streams the server's export to stdout or a file.
+func (a *app) export(ctx context.Context, args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", client.FormatNDJSON, "export format: ndjson or csv")
+	path := fs.String("file", "", "write to this file instead of stdout")
+	workspace := fs.String("workspace", "", "export the links of this workspace ID")
+	if rest, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("usage: shortctl export [-format ndjson|csv] [-file PATH] [-workspace ID]")
+	}
+
+	var w io.Writer = a.out.w
+	if *path != "" {
+		f, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	return a.client.Export(ctx, *format, *workspace, w)
+}
+
+// importLinks uploads a file ("-" for stdin) and prints the import report.
+func (a *app) importLinks(ctx context.Context, args []string) error {
+	fs := newFlagSet("import FILE")
+	format := fs.String("format", client.FormatNDJSON, "input format: ndjson, csv, yourls or bitly")
+	domain := fs.String("domain", "", "put every link on this custom domain")
+	workspace := fs.String("workspace", "", "import into this workspace ID")
+	path, err := parseWithArg(fs, args)
+	if err != nil {
+		return err
+	}
+
+	in := a.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	report, err := a.client.Import(ctx, *format, in, *domain, *workspace)
+	if err != nil {
+		return err
+	}
+	if err := a.out.importReport(report); err != nil {
+		return err
+	}
+	if report.Failed > 0 || report.Conflicted > 0 {
+		return fmt.Errorf("%d conflicts, %d errors", report.Conflicted, report.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Profile is one environment shortctl can talk to.
+type Profile struct {
+	URL    string `json:"url"`
+	APIKey string `json:"api_key,omitempty"`
+	// APIKeyEnv names an environment variable holding the API key, so the
+	// key itself need not be written to the config file.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+}
+
+// Config is the on-disk shortctl configuration.
+type Config struct {
+	Current  string             `json:"current"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// defaultConfigPath returns $SHORTCTL_CONFIG or shortctl/config.json in the
+// user's config directory.
+func defaultConfigPath() string {
+	if p := os.Getenv("SHORTCTL_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "shortctl.json"
+	}
+	return filepath.Join(dir, "shortctl", "config.json")
+}
+
+// loadConfig reads the config at path. A missing file is an empty config.
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{Profiles: map[string]Profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	return cfg, nil
+}
+
+// save writes the config readable by the owner only, since it may hold
+// API keys.
+func (c *Config) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// resolve picks the server URL and API key. Explicit flags win over
+// SHORTCTL_* environment variables, which win over the selected profile.
+func (c *Config) resolve(profile, baseURL, apiKey string) (string, string, error) {
+	if profile == "" {
+		profile = os.Getenv("SHORTCTL_PROFILE")
+	}
+	if profile == "" {
+		profile = c.Current
+	}
+
+	var p Profile
+	if profile != "" {
+		var ok bool
+		if p, ok = c.Profiles[profile]; !ok {
+			return "", "", fmt.Errorf("unknown profile %q", profile)
+		}
+	}
+
+	if baseURL == "" {
+		baseURL = os.Getenv("SHORTCTL_URL")
+	}
+	if baseURL == "" {
+		baseURL = p.URL
+	}
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	if apiKey == "" {
+		apiKey = os.Getenv("SHORTCTL_API_KEY")
+	}
+	if apiKey == "" && p.APIKeyEnv != "" {
+		apiKey = os.Getenv(p.APIKeyEnv)
+	}
+	if apiKey == "" {
+		apiKey = p.APIKey
+	}
+	return baseURL, apiKey, nil
+}
+
+func runConfig(args []string, path string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return usageError("config requires a subcommand: list, set, use, delete")
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "CURRENT\tNAME\tURL\tAPI KEY")
+		for _, name := range names {
+			p := cfg.Profiles[name]
+			current := ""
+			if name == cfg.Current {
+				current = "*"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, name, p.URL, describeKey(p))
+		}
+		return tw.Flush()
+
+	case "set":
+		flags := newFlagSet("config set NAME")
+		url := flags.String("url", "", "server base URL")
+		apiKey := flags.String("api-key", "", "API key to store in the config file")
+		apiKeyEnv := flags.String("api-key-env", "", "environment variable to read the API key from")
+		name, err := parseWithArg(flags, args[1:])
+		if err != nil {
+			return err
+		}
+
+		p := cfg.Profiles[name]
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "url":
+				p.URL = strings.TrimRight(*url, "/")
+			case "api-key":
+				p.APIKey = *apiKey
+			case "api-key-env":
+				p.APIKeyEnv = *apiKeyEnv
+			}
+		})
+		cfg.Profiles[name] = p
+		if cfg.Current == "" {
+			cfg.Current = name
+		}
+		return cfg.save(path)
+
+	case "use":
+		name, err := parseWithArg(newFlagSet("config use NAME"), args[1:])
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.Profiles[name]; !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		cfg.Current = name
+		return cfg.save(path)
+
+	case "delete":
+		name, err := parseWithArg(newFlagSet("config delete NAME"), args[1:])
+		if err != nil {
+			return err
+		}
+		if _, ok := cfg.Profiles[name]; !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		delete(cfg.Profiles, name)
+		if cfg.Current == name {
+			cfg.Current = ""
+		}
+		return cfg.save(path)
+
+	default:
+		return usageError("unknown config subcommand %q", args[0])
+	}
+}
+
+// describeKey reports where a profile's API key comes from without
+// printing it.
+func describeKey(p Profile) string {
+	switch {
+	case p.APIKeyEnv != "":
+		return "$" + p.APIKeyEnv
+	case len(p.APIKey) > 4:
+		return "****" + p.APIKey[len(p.APIKey)-4:]
+	case p.APIKey != "":
+		return "****"
+	default:
+		return ""
+	}
+}
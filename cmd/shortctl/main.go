@@ -0,0 +1,147 @@
+// Command shortctl manages short links through the HTTP API.
+//
+//	shortctl [global flags] <command> [flags] [args]
+//
+// Server URL and API key come from -url/-api-key, SHORTCTL_URL and
+// SHORTCTL_API_KEY, or a named profile in the config file (see
+// "shortctl config").
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kerbatek/url-shortener/pkg/client"
+)
+
+const usage = `Usage: shortctl [global flags] <command> [flags] [args]
+
+Commands:
+  create URL          Shorten a URL
+  bulk FILE           Shorten every URL in FILE ("-" for stdin), one per line
+                      or one JSON shorten request per line
+  get ID              Show a link
+  list                List links, newest first
+  update ID           Change a link's destination or query policy
+  delete ID...        Delete links
+  restore ID          Restore a deleted link
+  stats ID            Show click statistics
+  tags [TAG]          List tags, or show click statistics for one
+  campaigns [NAME]    List campaigns, or show click statistics for one
+  workspaces          List your workspaces and your role in each
+  export              Export every link as NDJSON or CSV
+  import FILE         Import links from our own export, YOURLS or Bitly
+  config              Manage profiles (list, set, use, delete)
+
+Global flags:
+`
+
+// usageErr marks errors caused by bad invocation; they exit with status 2.
+type usageErr struct{ msg string }
+
+func (e *usageErr) Error() string { return e.msg }
+
+func usageError(format string, args ...any) error {
+	return &usageErr{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes one shortctl invocation and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("shortctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	configPath := global.String("config", defaultConfigPath(), "config file")
+	profile := global.String("profile", "", "profile to use (default: the current profile)")
+	baseURL := global.String("url", "", "server base URL")
+	apiKey := global.String("api-key", "", "API key")
+	format := global.String("o", "table", "output format: table, json or csv")
+	global.Usage = func() {
+		_, _ = fmt.Fprint(stderr, usage)
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return 2
+	}
+
+	cmd, cmdArgs := global.Arg(0), global.Args()[1:]
+	err := func() error {
+		if cmd == "config" {
+			return runConfig(cmdArgs, *configPath, stdout)
+		}
+
+		p, err := newPrinter(stdout, *format)
+		if err != nil {
+			return err
+		}
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		url, key, err := cfg.resolve(*profile, *baseURL, *apiKey)
+		if err != nil {
+			return err
+		}
+
+		a := &app{
+			client: client.New(url, client.WithAPIKey(key)),
+			stdin:  stdin,
+			stderr: stderr,
+			out:    p,
+		}
+		return a.run(cmd, cmdArgs)
+	}()
+
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "shortctl: %v\n", err)
+		var ue *usageErr
+		if errors.As(err, &ue) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseArgs parses fs allowing flags before, between and after positional
+// arguments, which it returns.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, usageError("%s: %v", fs.Name(), err)
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// parseWithArg is parseArgs for commands taking exactly one argument.
+func parseWithArg(fs *flag.FlagSet, args []string) (string, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return "", err
+	}
+	if len(positional) != 1 {
+		return "", usageError("usage: shortctl %s", fs.Name())
+	}
+	return positional[0], nil
+}
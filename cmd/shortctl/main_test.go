@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/handler"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+func setupServer(t *testing.T, ctrl *gomock.Controller) (*httptest.Server, *mocks.MockURLRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	urls := mocks.NewMockURLRepository(ctrl)
+	h := handler.NewURLHandler(service.NewURLService(urls))
+
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.POST("/shorten", h.ShortenURL)
+	router.GET("/urls", h.ListURLs)
+	router.GET("/export", h.ExportURLs)
+	router.POST("/import", h.ImportURLs)
+	router.GET("/url/:id", h.GetURL)
+	router.PATCH("/url/:id", h.UpdateURL)
+	router.GET("/tags", h.ListTags)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, urls
+}
+
+// shortctl runs the CLI with an isolated config file.
+func shortctl(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	t.Setenv("SHORTCTL_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCreate_JSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	urls.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.URL) error {
+			if u.UTMParams["utm_source"] != "cli" {
+				t.Errorf("expected utm_source=cli, got %v", u.UTMParams)
+			}
+			if u.Title != "Docs" || u.Metadata["owner"] != "alice" {
+				t.Errorf("expected title and metadata, got %q %v", u.Title, u.Metadata)
+			}
+			u.ID = "550e8400-e29b-41d4-a716-446655440000"
+			u.CreatedAt = time.Now()
+			return nil
+		})
+
+	code, stdout, stderr := shortctl(t, "", "-url", srv.URL, "-o", "json", "create", "https://example.com", "-utm", "utm_source=cli",
+		"-title", "Docs", "-meta", "owner=alice")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var u model.URL
+	if err := json.Unmarshal([]byte(stdout), &u); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if u.OriginalURL != "https://example.com" {
+		t.Errorf("expected original URL https://example.com, got %s", u.OriginalURL)
+	}
+}
+
+func TestCreate_CodeStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	urls.EXPECT().NextCodeID(gomock.Any()).Return(int64(1000), nil)
+	urls.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	code, stdout, stderr := shortctl(t, "", "-url", srv.URL, "-o", "json", "create", "https://example.com", "-code", "sequential")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var u model.URL
+	if err := json.Unmarshal([]byte(stdout), &u); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if u.Code != "g8" {
+		t.Errorf("expected code g8, got %s", u.Code)
+	}
+}
+
+func TestBulk_ReportsFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	urls.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	input := "# links\nhttps://example.com/a\n\n{\"url\": \"https://example.com/b\", \"forward_query\": true}\nnot-a-url\n"
+	code, stdout, stderr := shortctl(t, input, "-url", srv.URL, "-o", "csv", "bulk", "-")
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d", code)
+	}
+	if !strings.Contains(stderr, "line 5:") || !strings.Contains(stderr, "1 of 3 links failed") {
+		t.Errorf("expected failure report, got %q", stderr)
+	}
+	if lines := strings.Count(stdout, "\n"); lines != 3 {
+		t.Errorf("expected header and 2 rows, got %d lines: %s", lines, stdout)
+	}
+}
+
+func TestUpdate_NothingToChange(t *testing.T) {
+	code, _, stderr := shortctl(t, "", "update", "550e8400-e29b-41d4-a716-446655440000")
+	if code != 2 {
+		t.Fatalf("expected exit 2, got %d", code)
+	}
+	if !strings.Contains(stderr, "nothing to change") {
+		t.Errorf("expected usage error, got %q", stderr)
+	}
+}
+
+func TestUpdate_SendsOnlySetFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	id := "550e8400-e29b-41d4-a716-446655440000"
+	urls.EXPECT().
+		GetByID(gomock.Any(), id).
+		Return(&model.URL{ID: id, OriginalURL: "https://example.com", ForwardQuery: true, QueryPrecedence: model.QueryPrecedenceIncoming}, nil)
+	urls.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.URL) error {
+			if u.OriginalURL != "https://example.org" || !u.ForwardQuery || u.MaxClicks != 1 {
+				t.Errorf("unexpected update %+v", u)
+			}
+			return nil
+		})
+
+	code, _, stderr := shortctl(t, "", "-url", srv.URL, "update", id, "-url", "https://example.org", "-max-clicks", "1")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+}
+
+func TestList_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	urls.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 20, Tags: []string{"mail", "promo"}, Campaign: "launch"}).
+		Return([]model.URL{}, nil)
+	urls.EXPECT().
+		Groups(gomock.Any(), model.GroupTag, "").
+		Return([]model.LinkGroup{{Name: "promo", Links: 4}}, nil)
+
+	code, _, stderr := shortctl(t, "", "-url", srv.URL, "list", "-tag", "promo", "-tag", "mail", "-campaign", "launch")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	code, stdout, stderr := shortctl(t, "", "-url", srv.URL, "tags")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "promo  4") {
+		t.Errorf("expected the tag with its link count, got %s", stdout)
+	}
+}
+
+func TestExport_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	urls.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		Return([]model.URL{
+			{ID: "550e8400-e29b-41d4-a716-446655440000", Code: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()},
+			{ID: "550e8400-e29b-41d4-a716-446655440001", Code: "def5678", OriginalURL: "https://example.org", CreatedAt: time.Now()},
+		}, nil)
+
+	file := filepath.Join(t.TempDir(), "links.csv")
+	code, _, stderr := shortctl(t, "", "-url", srv.URL, "export", "-format", "csv", "-file", file)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("expected export file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("expected header and 2 rows, got %d lines: %s", lines, data)
+	}
+}
+
+func TestImport_ReportsConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, urls := setupServer(t, ctrl)
+
+	urls.EXPECT().Import(gomock.Any(), gomock.Len(2)).Return([]int{1}, nil)
+
+	input := "keyword,url,title,timestamp\nabc,https://example.com,,2020-01-02 03:04:05\ndef,https://example.org,,2020-01-02 03:04:05\n"
+	code, stdout, _ := shortctl(t, input, "-url", srv.URL, "import", "-format", "yourls", "-")
+	if code != 1 {
+		t.Fatalf("expected exit 1, got %d", code)
+	}
+	if !strings.Contains(stdout, "Imported:   1") || !strings.Contains(stdout, "def") {
+		t.Errorf("expected report with the conflicting code, got %s", stdout)
+	}
+}
+
+func TestConfig_Profiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shortctl", "config.json")
+	var out bytes.Buffer
+
+	if err := runConfig([]string{"set", "prod", "-url", "https://sho.rt/", "-api-key", "secret-key"}, path, &out); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := runConfig([]string{"set", "dev", "-url", "http://localhost:8080", "-api-key-env", "DEV_KEY"}, path, &out); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected config file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Current != "prod" {
+		t.Errorf("expected first profile to become current, got %q", cfg.Current)
+	}
+
+	url, key, err := cfg.resolve("", "", "")
+	if err != nil || url != "https://sho.rt" || key != "secret-key" {
+		t.Errorf("expected prod profile, got %q %q %v", url, key, err)
+	}
+
+	t.Setenv("DEV_KEY", "from-env")
+	if _, key, _ := cfg.resolve("dev", "", ""); key != "from-env" {
+		t.Errorf("expected key from DEV_KEY, got %q", key)
+	}
+	if _, key, _ := cfg.resolve("dev", "", "flag-key"); key != "flag-key" {
+		t.Errorf("expected flag to win, got %q", key)
+	}
+	if _, _, err := cfg.resolve("staging", "", ""); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+
+	out.Reset()
+	if err := runConfig([]string{"list"}, path, &out); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if strings.Contains(out.String(), "secret-key") || !strings.Contains(out.String(), "****-key") {
+		t.Errorf("expected masked key, got %s", out.String())
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// printer renders command results as a table, JSON or CSV.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "table", "json", "csv":
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, usageError("unknown output format %q (want table, json or csv)", format)
+	}
+}
+
+var urlHeader = []string{"id", "code", "domain", "original_url", "forward_query", "query_precedence", "utm_params", "created_at"}
+
+func urlRecord(u model.URL) []string {
+	return []string{
+		u.ID, u.Code, u.Domain, u.OriginalURL,
+		strconv.FormatBool(u.ForwardQuery), u.QueryPrecedence,
+		formatParams(u.UTMParams), u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// formatParams renders params as a stable, query-string-like k=v list.
+func formatParams(params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for _, k := range slices.Sorted(maps.Keys(params)) {
+		pairs = append(pairs, k+"="+params[k])
+	}
+	return strings.Join(pairs, "&")
+}
+
+func (p *printer) url(u *model.URL) error {
+	if p.format == "json" {
+		return p.json(u)
+	}
+	return p.urls([]model.URL{*u})
+}
+
+func (p *printer) urls(urls []model.URL) error {
+	switch p.format {
+	case "json":
+		return p.json(urls)
+	case "csv":
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write(urlHeader)
+		for _, u := range urls {
+			_ = cw.Write(urlRecord(u))
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ID\tCODE\tDOMAIN\tORIGINAL URL\tCREATED")
+		for _, u := range urls {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				u.ID, u.Code, u.Domain, u.OriginalURL, u.CreatedAt.Format(time.DateTime))
+		}
+		return tw.Flush()
+	}
+}
+
+func (p *printer) stats(s *model.Stats) error {
+	switch p.format {
+	case "json":
+		return p.json(s)
+	case "csv":
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write([]string{"date", "clicks"})
+		for _, d := range s.Daily {
+			_ = cw.Write([]string{d.Date, strconv.FormatInt(d.Clicks, 10)})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		last := "never"
+		if s.LastClickedAt != nil {
+			last = s.LastClickedAt.Format(time.DateTime)
+		}
+		_, _ = fmt.Fprintf(p.w, "Total clicks: %d\nBot clicks:   %d\nLast click:   %s\n\n", s.TotalClicks, s.BotClicks, last)
+
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "DATE\tCLICKS")
+		for _, d := range s.Daily {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", d.Date, d.Clicks)
+		}
+		return tw.Flush()
+	}
+}
+
+func (p *printer) groups(groups []model.LinkGroup) error {
+	switch p.format {
+	case "json":
+		return p.json(groups)
+	case "csv":
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write([]string{"name", "links"})
+		for _, g := range groups {
+			_ = cw.Write([]string{g.Name, strconv.FormatInt(g.Links, 10)})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "NAME\tLINKS")
+		for _, g := range groups {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", g.Name, g.Links)
+		}
+		return tw.Flush()
+	}
+}
+
+func (p *printer) workspaces(workspaces []model.Workspace) error {
+	switch p.format {
+	case "json":
+		return p.json(workspaces)
+	case "csv":
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write([]string{"id", "name", "role"})
+		for _, w := range workspaces {
+			_ = cw.Write([]string{w.ID, w.Name, w.Role})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ID\tNAME\tROLE")
+		for _, w := range workspaces {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", w.ID, w.Name, w.Role)
+		}
+		return tw.Flush()
+	}
+}
+
+func (p *printer) groupStats(s *model.GroupStats) error {
+	switch p.format {
+	case "json":
+		return p.json(s)
+	case "csv":
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write([]string{"date", "clicks"})
+		for _, d := range s.Daily {
+			_ = cw.Write([]string{d.Date, strconv.FormatInt(d.Clicks, 10)})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		last := "never"
+		if s.LastClickedAt != nil {
+			last = s.LastClickedAt.Format(time.DateTime)
+		}
+		_, _ = fmt.Fprintf(p.w, "Links:        %d\nTotal clicks: %d\nBot clicks:   %d\nLast click:   %s\n\n",
+			s.Links, s.TotalClicks, s.BotClicks, last)
+
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "DATE\tCLICKS")
+		for _, d := range s.Daily {
+			_, _ = fmt.Fprintf(tw, "%s\t%d\n", d.Date, d.Clicks)
+		}
+		_, _ = fmt.Fprintln(tw, "\nCODE\tDOMAIN\tCLICKS")
+		for _, l := range s.TopLinks {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\n", l.Code, l.Domain, l.Clicks)
+		}
+		return tw.Flush()
+	}
+}
+
+func (p *printer) json(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (p *printer) importReport(r *model.ImportReport) error {
+	switch p.format {
+	case "json":
+		return p.json(r)
+	case "csv":
+		cw := csv.NewWriter(p.w)
+		_ = cw.Write([]string{"kind", "line", "code", "error"})
+		for _, c := range r.Conflicts {
+			_ = cw.Write([]string{"conflict", strconv.Itoa(c.Line), c.Code, c.Error})
+		}
+		for _, e := range r.Errors {
+			_ = cw.Write([]string{"error", strconv.Itoa(e.Line), e.Code, e.Error})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		_, _ = fmt.Fprintf(p.w, "Imported:   %d\nConflicted: %d\nFailed:     %d\n", r.Imported, r.Conflicted, r.Failed)
+		if len(r.Conflicts)+len(r.Errors) == 0 {
+			return nil
+		}
+		_, _ = fmt.Fprintln(p.w)
+		tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "LINE\tCODE\tPROBLEM")
+		for _, c := range r.Conflicts {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\n", c.Line, c.Code, c.Error)
+		}
+		for _, e := range r.Errors {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\n", e.Line, e.Code, e.Error)
+		}
+		return tw.Flush()
+	}
+}
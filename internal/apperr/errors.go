@@ -0,0 +1,70 @@
+// Package apperr defines the error kinds shared by the repository, service
+// and handler layers. Repositories and services return errors that wrap one
+// of the sentinels below; the HTTP layer translates them into responses.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalid      = errors.New("invalid")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnavailable  = errors.New("unavailable")
+	// ErrGone marks a resource that existed but will never be served
+	// again, such as a link that has used up its clicks.
+	ErrGone = errors.New("gone")
+)
+
+// Error is an error of a known kind with a message that is safe to show to
+// clients. The underlying cause, if any, is kept for logging only.
+type Error struct {
+	Kind    error
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Kind, e.Cause}
+	}
+	return []error{e.Kind}
+}
+
+func newf(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...any) error     { return newf(ErrNotFound, format, args...) }
+func Conflict(format string, args ...any) error     { return newf(ErrConflict, format, args...) }
+func Invalid(format string, args ...any) error      { return newf(ErrInvalid, format, args...) }
+func Unauthorized(format string, args ...any) error { return newf(ErrUnauthorized, format, args...) }
+func Forbidden(format string, args ...any) error    { return newf(ErrForbidden, format, args...) }
+func Gone(format string, args ...any) error         { return newf(ErrGone, format, args...) }
+
+// Unavailable reports that a dependency could not be reached, keeping cause
+// for the logs.
+func Unavailable(cause error, format string, args ...any) error {
+	return &Error{Kind: ErrUnavailable, Message: fmt.Sprintf(format, args...), Cause: cause}
+}
+
+// Message returns the client-facing message of err, or "" if err is not an
+// *Error and its text should not be exposed.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return ""
+}
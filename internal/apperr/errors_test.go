@@ -0,0 +1,44 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{NotFound("url %s not found", "abc"), ErrNotFound},
+		{Conflict("code taken"), ErrConflict},
+		{Invalid("bad url"), ErrInvalid},
+		{Unauthorized("api key required"), ErrUnauthorized},
+		{Forbidden("not yours"), ErrForbidden},
+		{Gone("used up"), ErrGone},
+		{Unavailable(errors.New("dial tcp: refused"), "database unavailable"), ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		wrapped := fmt.Errorf("context: %w", tt.err)
+		if !errors.Is(wrapped, tt.kind) {
+			t.Errorf("expected %v to be %v", tt.err, tt.kind)
+		}
+	}
+}
+
+func TestMessage_HidesCause(t *testing.T) {
+	cause := errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	err := Unavailable(cause, "database unavailable")
+
+	if got := Message(err); got != "database unavailable" {
+		t.Errorf("expected client message without cause, got %q", got)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected cause to remain inspectable")
+	}
+	if Message(errors.New("raw")) != "" {
+		t.Error("expected untyped errors to have no client message")
+	}
+}
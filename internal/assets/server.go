@@ -0,0 +1,266 @@
+// Package assets serves static files with validators, cache headers and
+// gzip or brotli encodings negotiated from Accept-Encoding.
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+)
+
+// minCompressSize is the size below which compressing is not worth the
+// bytes Content-Encoding costs.
+const minCompressSize = 512
+
+// Cache-Control values. Asset names are not fingerprinted, so HTML, which
+// names the assets, is always revalidated and assets are cached briefly.
+const (
+	htmlCacheControl  = "no-cache"
+	assetCacheControl = "public, max-age=3600"
+)
+
+// encodings in order of preference, with the suffix of a precompressed
+// sibling file.
+var encodings = []struct {
+	name, suffix string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// file is one static file and its encoded variants.
+type file struct {
+	contentType string
+	etag        string
+	body        []byte
+	encoded     map[string][]byte
+}
+
+// Server serves the files of an fs.FS. Built with live false, every file
+// is read, hashed and compressed once up front, which suits an embedded
+// FS. With live true each request reads the file again, so edits to an
+// override directory show up without a restart.
+type Server struct {
+	fsys  fs.FS
+	live  bool
+	files map[string]*file
+}
+
+func NewServer(fsys fs.FS, live bool) (*Server, error) {
+	s := &Server{fsys: fsys, live: live, files: map[string]*file{}}
+	if live {
+		return s, nil
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isSibling(name) {
+			return err
+		}
+		f, err := load(fsys, name, true)
+		if err != nil {
+			return err
+		}
+		s.files[name] = f
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading static files: %w", err)
+	}
+	return s, nil
+}
+
+// isSibling reports whether name is a precompressed copy of another file.
+func isSibling(name string) bool {
+	for _, e := range encodings {
+		if strings.HasSuffix(name, e.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads name and its encodings. Precompressed siblings such as
+// name.br are used when present; otherwise, if compress is set, the
+// encoding is produced here and kept when it is smaller.
+func load(fsys fs.FS, name string, compress bool) (*file, error) {
+	body, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	f := &file{
+		contentType: contentType(name, body),
+		etag:        hex.EncodeToString(sum[:16]),
+		body:        body,
+		encoded:     map[string][]byte{},
+	}
+	for _, e := range encodings {
+		if b, err := fs.ReadFile(fsys, name+e.suffix); err == nil {
+			f.encoded[e.name] = b
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if !compress || len(body) < minCompressSize || !compressible(f.contentType) {
+			continue
+		}
+		b, err := encode(e.name, body)
+		if err != nil {
+			return nil, fmt.Errorf("compressing %s: %w", name, err)
+		}
+		if len(b) < len(body) {
+			f.encoded[e.name] = b
+		}
+	}
+	return f, nil
+}
+
+func contentType(name string, body []byte) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(body)
+}
+
+func compressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "javascript") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "svg")
+}
+
+func encode(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "br":
+		w = brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	case "gzip":
+		w, _ = gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// File returns a handler that serves name.
+func (s *Server) File(name string) gin.HandlerFunc {
+	return func(c *gin.Context) { s.serve(c, name) }
+}
+
+// Dir serves the file named by the route's *filepath parameter.
+func (s *Server) Dir(c *gin.Context) {
+	s.serve(c, strings.TrimPrefix(c.Param("filepath"), "/"))
+}
+
+func (s *Server) lookup(name string) (*file, error) {
+	if !fs.ValidPath(name) || name == "." || isSibling(name) {
+		return nil, fs.ErrNotExist
+	}
+	if !s.live {
+		if f, ok := s.files[name]; ok {
+			return f, nil
+		}
+		return nil, fs.ErrNotExist
+	}
+	if info, err := fs.Stat(s.fsys, name); err != nil || info.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+	return load(s.fsys, name, false)
+}
+
+func (s *Server) serve(c *gin.Context, name string) {
+	f, err := s.lookup(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			_ = c.Error(apperr.NotFound("file not found"))
+		} else {
+			_ = c.Error(err)
+		}
+		return
+	}
+
+	body, encoding := f.body, ""
+	for _, e := range encodings {
+		if b, ok := f.encoded[e.name]; ok && accepts(c.GetHeader("Accept-Encoding"), e.name) {
+			body, encoding = b, e.name
+			break
+		}
+	}
+	// Each encoding is a different representation, so it gets its own tag.
+	etag := `"` + f.etag + `"`
+	if encoding != "" {
+		etag = `"` + f.etag + "-" + encoding + `"`
+	}
+
+	h := c.Writer.Header()
+	h.Set("ETag", etag)
+	h.Set("Vary", "Accept-Encoding")
+	if strings.HasPrefix(f.contentType, "text/html") {
+		h.Set("Cache-Control", htmlCacheControl)
+	} else {
+		h.Set("Cache-Control", assetCacheControl)
+	}
+	if etagMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+	}
+	if c.Request.Method == http.MethodHead {
+		h.Set("Content-Type", f.contentType)
+		h.Set("Content-Length", strconv.Itoa(len(body)))
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Data(http.StatusOK, f.contentType, body)
+}
+
+// accepts reports whether an Accept-Encoding header allows encoding,
+// honouring q=0 as a refusal.
+func accepts(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			v, err := strconv.ParseFloat(q, 64)
+			return err == nil && v > 0
+		}
+		return true
+	}
+	return false
+}
+
+// etagMatch applies the weak comparison If-None-Match calls for.
+func etagMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/middleware"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var css = strings.Repeat("body { color: #222; }\n", 100)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":        {Data: []byte("<!DOCTYPE html><title>x</title>")},
+		"style.css":         {Data: []byte(css)},
+		"script.js":         {Data: []byte(strings.Repeat("console.log(1);\n", 100))},
+		"script.js.gz":      {Data: []byte("precompressed")},
+		"images/logo.png":   {Data: []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 1000))},
+		"images/README.txt": {Data: []byte("hi")},
+	}
+}
+
+func setupRouter(t *testing.T, fsys fstest.MapFS, live bool) *gin.Engine {
+	t.Helper()
+	s, err := NewServer(fsys, live)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/", s.File("index.html"))
+	router.GET("/static/*filepath", s.Dir)
+	router.HEAD("/static/*filepath", s.Dir)
+	return router
+}
+
+func get(router *gin.Engine, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestServe_ETagAndCaching(t *testing.T) {
+	router := setupRouter(t, testFS(), false)
+
+	w := get(router, http.MethodGet, "/", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != htmlCacheControl {
+		t.Errorf("expected HTML to be revalidated, got %q", cc)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected text/html, got %q", ct)
+	}
+
+	w = get(router, http.MethodGet, "/static/style.css", nil)
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected an ETag and no encoding, got %q %q", etag, w.Header().Get("Content-Encoding"))
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != assetCacheControl {
+		t.Errorf("expected %q, got %q", assetCacheControl, cc)
+	}
+	if w.Body.String() != css {
+		t.Error("expected the file contents")
+	}
+
+	w = get(router, http.MethodGet, "/static/style.css", http.Header{"If-None-Match": {`"other", W/` + etag}})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status 304, got %d", w.Code)
+	}
+}
+
+func TestServe_Encodings(t *testing.T) {
+	router := setupRouter(t, testFS(), false)
+
+	w := get(router, http.MethodGet, "/static/style.css", http.Header{"Accept-Encoding": {"gzip, br"}})
+	if w.Header().Get("Content-Encoding") != "br" {
+		t.Fatalf("expected br, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if got, _ := io.ReadAll(brotli.NewReader(w.Body)); string(got) != css {
+		t.Error("expected brotli body to decode to the file")
+	}
+	brTag := w.Header().Get("ETag")
+
+	w = get(router, http.MethodGet, "/static/style.css", http.Header{"Accept-Encoding": {"gzip, br;q=0"}})
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip, got %q", w.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("expected gzip body, got %v", err)
+	}
+	if got, _ := io.ReadAll(zr); string(got) != css {
+		t.Error("expected gzip body to decode to the file")
+	}
+	if w.Header().Get("ETag") == brTag {
+		t.Error("expected each encoding to have its own ETag")
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected Vary: Accept-Encoding, got %q", w.Header().Get("Vary"))
+	}
+
+	// A precompressed sibling is served as is, and not by its own name.
+	w = get(router, http.MethodGet, "/static/script.js", http.Header{"Accept-Encoding": {"gzip"}})
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Body.String() != "precompressed" {
+		t.Errorf("expected the precompressed file, got %q", w.Body.String())
+	}
+	if w := get(router, http.MethodGet, "/static/script.js.gz", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for the sibling, got %d", w.Code)
+	}
+
+	// Binary and tiny files are not compressed.
+	for _, path := range []string{"/static/images/logo.png", "/static/images/README.txt"} {
+		w = get(router, http.MethodGet, path, http.Header{"Accept-Encoding": {"br, gzip"}})
+		if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: expected an unencoded 200, got %d %q", path, w.Code, w.Header().Get("Content-Encoding"))
+		}
+	}
+}
+
+func TestServe_NotFound(t *testing.T) {
+	router := setupRouter(t, testFS(), false)
+
+	for _, path := range []string{"/static/missing.css", "/static/images", "/static/../index.html", "/static/"} {
+		if w := get(router, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestServe_Head(t *testing.T) {
+	router := setupRouter(t, testFS(), false)
+
+	w := get(router, http.MethodHead, "/static/style.css", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 || w.Header().Get("Content-Length") != "2200" {
+		t.Errorf("expected headers only, got %d bytes and length %q", w.Body.Len(), w.Header().Get("Content-Length"))
+	}
+}
+
+func TestServe_Live(t *testing.T) {
+	fsys := testFS()
+	router := setupRouter(t, fsys, true)
+
+	first := get(router, http.MethodGet, "/static/style.css", nil)
+	fsys["style.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+	second := get(router, http.MethodGet, "/static/style.css", nil)
+
+	if second.Body.String() != "body { color: red; }" {
+		t.Errorf("expected the edited file, got %q", second.Body.String())
+	}
+	if first.Header().Get("ETag") == second.Header().Get("ETag") {
+		t.Error("expected the ETag to change with the contents")
+	}
+	if w := get(router, http.MethodGet, "/static/images", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a directory, got %d", w.Code)
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"gzip", true},
+		{"deflate, GZIP;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.000", false},
+		{"br", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := accepts(tt.header, "gzip"); got != tt.want {
+			t.Errorf("accepts(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	b, err := encode("gzip", []byte(css))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	zr, _ := gzip.NewReader(bytes.NewReader(b))
+	if got, _ := io.ReadAll(zr); string(got) != css {
+		t.Error("expected gzip to round trip")
+	}
+	if _, err := encode("zstd", nil); err == nil {
+		t.Error("expected an unknown encoding to fail")
+	}
+}
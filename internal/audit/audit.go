@@ -0,0 +1,54 @@
+// Package audit carries who is behind a request, and where it came from,
+// to the repositories, which write both to the audit log with every change
+// to a link.
+package audit
+
+import (
+	"context"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// Actor is who makes a change. ID is a user ID, model.ActorOperator or
+// model.ActorAnonymous.
+type Actor struct {
+	ID    string
+	Email string
+}
+
+// Origin is where a request came from.
+type Origin struct {
+	ClientIP  string
+	RequestID string
+}
+
+type (
+	actorKey  struct{}
+	originKey struct{}
+)
+
+// WithActor returns a copy of ctx acting for actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor ctx acts for, anonymous when none was set.
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || actor.ID == "" {
+		return Actor{ID: model.ActorAnonymous}
+	}
+	return actor
+}
+
+// WithOrigin returns a copy of ctx for a request from origin.
+func WithOrigin(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// OriginFrom returns where the request behind ctx came from, empty when
+// unknown.
+func OriginFrom(ctx context.Context) Origin {
+	origin, _ := ctx.Value(originKey{}).(Origin)
+	return origin
+}
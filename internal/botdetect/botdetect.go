@@ -0,0 +1,152 @@
+// Package botdetect recognises bots, crawlers and link unfurlers among the
+// clients following short links.
+//
+// User agents are matched against unfurlers.txt and patterns.txt,
+// maintained lists of case-insensitive substrings built into the binary;
+// unfurlers are the subset of bots that render link previews in chats and
+// posts. Known crawler address
+// ranges can be added from a local file of CIDR blocks, one per line,
+// optionally followed by the crawler's name:
+//
+//	66.249.64.0/19 googlebot
+//	2001:4860:4801::/48 googlebot
+//
+// Blank lines and lines starting with '#' are ignored in both.
+package botdetect
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+// noUserAgent names requests that send no user agent at all, which
+// browsers never do.
+const noUserAgent = "no user agent"
+
+var (
+	//go:embed patterns.txt
+	builtinPatterns string
+	//go:embed unfurlers.txt
+	builtinUnfurlers string
+)
+
+// Classifier is immutable once built and safe for concurrent use.
+type Classifier struct {
+	// patterns are lower-cased user agent substrings, in file order,
+	// starting with the unfurlers.
+	patterns  []string
+	unfurlers []string
+	ranges    []ipRange
+}
+
+type ipRange struct {
+	prefix netip.Prefix
+	name   string
+}
+
+// New returns a classifier using the built-in user agent patterns and no
+// address ranges.
+func New() *Classifier {
+	unfurlers := parsePatterns(builtinUnfurlers)
+	return &Classifier{
+		patterns:  append(slices.Clone(unfurlers), parsePatterns(builtinPatterns)...),
+		unfurlers: unfurlers,
+	}
+}
+
+func parsePatterns(list string) []string {
+	var patterns []string
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, strings.ToLower(line))
+		}
+	}
+	return patterns
+}
+
+// Len returns the number of patterns and address ranges in c.
+func (c *Classifier) Len() int {
+	return len(c.patterns) + len(c.ranges)
+}
+
+// LoadRanges returns a copy of c that also matches the address ranges
+// listed in the file at path. A range listed without a name is reported as
+// "crawler <range>".
+func (c *Classifier) LoadRanges(path string) (*Classifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	out := &Classifier{patterns: c.patterns, unfurlers: c.unfurlers, ranges: append([]ipRange(nil), c.ranges...)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid range %q", path, n, fields[0])
+		}
+		name := "crawler " + prefix.String()
+		if len(fields) > 1 {
+			name = strings.Join(fields[1:], " ")
+		}
+		out.ranges = append(out.ranges, ipRange{prefix: prefix.Masked(), name: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Classify reports whether a request from ip with the given user agent
+// comes from a bot, and names the pattern or range that matched. The user
+// agent is checked first; an unparseable ip only skips the range check.
+func (c *Classifier) Classify(userAgent, ip string) (name string, bot bool) {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return noUserAgent, true
+	}
+	for _, p := range c.patterns {
+		if strings.Contains(ua, p) {
+			return p, true
+		}
+	}
+
+	if len(c.ranges) == 0 {
+		return "", false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", false
+	}
+	addr = addr.Unmap()
+	for _, r := range c.ranges {
+		if r.prefix.Contains(addr) {
+			return r.name, true
+		}
+	}
+	return "", false
+}
+
+// Unfurler reports whether userAgent belongs to a link unfurler, such as
+// Slack or Twitter fetching a pasted link to render its preview card.
+func (c *Classifier) Unfurler(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, p := range c.unfurlers {
+		if strings.Contains(ua, p) {
+			return true
+		}
+	}
+	return false
+}
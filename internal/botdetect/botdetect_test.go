@@ -0,0 +1,104 @@
+package botdetect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	safariUA = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+)
+
+func TestClassify_UserAgents(t *testing.T) {
+	c := New()
+	tests := []struct {
+		userAgent string
+		bot       bool
+		name      string
+	}{
+		{chromeUA, false, ""},
+		{safariUA, false, ""},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true, "googlebot"},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true, "facebookexternalhit"},
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true, "slackbot"},
+		{"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true, "discordbot"},
+		{"curl/8.7.1", true, "curl/"},
+		{"Mozilla/5.0 (compatible; SomeNewBot/0.1)", true, "bot/"},
+		{"", true, noUserAgent},
+		{"   ", true, noUserAgent},
+	}
+	for _, tt := range tests {
+		name, bot := c.Classify(tt.userAgent, "203.0.113.7")
+		if bot != tt.bot || name != tt.name {
+			t.Errorf("Classify(%q) = %q, %v; expected %q, %v", tt.userAgent, name, bot, tt.name, tt.bot)
+		}
+	}
+}
+
+func TestUnfurler(t *testing.T) {
+	c := New()
+	for _, ua := range []string{
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+		"Twitterbot/1.0",
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+	} {
+		if !c.Unfurler(ua) {
+			t.Errorf("expected %q to be an unfurler", ua)
+		}
+	}
+	for _, ua := range []string{chromeUA, "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", ""} {
+		if c.Unfurler(ua) {
+			t.Errorf("expected %q not to be an unfurler", ua)
+		}
+	}
+}
+
+func TestLoadRanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawlers.txt")
+	data := "# Google\n66.249.64.0/19 googlebot\n\n2001:db8::/32\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	base := New()
+	c, err := base.LoadRanges(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if c.Len() != base.Len()+2 {
+		t.Errorf("expected 2 ranges added, got %d", c.Len()-base.Len())
+	}
+
+	tests := []struct {
+		ip   string
+		bot  bool
+		name string
+	}{
+		{"66.249.66.1", true, "googlebot"},
+		{"::ffff:66.249.66.1", true, "googlebot"},
+		{"2001:db8::1", true, "crawler 2001:db8::/32"},
+		{"203.0.113.7", false, ""},
+		{"not an address", false, ""},
+	}
+	for _, tt := range tests {
+		name, bot := c.Classify(chromeUA, tt.ip)
+		if bot != tt.bot || name != tt.name {
+			t.Errorf("Classify(%q) = %q, %v; expected %q, %v", tt.ip, name, bot, tt.name, tt.bot)
+		}
+	}
+	if _, bot := base.Classify(chromeUA, "66.249.66.1"); bot {
+		t.Error("expected LoadRanges to leave the original classifier unchanged")
+	}
+}
+
+func TestLoadRanges_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawlers.txt")
+	if err := os.WriteFile(path, []byte("66.249.64.0/19\n66.249.300.0/24\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New().LoadRanges(path); err == nil {
+		t.Error("expected an invalid range to fail the load")
+	}
+}
@@ -0,0 +1,141 @@
+// Package codegen makes the short codes of new links. Each strategy trades
+// code length against guessability and readability; the service picks one
+// per deployment, and callers may pick another per link.
+package codegen
+
+import (
+	"context"
+	"fmt"
+)
+
+// Strategies, as named by CODE_STRATEGY and a shorten request's
+// code_strategy. The alphabets named are those of case-sensitive
+// deployments; see Config.CaseInsensitive.
+const (
+	// Random codes are 7 characters drawn uniformly from Base62.
+	Random = "random"
+	// Friendly codes are 8 random characters without the easily confused
+	// 0, O, o, 1, l and I, for links that are read aloud or retyped.
+	Friendly = "friendly"
+	// Sequential codes are the link's sequence number in Base62: the
+	// shortest codes possible, but each reveals how many links came before
+	// and the next one is easy to guess.
+	Sequential = "sequential"
+	// Obfuscated codes are sequence numbers scrambled by a keyed
+	// permutation, in the style of Hashids and Sqids: at least 5
+	// characters and unique by construction, but not consecutive. The
+	// scrambling hides the order from casual readers; it is not
+	// encryption.
+	Obfuscated = "obfuscated"
+	// Words codes are three random words joined by hyphens.
+	Words = "words"
+)
+
+// Names lists the strategies.
+var Names = []string{Random, Friendly, Sequential, Obfuscated, Words}
+
+// Valid reports whether name is a strategy.
+func Valid(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Generator makes candidate codes. A code may already be taken, by an
+// imported link for instance; the caller tries another.
+type Generator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// Sequence hands out increasing numbers that are never reused, such as
+// those of a database sequence.
+type Sequence interface {
+	NextCodeID(ctx context.Context) (int64, error)
+}
+
+// Config configures the strategies.
+type Config struct {
+	// Salt keys the Obfuscated permutation. Changing it changes every code
+	// generated afterwards, which may then collide with earlier ones.
+	Salt string
+	// CaseInsensitive makes every strategy use lower case only: Base36
+	// instead of Base62 and FriendlyLower instead of FriendlyAlphabet,
+	// for deployments that look codes up regardless of case.
+	CaseInsensitive bool
+}
+
+// New returns the generators of every strategy by name, numbering
+// Sequential and Obfuscated codes from seq. The generators never return
+// a reserved code.
+func New(seq Sequence, cfg Config) map[string]Generator {
+	random, friendly, digits := Base62, FriendlyAlphabet, sequenceAlphabet
+	if cfg.CaseInsensitive {
+		random, friendly, digits = Base36, FriendlyLower, sequenceAlphabet[:36]
+	}
+	return map[string]Generator{
+		Random:     unreserved{NewRandom(random, 7)},
+		Friendly:   unreserved{NewRandom(friendly, 8)},
+		Sequential: unreserved{NewSequential(seq, digits)},
+		Obfuscated: unreserved{NewObfuscated(seq, digits, cfg.Salt, 5)},
+		Words:      unreserved{NewWords(3)},
+	}
+}
+
+// randomAttempts bounds the codes tried per link for random strategies,
+// which almost never collide. sequenceAttempts is larger because
+// sequences run into imported codes in stretches, and each taken code
+// costs only a sequence number.
+const (
+	randomAttempts   = 3
+	sequenceAttempts = 50
+)
+
+// Attempts returns how many of g's codes to try before giving up on a link.
+func Attempts(g Generator) int {
+	if u, ok := g.(unreserved); ok {
+		g = u.Generator
+	}
+	switch g.(type) {
+	case *sequential, *obfuscated:
+		return sequenceAttempts
+	default:
+		return randomAttempts
+	}
+}
+
+// reserved are the first path segments the server routes before short
+// codes, so links with these codes could never be followed.
+var reserved = map[string]bool{
+	"admin": true, "audit": true, "campaigns": true, "domains": true,
+	"export": true, "health": true, "import": true, "invites": true,
+	"metrics": true, "ready": true, "shorten": true, "static": true,
+	"tags": true, "url": true, "urls": true, "users": true,
+	"webhooks": true, "workspaces": true,
+}
+
+// Reserved reports whether code is routed to something other than a link.
+func Reserved(code string) bool {
+	return reserved[code]
+}
+
+// maxReservedSkips bounds the reserved codes skipped in a row, so a
+// generator that only makes reserved codes fails instead of looping.
+const maxReservedSkips = 10
+
+// unreserved skips the reserved codes of Generator.
+type unreserved struct {
+	Generator
+}
+
+func (u unreserved) Generate(ctx context.Context) (string, error) {
+	for i := 0; i < maxReservedSkips; i++ {
+		code, err := u.Generator.Generate(ctx)
+		if err != nil || !Reserved(code) {
+			return code, err
+		}
+	}
+	return "", fmt.Errorf("only reserved codes generated")
+}
@@ -0,0 +1,332 @@
+package codegen
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// counter is a Sequence counting up from next.
+type counter struct {
+	next int64
+	err  error
+}
+
+func (c *counter) NextCodeID(context.Context) (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.next++
+	return c.next - 1, nil
+}
+
+// generate returns n codes from g, failing the test on an error or when
+// more than maxRepeats of them were generated before.
+func generate(t *testing.T, g Generator, n, maxRepeats int) []string {
+	t.Helper()
+	seen := make(map[string]bool, n)
+	codes := make([]string, 0, n)
+	repeats := 0
+	for i := 0; i < n; i++ {
+		code, err := g.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if seen[code] {
+			if repeats++; repeats > maxRepeats {
+				t.Fatalf("code %q repeated, %d repeats in %d codes", code, repeats, i+1)
+			}
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// checkUniform fails the test when the counts of the given number of symbols
+// deviate from a uniform distribution more than chance allows: the
+// chi-squared statistic must stay below the value a uniform source exceeds
+// once in 100,000 runs, approximated for symbols-1 degrees of freedom.
+func checkUniform(t *testing.T, counts map[string]int, symbols int) {
+	t.Helper()
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if len(counts) != symbols {
+		t.Fatalf("expected all %d symbols to occur, got %d", symbols, len(counts))
+	}
+	expected := float64(total) / float64(symbols)
+	var chi2 float64
+	for _, n := range counts {
+		d := float64(n) - expected
+		chi2 += d * d / expected
+	}
+	df := float64(symbols - 1)
+	// Wilson-Hilferty approximation with z = 4.27.
+	limit := df * math.Pow(1-2/(9*df)+4.27*math.Sqrt(2/(9*df)), 3)
+	if chi2 > limit {
+		t.Errorf("distribution not uniform: chi-squared %.1f over %.1f", chi2, limit)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	codes := generate(t, NewRandom(Base62, 7), 20000, 0)
+
+	counts := map[string]int{}
+	for _, code := range codes {
+		if len(code) != 7 {
+			t.Fatalf("expected length 7, got %q", code)
+		}
+		for _, c := range code {
+			if !strings.ContainsRune(Base62, c) {
+				t.Fatalf("character %c of %q not in Base62", c, code)
+			}
+			counts[string(c)]++
+		}
+	}
+	checkUniform(t, counts, len(Base62))
+}
+
+func TestFriendly(t *testing.T) {
+	codes := generate(t, New(nil, Config{})[Friendly], 20000, 0)
+
+	counts := map[string]int{}
+	for _, code := range codes {
+		if len(code) != 8 {
+			t.Fatalf("expected length 8, got %q", code)
+		}
+		if strings.ContainsAny(code, "0Oo1lI") {
+			t.Fatalf("code %q has an ambiguous character", code)
+		}
+		for _, c := range code {
+			counts[string(c)]++
+		}
+	}
+	checkUniform(t, counts, len(FriendlyAlphabet))
+}
+
+func TestSequential(t *testing.T) {
+	tests := []struct {
+		id   int64
+		want string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{61, "Z"},
+		{62, "10"},
+		{1000, "g8"},
+		{62*62*62 - 1, "ZZZ"},
+	}
+	for _, tt := range tests {
+		code, err := NewSequential(&counter{next: tt.id}, sequenceAlphabet).Generate(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if code != tt.want {
+			t.Errorf("id %d: expected %q, got %q", tt.id, tt.want, code)
+		}
+	}
+
+	// Every code is as short as its number allows.
+	for i, code := range generate(t, NewSequential(&counter{next: 1}, sequenceAlphabet), 62*62, 0) {
+		if want := len(spell(uint64(i+1), sequenceAlphabet, 1)); len(code) != want {
+			t.Fatalf("expected %d characters for %d, got %q", want, i+1, code)
+		}
+	}
+}
+
+func TestSequential_SequenceError(t *testing.T) {
+	seqErr := errors.New("database down")
+	_, err := NewSequential(&counter{err: seqErr}, sequenceAlphabet).Generate(context.Background())
+	if !errors.Is(err, seqErr) {
+		t.Errorf("expected the sequence error, got %v", err)
+	}
+}
+
+func TestObfuscated(t *testing.T) {
+	// Past the last 5 character code, so both lengths are covered.
+	const start = 62*62*62*62*62 - 50000
+	g := NewObfuscated(&counter{next: start}, sequenceAlphabet, "salt", 5)
+	codes := generate(t, g, 100000, 0)
+
+	firsts := map[string]int{}
+	for i, code := range codes {
+		want := 5
+		if start+i >= 62*62*62*62*62 {
+			want = 6
+		}
+		if len(code) != want {
+			t.Fatalf("expected %d characters for %d, got %q", want, start+i, code)
+		}
+		if i < 50000 {
+			firsts[code[:1]]++
+		}
+	}
+	// Consecutive numbers do not share leading characters.
+	checkUniform(t, firsts, len(sequenceAlphabet))
+}
+
+func TestObfuscated_SmallNumbers(t *testing.T) {
+	codes := generate(t, NewObfuscated(&counter{}, sequenceAlphabet, "salt", 5), 10000, 0)
+	for _, code := range codes {
+		if len(code) != 5 {
+			t.Fatalf("expected 5 characters, got %q", code)
+		}
+	}
+}
+
+func TestObfuscated_Salt(t *testing.T) {
+	encode := func(salt string, n uint64) string {
+		code, err := NewObfuscated(nil, sequenceAlphabet, salt, 5).(*obfuscated).encode(n)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return code
+	}
+	if encode("a", 42) != encode("a", 42) {
+		t.Error("expected the same code for the same salt")
+	}
+	if encode("a", 42) == encode("b", 42) {
+		t.Error("expected different codes for different salts")
+	}
+}
+
+func TestObfuscated_PermutesWholeRange(t *testing.T) {
+	// With a minimum of 2 characters every 2 character code is used once.
+	codes := generate(t, NewObfuscated(&counter{}, sequenceAlphabet, "salt", 2), 62*62, 0)
+	for _, code := range codes {
+		if len(code) != 2 {
+			t.Fatalf("expected 2 characters, got %q", code)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	// Three words make 133 million codes, so 20,000 of them repeat once or
+	// twice by the birthday bound; far more repeats mean a skewed draw.
+	codes := generate(t, NewWords(3), 20000, 10)
+
+	known := map[string]bool{}
+	for _, w := range words {
+		if len(w) > 6 {
+			t.Fatalf("word %q is longer than 6 letters; three would overflow a 20-character code", w)
+		}
+		if known[w] {
+			t.Fatalf("word %q listed twice", w)
+		}
+		known[w] = true
+	}
+	counts := map[string]int{}
+	for _, code := range codes {
+		if len(code) > 20 {
+			t.Fatalf("expected at most 20 characters, got %q", code)
+		}
+		parts := strings.Split(code, "-")
+		if len(parts) != 3 {
+			t.Fatalf("expected 3 words, got %q", code)
+		}
+		for _, p := range parts {
+			if !known[p] {
+				t.Fatalf("word %q of %q not in the list", p, code)
+			}
+			counts[p]++
+		}
+	}
+	checkUniform(t, counts, len(words))
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	gens := New(&counter{next: 1000}, Config{CaseInsensitive: true})
+	for _, name := range Names {
+		counts := map[string]int{}
+		for _, code := range generate(t, gens[name], 5000, 1) {
+			if code != strings.ToLower(code) {
+				t.Fatalf("%s: code %q is not lower case", name, code)
+			}
+			if name == Friendly && strings.ContainsAny(code, "0o1l") {
+				t.Fatalf("code %q has an ambiguous character", code)
+			}
+			if name != Words && name != Sequential {
+				for _, c := range code {
+					counts[string(c)]++
+				}
+			}
+		}
+		switch name {
+		case Random:
+			checkUniform(t, counts, len(Base36))
+		case Friendly:
+			checkUniform(t, counts, len(FriendlyLower))
+		}
+	}
+
+	code, err := NewSequential(&counter{next: 1000}, sequenceAlphabet[:36]).Generate(context.Background())
+	if err != nil || code != "rs" {
+		t.Errorf("expected rs for 1000 in base 36, got %q, %v", code, err)
+	}
+}
+
+func TestObfuscated_LargestNumbers(t *testing.T) {
+	tests := []struct {
+		digits    string
+		maxLength int
+	}{
+		{sequenceAlphabet, 10},
+		{sequenceAlphabet[:36], 11},
+	}
+	for _, tt := range tests {
+		o := NewObfuscated(nil, tt.digits, "salt", 5).(*obfuscated)
+		last := uint64(1)
+		for i := 0; i < tt.maxLength; i++ {
+			last *= uint64(len(tt.digits))
+		}
+		code, err := o.encode(last - 1)
+		if err != nil {
+			t.Fatalf("base %d: expected no error, got %v", len(tt.digits), err)
+		}
+		if len(code) != tt.maxLength {
+			t.Errorf("base %d: expected %d characters, got %q", len(tt.digits), tt.maxLength, code)
+		}
+		if _, err := o.encode(last); err == nil {
+			t.Errorf("base %d: expected an error past the longest codes", len(tt.digits))
+		}
+	}
+}
+
+// fixed generates codes in order.
+type fixed struct{ codes []string }
+
+func (f *fixed) Generate(context.Context) (string, error) {
+	code := f.codes[0]
+	f.codes = f.codes[1:]
+	return code, nil
+}
+
+func TestUnreserved(t *testing.T) {
+	g := unreserved{&fixed{codes: []string{"urls", "admin", "abc"}}}
+	code, err := g.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if code != "abc" {
+		t.Errorf("expected reserved codes to be skipped, got %q", code)
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	gens := New(&counter{}, Config{})
+	for _, name := range Names {
+		if gens[name] == nil {
+			t.Fatalf("no generator for %s", name)
+		}
+	}
+	if Attempts(gens[Sequential]) <= Attempts(gens[Random]) {
+		t.Error("expected sequences to be tried longer than random codes")
+	}
+	if !Valid(Words) || Valid("emoji") {
+		t.Error("unexpected Valid result")
+	}
+}
@@ -0,0 +1,41 @@
+package codegen
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+)
+
+// Alphabets random codes are drawn from.
+const (
+	Base62 = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	Base36 = "abcdefghijklmnopqrstuvwxyz0123456789"
+	// FriendlyAlphabet is Base62 without 0, O, o, 1, l and I.
+	FriendlyAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// FriendlyLower is Base36 without 0, o, 1 and l.
+	FriendlyLower = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+type random struct {
+	alphabet string
+	length   int
+}
+
+// NewRandom returns a generator of codes of length characters drawn
+// uniformly from alphabet with crypto/rand.
+func NewRandom(alphabet string, length int) Generator {
+	return &random{alphabet: alphabet, length: length}
+}
+
+func (r *random) Generate(context.Context) (string, error) {
+	b := make([]byte, r.length)
+	max := big.NewInt(int64(len(r.alphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = r.alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,143 @@
+package codegen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// sequenceAlphabet spells sequence numbers in base 62, digits first so
+// small numbers read naturally. Its first 36 characters spell them in
+// base 36.
+const sequenceAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+type sequential struct {
+	seq    Sequence
+	digits string
+}
+
+// NewSequential returns a generator of the numbers of seq spelled with
+// digits, in base len(digits).
+func NewSequential(seq Sequence, digits string) Generator {
+	return &sequential{seq: seq, digits: digits}
+}
+
+func (s *sequential) Generate(ctx context.Context) (string, error) {
+	id, err := s.seq.NextCodeID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if id < 0 {
+		return "", fmt.Errorf("negative sequence number %d", id)
+	}
+	return spell(uint64(id), s.digits, 1), nil
+}
+
+// spell writes n in base len(digits) with at least width digits.
+func spell(n uint64, digits string, width int) string {
+	base := uint64(len(digits))
+	var b [64]byte
+	i := len(b)
+	for n > 0 || len(b)-i < width {
+		i--
+		b[i] = digits[n%base]
+		n /= base
+	}
+	return string(b[i:])
+}
+
+const (
+	// feistelRounds is enough rounds for every output bit to depend on
+	// every input bit.
+	feistelRounds = 4
+	// maxObfuscatedSpace bounds the codes of one length, keeping the
+	// permuted range, rounded up to an even number of bits, within 64.
+	maxObfuscatedSpace = 1 << 62
+)
+
+type obfuscated struct {
+	seq       Sequence
+	digits    string
+	minLength int
+	keys      [feistelRounds]uint64
+}
+
+// NewObfuscated returns a generator of the numbers of seq permuted within
+// the codes of their length, which is at least minLength, and spelled with
+// digits. The permutation is a Feistel network keyed by salt, so each
+// number has a code of its own and consecutive numbers get unrelated
+// codes.
+func NewObfuscated(seq Sequence, digits, salt string, minLength int) Generator {
+	o := &obfuscated{seq: seq, digits: digits, minLength: minLength}
+	sum := sha256.Sum256([]byte(salt))
+	for i := range o.keys {
+		o.keys[i] = binary.BigEndian.Uint64(sum[i*8:])
+	}
+	return o
+}
+
+func (o *obfuscated) Generate(ctx context.Context) (string, error) {
+	id, err := o.seq.NextCodeID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if id < 0 {
+		return "", fmt.Errorf("negative sequence number %d", id)
+	}
+	return o.encode(uint64(id))
+}
+
+// encode returns the code of n: the shortest length from minLength whose
+// codes include n, and n's image under the permutation of those codes.
+// Numbers too large for a shorter length are the only ones given a longer
+// one, so codes never repeat.
+func (o *obfuscated) encode(n uint64) (string, error) {
+	base := uint64(len(o.digits))
+	length, space := o.minLength, uint64(1)
+	for i := 0; i < length; i++ {
+		space *= base
+	}
+	for n >= space {
+		if space > maxObfuscatedSpace/base {
+			return "", fmt.Errorf("sequence number %d is too large", n)
+		}
+		length++
+		space *= base
+	}
+
+	// The Feistel network permutes a range of an even number of bits
+	// covering space; values that land outside space are permuted again
+	// until they fall inside (cycle walking), which keeps it a permutation
+	// of space alone.
+	width := bits.Len64(space - 1)
+	width += width % 2
+	x := o.permute(n, width)
+	for x >= space {
+		x = o.permute(x, width)
+	}
+	return spell(x, o.digits, length), nil
+}
+
+// permute applies the keyed Feistel network to x, a value of width bits.
+func (o *obfuscated) permute(x uint64, width int) uint64 {
+	half := width / 2
+	mask := uint64(1)<<half - 1
+	l, r := x>>half, x&mask
+	for _, key := range o.keys {
+		l, r = r, l^(mix(r^key)&mask)
+	}
+	return l<<half | r
+}
+
+// mix is the SplitMix64 finalizer, scrambling x so that each output bit
+// depends on every input bit.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
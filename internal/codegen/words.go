@@ -0,0 +1,40 @@
+package codegen
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"math/big"
+	"strings"
+)
+
+//go:embed words.txt
+var wordList string
+
+// words are short, common English words, easy to say and spell, with no
+// two alike. None is longer than six letters, so three of them joined by
+// hyphens never run past 20 characters.
+var words = strings.Fields(wordList)
+
+type wordCodes struct {
+	count int
+}
+
+// NewWords returns a generator of count words drawn uniformly with
+// crypto/rand and joined by hyphens, such as "maple-otter-cabin".
+func NewWords(count int) Generator {
+	return &wordCodes{count: count}
+}
+
+func (w *wordCodes) Generate(context.Context) (string, error) {
+	picked := make([]string, w.count)
+	max := big.NewInt(int64(len(words)))
+	for i := range picked {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		picked[i] = words[n.Int64()]
+	}
+	return strings.Join(picked, "-"), nil
+}
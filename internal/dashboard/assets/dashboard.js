@@ -0,0 +1,63 @@
+// Ask before submitting destructive forms.
+document.querySelectorAll('form[data-confirm]').forEach((form) => {
+    form.addEventListener('submit', (e) => {
+        if (!confirm(form.dataset.confirm)) {
+            e.preventDefault();
+        }
+    });
+});
+
+// Draw daily clicks from the stats API as a bar chart.
+const stats = document.getElementById('stats');
+if (stats) {
+    loadStats(stats);
+}
+
+async function loadStats(el) {
+    let data;
+    try {
+        const res = await fetch(el.dataset.src);
+        data = await res.json();
+        if (!res.ok) {
+            el.textContent = data.detail || data.title;
+            return;
+        }
+    } catch (err) {
+        el.textContent = 'Could not load click statistics';
+        return;
+    }
+
+    // The API omits days without clicks; fill them in so bars line up.
+    const days = 30;
+    const counts = new Map(data.daily.map((d) => [d.date, d.clicks]));
+    const series = [];
+    for (let i = days - 1; i >= 0; i--) {
+        const day = new Date(Date.now() - i * 86400000).toISOString().slice(0, 10);
+        series.push({ date: day, clicks: counts.get(day) || 0 });
+    }
+    const peak = Math.max(1, ...series.map((d) => d.clicks));
+
+    const ns = 'http://www.w3.org/2000/svg';
+    const svg = document.createElementNS(ns, 'svg');
+    svg.setAttribute('viewBox', `0 0 ${days * 10} 100`);
+    svg.setAttribute('preserveAspectRatio', 'none');
+    series.forEach((d, i) => {
+        const h = (d.clicks / peak) * 100;
+        const bar = document.createElementNS(ns, 'rect');
+        bar.setAttribute('x', i * 10 + 1);
+        bar.setAttribute('y', 100 - h);
+        bar.setAttribute('width', 8);
+        bar.setAttribute('height', h);
+        const title = document.createElementNS(ns, 'title');
+        title.textContent = `${d.date}: ${d.clicks}`;
+        bar.appendChild(title);
+        svg.appendChild(bar);
+    });
+
+    const summary = document.createElement('p');
+    summary.textContent = `${data.total_clicks} clicks in total, ${series.reduce((n, d) => n + d.clicks, 0)} in the last ${days} days, not counting ${data.bot_clicks} from bots`;
+    if (data.last_clicked_at) {
+        summary.textContent += `; last ${new Date(data.last_clicked_at).toLocaleString()}`;
+    }
+    el.replaceChildren(svg, summary);
+}
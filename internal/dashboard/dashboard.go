@@ -0,0 +1,440 @@
+// Package dashboard serves the admin web UI for managing links. Its
+// templates and assets are embedded in the binary.
+package dashboard
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/rs/zerolog"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/assets"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/kerbatek/url-shortener/internal/sso"
+)
+
+const (
+	basePath = "/admin"
+	pageSize = 25
+	// historySize is how many of a link's latest changes its page lists.
+	historySize = 20
+
+	defaultQRSize = 256
+	maxQRSize     = 1024
+)
+
+//go:embed templates assets
+var files embed.FS
+
+// flashes are the confirmations a redirect can ask the next page to show,
+// keyed so the query string never carries text of its own.
+var flashes = map[string]string{
+	"saved":    "Link saved.",
+	"deleted":  "Link deleted. It can be restored from the Deleted tab.",
+	"restored": "Link restored.",
+}
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04") },
+	"join": strings.Join,
+}
+
+// Dashboard renders the admin pages. Every page but login requires a
+// session, which is granted for the admin API key or, when SSO is set, to
+// operators signing in through the identity provider.
+type Dashboard struct {
+	// SSO offers sign-in through an OIDC identity provider when set
+	// before Register. Accounts must be set with it: it finds the user
+	// each sign-in acts as, so the audit log names them.
+	SSO      *sso.Provider
+	Accounts *service.AccountService
+
+	service  *service.URLService
+	sessions *sessions
+	logger   zerolog.Logger
+	pages    map[string]*template.Template
+	assets   *assets.Server
+}
+
+func New(svc *service.URLService, adminKey string, logger zerolog.Logger) *Dashboard {
+	pages := map[string]*template.Template{}
+	for _, name := range []string{"login", "links", "link", "error"} {
+		pages[name] = template.Must(template.New(name).Funcs(funcs).
+			ParseFS(files, "templates/layout.html", "templates/"+name+".html"))
+	}
+	sub, _ := fs.Sub(files, "assets")
+	static, err := assets.NewServer(sub, false)
+	if err != nil {
+		panic(err) // the files are embedded, so this cannot fail at run time
+	}
+	return &Dashboard{service: svc, sessions: newSessions(adminKey), logger: logger, pages: pages, assets: static}
+}
+
+// Register mounts the dashboard under /admin.
+func (d *Dashboard) Register(r gin.IRouter) {
+	g := r.Group(basePath)
+	g.GET("/assets/*filepath", d.assets.Dir)
+	g.HEAD("/assets/*filepath", d.assets.Dir)
+	g.GET("/login", d.loginPage)
+	g.POST("/login", d.login)
+	if d.SSO != nil {
+		g.GET("/sso", d.ssoStart)
+		g.GET("/sso/callback", d.ssoCallback)
+	}
+
+	auth := g.Group("", d.sessions.require, asOperator)
+	auth.POST("/logout", d.logout)
+	auth.GET("", d.links)
+	auth.GET("/links/:id", d.link)
+	auth.POST("/links/:id", d.update)
+	auth.POST("/links/:id/delete", d.delete)
+	auth.POST("/links/:id/restore", d.restore)
+	auth.GET("/links/:id/qr.png", d.qr)
+	auth.GET("/links/:id/stats", d.stats)
+}
+
+// asOperator lets signed-in admins see and change links in every
+// workspace. Changes are made in the name of the user who signed in
+// through SSO, or of the operator for the admin key.
+func asOperator(c *gin.Context) {
+	user, _ := c.Value(userKey).(*model.User)
+	ctx := service.WithCaller(c.Request.Context(), service.Caller{User: user, Operator: true})
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+func (d *Dashboard) render(c *gin.Context, status int, page string, data gin.H) {
+	data["CSRF"] = c.GetString(csrfField)
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Render(status, render.HTML{Template: d.pages[page], Name: "layout", Data: data})
+}
+
+// renderError shows err on the error page. Only apperr messages are shown;
+// anything else is logged.
+func (d *Dashboard) renderError(c *gin.Context, err error) {
+	status := middleware.StatusFor(err)
+	msg := apperr.Message(err)
+	if status >= http.StatusInternalServerError {
+		d.logger.Error().Err(err).Str("path", c.Request.URL.Path).Msg("dashboard request failed")
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	d.render(c, status, "error", gin.H{"Title": http.StatusText(status), "Error": msg})
+}
+
+func (d *Dashboard) loginPage(c *gin.Context) {
+	d.renderLogin(c, http.StatusOK, "")
+}
+
+// renderLogin shows the login page with an error, if any.
+func (d *Dashboard) renderLogin(c *gin.Context, status int, msg string) {
+	d.render(c, status, "login", gin.H{"Title": "Sign in", "Error": msg, "SSO": d.SSO != nil})
+}
+
+func (d *Dashboard) login(c *gin.Context) {
+	if !d.sessions.checkKey(c.PostForm("api_key")) {
+		d.renderLogin(c, http.StatusUnauthorized, "Invalid API key.")
+		return
+	}
+	d.sessions.setCookie(c, d.sessions.issue(nil), int(sessionTTL.Seconds()))
+	c.Redirect(http.StatusSeeOther, basePath)
+}
+
+func (d *Dashboard) logout(c *gin.Context) {
+	d.sessions.setCookie(c, "", -1)
+	c.Redirect(http.StatusSeeOther, basePath+"/login")
+}
+
+// row is a link as the table shows it.
+type row struct {
+	model.URL
+	ShortURL string
+}
+
+func (d *Dashboard) links(c *gin.Context) {
+	q := model.ListQuery{
+		Limit:    pageSize,
+		Cursor:   c.Query("cursor"),
+		Status:   c.Query("status"),
+		Search:   c.Query("q"),
+		Tags:     c.QueryArray("tag"),
+		Campaign: c.Query("campaign"),
+	}
+	page, err := d.service.List(c.Request.Context(), q)
+	if err != nil {
+		d.renderError(c, err)
+		return
+	}
+
+	rows := make([]row, len(page.URLs))
+	for i, u := range page.URLs {
+		rows[i] = row{URL: u, ShortURL: shortURL(c, &u)}
+	}
+	data := gin.H{
+		"Title":    "Links",
+		"Rows":     rows,
+		"Status":   q.Status,
+		"Search":   q.Search,
+		"Tags":     q.Tags,
+		"Campaign": q.Campaign,
+		"Flash":    flashes[c.Query("msg")],
+	}
+	if q.Cursor != "" {
+		data["First"] = listURL(q, "")
+	}
+	if page.NextCursor != "" {
+		data["Next"] = listURL(q, page.NextCursor)
+	}
+	d.render(c, http.StatusOK, "links", data)
+}
+
+// listURL links to the page of the listing q starting at cursor.
+func listURL(q model.ListQuery, cursor string) string {
+	v := url.Values{}
+	if cursor != "" {
+		v.Set("cursor", cursor)
+	}
+	if q.Status != "" {
+		v.Set("status", q.Status)
+	}
+	if q.Search != "" {
+		v.Set("q", q.Search)
+	}
+	for _, t := range q.Tags {
+		v.Add("tag", t)
+	}
+	if q.Campaign != "" {
+		v.Set("campaign", q.Campaign)
+	}
+	if len(v) == 0 {
+		return basePath
+	}
+	return basePath + "?" + v.Encode()
+}
+
+func (d *Dashboard) link(c *gin.Context) {
+	u, err := d.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		d.renderError(c, err)
+		return
+	}
+	d.renderLink(c, http.StatusOK, u, gin.H{"Flash": flashes[c.Query("msg")]})
+}
+
+func (d *Dashboard) renderLink(c *gin.Context, status int, u *model.URL, data gin.H) {
+	data["Title"] = u.Code
+	data["Link"] = u
+	data["ShortURL"] = shortURL(c, u)
+	if _, ok := data["UTM"]; !ok {
+		data["UTM"] = formatUTM(u.UTMParams)
+	}
+	// The page does without its history when the audit log is unavailable.
+	page, err := d.service.Audit(c.Request.Context(), model.AuditQuery{Link: u.ID, Limit: historySize})
+	if err == nil {
+		data["History"] = page.Entries
+	}
+	d.render(c, status, "link", data)
+}
+
+// update saves the edit form. Every field is submitted, so all of them are
+// applied.
+func (d *Dashboard) update(c *gin.Context) {
+	id := c.Param("id")
+	dest := strings.TrimSpace(c.PostForm("url"))
+	title := c.PostForm("title")
+	description := c.PostForm("description")
+	forward := c.PostForm("forward_query") == "on"
+	precedence := c.PostForm("query_precedence")
+	botPolicy := c.PostForm("bot_policy")
+	campaign := c.PostForm("campaign")
+	tags := strings.Split(c.PostForm("tags"), ",")
+	preview := model.LinkPreview{
+		Title:       c.PostForm("preview_title"),
+		Description: c.PostForm("preview_description"),
+		ImageURL:    c.PostForm("preview_image_url"),
+	}
+	utm, err := parseUTM(c.PostForm("utm_params"))
+	var maxClicks int64
+	if err == nil {
+		maxClicks, err = parseMaxClicks(c.PostForm("max_clicks"))
+	}
+	if err == nil {
+		_, err = d.service.Update(c.Request.Context(), id, model.UpdateRequest{
+			URL:             &dest,
+			ForwardQuery:    &forward,
+			QueryPrecedence: &precedence,
+			UTMParams:       &utm,
+			BotPolicy:       &botPolicy,
+			Campaign:        &campaign,
+			Tags:            &tags,
+			Preview:         &preview,
+			Title:           &title,
+			Description:     &description,
+			MaxClicks:       &maxClicks,
+		})
+	}
+	if err != nil {
+		if !errors.Is(err, apperr.ErrInvalid) {
+			d.renderError(c, err)
+			return
+		}
+		// Show the form again with what was submitted.
+		u, getErr := d.service.Get(c.Request.Context(), id)
+		if getErr != nil {
+			d.renderError(c, getErr)
+			return
+		}
+		u.OriginalURL, u.ForwardQuery, u.QueryPrecedence, u.BotPolicy = dest, forward, precedence, botPolicy
+		u.Campaign, u.Tags = campaign, tags
+		u.Title, u.Description, u.MaxClicks = title, description, maxClicks
+		u.Preview = &preview
+		d.renderLink(c, http.StatusBadRequest, u, gin.H{
+			"Error": apperr.Message(err),
+			"UTM":   c.PostForm("utm_params"),
+		})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, basePath+"/links/"+url.PathEscape(id)+"?msg=saved")
+}
+
+func (d *Dashboard) delete(c *gin.Context) {
+	if err := d.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		d.renderError(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, basePath+"?msg=deleted")
+}
+
+func (d *Dashboard) restore(c *gin.Context) {
+	u, err := d.service.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		d.renderError(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, basePath+"/links/"+url.PathEscape(u.ID)+"?msg=restored")
+}
+
+// stats serves the click statistics charted on a link's page, in the
+// shape of the API's. It cannot use the API itself, which does not know
+// the session and would refuse workspace links.
+func (d *Dashboard) stats(c *gin.Context) {
+	stats, err := d.service.Stats(c.Request.Context(), c.Param("id"), false)
+	if err != nil {
+		status := middleware.StatusFor(err)
+		if status >= http.StatusInternalServerError {
+			d.logger.Error().Err(err).Str("path", c.Request.URL.Path).Msg("dashboard request failed")
+		}
+		c.JSON(status, middleware.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: apperr.Message(err),
+		})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, stats)
+}
+
+// qr serves a PNG QR code of the short URL. ?size= sets its width in
+// pixels and ?download=1 saves it as a file.
+func (d *Dashboard) qr(c *gin.Context) {
+	size := defaultQRSize
+	if v := c.Query("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 64 || n > maxQRSize {
+			d.renderError(c, apperr.Invalid("size must be between 64 and %d", maxQRSize))
+			return
+		}
+		size = n
+	}
+	u, err := d.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		d.renderError(c, err)
+		return
+	}
+	png, err := qrcode.Encode(shortURL(c, u), qrcode.Medium, size)
+	if err != nil {
+		d.renderError(c, fmt.Errorf("encoding QR code: %w", err))
+		return
+	}
+	if c.Query("download") != "" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.png"`, u.Code))
+	}
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// shortURL is the address u is served at: its custom domain, or the host
+// the dashboard was reached on.
+func shortURL(c *gin.Context, u *model.URL) string {
+	host := c.Request.Host
+	if u.Domain != "" {
+		host = u.Domain
+	}
+	return scheme(c) + "://" + host + "/" + u.Code
+}
+
+func scheme(c *gin.Context) string {
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		return "https"
+	}
+	return "http"
+}
+
+// formatUTM renders UTM parameters one key=value per line, as parseUTM
+// reads them back.
+func formatUTM(params map[string]string) string {
+	lines := make([]string, 0, len(params))
+	for k, v := range params {
+		lines = append(lines, k+"="+v)
+	}
+	slices.Sort(lines)
+	return strings.Join(lines, "\n")
+}
+
+// parseMaxClicks reads the click limit field, where blank means no limit.
+func parseMaxClicks(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, apperr.Invalid("click limit must be a whole number, got %q", text)
+	}
+	return n, nil
+}
+
+func parseUTM(text string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, apperr.Invalid("UTM parameters must be key=value, got %q", line)
+		}
+		params[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return params, nil
+}
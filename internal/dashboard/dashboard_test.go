@@ -0,0 +1,352 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+)
+
+const (
+	testKey = "admin-secret"
+	testID  = "550e8400-e29b-41d4-a716-446655440000"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupRouter(ctrl *gomock.Controller) (*gin.Engine, *mocks.MockURLRepository) {
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	router := gin.New()
+	New(service.NewURLService(mockRepo), testKey, zerolog.Nop()).Register(router)
+	return router, mockRepo
+}
+
+// login signs in and returns the session cookie and its CSRF token.
+func login(t *testing.T, router *gin.Engine) (*http.Cookie, string) {
+	t.Helper()
+	w := postForm(router, "/admin/login", nil, url.Values{"api_key": {testKey}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie {
+		t.Fatalf("expected a session cookie, got %+v", cookies)
+	}
+	return cookies[0], newSessions(testKey).csrfToken(cookies[0].Value)
+}
+
+func postForm(router *gin.Engine, path string, cookie *http.Cookie, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func get(router *gin.Engine, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPages_RequireSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupRouter(ctrl)
+
+	w := get(router, "/admin", nil)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin/login" {
+		t.Errorf("expected redirect to login, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	forged := &http.Cookie{Name: sessionCookie, Value: "9999999999.deadbeef"}
+	if w := get(router, "/admin", forged); w.Code != http.StatusSeeOther {
+		t.Errorf("expected forged session to be refused, got %d", w.Code)
+	}
+}
+
+func TestLogin_InvalidKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupRouter(ctrl)
+
+	w := postForm(router, "/admin/login", nil, url.Values{"api_key": {"wrong"}})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("expected no session cookie")
+	}
+}
+
+func TestLinks_Table(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+	cookie, _ := login(t, router)
+
+	urls := make([]model.URL, pageSize)
+	for i := range urls {
+		urls[i] = model.URL{ID: testID, Code: "abc1234", OriginalURL: "https://example.com/<b>", CreatedAt: time.Now()}
+	}
+	urls[0].MaxClicks, urls[0].ClicksUsed = 1, 1
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: pageSize, Search: "example", AllWorkspaces: true}).
+		Return(urls, nil)
+
+	w := get(router, "/admin?q=example", cookie)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "http://example.com/abc1234") {
+		t.Error("expected the short URL in the table")
+	}
+	if strings.Contains(body, "<b>") {
+		t.Error("expected the destination to be escaped")
+	}
+	if !strings.Contains(body, "Next page") {
+		t.Error("expected a next page link on a full page")
+	}
+	if strings.Count(body, ">used up<") != 1 {
+		t.Error("expected the exhausted link to be marked used up")
+	}
+}
+
+func TestLinks_GroupFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+	cookie, _ := login(t, router)
+
+	urls := make([]model.URL, pageSize)
+	for i := range urls {
+		urls[i] = model.URL{ID: testID, Code: "abc1234", OriginalURL: "https://example.com",
+			Campaign: "Spring launch", Tags: []string{"promo"}, CreatedAt: time.Now()}
+	}
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: pageSize, Campaign: "Spring launch", AllWorkspaces: true}).
+		Return(urls, nil)
+
+	w := get(router, "/admin?campaign=Spring+launch", cookie)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `href="/admin?tag=promo"`) {
+		t.Error("expected tags to link to their listing")
+	}
+	if !strings.Contains(body, "campaign=Spring&#43;launch") {
+		t.Error("expected the next page to keep the campaign filter")
+	}
+}
+
+func TestDelete_RequiresCSRF(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+	cookie, csrf := login(t, router)
+
+	w := postForm(router, "/admin/links/"+testID+"/delete", cookie, nil)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without a CSRF token, got %d", w.Code)
+	}
+
+	mockRepo.EXPECT().Delete(gomock.Any(), testID).Return(nil)
+
+	w = postForm(router, "/admin/links/"+testID+"/delete", cookie, url.Values{csrfField: {csrf}})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin?msg=deleted" {
+		t.Errorf("expected redirect to the table, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestRestore_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+	cookie, csrf := login(t, router)
+
+	mockRepo.EXPECT().Restore(gomock.Any(), testID).Return(nil, apperr.NotFound("url not found"))
+
+	w := postForm(router, "/admin/links/"+testID+"/restore", cookie, url.Values{csrfField: {csrf}})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+	cookie, csrf := login(t, router)
+
+	existing := func() *model.URL {
+		return &model.URL{ID: testID, Code: "abc1234", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	}
+	mockRepo.EXPECT().GetByID(gomock.Any(), testID).Return(existing(), nil)
+	mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, u *model.URL) error {
+			if u.OriginalURL != "https://example.org" || !u.ForwardQuery || u.UTMParams["utm_source"] != "mail" ||
+				u.BotPolicy != model.BotPolicyPreview || u.Preview == nil || u.Preview.Title != "Spring sale" ||
+				u.Campaign != "Spring" || len(u.Tags) != 2 || u.Tags[0] != "mail" ||
+				u.Title != "Spring sale" || u.Description != "For the newsletter" || u.MaxClicks != 10 {
+				t.Errorf("unexpected update %+v", u)
+			}
+			return nil
+		})
+
+	w := postForm(router, "/admin/links/"+testID, cookie, url.Values{
+		csrfField:          {csrf},
+		"url":              {"https://example.org"},
+		"forward_query":    {"on"},
+		"query_precedence": {model.QueryPrecedenceIncoming},
+		"utm_params":       {"utm_source=mail\n"},
+		"bot_policy":       {model.BotPolicyPreview},
+		"preview_title":    {"Spring sale"},
+		"campaign":         {"Spring"},
+		"tags":             {"promo, Mail"},
+		"title":            {"Spring sale"},
+		"description":      {"For the newsletter"},
+		"max_clicks":       {"10"},
+	})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d", w.Code)
+	}
+
+	// An invalid form is shown again with the error.
+	mockRepo.EXPECT().GetByID(gomock.Any(), testID).Return(existing(), nil).Times(2)
+
+	w = postForm(router, "/admin/links/"+testID, cookie, url.Values{
+		csrfField:          {csrf},
+		"url":              {"https://example.org"},
+		"query_precedence": {model.QueryPrecedenceIncoming},
+		"utm_params":       {"source=mail"},
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "invalid UTM parameter") {
+		t.Error("expected the validation error on the page")
+	}
+}
+
+func TestQR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+	cookie, _ := login(t, router)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), testID).
+		Return(&model.URL{ID: testID, Code: "abc1234", Domain: "go.example.com"}, nil)
+
+	w := get(router, "/admin/links/"+testID+"/qr.png?download=1", cookie)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected image/png, got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "abc1234.png") {
+		t.Errorf("expected attachment named after the code, got %q", cd)
+	}
+
+	if w := get(router, "/admin/links/"+testID+"/qr.png?size=5", cookie); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a tiny QR code, got %d", w.Code)
+	}
+}
+
+func TestSessions_Expire(t *testing.T) {
+	s := newSessions(testKey)
+	session := s.issue(nil)
+	if user, ok := s.open(session); !ok || user != nil {
+		t.Fatalf("expected a fresh admin key session to be valid, got %v %v", user, ok)
+	}
+	if _, ok := newSessions("other-key").open(session); ok {
+		t.Error("expected a session signed with another key to be invalid")
+	}
+
+	s.now = func() time.Time { return time.Now().Add(sessionTTL + time.Minute) }
+	if _, ok := s.open(session); ok {
+		t.Error("expected an expired session to be invalid")
+	}
+}
+
+func TestSessions_User(t *testing.T) {
+	s := newSessions(testKey)
+	session := s.issue(&model.User{ID: "u1", Email: "ada.lovelace@example.com"})
+	user, ok := s.open(session)
+	if !ok || user == nil || user.ID != "u1" || user.Email != "ada.lovelace@example.com" {
+		t.Fatalf("expected the signed-in user back, got %+v %v", user, ok)
+	}
+
+	// Swapping in another user ID breaks the signature.
+	parts := strings.Split(session, ".")
+	parts[1] = "u2"
+	if _, ok := s.open(strings.Join(parts, ".")); ok {
+		t.Error("expected a tampered session to be invalid")
+	}
+}
+
+func TestLink_History(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	router := gin.New()
+	New(service.NewURLService(mockRepo, service.WithAudit(mockAudit)), testKey, zerolog.Nop()).Register(router)
+	cookie, _ := login(t, router)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), testID).
+		Return(&model.URL{ID: testID, Code: "abc1234", OriginalURL: "https://example.org"}, nil)
+	mockAudit.EXPECT().
+		List(gomock.Any(), model.AuditOptions{Limit: historySize, LinkID: testID, AllWorkspaces: true}).
+		Return([]model.AuditEntry{{
+			Actor: "u1", ActorEmail: "ada@example.com", Action: model.AuditUpdate, LinkID: testID,
+			Before: &model.URL{OriginalURL: "https://example.com"}, After: &model.URL{OriginalURL: "https://example.org"},
+		}}, nil)
+
+	w := get(router, "/admin/links/"+testID, cookie)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "ada@example.com") || !strings.Contains(body, "https://example.com → https://example.org") {
+		t.Error("expected the change in the link's history")
+	}
+}
@@ -0,0 +1,137 @@
+package dashboard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+const (
+	sessionCookie = "dashboard_session"
+	sessionTTL    = 12 * time.Hour
+	csrfField     = "csrf"
+	// userKey holds the signed-in user in the gin context.
+	userKey = "dashboard_user"
+)
+
+// sessions issues and verifies stateless session cookies. A session is its
+// expiry and who signed in, signed with a key derived from the admin key,
+// so changing the key signs everyone out.
+type sessions struct {
+	adminKey []byte
+	key      []byte
+	now      func() time.Time
+}
+
+func newSessions(adminKey string) *sessions {
+	key := sha256.Sum256([]byte("dashboard-session:" + adminKey))
+	return &sessions{adminKey: []byte(adminKey), key: key[:], now: time.Now}
+}
+
+// checkKey reports whether apiKey is the admin key.
+func (s *sessions) checkKey(apiKey string) bool {
+	return apiKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), s.adminKey) == 1
+}
+
+func (s *sessions) sign(msg string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// issue returns a new session value for user, or for whoever holds the
+// admin key when user is nil.
+func (s *sessions) issue(user *model.User) string {
+	var id, email string
+	if user != nil {
+		id, email = user.ID, user.Email
+	}
+	value := strings.Join([]string{
+		strconv.FormatInt(s.now().Add(sessionTTL).Unix(), 10),
+		id,
+		base64.RawURLEncoding.EncodeToString([]byte(email)),
+	}, ".")
+	return value + "." + s.sign("session:"+value)
+}
+
+// open returns who signed in to value, if it is an unexpired session this
+// key issued. The user is nil for the admin key.
+func (s *sessions) open(value string) (*model.User, bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 || !hmac.Equal([]byte(value[i+1:]), []byte(s.sign("session:"+value[:i]))) {
+		return nil, false
+	}
+	parts := strings.Split(value[:i], ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	unix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || s.now().Unix() >= unix {
+		return nil, false
+	}
+	if parts[1] == "" {
+		return nil, true
+	}
+	email, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, false
+	}
+	return &model.User{ID: parts[1], Email: string(email)}, true
+}
+
+// csrfToken ties form submissions to the session that rendered them.
+func (s *sessions) csrfToken(session string) string {
+	return s.sign("csrf:" + session)
+}
+
+func (s *sessions) setCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     basePath,
+		MaxAge:   maxAge,
+		Secure:   scheme(c) == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// require redirects requests without a valid session to the login page and
+// rejects unsafe requests that lack the session's CSRF token. The user who
+// signed in, if any, is left under userKey.
+func (s *sessions) require(c *gin.Context) {
+	session, err := c.Cookie(sessionCookie)
+	user, ok := s.open(session)
+	if err != nil || !ok {
+		if c.Request.Method == http.MethodGet {
+			c.Redirect(http.StatusSeeOther, basePath+"/login")
+		} else {
+			c.String(http.StatusUnauthorized, "session expired, sign in again")
+		}
+		c.Abort()
+		return
+	}
+	csrf := s.csrfToken(session)
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		if !hmac.Equal([]byte(c.PostForm(csrfField)), []byte(csrf)) {
+			c.String(http.StatusForbidden, "invalid CSRF token")
+			c.Abort()
+			return
+		}
+	}
+	c.Set(csrfField, csrf)
+	if user != nil {
+		c.Set(userKey, user)
+	}
+	c.Next()
+}
@@ -0,0 +1,124 @@
+package dashboard
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/sso"
+)
+
+const (
+	ssoCookie = "dashboard_sso"
+	ssoPath   = basePath + "/sso"
+	// ssoTTL is how long a sign-in may take at the identity provider.
+	ssoTTL = 10 * time.Minute
+)
+
+// ssoStart sends the browser to the identity provider, remembering the
+// sign-in in a short-lived signed cookie.
+func (d *Dashboard) ssoStart(c *gin.Context) {
+	login, err := sso.NewLogin()
+	if err != nil {
+		d.renderError(c, err)
+		return
+	}
+	expiry := strconv.FormatInt(d.sessions.now().Add(ssoTTL).Unix(), 10)
+	value := strings.Join([]string{login.State, login.Nonce, login.Verifier, expiry}, ".")
+	d.setSSOCookie(c, value+"."+d.sessions.sign("sso:"+value), int(ssoTTL.Seconds()))
+	c.Redirect(http.StatusFound, d.SSO.AuthCodeURL(login))
+}
+
+// ssoLogin reads back the sign-in ssoStart remembered, if it is intact and
+// unexpired.
+func (d *Dashboard) ssoLogin(c *gin.Context) (sso.Login, bool) {
+	cookie, err := c.Cookie(ssoCookie)
+	if err != nil {
+		return sso.Login{}, false
+	}
+	i := strings.LastIndexByte(cookie, '.')
+	if i < 0 || !hmac.Equal([]byte(cookie[i+1:]), []byte(d.sessions.sign("sso:"+cookie[:i]))) {
+		return sso.Login{}, false
+	}
+	parts := strings.Split(cookie[:i], ".")
+	if len(parts) != 4 {
+		return sso.Login{}, false
+	}
+	unix, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || d.sessions.now().Unix() >= unix {
+		return sso.Login{}, false
+	}
+	return sso.Login{State: parts[0], Nonce: parts[1], Verifier: parts[2]}, true
+}
+
+// setSSOCookie scopes the sign-in cookie to the SSO routes. It is
+// SameSite=Lax because the provider sends the browser back from another
+// site, where a Strict cookie would not be sent.
+func (d *Dashboard) setSSOCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     ssoCookie,
+		Value:    value,
+		Path:     ssoPath,
+		MaxAge:   maxAge,
+		Secure:   scheme(c) == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ssoCallback finishes a sign-in: the provider's code is exchanged for an
+// ID token, and members of operator groups get a session as their user,
+// created on first sign-in as for bearer tokens.
+func (d *Dashboard) ssoCallback(c *gin.Context) {
+	login, ok := d.ssoLogin(c)
+	d.setSSOCookie(c, "", -1)
+	if !ok || !hmac.Equal([]byte(c.Query("state")), []byte(login.State)) {
+		d.renderLogin(c, http.StatusBadRequest, "Sign-in expired or was started in another browser. Try again.")
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		d.logger.Warn().Str("error", e).Str("description", c.Query("error_description")).Msg("SSO sign-in refused")
+		d.renderLogin(c, http.StatusUnauthorized, "The identity provider did not sign you in.")
+		return
+	}
+
+	id, err := d.SSO.Exchange(c.Request.Context(), login, c.Query("code"))
+	if err != nil {
+		d.logger.Warn().Err(err).Msg("SSO sign-in failed")
+		d.renderLogin(c, http.StatusUnauthorized, "Sign-in failed.")
+		return
+	}
+	if !id.Operator {
+		d.logger.Warn().Str("subject", id.Subject).Str("email", id.Email).Msg("SSO sign-in without an operator group")
+		d.renderLogin(c, http.StatusForbidden, "Your account is not in a group allowed to use the dashboard.")
+		return
+	}
+
+	user, err := d.Accounts.ExternalUser(c.Request.Context(), id)
+	if err != nil {
+		d.logger.Warn().Err(err).Str("subject", id.Subject).Str("email", id.Email).Msg("SSO sign-in without a user")
+		msg := apperr.Message(err)
+		if msg == "" {
+			msg = "Sign-in failed."
+		}
+		d.renderLogin(c, middleware.StatusFor(err), msg)
+		return
+	}
+
+	d.logger.Info().Str("subject", id.Subject).Str("email", id.Email).Str("user", user.ID).Msg("SSO sign-in")
+	d.sessions.setCookie(c, d.sessions.issue(user), int(sessionTTL.Seconds()))
+	// The browser arrived from the provider's site, so a redirect would not
+	// carry the SameSite=Strict session cookie yet; a page on this site
+	// moving on to the dashboard does.
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(`<!DOCTYPE html>
+<meta http-equiv="refresh" content="0; url=`+basePath+`">
+<a href="`+basePath+`">Continue to the dashboard</a>
+`))
+}
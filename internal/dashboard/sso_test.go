@@ -0,0 +1,174 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+
+	"github.com/kerbatek/url-shortener/internal/audit"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/kerbatek/url-shortener/internal/sso"
+	"github.com/kerbatek/url-shortener/internal/sso/ssotest"
+)
+
+func setupSSORouter(t *testing.T, ctrl *gomock.Controller) (*gin.Engine, *mocks.MockURLRepository, *mocks.MockAccountRepository, *ssotest.Issuer) {
+	t.Helper()
+	issuer := ssotest.NewIssuer(t, "dashboard")
+	provider, err := sso.New(context.Background(), sso.Config{
+		Issuer:      issuer.URL,
+		ClientID:    "dashboard",
+		RedirectURL: "http://example.com/admin/sso/callback",
+		Roles:       sso.RoleMap{"admins": {sso.OperatorRole}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	router := gin.New()
+	d := New(service.NewURLService(mockRepo), testKey, zerolog.Nop())
+	d.SSO, d.Accounts = provider, service.NewAccountService(mockAccounts)
+	d.Register(router)
+	return router, mockRepo, mockAccounts, issuer
+}
+
+// ssoSignIn runs a sign-in through the issuer and returns the callback's
+// response.
+func ssoSignIn(t *testing.T, router *gin.Engine) *httptest.ResponseRecorder {
+	t.Helper()
+	start := get(router, "/admin/sso", nil)
+	if start.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", start.Code)
+	}
+	cookies := start.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != ssoCookie || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected a Lax sign-in cookie, got %+v", cookies)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(start.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || callback.Path != "/admin/sso/callback" {
+		t.Fatalf("expected a redirect to the callback, got %q", resp.Header.Get("Location"))
+	}
+	return get(router, callback.RequestURI(), cookies[0])
+}
+
+func TestSSO_SignsInOperators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo, mockAccounts, issuer := setupSSORouter(t, ctrl)
+	issuer.User["groups"] = []string{"admins"}
+	ada := &model.User{ID: "u1", Email: "ada@example.com"}
+
+	mockAccounts.EXPECT().
+		ExternalUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, id *model.Identity) (*model.User, error) {
+			if id.Subject != issuer.User["sub"] {
+				t.Errorf("unexpected identity %+v", id)
+			}
+			return ada, nil
+		})
+
+	w := ssoSignIn(t, router)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var session *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie {
+			session = c
+		}
+	}
+	if session == nil || !session.HttpOnly || session.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("expected an HttpOnly Strict session cookie, got %+v", w.Result().Cookies())
+	}
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: pageSize, AllWorkspaces: true}).
+		Return([]model.URL{}, nil)
+	if w := get(router, "/admin", session); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 with the SSO session, got %d", w.Code)
+	}
+
+	// Changes are audited as the user who signed in.
+	mockRepo.EXPECT().
+		Delete(gomock.Any(), testID).
+		DoAndReturn(func(ctx context.Context, _ string) error {
+			if actor := audit.ActorFrom(ctx); actor.ID != ada.ID || actor.Email != ada.Email {
+				t.Errorf("expected the change made as %s, got %+v", ada.ID, actor)
+			}
+			return nil
+		})
+	csrf := newSessions(testKey).csrfToken(session.Value)
+	if w := postForm(router, "/admin/links/"+testID+"/delete", session, url.Values{csrfField: {csrf}}); w.Code != http.StatusSeeOther {
+		t.Errorf("expected status 303, got %d", w.Code)
+	}
+}
+
+func TestSSO_RequiresOperatorGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _, _, issuer := setupSSORouter(t, ctrl)
+	issuer.User["groups"] = []string{"marketing"}
+
+	w := ssoSignIn(t, router)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie {
+			t.Error("expected no session cookie")
+		}
+	}
+}
+
+func TestSSO_CallbackChecksState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _, _, _ := setupSSORouter(t, ctrl)
+	start := get(router, "/admin/sso", nil)
+	cookie := start.Result().Cookies()[0]
+
+	w := get(router, "/admin/sso/callback?code=abc&state=forged", cookie)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+	w = get(router, "/admin/sso/callback?code=abc&state=forged", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without the sign-in cookie, got %d", w.Code)
+	}
+}
+
+func TestSSO_LoginPageOffersSSO(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _, _, _ := setupSSORouter(t, ctrl)
+	if body := get(router, "/admin/login", nil).Body.String(); !strings.Contains(body, `href="/admin/sso"`) {
+		t.Error("expected a single sign-on link on the login page")
+	}
+	plain, _ := setupRouter(ctrl)
+	if body := get(plain, "/admin/login", nil).Body.String(); strings.Contains(body, "/admin/sso") {
+		t.Error("expected no single sign-on link without SSO")
+	}
+}
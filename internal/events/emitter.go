@@ -0,0 +1,212 @@
+// Package events streams click events to external sinks: rotating NDJSON
+// files, an HTTP endpoint and Kafka-compatible brokers.
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// Sink delivers batches of events to an external system. An Emitter calls
+// Write and Close from a single goroutine, and reuses the batch slice once
+// Write returns. A failed Write is retried with the same batch, or with the
+// events a *PartialError says are left.
+type Sink interface {
+	Write(ctx context.Context, batch []model.ClickEvent) error
+	Close() error
+}
+
+// PartialError is returned by a Sink that delivered part of a batch before
+// failing, so only Remaining, the events it did not deliver, are retried.
+type PartialError struct {
+	Remaining []model.ClickEvent
+	Err       error
+}
+
+func (e *PartialError) Error() string { return e.Err.Error() }
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// Policy decides what Publish does when an emitter's buffer is full.
+type Policy string
+
+const (
+	// PolicyDrop discards the event, so a slow sink never delays a
+	// redirect.
+	PolicyDrop Policy = "drop"
+	// PolicyBlock waits for room, until the redirect's request is
+	// cancelled, so no event is lost while clients are still waiting.
+	PolicyBlock Policy = "block"
+)
+
+// Emitter buffers events for one sink and delivers them in batches from
+// Run. Delivery metrics are labelled with the emitter's name.
+type Emitter struct {
+	name   string
+	sink   Sink
+	queue  chan model.ClickEvent
+	logger zerolog.Logger
+
+	Policy        Policy
+	BatchSize     int
+	FlushInterval time.Duration
+	// MaxAttempts is how many times a batch is written before it is
+	// dropped; attempts are RetryBackoff, 2*RetryBackoff, ... apart.
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	// ShutdownTimeout bounds delivering what is still buffered when Run's
+	// context is cancelled.
+	ShutdownTimeout time.Duration
+}
+
+// NewEmitter returns an emitter for sink that buffers up to buffer events.
+func NewEmitter(name string, sink Sink, buffer int, logger zerolog.Logger) *Emitter {
+	return &Emitter{
+		name:            name,
+		sink:            sink,
+		queue:           make(chan model.ClickEvent, buffer),
+		logger:          logger.With().Str("sink", name).Logger(),
+		Policy:          PolicyDrop,
+		BatchSize:       100,
+		FlushInterval:   time.Second,
+		MaxAttempts:     3,
+		RetryBackoff:    500 * time.Millisecond,
+		ShutdownTimeout: 5 * time.Second,
+	}
+}
+
+// Publish queues event for delivery, applying Policy when the buffer is
+// full.
+func (e *Emitter) Publish(ctx context.Context, event model.ClickEvent) {
+	if e.Policy == PolicyBlock {
+		select {
+		case e.queue <- event:
+		case <-ctx.Done():
+			eventsDropped.WithLabelValues(e.name, "cancelled").Inc()
+			return
+		}
+	} else {
+		select {
+		case e.queue <- event:
+		default:
+			eventsDropped.WithLabelValues(e.name, "buffer_full").Inc()
+			return
+		}
+	}
+	eventsQueued.WithLabelValues(e.name).Inc()
+	bufferLength.WithLabelValues(e.name).Set(float64(len(e.queue)))
+}
+
+// Run delivers batches until ctx is cancelled, then flushes what is still
+// buffered, including a batch whose delivery the cancellation cut short,
+// and closes the sink.
+func (e *Emitter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.ClickEvent, 0, e.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			e.shutdown(batch)
+			return
+		case event := <-e.queue:
+			batch = append(batch, event)
+			if len(batch) < e.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if left := e.flush(ctx, batch); len(left) > 0 {
+			e.shutdown(left)
+			return
+		}
+		batch = batch[:0]
+	}
+}
+
+// shutdown delivers batch and then the rest of the buffer within
+// ShutdownTimeout, and closes the sink.
+func (e *Emitter) shutdown(batch []model.ClickEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ShutdownTimeout)
+	defer cancel()
+
+	for drained := false; !drained; {
+		for len(batch) < e.BatchSize && !drained {
+			select {
+			case event := <-e.queue:
+				batch = append(batch, event)
+			default:
+				drained = true
+			}
+		}
+		if len(batch) > 0 {
+			if left := e.flush(ctx, batch); len(left) > 0 {
+				dropped := len(left) + len(e.queue)
+				eventsDropped.WithLabelValues(e.name, "shutdown_timeout").Add(float64(dropped))
+				e.logger.Error().Int("events", dropped).Msg("Event delivery timed out on shutdown")
+				break
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := e.sink.Close(); err != nil {
+		e.logger.Error().Err(err).Msg("Closing event sink failed")
+	}
+}
+
+// flush writes batch, retrying what is left of it with backoff, and drops
+// it after MaxAttempts failures. When ctx is done first it stops retrying
+// and returns the events still to deliver, for the caller to keep.
+func (e *Emitter) flush(ctx context.Context, batch []model.ClickEvent) []model.ClickEvent {
+	bufferLength.WithLabelValues(e.name).Set(float64(len(e.queue)))
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := e.sink.Write(ctx, batch)
+		writeDuration.WithLabelValues(e.name).Observe(time.Since(start).Seconds())
+		if err == nil {
+			batchWrites.WithLabelValues(e.name, "success").Inc()
+			eventsDelivered.WithLabelValues(e.name).Add(float64(len(batch)))
+			return nil
+		}
+		batchWrites.WithLabelValues(e.name, "error").Inc()
+		var partial *PartialError
+		if errors.As(err, &partial) {
+			eventsDelivered.WithLabelValues(e.name).Add(float64(len(batch) - len(partial.Remaining)))
+			batch = partial.Remaining
+		}
+
+		if ctx.Err() != nil {
+			return batch
+		}
+		if attempt >= e.MaxAttempts {
+			eventsDropped.WithLabelValues(e.name, "delivery_failed").Add(float64(len(batch)))
+			e.logger.Error().Err(err).Int("events", len(batch)).Int("attempts", attempt).Msg("Event delivery failed")
+			return nil
+		}
+		e.logger.Warn().Err(err).Int("attempt", attempt).Msg("Event delivery failed, retrying")
+		select {
+		case <-ctx.Done():
+			return batch
+		case <-time.After(time.Duration(attempt) * e.RetryBackoff):
+		}
+	}
+}
+
+// Emitters publishes each event to every emitter, so sinks are fed and
+// fail independently.
+type Emitters []*Emitter
+
+func (es Emitters) Publish(ctx context.Context, event model.ClickEvent) {
+	for _, e := range es {
+		e.Publish(ctx, event)
+	}
+}
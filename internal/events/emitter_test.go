@@ -0,0 +1,216 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// fakeSink records the batches it is given and fails the first failures
+// writes. With partial set, a failing write takes the first event of the
+// batch and reports the rest as left.
+type fakeSink struct {
+	mu       sync.Mutex
+	batches  [][]model.ClickEvent
+	failures int
+	partial  bool
+	attempts int
+	closed   bool
+}
+
+func (s *fakeSink) Write(_ context.Context, batch []model.ClickEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.failures > 0 && s.partial {
+		s.failures--
+		s.batches = append(s.batches, append([]model.ClickEvent(nil), batch[:1]...))
+		return &PartialError{Remaining: batch[1:], Err: errors.New("sink partly unavailable")}
+	}
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.batches = append(s.batches, append([]model.ClickEvent(nil), batch...))
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSink) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sizes []int
+	for _, b := range s.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func event(id string) model.ClickEvent {
+	return model.ClickEvent{Click: model.Click{URLID: id, ClickedAt: time.Now()}, Code: "abc1234"}
+}
+
+func TestEmitter_BatchesAndFlushesOnShutdown(t *testing.T) {
+	sink := &fakeSink{}
+	e := NewEmitter(t.Name(), sink, 10, zerolog.Nop())
+	e.BatchSize = 3
+	e.FlushInterval = time.Hour
+
+	for i := range 7 {
+		e.Publish(context.Background(), event(string(rune('a'+i))))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { e.Run(ctx); close(done) }()
+
+	deadline := time.Now().Add(time.Second)
+	for len(sink.sizes()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	sizes := sink.sizes()
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Errorf("expected batches of 3, 3 and 1, got %v", sizes)
+	}
+	if !sink.closed {
+		t.Error("expected the sink to be closed")
+	}
+	if got := testutil.ToFloat64(eventsDelivered.WithLabelValues(t.Name())); got != 7 {
+		t.Errorf("expected 7 delivered, got %v", got)
+	}
+}
+
+func TestEmitter_FlushInterval(t *testing.T) {
+	sink := &fakeSink{}
+	e := NewEmitter(t.Name(), sink, 10, zerolog.Nop())
+	e.FlushInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	e.Publish(ctx, event("a"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(sink.sizes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sizes := sink.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("expected a partial batch after the interval, got %v", sizes)
+	}
+}
+
+func TestEmitter_Retries(t *testing.T) {
+	sink := &fakeSink{failures: 2}
+	e := NewEmitter(t.Name(), sink, 10, zerolog.Nop())
+	e.RetryBackoff = time.Millisecond
+
+	e.flush(context.Background(), []model.ClickEvent{event("a")})
+
+	if sizes := sink.sizes(); len(sizes) != 1 {
+		t.Fatalf("expected delivery on the third attempt, got %v", sizes)
+	}
+	if got := testutil.ToFloat64(batchWrites.WithLabelValues(t.Name(), "error")); got != 2 {
+		t.Errorf("expected 2 failed attempts, got %v", got)
+	}
+
+	sink.failures = 3
+	e.flush(context.Background(), []model.ClickEvent{event("b"), event("c")})
+
+	if sizes := sink.sizes(); len(sizes) != 1 {
+		t.Errorf("expected the batch to be dropped, got %v", sizes)
+	}
+	if got := testutil.ToFloat64(eventsDropped.WithLabelValues(t.Name(), "delivery_failed")); got != 2 {
+		t.Errorf("expected 2 events dropped, got %v", got)
+	}
+}
+
+func TestEmitter_RetriesOnlyWhatIsLeft(t *testing.T) {
+	sink := &fakeSink{failures: 1, partial: true}
+	e := NewEmitter(t.Name(), sink, 10, zerolog.Nop())
+	e.RetryBackoff = time.Millisecond
+
+	e.flush(context.Background(), []model.ClickEvent{event("a"), event("b"), event("c")})
+
+	if sizes := sink.sizes(); len(sizes) != 2 || sizes[0] != 1 || sizes[1] != 2 {
+		t.Errorf("expected the retry to carry the 2 events left, got %v", sizes)
+	}
+	if got := testutil.ToFloat64(eventsDelivered.WithLabelValues(t.Name())); got != 3 {
+		t.Errorf("expected 3 delivered, got %v", got)
+	}
+}
+
+func TestEmitter_ShutdownKeepsBatchInFlight(t *testing.T) {
+	sink := &fakeSink{failures: 1}
+	e := NewEmitter(t.Name(), sink, 10, zerolog.Nop())
+	e.BatchSize = 2
+	e.RetryBackoff = time.Hour // the retry only happens on shutdown
+	e.Publish(context.Background(), event("a"))
+	e.Publish(context.Background(), event("b"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { e.Run(ctx); close(done) }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		sink.mu.Lock()
+		attempts := sink.attempts
+		sink.mu.Unlock()
+		if attempts > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if sizes := sink.sizes(); len(sizes) != 1 || sizes[0] != 2 {
+		t.Errorf("expected the failed batch delivered on shutdown, got %v", sizes)
+	}
+	if got := testutil.ToFloat64(eventsDropped.WithLabelValues(t.Name(), "delivery_failed")); got != 0 {
+		t.Errorf("expected nothing dropped, got %v", got)
+	}
+}
+
+func TestEmitter_Policies(t *testing.T) {
+	drop := NewEmitter(t.Name()+"-drop", &fakeSink{}, 1, zerolog.Nop())
+	drop.Publish(context.Background(), event("a"))
+	drop.Publish(context.Background(), event("b"))
+
+	if got := testutil.ToFloat64(eventsDropped.WithLabelValues(t.Name()+"-drop", "buffer_full")); got != 1 {
+		t.Errorf("expected 1 event dropped, got %v", got)
+	}
+
+	block := NewEmitter(t.Name()+"-block", &fakeSink{}, 1, zerolog.Nop())
+	block.Policy = PolicyBlock
+	block.Publish(context.Background(), event("a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	block.Publish(ctx, event("b"))
+
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("expected Publish to block until the context ended")
+	}
+	if got := testutil.ToFloat64(eventsDropped.WithLabelValues(t.Name()+"-block", "cancelled")); got != 1 {
+		t.Errorf("expected 1 event dropped, got %v", got)
+	}
+}
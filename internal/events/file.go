@@ -0,0 +1,131 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// FileSink appends events as NDJSON to files in a directory, starting a
+// new file before a batch when the current one has reached MaxSize or has
+// been open for MaxAge, and removing the oldest beyond MaxFiles. Files are
+// named clicks-<UTC start time>.ndjson, so they sort by age. A batch is
+// written whole or not at all, so retrying a failed Write never repeats
+// events.
+type FileSink struct {
+	dir string
+
+	MaxSize int64
+	MaxAge  time.Duration
+	// MaxFiles is how many files are kept, including the current one;
+	// zero keeps them all.
+	MaxFiles int
+
+	file   *os.File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+}
+
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating event directory: %w", err)
+	}
+	return &FileSink{
+		dir:      dir,
+		MaxSize:  100 << 20,
+		MaxAge:   time.Hour,
+		MaxFiles: 48,
+		now:      time.Now,
+	}, nil
+}
+
+func (s *FileSink) Write(_ context.Context, batch []model.ClickEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range batch {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	if s.file == nil || s.size >= s.MaxSize || s.now().Sub(s.opened) >= s.MaxAge {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(buf.Bytes())
+	if err != nil {
+		// Cut off the part of the batch that was written, and start a
+		// new file for the retry in case this one is broken.
+		if n > 0 {
+			if terr := s.file.Truncate(s.size); terr != nil {
+				err = fmt.Errorf("%w; truncating event file: %v", err, terr)
+			}
+		}
+		_ = s.closeFile()
+		return err
+	}
+	s.size += int64(n)
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	s.opened = s.now()
+	name := filepath.Join(s.dir, "clicks-"+s.opened.UTC().Format("20060102T150405.000Z")+".ndjson")
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening event file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return s.prune()
+}
+
+// prune removes the oldest files beyond MaxFiles.
+func (s *FileSink) prune() error {
+	if s.MaxFiles <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(s.dir, "clicks-*.ndjson"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > s.MaxFiles {
+		if err := os.Remove(files[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing old event file: %w", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+func (s *FileSink) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) Close() error {
+	return s.closeFile()
+}
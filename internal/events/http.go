@@ -0,0 +1,65 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// HTTPSink POSTs each batch to a URL as an NDJSON body. Any 2xx response
+// acknowledges the whole batch; anything else fails it.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+	// Header is added to every request, for example to authenticate.
+	Header http.Header
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		Header: http.Header{},
+	}
+}
+
+func (s *HTTPSink) Write(ctx context.Context, batch []model.ClickEvent) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, ev := range batch {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("event endpoint responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *HTTPSink) Close() error {
+	return nil
+}
@@ -0,0 +1,331 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// maxResponseSize bounds a broker response so a corrupt length cannot
+// make the producer allocate without limit.
+const maxResponseSize = 16 << 20
+
+// KafkaSink produces events as JSON to a Kafka topic, keyed by link ID so
+// each link's clicks stay in order on one partition. It speaks the Kafka
+// wire protocol directly and works with any compatible broker.
+//
+// Cluster metadata is fetched on first use and again after any error, so
+// leader changes are picked up on the emitter's retry. A write that only
+// some partitions took fails with a *PartialError, so the retry sends only
+// the others and the events already produced are not repeated.
+type KafkaSink struct {
+	brokers []string
+	topic   string
+
+	ClientID string
+	// RequiredAcks is -1 to wait for all in-sync replicas or 1 for the
+	// leader only.
+	RequiredAcks int16
+	Timeout      time.Duration
+
+	correlation int32
+	partitions  []int32
+	leaders     map[int32]string
+	conns       map[string]*kafkaConn
+}
+
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return &KafkaSink{
+		brokers:      brokers,
+		topic:        topic,
+		ClientID:     "url-shortener",
+		RequiredAcks: -1,
+		Timeout:      10 * time.Second,
+		conns:        map[string]*kafkaConn{},
+	}
+}
+
+func (s *KafkaSink) Write(ctx context.Context, batch []model.ClickEvent) error {
+	if err := s.write(ctx, batch); err != nil {
+		s.reset()
+		return err
+	}
+	return nil
+}
+
+func (s *KafkaSink) write(ctx context.Context, batch []model.ClickEvent) error {
+	if s.leaders == nil {
+		if err := s.refreshMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	byPartition := map[int32][]record{}
+	events := map[int32][]model.ClickEvent{}
+	for _, ev := range batch {
+		value, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		p := s.partition(ev.URLID)
+		byPartition[p] = append(byPartition[p], record{key: []byte(ev.URLID), value: value, time: ev.ClickedAt})
+		events[p] = append(events[p], ev)
+	}
+
+	// One request per leader, covering all of its partitions. Partitions
+	// that fail are collected so that only their events are retried.
+	var failed []int32
+	var errs []error
+	byLeader := map[string]map[int32][]record{}
+	for p, records := range byPartition {
+		addr, ok := s.leaders[p]
+		if !ok {
+			failed = append(failed, p)
+			errs = append(errs, fmt.Errorf("kafka: no leader for %s/%d", s.topic, p))
+			continue
+		}
+		if byLeader[addr] == nil {
+			byLeader[addr] = map[int32][]record{}
+		}
+		byLeader[addr][p] = records
+	}
+	for addr, partitions := range byLeader {
+		rejected, err := s.produce(ctx, addr, partitions)
+		if err != nil {
+			failed = append(failed, rejected...)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	err := errors.Join(errs...)
+	if len(failed) == len(byPartition) {
+		return err
+	}
+	var remaining []model.ClickEvent
+	for _, p := range failed {
+		remaining = append(remaining, events[p]...)
+	}
+	return &PartialError{Remaining: remaining, Err: err}
+}
+
+func (s *KafkaSink) partition(key string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.partitions[h.Sum32()%uint32(len(s.partitions))]
+}
+
+// produce sends partitions to their leader at addr, returning those that
+// were not written when it fails.
+func (s *KafkaSink) produce(ctx context.Context, addr string, partitions map[int32][]record) ([]int32, error) {
+	var e encoder
+	e.nullString() // transactional ID
+	e.int16(s.RequiredAcks)
+	e.int32(int32(s.Timeout.Milliseconds()))
+	e.int32(1)
+	e.string(s.topic)
+	e.int32(int32(len(partitions)))
+	for p, records := range partitions {
+		e.int32(p)
+		e.bytes(encodeRecordBatch(records))
+	}
+
+	all := make([]int32, 0, len(partitions))
+	for p := range partitions {
+		all = append(all, p)
+	}
+	resp, err := s.roundTrip(ctx, addr, apiProduce, produceVersion, e.buf)
+	if err != nil {
+		return all, err
+	}
+	d := decoder{buf: resp}
+	var failed []int32
+	var errs []error
+	for range d.arrayLen() {
+		d.string()
+		for range d.arrayLen() {
+			partition := d.int32()
+			code := d.int16()
+			d.int64() // base offset
+			d.int64() // log append time
+			if code != 0 && d.err == nil {
+				failed = append(failed, partition)
+				errs = append(errs, fmt.Errorf("producing to %s/%d: %w", s.topic, partition, kafkaError(code)))
+			}
+		}
+	}
+	if d.err != nil {
+		// Which partitions were written cannot be told.
+		return all, d.err
+	}
+	return failed, errors.Join(errs...)
+}
+
+// refreshMetadata learns the topic's partitions and their leaders from the
+// first bootstrap broker that answers.
+func (s *KafkaSink) refreshMetadata(ctx context.Context) error {
+	var e encoder
+	e.int32(1)
+	e.string(s.topic)
+	e.int8(0) // don't auto-create the topic
+
+	var errs []error
+	for _, addr := range s.brokers {
+		resp, err := s.roundTrip(ctx, addr, apiMetadata, metadataVersion, e.buf)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return s.parseMetadata(resp)
+	}
+	return fmt.Errorf("fetching kafka metadata: %w", errors.Join(errs...))
+}
+
+func (s *KafkaSink) parseMetadata(resp []byte) error {
+	d := decoder{buf: resp}
+	d.int32() // throttle time
+	brokers := map[int32]string{}
+	for range d.arrayLen() {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.string() // cluster ID
+	d.int32()  // controller ID
+
+	leaders := map[int32]string{}
+	var partitions []int32
+	for range d.arrayLen() {
+		code := d.int16()
+		name := d.string()
+		d.int8() // is internal
+		if code != 0 && d.err == nil {
+			return fmt.Errorf("kafka metadata for %s: %w", name, kafkaError(code))
+		}
+		for range d.arrayLen() {
+			d.int16() // partition error; a missing leader is reported below
+			p := d.int32()
+			leader := d.int32()
+			for range d.arrayLen() {
+				d.int32() // replicas
+			}
+			for range d.arrayLen() {
+				d.int32() // in-sync replicas
+			}
+			partitions = append(partitions, p)
+			if addr, ok := brokers[leader]; ok {
+				leaders[p] = addr
+			}
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("kafka: topic %s has no partitions", s.topic)
+	}
+	s.partitions, s.leaders = partitions, leaders
+	return nil
+}
+
+func (s *KafkaSink) roundTrip(ctx context.Context, addr string, apiKey, version int16, body []byte) ([]byte, error) {
+	conn, err := s.conn(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	s.correlation++
+	resp, err := conn.roundTrip(ctx, s.Timeout, apiKey, version, s.correlation, s.ClientID, body)
+	if err != nil {
+		_ = conn.Close()
+		delete(s.conns, addr)
+		return nil, fmt.Errorf("kafka %s: %w", addr, err)
+	}
+	return resp, nil
+}
+
+func (s *KafkaSink) conn(ctx context.Context, addr string) (*kafkaConn, error) {
+	if c, ok := s.conns[addr]; ok {
+		return c, nil
+	}
+	dialer := net.Dialer{Timeout: s.Timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("kafka %s: %w", addr, err)
+	}
+	c := &kafkaConn{Conn: nc, r: bufio.NewReader(nc)}
+	s.conns[addr] = c
+	return c, nil
+}
+
+// reset drops connections and metadata after an error so the next write
+// starts from a fresh view of the cluster.
+func (s *KafkaSink) reset() {
+	for addr, c := range s.conns {
+		_ = c.Close()
+		delete(s.conns, addr)
+	}
+	s.partitions, s.leaders = nil, nil
+}
+
+func (s *KafkaSink) Close() error {
+	s.reset()
+	return nil
+}
+
+// kafkaConn is a broker connection carrying one request at a time.
+type kafkaConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *kafkaConn) roundTrip(ctx context.Context, timeout time.Duration, apiKey, version int16, correlation int32, clientID string, body []byte) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var e encoder
+	e.int32(0) // size, filled in below
+	e.int16(apiKey)
+	e.int16(version)
+	e.int32(correlation)
+	e.string(clientID)
+	e.buf = append(e.buf, body...)
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	if _, err := c.Write(e.buf); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c.r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > maxResponseSize {
+		return nil, fmt.Errorf("invalid response size %d", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c.r, resp); err != nil {
+		return nil, err
+	}
+	if got := int32(binary.BigEndian.Uint32(resp)); got != correlation {
+		return nil, fmt.Errorf("response for request %d, expected %d", got, correlation)
+	}
+	return resp[4:], nil
+}
@@ -0,0 +1,332 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// standInBroker is a single-node Kafka stand-in that answers Metadata and
+// Produce requests for one topic and keeps what it is sent.
+type standInBroker struct {
+	t          *testing.T
+	ln         net.Listener
+	topic      string
+	partitions int32
+
+	mu       sync.Mutex
+	records  map[int32][]record
+	metadata int
+	// failProduce is returned for every partition of the next Produce,
+	// and failPartition's code for that partition alone.
+	failProduce   int16
+	failPartition map[int32]int16
+}
+
+func newStandInBroker(t *testing.T, topic string, partitions int32) *standInBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	b := &standInBroker{t: t, ln: ln, topic: topic, partitions: partitions, records: map[int32][]record{}}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *standInBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(r, req); err != nil {
+			return
+		}
+		d := decoder{buf: req}
+		apiKey, version, correlation := d.int16(), d.int16(), d.int32()
+		d.string() // client ID
+
+		var resp encoder
+		resp.int32(0)
+		resp.int32(correlation)
+		switch {
+		case apiKey == apiMetadata && version == metadataVersion:
+			b.metadataResponse(&d, &resp)
+		case apiKey == apiProduce && version == produceVersion:
+			b.produceResponse(&d, &resp)
+		default:
+			b.t.Errorf("unexpected request: api %d version %d", apiKey, version)
+			return
+		}
+		binary.BigEndian.PutUint32(resp.buf, uint32(len(resp.buf)-4))
+		if _, err := conn.Write(resp.buf); err != nil {
+			return
+		}
+	}
+}
+
+func (b *standInBroker) metadataResponse(d *decoder, resp *encoder) {
+	var topics []string
+	for range d.arrayLen() {
+		topics = append(topics, d.string())
+	}
+	b.mu.Lock()
+	b.metadata++
+	b.mu.Unlock()
+
+	host, port, _ := net.SplitHostPort(b.ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	resp.int32(0) // throttle
+	resp.int32(1)
+	resp.int32(1) // node ID
+	resp.string(host)
+	resp.int32(int32(portNum))
+	resp.nullString() // rack
+	resp.nullString() // cluster ID
+	resp.int32(1)     // controller
+	resp.int32(int32(len(topics)))
+	for _, topic := range topics {
+		if topic != b.topic {
+			resp.int16(3) // unknown topic
+			resp.string(topic)
+			resp.int8(0)
+			resp.int32(0)
+			continue
+		}
+		resp.int16(0)
+		resp.string(topic)
+		resp.int8(0)
+		resp.int32(b.partitions)
+		for p := range b.partitions {
+			resp.int16(0)
+			resp.int32(p)
+			resp.int32(1) // leader
+			resp.int32(1)
+			resp.int32(1) // replicas
+			resp.int32(1)
+			resp.int32(1) // ISR
+		}
+	}
+}
+
+func (b *standInBroker) produceResponse(d *decoder, resp *encoder) {
+	d.string() // transactional ID
+	if acks := d.int16(); acks != -1 {
+		b.t.Errorf("expected acks=-1, got %d", acks)
+	}
+	d.int32() // timeout
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	failAll := b.failProduce
+	b.failProduce = 0
+	failPartition := b.failPartition
+	b.failPartition = nil
+
+	resp.int32(int32(d.arrayLen()))
+	// Only one topic is ever sent, so the counts can be echoed as read.
+	topic := d.string()
+	resp.string(topic)
+	n := d.arrayLen()
+	resp.int32(int32(n))
+	for range n {
+		p := d.int32()
+		batch := decodeRecordBatch(b.t, d.bytes())
+		code := failAll
+		if c, ok := failPartition[p]; ok {
+			code = c
+		}
+		if code == 0 {
+			b.records[p] = append(b.records[p], batch...)
+		}
+		resp.int32(p)
+		resp.int16(code)
+		resp.int64(0)  // base offset
+		resp.int64(-1) // log append time
+	}
+	resp.int32(0) // throttle
+}
+
+func decodeRecordBatch(t *testing.T, b []byte) []record {
+	d := decoder{buf: b}
+	d.int64() // base offset
+	length := d.int32()
+	d.int32() // leader epoch
+	if magic := d.int8(); magic != 2 {
+		t.Errorf("expected magic 2, got %d", magic)
+	}
+	crc := uint32(d.int32())
+	if int(length) != 4+1+4+len(d.buf) {
+		t.Errorf("batch length %d does not match %d bytes", length, 9+len(d.buf))
+	}
+	if crc32.Checksum(d.buf, castagnoli) != crc {
+		t.Error("record batch CRC mismatch")
+	}
+
+	d.int16() // attributes
+	d.int32() // last offset delta
+	base := d.int64()
+	d.int64() // max timestamp
+	d.int64() // producer ID
+	d.int16() // producer epoch
+	d.int32() // base sequence
+	var records []record
+	for i := range d.int32() {
+		d.varint() // length
+		d.int8()   // attributes
+		ts := d.varint()
+		if delta := d.varint(); delta != int64(i) {
+			t.Errorf("expected offset delta %d, got %d", i, delta)
+		}
+		r := record{key: d.varbytes(), value: d.varbytes(), time: time.UnixMilli(base + ts)}
+		d.varint() // headers
+		records = append(records, r)
+	}
+	if d.err != nil {
+		t.Errorf("decoding record batch: %v", d.err)
+	}
+	return records
+}
+
+func TestKafkaSink_Produce(t *testing.T) {
+	broker := newStandInBroker(t, "clicks", 3)
+	sink := NewKafkaSink([]string{"127.0.0.1:1", broker.ln.Addr().String()}, "clicks")
+	sink.Timeout = time.Second
+	defer sink.Close()
+
+	var batch []model.ClickEvent
+	for i := range 20 {
+		batch = append(batch, event("link-"+strconv.Itoa(i%5)))
+	}
+	if err := sink.Write(context.Background(), batch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	total := 0
+	partitionOf := map[string]int32{}
+	for p, records := range broker.records {
+		for _, r := range records {
+			var ev model.ClickEvent
+			if err := json.Unmarshal(r.value, &ev); err != nil {
+				t.Fatalf("expected JSON value, got %v", err)
+			}
+			if string(r.key) != ev.URLID {
+				t.Errorf("expected key %q, got %q", ev.URLID, r.key)
+			}
+			if prev, ok := partitionOf[ev.URLID]; ok && prev != p {
+				t.Errorf("expected %s on one partition, got %d and %d", ev.URLID, prev, p)
+			}
+			partitionOf[ev.URLID] = p
+			total++
+		}
+	}
+	if total != 20 {
+		t.Errorf("expected 20 records, got %d", total)
+	}
+}
+
+func TestKafkaSink_RefreshesMetadataAfterError(t *testing.T) {
+	broker := newStandInBroker(t, "clicks", 1)
+	broker.failProduce = 6 // not leader
+	sink := NewKafkaSink([]string{broker.ln.Addr().String()}, "clicks")
+	defer sink.Close()
+
+	err := sink.Write(context.Background(), []model.ClickEvent{event("a")})
+	var kerr kafkaError
+	if !errors.As(err, &kerr) || kerr != 6 {
+		t.Fatalf("expected not leader error, got %v", err)
+	}
+	if err := sink.Write(context.Background(), []model.ClickEvent{event("a")}); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.metadata != 2 {
+		t.Errorf("expected metadata to be fetched again, got %d fetches", broker.metadata)
+	}
+	if len(broker.records[0]) != 1 {
+		t.Errorf("expected one record, got %d", len(broker.records[0]))
+	}
+}
+
+func TestKafkaSink_RetriesOnlyFailedPartitions(t *testing.T) {
+	broker := newStandInBroker(t, "clicks", 3)
+	sink := NewKafkaSink([]string{broker.ln.Addr().String()}, "clicks")
+	defer sink.Close()
+
+	var batch []model.ClickEvent
+	for i := range 20 {
+		batch = append(batch, event("link-"+strconv.Itoa(i%5)))
+	}
+	if err := sink.Write(context.Background(), batch); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	broker.mu.Lock()
+	var failing int32 = -1
+	for p, records := range broker.records {
+		if len(records) > 0 {
+			failing = p
+		}
+	}
+	want := len(broker.records[failing])
+	broker.records = map[int32][]record{}
+	broker.failPartition = map[int32]int16{failing: 6} // not leader
+	broker.mu.Unlock()
+
+	err := sink.Write(context.Background(), batch)
+	var partial *PartialError
+	if !errors.As(err, &partial) || len(partial.Remaining) != want {
+		t.Fatalf("expected the %d events of partition %d left, got %v", want, failing, err)
+	}
+	if err := sink.Write(context.Background(), partial.Remaining); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	total := 0
+	for _, records := range broker.records {
+		total += len(records)
+	}
+	if total != 20 {
+		t.Errorf("expected each of the 20 events produced once, got %d records", total)
+	}
+}
+
+func TestKafkaSink_UnknownTopic(t *testing.T) {
+	broker := newStandInBroker(t, "clicks", 1)
+	sink := NewKafkaSink([]string{broker.ln.Addr().String()}, "missing")
+	defer sink.Close()
+
+	err := sink.Write(context.Background(), []model.ClickEvent{event("a")})
+	var kerr kafkaError
+	if !errors.As(err, &kerr) || kerr != 3 {
+		t.Errorf("expected unknown topic error, got %v", err)
+	}
+}
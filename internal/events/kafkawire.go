@@ -0,0 +1,230 @@
+package events
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strconv"
+	"time"
+)
+
+// The subset of the Kafka protocol the producer speaks. These versions
+// use the classic (non-flexible) encoding and are supported by Kafka 2.x
+// through 4.x and by compatible brokers such as Redpanda.
+const (
+	apiProduce  = 0
+	apiMetadata = 3
+
+	produceVersion  = 3
+	metadataVersion = 4
+)
+
+var (
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+	errShortRead = errors.New("kafka: truncated message")
+)
+
+// encoder appends big-endian Kafka primitives to buf.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) int8(v int8)     { e.buf = append(e.buf, byte(v)) }
+func (e *encoder) int16(v int16)   { e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v)) }
+func (e *encoder) int32(v int32)   { e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v)) }
+func (e *encoder) int64(v int64)   { e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v)) }
+func (e *encoder) uint32(v uint32) { e.buf = binary.BigEndian.AppendUint32(e.buf, v) }
+func (e *encoder) varint(v int64)  { e.buf = binary.AppendVarint(e.buf, v) }
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) nullString() { e.int16(-1) }
+
+func (e *encoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// varbytes writes a record key or value; nil is encoded as null.
+func (e *encoder) varbytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// decoder reads Kafka primitives from buf. The first short read sets err
+// and every later read returns zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.buf) < n {
+		d.err = errShortRead
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *decoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *decoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *decoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errShortRead
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+func (d *decoder) varbytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+// arrayLen reads an array length, treating null as empty.
+func (d *decoder) arrayLen() int {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return 0
+	}
+	// Every element takes at least a byte, so a longer count is corrupt.
+	if int(n) > len(d.buf) {
+		d.err = errShortRead
+		return 0
+	}
+	return int(n)
+}
+
+// record is one message in a record batch.
+type record struct {
+	key, value []byte
+	time       time.Time
+}
+
+// encodeRecordBatch encodes records as an uncompressed v2 record batch
+// with no producer ID, as an idempotence-free producer sends them.
+func encodeRecordBatch(records []record) []byte {
+	base := records[0].time.UnixMilli()
+	maxTS := base
+	for _, r := range records {
+		maxTS = max(maxTS, r.time.UnixMilli())
+	}
+
+	// Everything after the CRC, which covers it.
+	var body encoder
+	body.int16(0) // attributes: no compression, create time
+	body.int32(int32(len(records) - 1))
+	body.int64(base)
+	body.int64(maxTS)
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(records)))
+	for i, r := range records {
+		var rec encoder
+		rec.int8(0) // attributes
+		rec.varint(r.time.UnixMilli() - base)
+		rec.varint(int64(i))
+		rec.varbytes(r.key)
+		rec.varbytes(r.value)
+		rec.varint(0) // headers
+		body.varint(int64(len(rec.buf)))
+		body.buf = append(body.buf, rec.buf...)
+	}
+
+	var batch encoder
+	batch.int64(0)                                // base offset, assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + len(body.buf))) // length of what follows
+	batch.int32(-1)                               // partition leader epoch
+	batch.int8(2)                                 // magic
+	batch.uint32(crc32.Checksum(body.buf, castagnoli))
+	batch.buf = append(batch.buf, body.buf...)
+	return batch.buf
+}
+
+// kafkaError is a non-zero error code from a broker.
+type kafkaError int16
+
+func (e kafkaError) Error() string {
+	if name, ok := kafkaErrorNames[int16(e)]; ok {
+		return "kafka: " + name
+	}
+	return "kafka: error code " + strconv.Itoa(int(e))
+}
+
+// kafkaErrorNames covers the codes a producer is likely to see.
+var kafkaErrorNames = map[int16]string{
+	2:  "corrupt message",
+	3:  "unknown topic or partition",
+	5:  "leader not available",
+	6:  "not leader or follower",
+	7:  "request timed out",
+	10: "message too large",
+	19: "not enough replicas",
+	20: "not enough replicas after append",
+	29: "topic authorization failed",
+	87: "invalid record",
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventsQueued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_click_events_queued_total",
+		Help: "Click events accepted into a sink's buffer.",
+	}, []string{"sink"})
+	eventsDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_click_events_delivered_total",
+		Help: "Click events a sink acknowledged.",
+	}, []string{"sink"})
+	eventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_click_events_dropped_total",
+		Help: "Click events lost, by reason: buffer_full, cancelled, delivery_failed or shutdown_timeout.",
+	}, []string{"sink", "reason"})
+	batchWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_click_event_batch_writes_total",
+		Help: "Batch write attempts, by result.",
+	}, []string{"sink", "result"})
+	writeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "shortener_click_event_batch_write_seconds",
+		Help:    "Time taken by each batch write attempt.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"sink"})
+	bufferLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "shortener_click_event_buffer_length",
+		Help: "Click events waiting in a sink's buffer.",
+	}, []string{"sink"})
+)
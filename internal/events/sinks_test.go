@@ -0,0 +1,144 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func TestFileSink_RotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+	sink.MaxSize = 1 // one event per file
+	sink.MaxFiles = 2
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := sink.Write(context.Background(), []model.ClickEvent{event(id)}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "clicks-*.ndjson"))
+	if len(files) != 2 {
+		t.Fatalf("expected the 2 newest files, got %v", files)
+	}
+	f, err := os.Open(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got model.ClickEvent
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &got) != nil || got.URLID != "c" {
+		t.Errorf("expected the last event in the newest file, got %+v", got)
+	}
+}
+
+func TestFileSink_RotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	now := time.Now()
+	sink.now = func() time.Time { return now }
+
+	_ = sink.Write(context.Background(), []model.ClickEvent{event("a"), event("b")})
+	now = now.Add(sink.MaxAge)
+	_ = sink.Write(context.Background(), []model.ClickEvent{event("c")})
+	_ = sink.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "clicks-*.ndjson"))
+	if len(files) != 2 {
+		t.Errorf("expected a new file after MaxAge, got %v", files)
+	}
+}
+
+func TestFileSink_RetryDoesNotRepeatEvents(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := sink.Write(context.Background(), []model.ClickEvent{event("a")}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_ = sink.file.Close() // break the open file under the sink
+	batch := []model.ClickEvent{event("b"), event("c")}
+	if err := sink.Write(context.Background(), batch); err == nil {
+		t.Fatal("expected the write to a closed file to fail")
+	}
+	if err := sink.Write(context.Background(), batch); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	_ = sink.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "clicks-*.ndjson"))
+	var ids []string
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var ev model.ClickEvent
+			if err := json.Unmarshal([]byte(line), &ev); err != nil {
+				t.Fatalf("expected NDJSON, got %q", line)
+			}
+			ids = append(ids, ev.URLID)
+		}
+	}
+	if strings.Join(ids, ",") != "a,b,c" {
+		t.Errorf("expected each event once, got %v", ids)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var lines []string
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		lines = strings.Split(strings.TrimSpace(string(body)), "\n")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPSink(srv.URL + "/events")
+	sink.Header.Set("Authorization", "Bearer token")
+
+	if err := sink.Write(context.Background(), []model.ClickEvent{event("a"), event("b")}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(lines) != 2 || !strings.Contains(lines[1], `"url_id":"b"`) {
+		t.Errorf("expected two NDJSON lines, got %q", lines)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("expected the configured header, got %q", auth)
+	}
+
+	if err := NewHTTPSink(srv.URL+"/fail").Write(context.Background(), []model.ClickEvent{event("a")}); err == nil {
+		t.Error("expected a 503 to fail the batch")
+	}
+}
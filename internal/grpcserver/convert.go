@@ -0,0 +1,109 @@
+package grpcserver
+
+import (
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	pb "github.com/kerbatek/url-shortener/pkg/api/shortener/v1"
+)
+
+func urlToProto(u *model.URL) *pb.URL {
+	pu := &pb.URL{
+		Id:              u.ID,
+		Code:            u.Code,
+		Domain:          u.Domain,
+		OriginalUrl:     u.OriginalURL,
+		ForwardQuery:    u.ForwardQuery,
+		QueryPrecedence: precedenceToProto(u.QueryPrecedence),
+		UtmParams:       u.UTMParams,
+		BotPolicy:       botPolicyToProto(u.BotPolicy),
+		Campaign:        u.Campaign,
+		Tags:            u.Tags,
+		Title:           u.Title,
+		Description:     u.Description,
+		MaxClicks:       u.MaxClicks,
+		ClicksUsed:      u.ClicksUsed,
+		CreatedAt:       timestamppb.New(u.CreatedAt),
+		UpdatedAt:       timestamppb.New(u.UpdatedAt),
+		DisabledReason:  u.DisabledReason,
+	}
+	if u.WorkspaceID != nil {
+		pu.WorkspaceId = *u.WorkspaceID
+	}
+	if u.DisabledAt != nil {
+		pu.DisabledAt = timestamppb.New(*u.DisabledAt)
+	}
+	if len(u.Metadata) > 0 {
+		// Metadata decoded from JSON always converts.
+		pu.Metadata, _ = structpb.NewStruct(u.Metadata)
+	}
+	if p := u.Preview; p != nil {
+		pu.Preview = &pb.LinkPreview{Title: p.Title, Description: p.Description, ImageUrl: p.ImageURL}
+	}
+	if h := u.Health; h != nil {
+		pu.Health = &pb.LinkHealth{
+			StatusCode: int32(h.StatusCode),
+			LatencyMs:  int32(h.LatencyMS),
+			Error:      h.Error,
+			Broken:     h.Broken,
+			CheckedAt:  timestamppb.New(h.CheckedAt),
+		}
+	}
+	return pu
+}
+
+func precedenceToProto(p string) pb.QueryPrecedence {
+	switch p {
+	case model.QueryPrecedenceIncoming:
+		return pb.QueryPrecedence_QUERY_PRECEDENCE_INCOMING
+	case model.QueryPrecedenceDestination:
+		return pb.QueryPrecedence_QUERY_PRECEDENCE_DESTINATION
+	default:
+		return pb.QueryPrecedence_QUERY_PRECEDENCE_UNSPECIFIED
+	}
+}
+
+// precedenceFromProto maps UNSPECIFIED to "" so the service applies its
+// default.
+func precedenceFromProto(p pb.QueryPrecedence) string {
+	switch p {
+	case pb.QueryPrecedence_QUERY_PRECEDENCE_INCOMING:
+		return model.QueryPrecedenceIncoming
+	case pb.QueryPrecedence_QUERY_PRECEDENCE_DESTINATION:
+		return model.QueryPrecedenceDestination
+	default:
+		return ""
+	}
+}
+
+func botPolicyToProto(p string) pb.BotPolicy {
+	switch p {
+	case model.BotPolicyRedirect:
+		return pb.BotPolicy_BOT_POLICY_REDIRECT
+	case model.BotPolicyPreview:
+		return pb.BotPolicy_BOT_POLICY_PREVIEW
+	default:
+		return pb.BotPolicy_BOT_POLICY_UNSPECIFIED
+	}
+}
+
+// botPolicyFromProto maps UNSPECIFIED to "" so the service applies its
+// default.
+func botPolicyFromProto(p pb.BotPolicy) string {
+	switch p {
+	case pb.BotPolicy_BOT_POLICY_REDIRECT:
+		return model.BotPolicyRedirect
+	case pb.BotPolicy_BOT_POLICY_PREVIEW:
+		return model.BotPolicyPreview
+	default:
+		return ""
+	}
+}
+
+func previewFromProto(p *pb.LinkPreview) *model.LinkPreview {
+	if p == nil {
+		return nil
+	}
+	return &model.LinkPreview{Title: p.GetTitle(), Description: p.GetDescription(), ImageURL: p.GetImageUrl()}
+}
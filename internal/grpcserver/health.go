@@ -0,0 +1,82 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/kerbatek/url-shortener/pkg/api/shortener/v1"
+)
+
+// DBPinger is satisfied by *pgxpool.Pool and *replica.Set.
+type DBPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthServer implements the standard gRPC health protocol with the same
+// semantics as HealthHandler: the empty service name reports liveness and
+// the Shortener service reports readiness, i.e. whether the DB is reachable.
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+	db DBPinger
+
+	// WatchInterval is how often Watch re-checks readiness.
+	WatchInterval time.Duration
+}
+
+func NewHealthServer(db DBPinger) *HealthServer {
+	return &HealthServer{db: db, WatchInterval: 5 * time.Second}
+}
+
+func (h *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, err := h.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the current status and then every change until the client
+// goes away.
+func (h *HealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	ticker := time.NewTicker(h.WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		st, err := h.status(ctx, req.GetService())
+		if status.Code(err) == codes.NotFound {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HealthServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	switch service {
+	case "":
+		return healthpb.HealthCheckResponse_SERVING, nil
+	case pb.Shortener_ServiceDesc.ServiceName:
+		if err := h.db.Ping(ctx); err != nil {
+			return healthpb.HealthCheckResponse_NOT_SERVING, nil
+		}
+		return healthpb.HealthCheckResponse_SERVING, nil
+	default:
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status.Errorf(codes.NotFound, "unknown service %q", service)
+	}
+}
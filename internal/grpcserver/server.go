@@ -0,0 +1,277 @@
+// Package grpcserver exposes URLService over gRPC, alongside the Gin HTTP API.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/audit"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/service"
+	pb "github.com/kerbatek/url-shortener/pkg/api/shortener/v1"
+)
+
+// APIKeyMetadata is the metadata key carrying the caller's API key, the
+// gRPC counterpart of the X-API-Key header.
+const APIKeyMetadata = "x-api-key"
+
+// RequestIDMetadata is the metadata key carrying the caller's ID for a
+// call, the gRPC counterpart of the X-Request-ID header.
+const RequestIDMetadata = "x-request-id"
+
+// New returns a gRPC server serving the Shortener service, the standard
+// health protocol and server reflection. Callers are identified through
+// accounts, or all anonymous when it is nil.
+func New(svc *service.URLService, accounts *service.AccountService, db DBPinger, logger zerolog.Logger) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryLogger(logger), unaryOrigin, unaryAuth(accounts)),
+	)
+	pb.RegisterShortenerServer(s, NewServer(svc))
+	healthpb.RegisterHealthServer(s, NewHealthServer(db))
+	reflection.Register(s)
+	return s
+}
+
+// Server implements pb.ShortenerServer on top of URLService.
+type Server struct {
+	pb.UnimplementedShortenerServer
+	service *service.URLService
+}
+
+func NewServer(service *service.URLService) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) Shorten(ctx context.Context, req *pb.ShortenRequest) (*pb.URL, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+	u, err := s.service.Shorten(ctx, model.ShortenRequest{
+		URL:             req.GetUrl(),
+		ForwardQuery:    req.GetForwardQuery(),
+		QueryPrecedence: precedenceFromProto(req.GetQueryPrecedence()),
+		UTMParams:       req.GetUtmParams(),
+		BotPolicy:       botPolicyFromProto(req.GetBotPolicy()),
+		Preview:         previewFromProto(req.GetPreview()),
+		FetchPreview:    req.GetFetchPreview(),
+		Campaign:        req.GetCampaign(),
+		Tags:            req.GetTags(),
+		Title:           req.GetTitle(),
+		Description:     req.GetDescription(),
+		Metadata:        req.GetMetadata().AsMap(),
+		MaxClicks:       req.GetMaxClicks(),
+		Domain:          req.GetDomain(),
+		Workspace:       req.GetWorkspace(),
+		CodeStrategy:    req.GetCodeStrategy(),
+		APIKey:          apiKey(ctx),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return urlToProto(u), nil
+}
+
+func (s *Server) Resolve(ctx context.Context, req *pb.ResolveRequest) (*pb.ResolveResponse, error) {
+	incoming, err := url.ParseQuery(req.GetQuery())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid query: %v", err)
+	}
+	u, err := s.service.Resolve(ctx, req.GetHost(), req.GetCode())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if u.DisabledAt != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "link disabled: destination listed as %s", u.DisabledReason)
+	}
+	target, err := service.RedirectTarget(u, incoming)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.service.ConsumeClick(ctx, u); err != nil {
+		return nil, toStatus(err)
+	}
+	// The click is the caller's: its address and its user-agent and
+	// referer metadata.
+	click := model.Click{
+		IP:        audit.OriginFrom(ctx).ClientIP,
+		UserAgent: firstMetadata(ctx, "user-agent"),
+		Referrer:  firstMetadata(ctx, "referer"),
+	}
+	click.Bot = s.service.IsBot(click.UserAgent, click.IP)
+	if err := s.service.RecordClick(ctx, u, target, click); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("short_code", u.Code).Msg("recording click failed")
+	}
+	return &pb.ResolveResponse{Url: urlToProto(u), Target: target}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.URL, error) {
+	u, err := s.service.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return urlToProto(u), nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := s.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *Server) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	page, err := s.service.List(ctx, model.ListQuery{
+		Limit:     int(req.GetLimit()),
+		Cursor:    req.GetCursor(),
+		Status:    req.GetStatus(),
+		Search:    req.GetSearch(),
+		Tags:      req.GetTags(),
+		Campaign:  req.GetCampaign(),
+		Metadata:  req.GetMetadata(),
+		Workspace: req.GetWorkspace(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListResponse{NextCursor: page.NextCursor}
+	for i := range page.URLs {
+		resp.Urls = append(resp.Urls, urlToProto(&page.URLs[i]))
+	}
+	return resp, nil
+}
+
+func (s *Server) Stats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsResponse, error) {
+	st, err := s.service.Stats(ctx, req.GetId(), req.GetIncludeBots())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.StatsResponse{UrlId: st.URLID, TotalClicks: st.TotalClicks, BotClicks: st.BotClicks}
+	if st.LastClickedAt != nil {
+		resp.LastClickedAt = timestamppb.New(*st.LastClickedAt)
+	}
+	for _, d := range st.Daily {
+		resp.Daily = append(resp.Daily, &pb.DailyClicks{Date: d.Date, Clicks: d.Clicks})
+	}
+	return resp, nil
+}
+
+func apiKey(ctx context.Context) string {
+	return firstMetadata(ctx, APIKeyMetadata)
+}
+
+// bearerToken returns the token of "authorization: Bearer <token>"
+// metadata, as sent by per-RPC OAuth credentials.
+func bearerToken(ctx context.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(firstMetadata(ctx, "authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// toStatus translates apperr kinds into gRPC status codes, mirroring
+// middleware.StatusFor on the HTTP side.
+func toStatus(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, apperr.ErrInvalid):
+		code = codes.InvalidArgument
+	case errors.Is(err, apperr.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, apperr.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, apperr.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, apperr.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, apperr.ErrGone):
+		code = codes.ResourceExhausted
+	case errors.Is(err, apperr.ErrUnavailable):
+		code = codes.Unavailable
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+	return status.Error(code, apperr.Message(err))
+}
+
+// unaryAuth identifies the caller from a bearer token or the API key
+// metadata, the gRPC counterpart of middleware.Authenticate.
+func unaryAuth(accounts *service.AccountService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if accounts == nil {
+			return handler(ctx, req)
+		}
+		var caller service.Caller
+		var err error
+		if token, ok := bearerToken(ctx); ok {
+			caller, err = accounts.AuthenticateToken(ctx, token)
+		} else {
+			caller, err = accounts.Authenticate(ctx, apiKey(ctx))
+		}
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return handler(service.WithCaller(ctx, caller), req)
+	}
+}
+
+// unaryOrigin records where each call came from for the audit log, the
+// gRPC counterpart of middleware.Origin.
+func unaryOrigin(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	origin := audit.Origin{RequestID: firstMetadata(ctx, RequestIDMetadata)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		origin.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(origin.ClientIP); err == nil {
+			origin.ClientIP = host
+		}
+	}
+	return handler(audit.WithOrigin(ctx, origin), req)
+}
+
+func unaryLogger(logger zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(logger.WithContext(ctx), req)
+		code := status.Code(err)
+
+		event := logger.Info()
+		if code == codes.Internal || code == codes.Unknown {
+			event = logger.Error().Err(err)
+		}
+		event.
+			Str("method", info.FullMethod).
+			Str("code", code.String()).
+			Dur("latency", time.Since(start)).
+			Msg("grpc request")
+		return resp, err
+	}
+}
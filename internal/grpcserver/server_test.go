@@ -0,0 +1,275 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	pb "github.com/kerbatek/url-shortener/pkg/api/shortener/v1"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (f *fakePinger) Ping(ctx context.Context) error {
+	return f.err
+}
+
+func setupServer(t *testing.T, ctrl *gomock.Controller, db DBPinger, opts ...service.Option) (*grpc.ClientConn, *mocks.MockURLRepository, *mocks.MockDomainRepository) {
+	t.Helper()
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockDomains := mocks.NewMockDomainRepository(ctrl)
+	svc := service.NewURLService(mockRepo, append([]service.Option{service.WithDomains(mockDomains)}, opts...)...)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := New(svc, nil, db, zerolog.Nop())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, mockRepo, mockDomains
+}
+
+func TestShorten_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, mockRepo, _ := setupServer(t, ctrl, &fakePinger{})
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.URL) error {
+			u.ID = "550e8400-e29b-41d4-a716-446655440000"
+			u.CreatedAt = time.Now()
+			u.UpdatedAt = time.Now()
+			return nil
+		})
+
+	resp, err := pb.NewShortenerClient(conn).Shorten(context.Background(), &pb.ShortenRequest{
+		Url:             "https://example.com",
+		QueryPrecedence: pb.QueryPrecedence_QUERY_PRECEDENCE_DESTINATION,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.GetOriginalUrl() != "https://example.com" {
+		t.Errorf("expected original URL https://example.com, got %s", resp.GetOriginalUrl())
+	}
+	if resp.GetQueryPrecedence() != pb.QueryPrecedence_QUERY_PRECEDENCE_DESTINATION {
+		t.Errorf("expected destination precedence, got %v", resp.GetQueryPrecedence())
+	}
+}
+
+func TestShorten_ForwardsAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, _, mockDomains := setupServer(t, ctrl, &fakePinger{})
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "d1", Host: "go.example.com", OwnerKeyHash: service.HashAPIKey("owner")}, nil)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, "intruder")
+	_, err := pb.NewShortenerClient(conn).Shorten(ctx, &pb.ShortenRequest{Url: "https://example.com", Domain: "go.example.com"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+}
+
+func TestResolve_Target(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, mockRepo, mockDomains := setupServer(t, ctrl, &fakePinger{})
+
+	mockDomains.EXPECT().GetByHost(gomock.Any(), "sho.rt").Return(nil, apperr.NotFound("domain not found"))
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{Code: "abc1234", OriginalURL: "https://example.com/?a=1", ForwardQuery: true, QueryPrecedence: model.QueryPrecedenceIncoming}, nil)
+
+	resp, err := pb.NewShortenerClient(conn).Resolve(context.Background(), &pb.ResolveRequest{Code: "abc1234", Host: "sho.rt", Query: "b=2"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.GetTarget() != "https://example.com/?a=1&b=2" {
+		t.Errorf("expected merged target, got %s", resp.GetTarget())
+	}
+}
+
+func TestResolve_RecordsClick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	conn, mockRepo, mockDomains := setupServer(t, ctrl, &fakePinger{}, service.WithClicks(mockClicks))
+
+	mockDomains.EXPECT().GetByHost(gomock.Any(), "sho.rt").Return(nil, apperr.NotFound("domain not found"))
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{ID: "id", Code: "abc1234", OriginalURL: "https://example.com"}, nil)
+	mockClicks.EXPECT().
+		Record(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, click *model.Click) error {
+			if click.URLID != "id" || click.Referrer != "https://news.example.com/" {
+				t.Errorf("unexpected click %+v", click)
+			}
+			return nil
+		})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "referer", "https://news.example.com/")
+	if _, err := pb.NewShortenerClient(conn).Resolve(ctx, &pb.ResolveRequest{Code: "abc1234", Host: "sho.rt"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestResolve_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, mockRepo, mockDomains := setupServer(t, ctrl, &fakePinger{})
+
+	disabledAt := time.Now()
+	mockDomains.EXPECT().GetByHost(gomock.Any(), "sho.rt").Return(nil, apperr.NotFound("domain not found"))
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{Code: "abc1234", OriginalURL: "https://evil.example.com", DisabledAt: &disabledAt, DisabledReason: "malware"}, nil)
+
+	_, err := pb.NewShortenerClient(conn).Resolve(context.Background(), &pb.ResolveRequest{Code: "abc1234", Host: "sho.rt"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestResolve_ClickLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, mockRepo, mockDomains := setupServer(t, ctrl, &fakePinger{})
+
+	mockDomains.EXPECT().GetByHost(gomock.Any(), "sho.rt").Return(nil, apperr.NotFound("domain not found"))
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{ID: "id", Code: "abc1234", OriginalURL: "https://example.com", MaxClicks: 1, ClicksUsed: 1}, nil)
+	mockRepo.EXPECT().ConsumeClick(gomock.Any(), "id").Return(int64(0), apperr.Gone("link has reached its click limit"))
+
+	_, err := pb.NewShortenerClient(conn).Resolve(context.Background(), &pb.ResolveRequest{Code: "abc1234", Host: "sho.rt"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"not found", apperr.NotFound("url not found"), codes.NotFound},
+		{"unavailable", apperr.Unavailable(errors.New("dial tcp"), "database unavailable"), codes.Unavailable},
+		{"unknown", errors.New("boom"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			conn, mockRepo, _ := setupServer(t, ctrl, &fakePinger{})
+
+			mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(nil, tt.err)
+
+			_, err := pb.NewShortenerClient(conn).Get(context.Background(), &pb.GetRequest{Id: "id"})
+			if status.Code(err) != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+			if tt.want == codes.Internal && status.Convert(err).Message() != "internal server error" {
+				t.Errorf("expected cause to stay out of the status, got %q", status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestList_Paginates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, mockRepo, _ := setupServer(t, ctrl, &fakePinger{})
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 1}).
+		Return([]model.URL{{ID: "a", Code: "abc1234", CreatedAt: time.Now()}}, nil)
+
+	resp, err := pb.NewShortenerClient(conn).List(context.Background(), &pb.ListRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(resp.GetUrls()) != 1 || resp.GetNextCursor() == "" {
+		t.Errorf("expected one URL and a next cursor, got %v", resp)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		pingErr error
+		want    healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"liveness", "", errors.New("down"), healthpb.HealthCheckResponse_SERVING},
+		{"ready", pb.Shortener_ServiceDesc.ServiceName, nil, healthpb.HealthCheckResponse_SERVING},
+		{"not ready", pb.Shortener_ServiceDesc.ServiceName, errors.New("down"), healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			conn, _, _ := setupServer(t, ctrl, &fakePinger{err: tt.pingErr})
+
+			resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: tt.service})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if resp.GetStatus() != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, resp.GetStatus())
+			}
+		})
+	}
+}
+
+func TestHealth_UnknownService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, _, _ := setupServer(t, ctrl, &fakePinger{})
+
+	_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "nope"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestHealth_Watch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	conn, _, _ := setupServer(t, ctrl, &fakePinger{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{Service: pb.Shortener_ServiceDesc.ServiceName})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v", resp.GetStatus())
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/service"
+)
+
+type AccountHandler struct {
+	service *service.AccountService
+}
+
+func NewAccountHandler(service *service.AccountService) *AccountHandler {
+	return &AccountHandler{service: service}
+}
+
+// SignUp creates a user and returns their API key, which is not shown
+// again.
+func (h *AccountHandler) SignUp(c *gin.Context) {
+	var req model.SignUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("email is required"))
+		return
+	}
+
+	user, err := h.service.SignUp(c.Request.Context(), req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// CreateWorkspace creates a workspace with the caller as its admin.
+func (h *AccountHandler) CreateWorkspace(c *gin.Context) {
+	var req model.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("name is required"))
+		return
+	}
+
+	workspace, err := h.service.CreateWorkspace(c.Request.Context(), req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, workspace)
+}
+
+// ListWorkspaces returns the workspaces the caller is a member of, with
+// their role in each.
+func (h *AccountHandler) ListWorkspaces(c *gin.Context) {
+	workspaces, err := h.service.Workspaces(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+func (h *AccountHandler) ListMembers(c *gin.Context) {
+	members, err := h.service.Members(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// SetRole changes a member's role. Only admins may, and the last admin
+// cannot step down.
+func (h *AccountHandler) SetRole(c *gin.Context) {
+	var req model.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("role is required"))
+		return
+	}
+
+	if err := h.service.SetRole(c.Request.Context(), c.Param("id"), c.Param("user_id"), req.Role); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveMember removes a member from a workspace; members may remove
+// themselves.
+func (h *AccountHandler) RemoveMember(c *gin.Context) {
+	if err := h.service.RemoveMember(c.Request.Context(), c.Param("id"), c.Param("user_id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateInvite invites someone to a workspace and returns the invite
+// token, which is not shown again.
+func (h *AccountHandler) CreateInvite(c *gin.Context) {
+	var req model.InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("invalid request body"))
+		return
+	}
+
+	invite, err := h.service.Invite(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// AcceptInvite adds the caller to the workspace of the invite whose token
+// is in the body. The token travels in the body rather than the path so it
+// stays out of access logs.
+func (h *AccountHandler) AcceptInvite(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("token is required"))
+		return
+	}
+
+	workspace, err := h.service.AcceptInvite(c.Request.Context(), req.Token)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
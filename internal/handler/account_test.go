@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+const testWorkspace = "11111111-1111-1111-1111-111111111111"
+
+func setupAccountRouter(ctrl *gomock.Controller) (*gin.Engine, *mocks.MockAccountRepository) {
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := service.NewAccountService(mockRepo)
+	h := NewAccountHandler(svc)
+
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.Use(middleware.Authenticate(svc))
+	router.POST("/users", h.SignUp)
+	router.POST("/workspaces", h.CreateWorkspace)
+	router.PATCH("/workspaces/:id/members/:user_id", h.SetRole)
+	router.POST("/invites/accept", h.AcceptInvite)
+
+	return router, mockRepo
+}
+
+func TestSignUp_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupAccountRouter(ctrl)
+
+	mockRepo.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.User) error {
+			u.ID = "u1"
+			return nil
+		})
+
+	body := `{"email": "ada@example.com", "name": "Ada"}`
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["api_key"] == "" || resp["api_key"] == nil {
+		t.Error("expected API key in sign-up response")
+	}
+	if _, ok := resp["APIKeyHash"]; ok {
+		t.Error("expected key hash to stay out of the response")
+	}
+}
+
+func TestCreateWorkspace_Anonymous(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupAccountRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodPost, "/workspaces", strings.NewReader(`{"name": "Team"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", w.Code)
+	}
+}
+
+func TestSetRole_LastAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupAccountRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetUserByKeyHash(gomock.Any(), service.HashAPIKey("ada-key")).
+		Return(&model.User{ID: "u1"}, nil)
+	mockRepo.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, "u1").
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleAdmin}, nil)
+	mockRepo.EXPECT().
+		SetRole(gomock.Any(), testWorkspace, "u1", model.RoleViewer).
+		Return(apperr.Conflict("a workspace needs at least one admin"))
+
+	req := httptest.NewRequest(http.MethodPatch, "/workspaces/"+testWorkspace+"/members/u1", strings.NewReader(`{"role": "viewer"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "ada-key")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", w.Code)
+	}
+}
+
+func TestAcceptInvite_MissingToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupAccountRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetUserByKeyHash(gomock.Any(), service.HashAPIKey("ada-key")).
+		Return(&model.User{ID: "u1"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/invites/accept", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "ada-key")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// Audit returns a page of the audit log, narrowed by ?actor, ?link and the
+// RFC 3339 times ?since and ?until.
+func (h *URLHandler) Audit(c *gin.Context) {
+	limit, err := limitParam(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	since, err := timeParam(c, "since")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	until, err := timeParam(c, "until")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := h.service.Audit(c.Request.Context(), model.AuditQuery{
+		Limit:     limit,
+		Cursor:    c.Query("cursor"),
+		Actor:     c.Query("actor"),
+		Link:      c.Query("link"),
+		Since:     since,
+		Until:     until,
+		Workspace: c.Query("workspace"),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func timeParam(c *gin.Context, name string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, apperr.Invalid("%s must be an RFC 3339 time", name)
+	}
+	return &t, nil
+}
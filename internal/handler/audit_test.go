@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+func setupAuditRouter(ctrl *gomock.Controller) (*gin.Engine, *mocks.MockAuditRepository) {
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	svc := service.NewURLService(mocks.NewMockURLRepository(ctrl), service.WithAudit(mockAudit))
+	h := NewURLHandler(svc)
+
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.Use(func(c *gin.Context) {
+		ctx := service.WithCaller(c.Request.Context(), service.Caller{Operator: true})
+		c.Request = c.Request.WithContext(ctx)
+	})
+	router.GET("/audit", h.Audit)
+	return router, mockAudit
+}
+
+func TestAudit_Filters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockAudit := setupAuditRouter(ctrl)
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	link := "550e8400-e29b-41d4-a716-446655440000"
+
+	mockAudit.EXPECT().
+		List(gomock.Any(), model.AuditOptions{
+			Limit: 5, Actor: "u1", LinkID: link, Since: &since, Until: &until, AllWorkspaces: true,
+		}).
+		Return([]model.AuditEntry{{ID: 7, Actor: "u1", Action: model.AuditDelete, LinkID: link}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/audit?limit=5&actor=u1&link="+link+"&since=2026-03-01T00:00:00Z&until=2026-03-02T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var page model.AuditPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Action != model.AuditDelete {
+		t.Errorf("unexpected page %+v", page)
+	}
+}
+
+func TestAudit_InvalidTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupAuditRouter(ctrl)
+	req := httptest.NewRequest(http.MethodGet, "/audit?since=yesterday", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
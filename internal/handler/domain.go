@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/service"
+)
+
+type DomainHandler struct {
+	service *service.DomainService
+}
+
+func NewDomainHandler(service *service.DomainService) *DomainHandler {
+	return &DomainHandler{service: service}
+}
+
+// RegisterDomain claims a short host for the caller's X-API-Key, which
+// must belong to a user or be the admin key.
+func (h *DomainHandler) RegisterDomain(c *gin.Context) {
+	var req struct {
+		Host        string `json:"host" binding:"required"`
+		FallbackURL string `json:"fallback_url"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("host is required"))
+		return
+	}
+
+	apiKey := c.GetHeader("X-API-Key")
+	if apiKey == "" {
+		_ = c.Error(apperr.Unauthorized("api key is required"))
+		return
+	}
+
+	domain, err := h.service.Register(c.Request.Context(), req.Host, req.FallbackURL, apiKey)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain)
+}
+
+// ListDomains returns the domains owned by the caller's X-API-Key.
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+	apiKey := c.GetHeader("X-API-Key")
+	if apiKey == "" {
+		_ = c.Error(apperr.Unauthorized("api key is required"))
+		return
+	}
+
+	domains, err := h.service.List(c.Request.Context(), apiKey)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domains)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+func setupDomainRouter(ctrl *gomock.Controller) (*gin.Engine, *mocks.MockDomainRepository) {
+	mockRepo := mocks.NewMockDomainRepository(ctrl)
+	h := NewDomainHandler(service.NewDomainService(mockRepo))
+
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	// The API key "secret" belongs to a user; other keys to nobody.
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("X-API-Key") == "secret" {
+			ctx := service.WithCaller(c.Request.Context(), service.Caller{User: &model.User{ID: "u1"}})
+			c.Request = c.Request.WithContext(ctx)
+		}
+	})
+	router.POST("/domains", h.RegisterDomain)
+	router.GET("/domains", h.ListDomains)
+
+	return router, mockRepo
+}
+
+func TestRegisterDomain_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupDomainRouter(ctrl)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, d *model.Domain) error {
+			d.ID = "11111111-1111-1111-1111-111111111111"
+			return nil
+		})
+
+	body := `{"host": "go.example.com", "fallback_url": "https://example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["host"] != "go.example.com" {
+		t.Errorf("expected host go.example.com, got %v", resp["host"])
+	}
+	if _, ok := resp["owner_key_hash"]; ok {
+		t.Error("expected owner key hash to be omitted from response")
+	}
+}
+
+func TestRegisterDomain_MissingKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupDomainRouter(ctrl)
+
+	body := `{"host": "go.example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
+
+func TestRegisterDomain_KeyOfNoUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupDomainRouter(ctrl)
+
+	body := `{"host": "go.example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/domains", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "made-up")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
+
+func TestListDomains_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupDomainRouter(ctrl)
+
+	mockRepo.EXPECT().
+		ListByOwner(gomock.Any(), service.HashAPIKey("secret")).
+		Return([]model.Domain{{ID: "dom-1", Host: "go.example.com"}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/domains", nil)
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp []model.Domain
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(resp) != 1 || resp[0].Host != "go.example.com" {
+		t.Errorf("unexpected domains %+v", resp)
+	}
+}
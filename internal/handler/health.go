@@ -5,13 +5,29 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/replica"
 )
 
-// DBPinger is satisfied by *pgxpool.Pool.
+// DBPinger is satisfied by *pgxpool.Pool and *replica.Set.
 type DBPinger interface {
 	Ping(ctx context.Context) error
 }
 
+// PoolReporter is a DBPinger that reports each of its pools, as
+// *replica.Set does.
+type PoolReporter interface {
+	DBPinger
+	Pools(ctx context.Context) []replica.PoolStatus
+}
+
+type poolResponse struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Status string `json:"status"`
+	LagMS  *int64 `json:"lag_ms,omitempty"`
+}
+
 type HealthHandler struct {
 	db DBPinger
 }
@@ -26,10 +42,33 @@ func (h *HealthHandler) Liveness(c *gin.Context) {
 }
 
 // Readiness reports that the service can serve traffic (DB reachable).
+// With replicas, it lists every pool's state; only the primary being
+// unavailable makes the service unready, since reads fall back to it.
 func (h *HealthHandler) Readiness(c *gin.Context) {
+	if reporter, ok := h.db.(PoolReporter); ok {
+		h.poolReadiness(c, reporter)
+		return
+	}
 	if err := h.db.Ping(c.Request.Context()); err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
+
+func (h *HealthHandler) poolReadiness(c *gin.Context, reporter PoolReporter) {
+	code, status := http.StatusOK, "ok"
+	var pools []poolResponse
+	for _, p := range reporter.Pools(c.Request.Context()) {
+		if p.Role == replica.RolePrimary && p.State != replica.StateOK {
+			code, status = http.StatusServiceUnavailable, "unavailable"
+		}
+		resp := poolResponse{Name: p.Name, Role: p.Role, Status: p.State}
+		if p.Role == replica.RoleReplica && (p.State == replica.StateOK || p.State == replica.StateLagging) {
+			lag := p.Lag.Milliseconds()
+			resp.LagMS = &lag
+		}
+		pools = append(pools, resp)
+	}
+	c.JSON(code, gin.H{"status": status, "pools": pools})
+}
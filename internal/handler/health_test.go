@@ -2,12 +2,16 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/replica"
 )
 
 type stubPinger struct{ err error }
@@ -55,3 +59,57 @@ func TestReadiness_DBDown(t *testing.T) {
 		t.Fatalf("expected 503, got %d", w.Code)
 	}
 }
+
+type stubPools struct {
+	stubPinger
+	pools []replica.PoolStatus
+}
+
+func (s *stubPools) Pools(_ context.Context) []replica.PoolStatus { return s.pools }
+
+func TestReadiness_Pools(t *testing.T) {
+	tests := []struct {
+		name       string
+		primary    string
+		wantStatus int
+	}{
+		{"primary up", replica.StateOK, http.StatusOK},
+		{"primary down", replica.StateUnavailable, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupHealthRouter(&stubPools{pools: []replica.PoolStatus{
+				{Name: "primary", Role: replica.RolePrimary, State: tt.primary},
+				{Name: "replica-1:5432", Role: replica.RoleReplica, State: replica.StateLagging, Lag: 12 * time.Second},
+				{Name: "replica-2:5432", Role: replica.RoleReplica, State: replica.StateUnavailable},
+			}})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/ready", nil)
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, w.Code)
+			}
+			var body struct {
+				Pools []struct {
+					Name   string `json:"name"`
+					Status string `json:"status"`
+					LagMS  *int64 `json:"lag_ms"`
+				} `json:"pools"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if len(body.Pools) != 3 {
+				t.Fatalf("expected 3 pools, got %d", len(body.Pools))
+			}
+			if p := body.Pools[1]; p.Status != replica.StateLagging || p.LagMS == nil || *p.LagMS != 12000 {
+				t.Errorf("unexpected lagging replica %+v", p)
+			}
+			if p := body.Pools[2]; p.Status != replica.StateUnavailable || p.LagMS != nil {
+				t.Errorf("unexpected unavailable replica %+v", p)
+			}
+		})
+	}
+}
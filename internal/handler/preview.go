@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// previewPage is served instead of redirecting unfurlers and other bots
+// that the link shows its preview to. It describes the link with Open
+// Graph and Twitter card tags without sending them on to the destination.
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex">
+    <title>{{.Title}}</title>
+    <meta name="description" content="{{.Description}}">
+    <meta property="og:type" content="website">
+    <meta property="og:url" content="{{.ShortURL}}">
+    <meta property="og:title" content="{{.Title}}">
+    <meta property="og:description" content="{{.Description}}">
+    {{- with .ImageURL}}
+    <meta property="og:image" content="{{.}}">
+    <meta name="twitter:card" content="summary_large_image">
+    <meta name="twitter:image" content="{{.}}">
+    {{- else}}
+    <meta name="twitter:card" content="summary">
+    {{- end}}
+    <meta name="twitter:title" content="{{.Title}}">
+    <meta name="twitter:description" content="{{.Description}}">
+</head>
+<body>
+    <h1>{{.Title}}</h1>
+    <p>{{.Description}}</p>
+</body>
+</html>
+`))
+
+// renderPreview serves the preview page for u. Fields the link's preview
+// leaves empty describe its destination instead, by host only for links
+// with a click limit, whose full URL may be the very thing being rationed.
+func renderPreview(c *gin.Context, u *model.URL) {
+	var p model.LinkPreview
+	if u.Preview != nil {
+		p = *u.Preview
+	}
+	host := u.OriginalURL
+	if dest, err := url.Parse(u.OriginalURL); err == nil && dest.Host != "" {
+		host = dest.Host
+	}
+	if p.Title == "" {
+		p.Title = host
+	}
+	if p.Description == "" {
+		p.Description = "Short link to " + u.OriginalURL
+		if u.MaxClicks > 0 {
+			p.Description = "Short link to " + host
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Render(http.StatusOK, render.HTML{
+		Template: previewPage,
+		Data: gin.H{
+			"Title":       p.Title,
+			"Description": p.Description,
+			"ImageURL":    p.ImageURL,
+			"ShortURL":    requestScheme(c) + "://" + c.Request.Host + c.Request.URL.Path,
+		},
+	})
+}
+
+func requestScheme(c *gin.Context) string {
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/transfer"
+)
+
+// exportFlushEvery is how many links are written between flushes, so
+// clients see a steady stream instead of one buffered response.
+const exportFlushEvery = 1000
+
+// ExportURLs streams every link as NDJSON (default) or CSV. ?workspace=
+// exports a workspace's links instead of those outside any.
+func (h *URLHandler) ExportURLs(c *gin.Context) {
+	format := c.DefaultQuery("format", model.FormatNDJSON)
+	enc, err := transfer.NewEncoder(c.Writer, format)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", transfer.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="links.`+format+`"`)
+
+	n := 0
+	err = h.service.Export(c.Request.Context(), c.Query("workspace"), func(u *model.URL) error {
+		if err := enc.Encode(u); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		if c.Writer.Written() {
+			// Too late for an error response; cut the stream short.
+			log.Ctx(c.Request.Context()).Error().Err(err).Int("exported", n).Msg("export failed")
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// ImportURLs reads links from the request body in the format given by
+// ?format= and reports how many were imported, conflicted or failed.
+// ?domain= assigns every link to a custom domain the caller owns, and
+// ?workspace= to a workspace the caller edits.
+func (h *URLHandler) ImportURLs(c *gin.Context) {
+	dec, err := transfer.NewDecoder(c.Request.Body, c.DefaultQuery("format", model.FormatNDJSON))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	report, err := h.service.Import(c.Request.Context(), dec, c.Query("domain"), c.Query("workspace"), c.GetHeader("X-API-Key"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
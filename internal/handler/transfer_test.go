@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportURLs_CSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		Return([]model.URL{{ID: "1", Code: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv, got %s", ct)
+	}
+	if lines := strings.Count(w.Body.String(), "\n"); lines != 2 {
+		t.Errorf("expected header and 1 row, got %d lines: %s", lines, w.Body.String())
+	}
+}
+
+func TestExportURLs_UnknownFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/export?format=xml", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestExportURLs_DatabaseUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		Return(nil, apperr.Unavailable(errors.New("conn refused"), "database unavailable"))
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Disposition") != "" {
+		t.Error("expected no attachment header on an error response")
+	}
+}
+
+func TestImportURLs_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().Import(gomock.Any(), gomock.Len(2)).Return([]int{1}, nil)
+
+	body := "{\"code\": \"abc\", \"original_url\": \"https://example.com\"}\n" +
+		"{\"code\": \"def\", \"original_url\": \"https://example.org\"}\n" +
+		"{\"code\": \"ghi\", \"original_url\": \"example.net\"}\n"
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var report model.ImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if report.Imported != 1 || report.Conflicted != 1 || report.Failed != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.Conflicts[0].Code != "def" || report.Errors[0].Line != 3 {
+		t.Errorf("unexpected problems %+v %+v", report.Conflicts, report.Errors)
+	}
+}
+
+func TestImportURLs_MissingColumns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodPost, "/import?format=csv", strings.NewReader("code,title\nabc,Hi\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
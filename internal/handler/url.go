@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
 	"github.com/kerbatek/url-shortener/internal/service"
 )
 
@@ -18,17 +23,16 @@ func NewURLHandler(service *service.URLService) *URLHandler {
 }
 
 func (h *URLHandler) ShortenURL(c *gin.Context) {
-	var req struct {
-		URL string `json:"url" binding:"required"`
-	}
+	var req model.ShortenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+		_ = c.Error(apperr.Invalid("url is required"))
 		return
 	}
+	req.APIKey = c.GetHeader("X-API-Key")
 
-	url, err := h.service.Shorten(c.Request.Context(), req.URL)
+	url, err := h.service.Shorten(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -37,31 +41,255 @@ func (h *URLHandler) ShortenURL(c *gin.Context) {
 
 func (h *URLHandler) RedirectURL(c *gin.Context) {
 	code := c.Param("code")
+	logger := log.Ctx(c.Request.Context())
+
+	url, err := h.service.Resolve(c.Request.Context(), c.Request.Host, code)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			if fallback := h.service.FallbackURL(c.Request.Context(), c.Request.Host); fallback != "" {
+				c.Redirect(http.StatusFound, fallback)
+				return
+			}
+		}
+		_ = c.Error(err)
+		return
+	}
+	if url.DisabledAt != nil {
+		logger.Info().Str("short_code", code).Str("reason", url.DisabledReason).Msg("disabled link")
+		renderWarning(c, url)
+		return
+	}
 
-	url, err := h.service.Resolve(c.Request.Context(), code)
+	target, err := service.RedirectTarget(url, c.Request.URL.Query())
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "short url not found"})
+		_ = c.Error(err)
+		return
+	}
+
+	click := model.Click{IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), Referrer: c.Request.Referer()}
+	click.Bot = h.service.IsBot(click.UserAgent, click.IP)
+	preview := h.service.ServesPreview(url, click.UserAgent, click.Bot)
+	// Previews do not send the client on, so only redirects use up a
+	// limited link.
+	if !preview {
+		if err := h.service.ConsumeClick(c.Request.Context(), url); err != nil {
+			if errors.Is(err, apperr.ErrGone) {
+				logger.Info().Str("short_code", code).Int64("max_clicks", url.MaxClicks).Msg("click limit reached")
+			}
+			_ = c.Error(err)
+			return
+		}
+	}
+	if err := h.service.RecordClick(c.Request.Context(), url, target, click); err != nil {
+		logger.Error().Err(err).Str("short_code", code).Msg("recording click failed")
+	}
+
+	if preview {
+		logger.Info().Str("short_code", code).Str("user_agent", click.UserAgent).Msg("bot preview")
+		renderPreview(c, url)
 		return
 	}
 
-	log.Info().
+	logger.Info().
 		Str("short_code", code).
 		Str("original_url", url.OriginalURL).
+		Str("target_url", target).
 		Str("ip", c.ClientIP()).
+		Bool("bot", click.Bot).
 		Msg("redirect")
 
-	c.Redirect(http.StatusFound, url.OriginalURL)
+	c.Redirect(http.StatusFound, target)
+}
+
+func (h *URLHandler) GetURL(c *gin.Context) {
+	url, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, url)
+}
+
+// ListURLs returns a page of links, newest first. Pass the returned
+// next_cursor as ?cursor= to fetch the following page; ?status=broken
+// lists only links whose destination check failed, and each ?meta=key=value
+// only links whose metadata has key set to value. ?workspace= lists a
+// workspace's links instead of those outside any.
+func (h *URLHandler) ListURLs(c *gin.Context) {
+	limit, err := limitParam(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	metadata, err := metadataParam(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := h.service.List(c.Request.Context(), model.ListQuery{
+		Limit:     limit,
+		Cursor:    c.Query("cursor"),
+		Status:    c.Query("status"),
+		Search:    c.Query("q"),
+		Tags:      c.QueryArray("tag"),
+		Campaign:  c.Query("campaign"),
+		Metadata:  metadata,
+		Workspace: c.Query("workspace"),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// limitParam reads ?limit, 0 when absent so the service picks its
+// default.
+func limitParam(c *gin.Context) (int, error) {
+	v := c.Query("limit")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, apperr.Invalid("limit must be a positive integer")
+	}
+	return n, nil
+}
+
+// URLStats returns click statistics for a link. Bot clicks are counted
+// separately unless ?include_bots=true.
+func (h *URLHandler) URLStats(c *gin.Context) {
+	includeBots, err := includeBotsParam(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	stats, err := h.service.Stats(c.Request.Context(), c.Param("id"), includeBots)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func includeBotsParam(c *gin.Context) (bool, error) {
+	v := c.Query("include_bots")
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, apperr.Invalid("include_bots must be true or false")
+	}
+	return b, nil
+}
+
+// metadataParam parses the repeatable ?meta=key=value filter.
+func metadataParam(c *gin.Context) (map[string]string, error) {
+	values := c.QueryArray("meta")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, apperr.Invalid("meta must be key=value, got %q", v)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// ListTags returns the tags in use with their link counts.
+func (h *URLHandler) ListTags(c *gin.Context) {
+	h.listGroups(c, model.GroupTag)
+}
+
+// ListCampaigns returns the campaigns in use with their link counts.
+func (h *URLHandler) ListCampaigns(c *gin.Context) {
+	h.listGroups(c, model.GroupCampaign)
+}
+
+func (h *URLHandler) listGroups(c *gin.Context, kind string) {
+	groups, err := h.service.Groups(c.Request.Context(), kind, c.Query("workspace"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// TagStats returns click statistics summed over the links with a tag.
+func (h *URLHandler) TagStats(c *gin.Context) {
+	h.groupStats(c, model.GroupTag)
+}
+
+// CampaignStats returns click statistics summed over a campaign's links.
+func (h *URLHandler) CampaignStats(c *gin.Context) {
+	h.groupStats(c, model.GroupCampaign)
+}
+
+func (h *URLHandler) groupStats(c *gin.Context, kind string) {
+	includeBots, err := includeBotsParam(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	stats, err := h.service.GroupStats(c.Request.Context(), kind, c.Param("name"), c.Query("workspace"), includeBots)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// UpdateURL changes the destination or query policy of a link. Fields left
+// out of the body keep their current values.
+func (h *URLHandler) UpdateURL(c *gin.Context) {
+	var req model.UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("invalid request body"))
+		return
+	}
+
+	url, err := h.service.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, url)
+}
+
+func (h *URLHandler) RestoreURL(c *gin.Context) {
+	url, err := h.service.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, url)
 }
 
 func (h *URLHandler) DeleteURL(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		_ = c.Error(apperr.Invalid("invalid id"))
 		return
 	}
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "url not found"})
+		_ = c.Error(err)
 		return
 	}
 
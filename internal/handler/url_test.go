@@ -11,9 +11,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/botdetect"
+	"github.com/kerbatek/url-shortener/internal/middleware"
 	"github.com/kerbatek/url-shortener/internal/model"
 	"github.com/kerbatek/url-shortener/internal/repository/mocks"
 	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
 	"go.uber.org/mock/gomock"
 )
 
@@ -27,9 +31,18 @@ func setupRouter(ctrl *gomock.Controller) (*gin.Engine, *mocks.MockURLRepository
 	h := NewURLHandler(svc)
 
 	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
 	router.POST("/shorten", h.ShortenURL)
 	router.GET("/:code", h.RedirectURL)
+	router.GET("/urls", h.ListURLs)
+	router.GET("/export", h.ExportURLs)
+	router.POST("/import", h.ImportURLs)
+	router.GET("/url/:id", h.GetURL)
+	router.PATCH("/url/:id", h.UpdateURL)
 	router.DELETE("/url/:id", h.DeleteURL)
+	router.POST("/url/:id/restore", h.RestoreURL)
+	router.GET("/tags", h.ListTags)
+	router.GET("/campaigns", h.ListCampaigns)
 
 	return router, mockRepo
 }
@@ -128,6 +141,31 @@ func TestShortenURL_InvalidURL(t *testing.T) {
 	}
 }
 
+func TestShortenURL_InternalErrorNotLeaked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(fmt.Errorf(`ERROR: relation "urls" does not exist (SQLSTATE 42P01)`))
+
+	body := `{"url": "https://example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "SQLSTATE") {
+		t.Errorf("expected database error to stay out of the response, got %s", w.Body.String())
+	}
+}
+
 func TestRedirectURL_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -135,7 +173,7 @@ func TestRedirectURL_Success(t *testing.T) {
 	router, mockRepo := setupRouter(ctrl)
 
 	mockRepo.EXPECT().
-		GetByCode(gomock.Any(), "abc1234").
+		GetByCode(gomock.Any(), "", "abc1234").
 		Return(&model.URL{
 			ID:          "550e8400-e29b-41d4-a716-446655440000",
 			Code:        "abc1234",
@@ -156,6 +194,72 @@ func TestRedirectURL_Success(t *testing.T) {
 	}
 }
 
+func TestRedirectURL_ClickLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	link := func() *model.URL {
+		return &model.URL{ID: "550e8400-e29b-41d4-a716-446655440000", Code: "abc1234", OriginalURL: "https://example.com", MaxClicks: 1}
+	}
+	mockRepo.EXPECT().GetByCode(gomock.Any(), "", "abc1234").Return(link(), nil).Times(2)
+	gomock.InOrder(
+		mockRepo.EXPECT().ConsumeClick(gomock.Any(), link().ID).Return(int64(1), nil),
+		mockRepo.EXPECT().ConsumeClick(gomock.Any(), link().ID).Return(int64(0), apperr.Gone("link has reached its click limit")),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected status 410, got %d", w.Code)
+	}
+	if w.Header().Get("Location") != "" {
+		t.Error("expected no redirect once the limit is reached")
+	}
+}
+
+func TestRedirectURL_ForwardsQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{
+			ID:              "550e8400-e29b-41d4-a716-446655440000",
+			Code:            "abc1234",
+			OriginalURL:     "https://example.com?ref=site",
+			ForwardQuery:    true,
+			QueryPrecedence: model.QueryPrecedenceIncoming,
+			UTMParams:       map[string]string{"utm_medium": "email"},
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234?ref=mail&id=7", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Errorf("expected status 302, got %d", w.Code)
+	}
+	location := w.Header().Get("Location")
+	if location != "https://example.com?id=7&ref=mail&utm_medium=email" {
+		t.Errorf("unexpected redirect target %s", location)
+	}
+}
+
 func TestRedirectURL_NotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -163,8 +267,8 @@ func TestRedirectURL_NotFound(t *testing.T) {
 	router, mockRepo := setupRouter(ctrl)
 
 	mockRepo.EXPECT().
-		GetByCode(gomock.Any(), "missing").
-		Return(nil, fmt.Errorf("not found"))
+		GetByCode(gomock.Any(), "", "missing").
+		Return(nil, apperr.NotFound("url not found"))
 
 	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
 	w := httptest.NewRecorder()
@@ -176,6 +280,62 @@ func TestRedirectURL_NotFound(t *testing.T) {
 	}
 }
 
+func TestRedirectURL_DomainFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockDomains := mocks.NewMockDomainRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mockRepo, service.WithDomains(mockDomains)))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/:code", h.RedirectURL)
+
+	domain := &model.Domain{ID: "dom-1", Host: "go.example.com", FallbackURL: "https://example.com/404"}
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(domain, nil).
+		Times(2)
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "dom-1", "missing").
+		Return(nil, apperr.NotFound("url not found"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://go.example.com/missing", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Errorf("expected status 302, got %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "https://example.com/404" {
+		t.Errorf("expected redirect to fallback, got %s", location)
+	}
+}
+
+func TestRedirectURL_DatabaseUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(nil, apperr.Unavailable(fmt.Errorf("dial tcp: connection refused"), "database unavailable"))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "dial tcp") {
+		t.Errorf("expected cause to stay out of the response, got %s", w.Body.String())
+	}
+}
+
 func TestDeleteURL_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -204,7 +364,7 @@ func TestDeleteURL_NotFound(t *testing.T) {
 
 	mockRepo.EXPECT().
 		Delete(gomock.Any(), "00000000-0000-0000-0000-000000000000").
-		Return(fmt.Errorf("url with id 00000000-0000-0000-0000-000000000000 not found"))
+		Return(apperr.NotFound("url not found"))
 
 	req := httptest.NewRequest(http.MethodDelete, "/url/00000000-0000-0000-0000-000000000000", nil)
 	w := httptest.NewRecorder()
@@ -224,7 +384,7 @@ func TestDeleteURL_InvalidID(t *testing.T) {
 
 	mockRepo.EXPECT().
 		Delete(gomock.Any(), "abc").
-		Return(fmt.Errorf("invalid uuid"))
+		Return(apperr.NotFound("url not found"))
 
 	req := httptest.NewRequest(http.MethodDelete, "/url/abc", nil)
 	w := httptest.NewRecorder()
@@ -235,3 +395,495 @@ func TestDeleteURL_InvalidID(t *testing.T) {
 		t.Errorf("expected status 404, got %d", w.Code)
 	}
 }
+
+func TestGetURL_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "550e8400-e29b-41d4-a716-446655440000").
+		Return(&model.URL{ID: "550e8400-e29b-41d4-a716-446655440000", Code: "abc1234", OriginalURL: "https://example.com"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/url/550e8400-e29b-41d4-a716-446655440000", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var got model.URL
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if got.Code != "abc1234" {
+		t.Errorf("expected code abc1234, got %s", got.Code)
+	}
+}
+
+func TestListURLs_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 1}).
+		Return([]model.URL{{ID: "550e8400-e29b-41d4-a716-446655440000", CreatedAt: time.Now()}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls?limit=1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var page model.URLPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(page.URLs) != 1 || page.NextCursor == "" {
+		t.Errorf("expected one URL and a next cursor, got %+v", page)
+	}
+}
+
+func TestListURLs_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 20, Tags: []string{"mail", "promo"}, Campaign: "Spring launch"}).
+		Return([]model.URL{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls?tag=promo&tag=mail&campaign=Spring+launch", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+}
+
+func TestListURLs_Metadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 20, Metadata: map[string]string{"owner": "alice", "ticket": "a=b"}}).
+		Return([]model.URL{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls?meta=owner=alice&meta=ticket=a%3Db", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/urls?meta=owner", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestListTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		Groups(gomock.Any(), model.GroupTag, "").
+		Return([]model.LinkGroup{{Name: "promo", Links: 3}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); body != `[{"name":"promo","links":3}]` {
+		t.Errorf("unexpected body %s", body)
+	}
+}
+
+func TestListURLs_Broken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 20, Broken: true}).
+		Return([]model.URL{{ID: "550e8400-e29b-41d4-a716-446655440000", CreatedAt: time.Now(),
+			Health: &model.LinkHealth{StatusCode: 404, Broken: true, CheckedAt: time.Now()}}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls?status=broken", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var page model.URLPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(page.URLs) != 1 || page.URLs[0].Health == nil || page.URLs[0].Health.StatusCode != 404 {
+		t.Errorf("expected one broken URL with its health, got %+v", page)
+	}
+}
+
+func TestListURLs_SearchDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 20, Deleted: true, Search: "example"}).
+		Return(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls?status=deleted&q=example", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+}
+
+func TestListURLs_InvalidLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/urls?limit=abc", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestUpdateURL_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "550e8400-e29b-41d4-a716-446655440000").
+		Return(&model.URL{ID: "550e8400-e29b-41d4-a716-446655440000", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	body := `{"url": "https://example.org"}`
+	req := httptest.NewRequest(http.MethodPatch, "/url/550e8400-e29b-41d4-a716-446655440000", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var got model.URL
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if got.OriginalURL != "https://example.org" {
+		t.Errorf("expected original URL https://example.org, got %s", got.OriginalURL)
+	}
+}
+
+func TestUpdateURL_InvalidJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodPatch, "/url/550e8400-e29b-41d4-a716-446655440000", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestRestoreURL_NotDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	mockRepo.EXPECT().
+		Restore(gomock.Any(), "550e8400-e29b-41d4-a716-446655440000").
+		Return(nil, apperr.NotFound("url not found"))
+
+	req := httptest.NewRequest(http.MethodPost, "/url/550e8400-e29b-41d4-a716-446655440000/restore", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestRedirectURL_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupRouter(ctrl)
+
+	disabledAt := time.Now()
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{ID: "1", Code: "abc1234", OriginalURL: "https://evil.example.com/<script>",
+			DisabledAt: &disabledAt, DisabledReason: "malware"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "" {
+		t.Errorf("expected no redirect, got Location %s", loc)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "malware") || !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("expected escaped warning page, got %s", body)
+	}
+}
+
+func TestRedirectURL_BotPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mockRepo,
+		service.WithClicks(mockClicks), service.WithBotClassifier(botdetect.New())))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/:code", h.RedirectURL)
+
+	link := &model.URL{ID: "1", Code: "abc1234", OriginalURL: "https://example.com/page", BotPolicy: model.BotPolicyPreview}
+	mockRepo.EXPECT().GetByCode(gomock.Any(), "", "abc1234").Return(link, nil).Times(2)
+	var bots []bool
+	mockClicks.EXPECT().Record(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, click *model.Click) error {
+			bots = append(bots, click.Bot)
+			return nil
+		}).Times(2)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	req.Header.Set("User-Agent", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `<meta property="og:title" content="example.com">`) {
+		t.Errorf("expected an Open Graph preview, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Errorf("expected status 302 for a browser, got %d", w.Code)
+	}
+	if len(bots) != 2 || !bots[0] || bots[1] {
+		t.Errorf("expected the first click flagged as a bot, got %v", bots)
+	}
+}
+
+func TestRedirectURL_PreviewKeepsClickLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mockRepo, service.WithBotClassifier(botdetect.New())))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/:code", h.RedirectURL)
+
+	// No ConsumeClick is expected: the unfurler gets the preview.
+	link := &model.URL{ID: "1", Code: "abc1234", OriginalURL: "https://example.com/secret-token", BotPolicy: model.BotPolicyPreview, MaxClicks: 1}
+	mockRepo.EXPECT().GetByCode(gomock.Any(), "", "abc1234").Return(link, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	req.Header.Set("User-Agent", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "secret-token") {
+		t.Errorf("expected the preview of a limited link to hide its path, got %s", w.Body.String())
+	}
+}
+
+func TestRedirectURL_BotUsesClickLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mockRepo, service.WithBotClassifier(botdetect.New())))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/:code", h.RedirectURL)
+
+	// Clients choose their own user agent, so a redirect classified as a
+	// bot uses up the link like any other.
+	link := &model.URL{ID: "1", Code: "abc1234", OriginalURL: "https://example.com/file", MaxClicks: 1}
+	mockRepo.EXPECT().GetByCode(gomock.Any(), "", "abc1234").Return(link, nil).Times(2)
+	gomock.InOrder(
+		mockRepo.EXPECT().ConsumeClick(gomock.Any(), "1").Return(int64(1), nil),
+		mockRepo.EXPECT().ConsumeClick(gomock.Any(), "1").Return(int64(0), apperr.Gone("link has reached its click limit")),
+	)
+
+	for _, want := range []int{http.StatusFound, http.StatusGone} {
+		req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+		req.Header.Set("User-Agent", "curl/8.5.0")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != want {
+			t.Errorf("expected status %d, got %d", want, w.Code)
+		}
+	}
+}
+
+func TestURLStats_IncludeBots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mockRepo, service.WithClicks(mockClicks)))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/url/:id/stats", h.URLStats)
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), "1").Return(&model.URL{ID: "1"}, nil)
+	mockClicks.EXPECT().Stats(gomock.Any(), "1", model.StatsDays, true).
+		Return(&model.Stats{URLID: "1", TotalClicks: 5, BotClicks: 2}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/url/1/stats?include_bots=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"bot_clicks":2`) {
+		t.Errorf("expected bot_clicks in the response, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/url/1/stats?include_bots=maybe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestCampaignStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mocks.NewMockURLRepository(ctrl), service.WithClicks(mockClicks)))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/campaigns/:name/stats", h.CampaignStats)
+
+	mockClicks.EXPECT().GroupStats(gomock.Any(), model.GroupCampaign, "Spring launch", "", model.StatsDays, false).
+		Return(&model.GroupStats{Group: model.GroupCampaign, Name: "Spring launch", Links: 2, TotalClicks: 9}, nil)
+	mockClicks.EXPECT().GroupStats(gomock.Any(), model.GroupCampaign, "gone", "", model.StatsDays, false).
+		Return(nil, apperr.NotFound("campaign %q has no links", "gone"))
+
+	req := httptest.NewRequest(http.MethodGet, "/campaigns/Spring%20launch/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"total_clicks":9`) {
+		t.Errorf("expected total_clicks in the response, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/campaigns/gone/stats", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestRedirectURL_UnfurlerPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	h := NewURLHandler(service.NewURLService(mockRepo, service.WithBotClassifier(botdetect.New())))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.GET("/:code", h.RedirectURL)
+
+	mockRepo.EXPECT().GetByCode(gomock.Any(), "", "abc1234").Return(&model.URL{
+		ID: "1", Code: "abc1234", OriginalURL: "https://example.com/sale", BotPolicy: model.BotPolicyRedirect,
+		Preview: &model.LinkPreview{Title: "Spring <sale>", ImageURL: "https://cdn.example.com/card.png"},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/abc1234", nil)
+	req.Header.Set("User-Agent", "Twitterbot/1.0")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, tag := range []string{
+		`<meta property="og:title" content="Spring &lt;sale&gt;">`,
+		`<meta property="og:description" content="Short link to https://example.com/sale">`,
+		`<meta property="og:image" content="https://cdn.example.com/card.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta property="og:url" content="http://example.com/abc1234">`,
+	} {
+		if !strings.Contains(body, tag) {
+			t.Errorf("expected %s in the preview, got %s", tag, body)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// warningPage is served instead of redirecting to a disabled link. The
+// destination is shown as text, not a link, so it cannot be followed by a
+// stray click.
+var warningPage = template.Must(template.New("warning").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="robots" content="noindex">
+    <title>Link disabled</title>
+    <link rel="stylesheet" href="/static/style.css">
+</head>
+<body>
+    <h1>Link disabled</h1>
+    <p>This short link has been disabled because its destination is listed as <strong>{{.Reason}}</strong>.</p>
+    <p>Destination: <code>{{.Destination}}</code></p>
+</body>
+</html>
+`))
+
+// renderWarning serves the warning page for a disabled link.
+func renderWarning(c *gin.Context, u *model.URL) {
+	c.Header("Cache-Control", "no-store")
+	c.Render(http.StatusForbidden, render.HTML{
+		Template: warningPage,
+		Data:     gin.H{"Reason": u.DisabledReason, "Destination": u.OriginalURL},
+	})
+}
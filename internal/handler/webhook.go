@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/service"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// RegisterWebhook adds an endpoint owned by the calling user for the links
+// of the requested workspace, or of links outside any workspace.
+func (h *WebhookHandler) RegisterWebhook(c *gin.Context) {
+	var req struct {
+		URL       string   `json:"url" binding:"required"`
+		Events    []string `json:"events"`
+		Workspace string   `json:"workspace"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperr.Invalid("url is required"))
+		return
+	}
+
+	webhook, err := h.service.Register(c.Request.Context(), req.URL, req.Events, req.Workspace)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// ListWebhooks returns the webhooks owned by the calling user.
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.service.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// ListDeliveries returns recent delivery attempts for one webhook.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	deliveries, err := h.service.Deliveries(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+func setupWebhookRouter(ctrl *gomock.Controller) (*gin.Engine, *mocks.MockWebhookRepository) {
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	h := NewWebhookHandler(service.NewWebhookService(mockRepo, nil))
+
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	// Each API key stands for the user with the same ID.
+	router.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			ctx := service.WithCaller(c.Request.Context(), service.Caller{User: &model.User{ID: key}})
+			c.Request = c.Request.WithContext(ctx)
+		}
+	})
+	router.POST("/webhooks", h.RegisterWebhook)
+	router.GET("/webhooks", h.ListWebhooks)
+	router.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+
+	return router, mockRepo
+}
+
+func TestRegisterWebhook_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupWebhookRouter(ctrl)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, w *model.Webhook) error {
+			w.ID = "22222222-2222-2222-2222-222222222222"
+			return nil
+		})
+
+	body := `{"url": "https://hooks.example.com", "events": ["link.created"]}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	var resp model.Webhook
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Secret == "" {
+		t.Error("expected signing secret in creation response")
+	}
+}
+
+func TestRegisterWebhook_MissingKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, _ := setupWebhookRouter(ctrl)
+
+	body := `{"url": "https://hooks.example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
+
+func TestListDeliveries_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupWebhookRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "w1").
+		Return(&model.Webhook{ID: "w1", OwnerUserID: "secret"}, nil)
+	mockRepo.EXPECT().
+		ListDeliveries(gomock.Any(), "w1", gomock.Any()).
+		Return([]model.WebhookDelivery{{ID: 7, WebhookID: "w1", Status: model.DeliveryDelivered, Secret: "shh"}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/w1/deliveries", nil)
+	req.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "shh") {
+		t.Error("expected webhook secret to be omitted from deliveries")
+	}
+}
+
+func TestListDeliveries_NotOwned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router, mockRepo := setupWebhookRouter(ctrl)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "w1").
+		Return(&model.Webhook{ID: "w1", OwnerUserID: "owner"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/w1/deliveries", nil)
+	req.Header.Set("X-API-Key", "intruder")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,177 @@
+// Package linkcheck periodically requests link destinations and records
+// whether they still resolve.
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+	"github.com/kerbatek/url-shortener/internal/repository"
+)
+
+// maxBodyRead bounds how much of a GET response is read before the
+// connection is dropped.
+const maxBodyRead = 64 << 10
+
+// Checker claims links due for a check, requests their destinations and
+// records the outcome. Links on the same host are checked one at a time,
+// HostDelay apart; up to Concurrency hosts are checked in parallel.
+type Checker struct {
+	repo   repository.URLRepository
+	client *http.Client
+	logger zerolog.Logger
+
+	Interval    time.Duration
+	BatchSize   int
+	Concurrency int
+	HostDelay   time.Duration
+	// Recheck is how long after a check the link is checked again.
+	Recheck time.Duration
+	// AllowPrivate permits checking destinations on loopback, private and
+	// link-local addresses, which are refused by default so links cannot
+	// be used to probe the internal network.
+	AllowPrivate bool
+	UserAgent    string
+}
+
+func NewChecker(repo repository.URLRepository, logger zerolog.Logger) *Checker {
+	c := &Checker{
+		repo:        repo,
+		logger:      logger,
+		Interval:    time.Minute,
+		BatchSize:   200,
+		Concurrency: 8,
+		HostDelay:   time.Second,
+		Recheck:     24 * time.Hour,
+		UserAgent:   "url-shortener-linkcheck/1.0",
+	}
+	transport := netguard.Transport(func() bool { return c.AllowPrivate })
+	c.client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return c
+}
+
+// Broken reports whether a check status means the destination is dead. A
+// status of 0 means no response. Responses that only deny access or
+// throttle the checker show the destination exists.
+func Broken(status int) bool {
+	switch status {
+	case 0:
+		return true
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	default:
+		return status >= 400
+	}
+}
+
+// Run checks due links every Interval until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.RunOnce(ctx); err != nil {
+			c.logger.Error().Err(err).Msg("Link check failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks one batch of due links and returns how many it checked.
+func (c *Checker) RunOnce(ctx context.Context) (int, error) {
+	// Long enough for a batch that is all on one host.
+	lease := time.Duration(c.BatchSize) * (c.client.Timeout + c.HostDelay)
+	urls, err := c.repo.ClaimDueChecks(ctx, c.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	byHost := map[string][]model.URL{}
+	for _, u := range urls {
+		host := ""
+		if parsed, err := url.Parse(u.OriginalURL); err == nil {
+			host = strings.ToLower(parsed.Hostname())
+		}
+		byHost[host] = append(byHost[host], u)
+	}
+
+	sem := make(chan struct{}, max(1, c.Concurrency))
+	var wg sync.WaitGroup
+	for _, links := range byHost {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			c.checkHost(ctx, links)
+		}()
+	}
+	wg.Wait()
+	return len(urls), nil
+}
+
+// checkHost checks links that share a host one after another.
+func (c *Checker) checkHost(ctx context.Context, links []model.URL) {
+	for i, u := range links {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.HostDelay):
+			}
+		}
+		health := c.Check(ctx, u.OriginalURL)
+		if err := c.repo.RecordCheck(ctx, u.ID, &health, health.CheckedAt.Add(c.Recheck)); err != nil {
+			c.logger.Error().Err(err).Str("url_id", u.ID).Msg("Recording link check failed")
+		}
+	}
+}
+
+// Check requests rawURL, following redirects. A HEAD that fails is
+// confirmed with a GET, since some servers reject or mishandle HEAD.
+func (c *Checker) Check(ctx context.Context, rawURL string) model.LinkHealth {
+	start := time.Now()
+	status, err := c.request(ctx, http.MethodHead, rawURL)
+	if err == nil && status >= 400 {
+		status, err = c.request(ctx, http.MethodGet, rawURL)
+	}
+	health := model.LinkHealth{
+		StatusCode: status,
+		LatencyMS:  int(time.Since(start).Milliseconds()),
+		CheckedAt:  start.UTC(),
+	}
+	if err != nil {
+		health.StatusCode = 0
+		health.Error = err.Error()
+	}
+	health.Broken = Broken(health.StatusCode)
+	return health
+}
+
+func (c *Checker) request(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.CopyN(io.Discard, resp.Body, maxBodyRead)
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
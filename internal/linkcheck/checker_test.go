@@ -0,0 +1,180 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+// newTarget serves the destinations used by the tests.
+func newTarget(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/gone", http.StatusFound)
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusForbidden) })
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newChecker(repo *mocks.MockURLRepository) *Checker {
+	c := NewChecker(repo, zerolog.Nop())
+	c.AllowPrivate = true
+	c.HostDelay = 0
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	srv := newTarget(t)
+	c := newChecker(nil)
+
+	tests := []struct {
+		path   string
+		status int
+		broken bool
+	}{
+		{"/ok", http.StatusOK, false},
+		{"/gone", http.StatusNotFound, true},
+		{"/no-head", http.StatusOK, false},
+		{"/moved", http.StatusNotFound, true},
+		{"/private", http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			h := c.Check(context.Background(), srv.URL+tt.path)
+			if h.StatusCode != tt.status || h.Broken != tt.broken {
+				t.Errorf("expected %d broken=%v, got %d broken=%v (%s)", tt.status, tt.broken, h.StatusCode, h.Broken, h.Error)
+			}
+			if h.CheckedAt.IsZero() {
+				t.Error("expected CheckedAt to be set")
+			}
+		})
+	}
+}
+
+func TestCheck_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	addr := srv.URL
+	srv.Close()
+
+	h := newChecker(nil).Check(context.Background(), addr)
+	if h.StatusCode != 0 || !h.Broken || h.Error == "" {
+		t.Errorf("expected an unreachable, broken result, got %+v", h)
+	}
+}
+
+func TestCheck_RefusesPrivateAddresses(t *testing.T) {
+	srv := newTarget(t)
+	c := NewChecker(nil, zerolog.Nop())
+
+	h := c.Check(context.Background(), srv.URL+"/ok")
+	if h.StatusCode != 0 || !strings.Contains(h.Error, netguard.ErrPrivateAddress.Error()) {
+		t.Errorf("expected loopback target to be refused, got %+v", h)
+	}
+}
+
+func TestBroken(t *testing.T) {
+	tests := map[int]bool{0: true, 200: false, 301: false, 401: false, 403: false, 404: true, 410: true, 429: false, 500: true, 503: true}
+	for status, want := range tests {
+		if got := Broken(status); got != want {
+			t.Errorf("Broken(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestRunOnce_RecordsEachLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := newTarget(t)
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	c := newChecker(mockRepo)
+	c.Recheck = time.Hour
+
+	mockRepo.EXPECT().
+		ClaimDueChecks(gomock.Any(), c.BatchSize, gomock.Any()).
+		Return([]model.URL{
+			{ID: "ok", OriginalURL: srv.URL + "/ok"},
+			{ID: "gone", OriginalURL: srv.URL + "/gone"},
+			{ID: "bad", OriginalURL: "http://[::1"},
+		}, nil)
+
+	var mu sync.Mutex
+	got := map[string]model.LinkHealth{}
+	mockRepo.EXPECT().
+		RecordCheck(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, id string, h *model.LinkHealth, next time.Time) error {
+			if !next.Equal(h.CheckedAt.Add(time.Hour)) {
+				t.Errorf("expected next check an hour after %v, got %v", h.CheckedAt, next)
+			}
+			mu.Lock()
+			got[id] = *h
+			mu.Unlock()
+			return nil
+		}).
+		Times(3)
+
+	n, err := c.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 links checked, got %d", n)
+	}
+	if got["ok"].Broken || !got["gone"].Broken || !got["bad"].Broken {
+		t.Errorf("unexpected results %+v", got)
+	}
+}
+
+func TestCheckHost_SpacesRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var mu sync.Mutex
+	var hits []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	c := newChecker(mockRepo)
+	c.HostDelay = 50 * time.Millisecond
+	mockRepo.EXPECT().RecordCheck(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	c.checkHost(context.Background(), []model.URL{
+		{ID: "1", OriginalURL: srv.URL + "/a"},
+		{ID: "2", OriginalURL: srv.URL + "/b"},
+		{ID: "3", OriginalURL: srv.URL + "/c"},
+	})
+
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(hits))
+	}
+	for i := 1; i < len(hits); i++ {
+		if gap := hits[i].Sub(hits[i-1]); gap < c.HostDelay {
+			t.Errorf("expected requests %s apart, got %s", c.HostDelay, gap)
+		}
+	}
+}
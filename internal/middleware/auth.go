@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/service"
+)
+
+// Authenticator resolves an API key, and a bearer token from the identity
+// provider, to the caller it identifies: a key that belongs to no user
+// identifies nobody. service.AccountService implements it.
+type Authenticator interface {
+	Authenticate(ctx context.Context, apiKey string) (service.Caller, error)
+	AuthenticateToken(ctx context.Context, token string) (service.Caller, error)
+}
+
+// Authenticate identifies the caller from an Authorization: Bearer token
+// or, failing that, the X-API-Key header, and attaches it to the request
+// context, where the services authorize against it. Requests without a
+// key, or with one that belongs to no user, go on anonymously: keys that
+// only own domains and webhooks keep working. Invalid bearer tokens are
+// rejected outright.
+func Authenticate(users Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var caller service.Caller
+		var err error
+		if token, ok := bearerToken(c.GetHeader("Authorization")); ok {
+			caller, err = users.AuthenticateToken(c.Request.Context(), token)
+		} else {
+			caller, err = users.Authenticate(c.Request.Context(), c.GetHeader("X-API-Key"))
+		}
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		ctx := service.WithCaller(c.Request.Context(), caller)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token from an Authorization header value.
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
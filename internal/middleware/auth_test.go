@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/service"
+)
+
+type stubAuthenticator struct {
+	users map[string]*model.User
+	err   error
+}
+
+func (s *stubAuthenticator) Authenticate(_ context.Context, apiKey string) (service.Caller, error) {
+	return service.Caller{User: s.users[apiKey]}, s.err
+}
+
+func (s *stubAuthenticator) AuthenticateToken(_ context.Context, token string) (service.Caller, error) {
+	if token != "good-jwt" {
+		return service.Caller{}, apperr.Unauthorized("invalid bearer token")
+	}
+	return service.Caller{User: s.users["ada-key"]}, s.err
+}
+
+func TestAuthenticate(t *testing.T) {
+	ada := &model.User{ID: "u1"}
+	tests := []struct {
+		name       string
+		key        string
+		auth       string
+		err        error
+		wantStatus int
+		wantUser   *model.User
+	}{
+		{"user", "ada-key", "", nil, http.StatusOK, ada},
+		{"anonymous", "", "", nil, http.StatusOK, nil},
+		{"key without user", "domain-key", "", nil, http.StatusOK, nil},
+		{"lookup fails", "ada-key", "", errors.New("connection reset"), http.StatusInternalServerError, nil},
+		{"bearer token", "", "Bearer good-jwt", nil, http.StatusOK, ada},
+		{"bearer token wins over key", "domain-key", "bearer good-jwt", nil, http.StatusOK, ada},
+		{"invalid bearer token", "ada-key", "Bearer forged", nil, http.StatusUnauthorized, nil},
+		{"other scheme", "", "Basic YWRhOnB3", nil, http.StatusOK, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *model.User
+			router := gin.New()
+			router.Use(Errors(zerolog.Nop()))
+			router.Use(Authenticate(&stubAuthenticator{users: map[string]*model.User{"ada-key": ada}, err: tt.err}))
+			router.GET("/me", func(c *gin.Context) {
+				got = service.CallerFrom(c.Request.Context()).User
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			req.Header.Set("Authorization", tt.auth)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got != tt.wantUser {
+				t.Errorf("expected caller %v, got %v", tt.wantUser, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+)
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// StatusFor maps an apperr kind to its HTTP status. Errors of no known kind
+// are internal server errors.
+func StatusFor(err error) int {
+	switch {
+	case errors.Is(err, apperr.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, apperr.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, apperr.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, apperr.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperr.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, apperr.ErrGone):
+		return http.StatusGone
+	case errors.Is(err, apperr.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Errors renders the last error a handler attached with c.Error as an
+// application/problem+json response. Only apperr messages reach the client;
+// anything else is logged and reported as a bare 500.
+func Errors(logger zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		status := StatusFor(err)
+		if status >= http.StatusInternalServerError {
+			requestLogger(c, logger).Error().Err(err).Str("path", c.Request.URL.Path).Msg("request failed")
+		}
+
+		c.Header("Content-Type", "application/problem+json")
+		c.JSON(status, Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   apperr.Message(err),
+			Instance: c.Request.URL.Path,
+		})
+	}
+}
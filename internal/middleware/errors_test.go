@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+)
+
+func TestErrors_RendersProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+	}{
+		{"invalid", apperr.Invalid("invalid URL"), http.StatusBadRequest, "invalid URL"},
+		{"unauthorized", apperr.Unauthorized("api key is required"), http.StatusUnauthorized, "api key is required"},
+		{"forbidden", apperr.Forbidden("not yours"), http.StatusForbidden, "not yours"},
+		{"not found", apperr.NotFound("url not found"), http.StatusNotFound, "url not found"},
+		{"conflict", apperr.Conflict("url already exists"), http.StatusConflict, "url already exists"},
+		{"gone", apperr.Gone("link used up"), http.StatusGone, "link used up"},
+		{"unavailable", apperr.Unavailable(errors.New("dial tcp: refused"), "database unavailable"), http.StatusServiceUnavailable, "database unavailable"},
+		{"untyped", errors.New(`pq: relation "urls" does not exist`), http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Errors(zerolog.Nop()))
+			router.GET("/fail", func(c *gin.Context) { _ = c.Error(tt.err) })
+
+			req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("expected problem+json content type, got %s", ct)
+			}
+			var p Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("failed to parse problem: %v", err)
+			}
+			if p.Status != tt.wantStatus || p.Title != http.StatusText(tt.wantStatus) {
+				t.Errorf("unexpected problem %+v", p)
+			}
+			if p.Detail != tt.wantDetail {
+				t.Errorf("expected detail %q, got %q", tt.wantDetail, p.Detail)
+			}
+			if p.Instance != "/fail" {
+				t.Errorf("expected instance /fail, got %s", p.Instance)
+			}
+		})
+	}
+}
+
+func TestErrors_LogsInternalErrors(t *testing.T) {
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(Errors(zerolog.New(&buf)))
+	router.GET("/fail", func(c *gin.Context) { _ = c.Error(errors.New("boom")) })
+	router.GET("/missing", func(c *gin.Context) { _ = c.Error(apperr.NotFound("nope")) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if buf.Len() != 0 {
+		t.Errorf("expected client errors not to be logged, got %s", buf.String())
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if !strings.Contains(buf.String(), "boom") {
+		t.Errorf("expected internal error to be logged, got %s", buf.String())
+	}
+}
+
+func TestErrors_LeavesWrittenResponses(t *testing.T) {
+	router := gin.New()
+	router.Use(Errors(zerolog.Nop()))
+	router.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		c.Writer.WriteHeaderNow()
+		_ = c.Error(errors.New("after the fact"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status 202, got %d", w.Code)
+	}
+}
@@ -16,7 +16,7 @@ func Logger(logger zerolog.Logger) gin.HandlerFunc {
 
 		latency := time.Since(start)
 
-		logger.Info().
+		requestLogger(c, logger).Info().
 			Str("ip", c.ClientIP()).
 			Str("method", c.Request.Method).
 			Str("path", path).
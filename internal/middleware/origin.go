@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/audit"
+)
+
+// Origin attaches where each request came from, its client IP and request
+// ID, to the request context for the audit log. It goes after RequestID,
+// whose ID it records; without it, the X-Request-ID header is taken as is.
+func Origin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := RequestIDFrom(c)
+		if id == "" {
+			id = c.GetHeader(RequestIDHeader)
+		}
+		ctx := audit.WithOrigin(c.Request.Context(), audit.Origin{ClientIP: c.ClientIP(), RequestID: id})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
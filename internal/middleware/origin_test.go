@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/audit"
+)
+
+func TestOrigin_RecordsClientAndRequestID(t *testing.T) {
+	var got audit.Origin
+	router := gin.New()
+	router.Use(Origin())
+	router.GET("/test", func(c *gin.Context) {
+		got = audit.OriginFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set(RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.ClientIP != "203.0.113.7" || got.RequestID != "req-1" {
+		t.Errorf("unexpected origin %+v", got)
+	}
+}
+
+func TestOrigin_ForwardedForOnlyFromTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{"no proxies", nil, "203.0.113.7"},
+		{"another proxy", []string{"10.0.0.0/8"}, "203.0.113.7"},
+		{"trusted proxy", []string{"203.0.113.0/24"}, "198.51.100.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got audit.Origin
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			router.Use(Origin())
+			router.GET("/test", func(c *gin.Context) {
+				got = audit.OriginFrom(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = "203.0.113.7:4321"
+			req.Header.Set("X-Forwarded-For", "198.51.100.9")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got.ClientIP != tt.want {
+				t.Errorf("expected client IP %s, got %s", tt.want, got.ClientIP)
+			}
+		})
+	}
+}
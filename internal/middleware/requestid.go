@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries a request's ID. An incoming one is kept, so a
+// request can be followed from the proxy or client that sent it; otherwise
+// one is generated. Either way it is echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps incoming request IDs, which end up in every log
+// line and audit entry of the request.
+const maxRequestIDLength = 128
+
+// requestIDKey is the gin context key holding the request ID.
+const requestIDKey = "request_id"
+
+// RequestID assigns every request an ID, honoring a well-formed incoming
+// X-Request-ID, and attaches logger, with the ID and the request's trace
+// ID, to the request context. Handlers log through zerolog.Ctx so their
+// lines can be correlated with the request log and traces.
+func RequestID(logger zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		ctx := c.Request.Context()
+		fields := logger.With().Str("request_id", id)
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			fields = fields.Str("trace_id", sc.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(fields.Logger().WithContext(ctx))
+		c.Next()
+	}
+}
+
+// RequestIDFrom returns the ID RequestID assigned to the request, or ""
+// without the middleware.
+func RequestIDFrom(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestLogger returns logger with the request's ID, if it has one.
+func requestLogger(c *gin.Context, logger zerolog.Logger) *zerolog.Logger {
+	if id := RequestIDFrom(c); id != "" {
+		logger = logger.With().Str("request_id", id).Logger()
+	}
+	return &logger
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, such as
+// UUIDs and the IDs proxies generate, that are not too long.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"honors incoming", "req-abc-123", true},
+		{"generates when missing", "", false},
+		{"replaces with spaces", "two words", false},
+		{"replaces too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+			router := gin.New()
+			router.Use(RequestID(logger), Logger(logger))
+			var got string
+			router.GET("/test", func(c *gin.Context) {
+				got = RequestIDFrom(c)
+				zerolog.Ctx(c.Request.Context()).Info().Msg("handled")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if tt.wantSame && got != tt.incoming {
+				t.Errorf("expected request ID %q, got %q", tt.incoming, got)
+			}
+			if !tt.wantSame && (got == tt.incoming || len(got) != 32) {
+				t.Errorf("expected a generated request ID, got %q", got)
+			}
+			if h := w.Header().Get(RequestIDHeader); h != got {
+				t.Errorf("expected response header %q, got %q", got, h)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 log lines, got %d", len(lines))
+			}
+			for _, line := range lines {
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("failed to parse log entry: %v", err)
+				}
+				if entry["request_id"] != got {
+					t.Errorf("expected request_id %q in %q line, got %v", got, entry["message"], entry["request_id"])
+				}
+			}
+		})
+	}
+}
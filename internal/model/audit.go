@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Audited actions on a link.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// Actors that are not users.
+const (
+	// ActorOperator is the server's administrator, such as the dashboard.
+	ActorOperator = "operator"
+	// ActorAnonymous is a caller without a user, including API keys that
+	// only own domains and webhooks.
+	ActorAnonymous = "anonymous"
+)
+
+// AuditEntry records one change to a link. Before is nil for creates and
+// restores, After for deletes.
+type AuditEntry struct {
+	ID int64 `json:"id"`
+	// Actor is the ID of the user who made the change, or ActorOperator
+	// or ActorAnonymous. ActorEmail is the user's email at the time.
+	Actor       string    `json:"actor"`
+	ActorEmail  string    `json:"actor_email,omitempty"`
+	Action      string    `json:"action"`
+	LinkID      string    `json:"link_id"`
+	WorkspaceID *string   `json:"workspace_id,omitempty"`
+	Before      *URL      `json:"before,omitempty"`
+	After       *URL      `json:"after,omitempty"`
+	ClientIP    string    `json:"client_ip,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AuditQuery is a caller's request for a page of the audit log. Actor and
+// Link narrow it to one actor or link, and Since and Until to the entries
+// written in [Since, Until). Workspace reads the log of that workspace
+// instead of the links outside any.
+type AuditQuery struct {
+	Limit     int
+	Cursor    string
+	Actor     string
+	Link      string
+	Since     *time.Time
+	Until     *time.Time
+	Workspace string
+}
+
+// AuditOptions selects a page of the audit log, newest first. After is
+// nil for the first page; its ID is the entry's ID.
+type AuditOptions struct {
+	Limit  int
+	After  *Cursor
+	Actor  string
+	LinkID string
+	Since  *time.Time
+	Until  *time.Time
+	// Workspace restricts the page to changes to links of this workspace,
+	// or to links outside any workspace when empty, unless AllWorkspaces
+	// is set.
+	Workspace     string
+	AllWorkspaces bool
+}
+
+// AuditPage is one page of the audit log; NextCursor is empty on the last
+// page.
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Click is one redirect served for a link.
+type Click struct {
+	URLID     string    `json:"url_id" db:"url_id"`
+	ClickedAt time.Time `json:"clicked_at" db:"clicked_at"`
+	IP        string    `json:"ip" db:"ip"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	Referrer  string    `json:"referrer" db:"referrer"`
+	// Bot is set when the client was recognised as a bot or crawler.
+	Bot bool `json:"bot" db:"bot"`
+}
+
+// ClickEvent is a redirect as streamed to event sinks. Destination is the
+// link's stored URL and Target where the client was actually sent, after
+// query forwarding and UTM parameters.
+type ClickEvent struct {
+	Click
+	Code        string `json:"code"`
+	Domain      string `json:"domain,omitempty"`
+	Destination string `json:"destination"`
+	Target      string `json:"target"`
+}
+
+// Stats summarises the clicks on a link. Daily covers the last StatsDays
+// days, oldest first, and omits days without clicks. Unless bots were
+// included, TotalClicks, LastClickedAt and Daily count human clicks only;
+// BotClicks always counts the bot clicks.
+type Stats struct {
+	URLID         string        `json:"url_id"`
+	TotalClicks   int64         `json:"total_clicks"`
+	BotClicks     int64         `json:"bot_clicks"`
+	LastClickedAt *time.Time    `json:"last_clicked_at,omitempty"`
+	Daily         []DailyClicks `json:"daily"`
+}
+
+type DailyClicks struct {
+	Date   string `json:"date"`
+	Clicks int64  `json:"clicks"`
+}
+
+// StatsDays is the window covered by Stats.Daily.
+const StatsDays = 30
@@ -1,10 +1,84 @@
 package model
 
+import "time"
+
 type Config struct {
 	AppPort    int
+	GRPCPort   int
 	DBName     string
 	DBUser     string
 	DBPassword string
 	DBHost     string
 	DBPort     int
+	// DBReplicas are read replicas, as host or host:port, that take link
+	// lookups and lists off the primary while they are no more than
+	// DBReplicaMaxLag behind it. They are checked every
+	// DBReplicaCheckInterval and share the primary's credentials.
+	DBReplicas             []string
+	DBReplicaMaxLag        time.Duration
+	DBReplicaCheckInterval time.Duration
+	// TrustedProxies are the addresses or CIDR ranges of the reverse
+	// proxies whose X-Forwarded-For header names the client. With none,
+	// the client is the connection's peer.
+	TrustedProxies []string
+	// DefaultHosts are the hosts the default namespace is served on,
+	// which cannot be registered as custom domains.
+	DefaultHosts []string
+	// ThreatListDir holds the threat lists links are screened against;
+	// screening is off when it is empty.
+	ThreatListDir        string
+	ThreatRescanInterval time.Duration
+	// LinkCheckInterval is how often each destination is re-checked; zero
+	// disables the checker.
+	LinkCheckInterval time.Duration
+	// LinkCheckAllowPrivate lets the checker, and preview fetching, reach
+	// destinations on private addresses, and domains fall back to them.
+	LinkCheckAllowPrivate bool
+	// WebhookAllowPrivate lets webhooks target private addresses.
+	WebhookAllowPrivate bool
+	// StaticDir and MigrationsDir replace the embedded web page and
+	// migrations with the contents of a directory, for development.
+	StaticDir     string
+	MigrationsDir string
+	// Click events are streamed to each sink that is configured: NDJSON
+	// files in EventsFileDir, batches POSTed to EventsHTTPURL and records
+	// produced to EventsKafkaTopic on EventsKafkaBrokers.
+	EventsFileDir           string
+	EventsHTTPURL           string
+	EventsHTTPAuthorization string
+	EventsKafkaBrokers      []string
+	EventsKafkaTopic        string
+	// EventsBuffer is how many events each sink buffers; EventsPolicy says
+	// whether redirects drop or wait for events when a buffer is full.
+	EventsBuffer int
+	EventsPolicy string
+	// BotIPRangesFile lists crawler address ranges that mark clicks as bot
+	// clicks in addition to the built-in user agent patterns.
+	BotIPRangesFile string
+	// AdminAPIKey signs in to the admin dashboard, which is not served
+	// when it is empty.
+	AdminAPIKey string
+	// OIDCIssuer turns on single sign-on through that OpenID Connect
+	// provider: bearer tokens it issues for OIDCAudience (OIDCClientID
+	// when empty) are accepted by the API, and the dashboard signs
+	// operators in through it when OIDCRedirectURL is set. OIDCRoles maps
+	// the groups in OIDCGroupsClaim to roles, as parsed by
+	// sso.ParseRoleMap.
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCAudience     string
+	OIDCGroupsClaim  string
+	OIDCRoles        string
+	// CodeStrategy is the codegen strategy that makes codes for requests
+	// that name none; CodeSalt keys the obfuscated strategy.
+	CodeStrategy string
+	CodeSalt     string
+	// CaseInsensitiveCodes makes new codes lower case and looks codes up
+	// regardless of case, once no existing codes differ only in case.
+	CaseInsensitiveCodes bool
+	// TracesExporter is where OpenTelemetry spans go: "otlp", "stdout" or
+	// "none", the default.
+	TracesExporter string
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Domain is a branded short host served by this deployment. Links created
+// on a domain share its code namespace, and OwnerKeyHash is the SHA-256 of
+// the API key allowed to create links on it.
+type Domain struct {
+	ID           string    `json:"id" db:"id"`
+	Host         string    `json:"host" db:"host"`
+	FallbackURL  string    `json:"fallback_url,omitempty" db:"fallback_url"`
+	OwnerKeyHash string    `json:"-" db:"owner_key_hash"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
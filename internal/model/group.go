@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Kinds of link group: links are grouped by the tags they carry and by
+// the campaign they belong to.
+const (
+	GroupTag      = "tag"
+	GroupCampaign = "campaign"
+)
+
+// LinkGroup is a tag or campaign with the number of live links in it.
+type LinkGroup struct {
+	Name  string `json:"name"`
+	Links int64  `json:"links"`
+}
+
+// GroupStats summarises the clicks on every live link in a tag or
+// campaign, counted the same way as Stats. TopLinks lists the most clicked
+// links in the group, most clicked first.
+type GroupStats struct {
+	Group         string        `json:"group"`
+	Name          string        `json:"name"`
+	Links         int64         `json:"links"`
+	TotalClicks   int64         `json:"total_clicks"`
+	BotClicks     int64         `json:"bot_clicks"`
+	LastClickedAt *time.Time    `json:"last_clicked_at,omitempty"`
+	Daily         []DailyClicks `json:"daily"`
+	TopLinks      []LinkClicks  `json:"top_links"`
+}
+
+// LinkClicks is the click count of one link within GroupStats.
+type LinkClicks struct {
+	URLID  string `json:"url_id"`
+	Code   string `json:"code"`
+	Domain string `json:"domain,omitempty"`
+	Clicks int64  `json:"clicks"`
+}
+
+// GroupTopLinks is how many links GroupStats.TopLinks lists.
+const GroupTopLinks = 10
@@ -0,0 +1,20 @@
+package model
+
+// Identity is who a token from the OIDC identity provider says its bearer
+// is, with the roles the provider's groups grant them.
+type Identity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	// EmailVerified is set when the provider reports having verified
+	// Email; otherwise the email is not trusted.
+	EmailVerified bool
+	Name          string
+	Groups        []string
+	// Operator is set for members of groups mapped to the operator role,
+	// who may act in every workspace and use the admin dashboard.
+	Operator bool
+	// Roles maps workspace IDs to the role groups grant in each. They add
+	// to, and never lower, the roles of the user's memberships.
+	Roles map[string]string
+}
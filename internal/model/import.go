@@ -0,0 +1,33 @@
+package model
+
+// Import formats accepted by POST /import, and export formats served by
+// GET /export.
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatYOURLS = "yourls"
+	FormatBitly  = "bitly"
+)
+
+// MaxImportProblems caps how many conflicts and errors an ImportReport
+// lists individually; the counts always cover every record.
+const MaxImportProblems = 1000
+
+// ImportReport summarises an import. Conflicts are records whose code was
+// already taken on their domain; Errors are records that could not be
+// parsed or failed validation.
+type ImportReport struct {
+	Imported   int             `json:"imported"`
+	Conflicted int             `json:"conflicted"`
+	Failed     int             `json:"failed"`
+	Conflicts  []ImportProblem `json:"conflicts"`
+	Errors     []ImportProblem `json:"errors"`
+}
+
+// ImportProblem points at one input record by its 1-based line (or CSV
+// row) number.
+type ImportProblem struct {
+	Line  int    `json:"line"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error"`
+}
@@ -2,10 +2,227 @@ package model
 
 import "time"
 
+// Query precedence values decide which side wins when an incoming query
+// parameter collides with one already present on the destination URL.
+const (
+	QueryPrecedenceIncoming    = "incoming"
+	QueryPrecedenceDestination = "destination"
+)
+
+// Bot policies decide what clients recognised as bots are served when they
+// follow a link: the redirect, like everyone else, or a preview page
+// describing the link without sending them on.
+const (
+	BotPolicyRedirect = "redirect"
+	BotPolicyPreview  = "preview"
+)
+
 type URL struct {
-	ID          string    `json:"id" db:"id"`
-	Code        string    `json:"code" db:"code"`
-	OriginalURL string    `json:"original_url" db:"original_url"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID              string            `json:"id" db:"id"`
+	Code            string            `json:"code" db:"code"`
+	DomainID        *string           `json:"-" db:"domain_id"`
+	Domain          string            `json:"domain,omitempty" db:"-"`
+	OriginalURL     string            `json:"original_url" db:"original_url"`
+	ForwardQuery    bool              `json:"forward_query" db:"forward_query"`
+	QueryPrecedence string            `json:"query_precedence" db:"query_precedence"`
+	UTMParams       map[string]string `json:"utm_params,omitempty" db:"utm_params"`
+	BotPolicy       string            `json:"bot_policy" db:"bot_policy"`
+	Campaign        string            `json:"campaign,omitempty" db:"-"`
+	Tags            []string          `json:"tags,omitempty" db:"-"`
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at" db:"updated_at"`
+	// DisabledAt is set while the destination is on a threat list;
+	// DisabledReason names the list.
+	DisabledAt     *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	DisabledReason string     `json:"disabled_reason,omitempty" db:"disabled_reason"`
+	// Health is the outcome of the last destination check, nil until the
+	// link has been checked.
+	Health *LinkHealth `json:"health,omitempty" db:"-"`
+	// Preview is nil unless the link carries custom preview metadata.
+	Preview *LinkPreview `json:"preview,omitempty" db:"-"`
+	// Title, Description and Metadata are notes for the people managing
+	// the link, such as why it exists and who asked for it; visitors never
+	// see them.
+	Title       string         `json:"title,omitempty" db:"title"`
+	Description string         `json:"description,omitempty" db:"description"`
+	Metadata    map[string]any `json:"metadata,omitempty" db:"metadata"`
+	// MaxClicks limits how many redirects the link serves, 0 meaning no
+	// limit; ClicksUsed counts the redirects served while it had one.
+	MaxClicks  int64 `json:"max_clicks,omitempty" db:"max_clicks"`
+	ClicksUsed int64 `json:"clicks_used,omitempty" db:"clicks_used"`
+	// WorkspaceID is the workspace the link belongs to, nil for links
+	// outside any workspace.
+	WorkspaceID *string `json:"workspace_id,omitempty" db:"workspace_id"`
+}
+
+// Exhausted reports whether u has served all the redirects it is allowed.
+func (u *URL) Exhausted() bool {
+	return u.MaxClicks > 0 && u.ClicksUsed >= u.MaxClicks
+}
+
+// LinkPreview is shown to link unfurlers, and to every bot under
+// BotPolicyPreview, in place of the redirect. Empty fields fall back to
+// describing the destination.
+type LinkPreview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+// Caps on LinkPreview fields, in characters.
+const (
+	MaxPreviewTitle       = 200
+	MaxPreviewDescription = 500
+	MaxPreviewImageURL    = 2048
+)
+
+// Limits on how links are grouped. Tags are lower-cased; campaign names
+// keep their case.
+const (
+	MaxTags           = 20
+	MaxTagLength      = 64
+	MaxCampaignLength = 100
+)
+
+// Caps on a link's notes. Title and description are counted in characters,
+// metadata by the size of its JSON encoding.
+const (
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 2000
+	MaxMetadataKeys      = 50
+	MaxMetadataKeyLength = 64
+	MaxMetadataBytes     = 8192
+)
+
+// LinkHealth records one check of a link's destination. StatusCode is 0
+// when no response was received, in which case Error says why.
+type LinkHealth struct {
+	StatusCode int       `json:"status_code"`
+	LatencyMS  int       `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+	Broken     bool      `json:"broken"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Link statuses List can filter by. Deleted links appear only under
+// LinkStatusDeleted.
+const (
+	LinkStatusAll     = ""
+	LinkStatusBroken  = "broken"
+	LinkStatusDeleted = "deleted"
+)
+
+// ShortenRequest carries everything a caller can configure when creating a link.
+type ShortenRequest struct {
+	URL             string            `json:"url" binding:"required"`
+	ForwardQuery    bool              `json:"forward_query"`
+	QueryPrecedence string            `json:"query_precedence"`
+	UTMParams       map[string]string `json:"utm_params"`
+	BotPolicy       string            `json:"bot_policy"`
+	Campaign        string            `json:"campaign"`
+	Tags            []string          `json:"tags"`
+	Title           string            `json:"title"`
+	Description     string            `json:"description"`
+	Metadata        map[string]any    `json:"metadata"`
+	MaxClicks       int64             `json:"max_clicks"`
+	Preview         *LinkPreview      `json:"preview"`
+	// FetchPreview fills the preview fields left empty from the
+	// destination's own title, description and image.
+	FetchPreview bool   `json:"fetch_preview"`
+	Domain       string `json:"domain"`
+	// Workspace is the ID of the workspace to create the link in, which
+	// the caller must be an editor of; "" creates it outside any.
+	Workspace string `json:"workspace"`
+	// CodeStrategy names the codegen strategy that makes the link's code;
+	// "" uses the deployment's default.
+	CodeStrategy string `json:"code_strategy"`
+	// APIKey is taken from the X-API-Key header, never from the body.
+	APIKey string `json:"-"`
+}
+
+// UpdateRequest changes a link in place. Nil fields are left unchanged.
+type UpdateRequest struct {
+	URL             *string            `json:"url"`
+	ForwardQuery    *bool              `json:"forward_query"`
+	QueryPrecedence *string            `json:"query_precedence"`
+	UTMParams       *map[string]string `json:"utm_params"`
+	BotPolicy       *string            `json:"bot_policy"`
+	// Campaign moves the link to another campaign; "" takes it out of its
+	// campaign.
+	Campaign *string `json:"campaign"`
+	// Tags replaces all of the link's tags.
+	Tags *[]string `json:"tags"`
+	// Title and Description replace the link's notes; "" clears them.
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	// Metadata replaces the whole map; an empty one removes it.
+	Metadata *map[string]any `json:"metadata"`
+	// MaxClicks replaces the click limit; 0 removes it. Redirects already
+	// counted against an earlier limit count against the new one.
+	MaxClicks *int64 `json:"max_clicks"`
+	// Preview replaces the whole preview; an empty one removes it.
+	Preview *LinkPreview `json:"preview"`
+}
+
+// Cursor is a position in the newest-first listing of links.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ListQuery is a caller's request for a page of links. Cursor is the
+// NextCursor of the previous page, or "" for the first; Status is one of
+// the LinkStatus values; Search matches a substring of the code,
+// destination, title, description or metadata. Metadata narrows the page
+// to links whose metadata has every key set to the given string. Tags and
+// Campaign, when set, narrow the page to links carrying every one of the
+// tags and belonging to the campaign. Workspace lists the links of that
+// workspace instead of those outside any.
+type ListQuery struct {
+	Limit     int
+	Cursor    string
+	Status    string
+	Search    string
+	Tags      []string
+	Campaign  string
+	Metadata  map[string]string
+	Workspace string
+}
+
+// ListOptions selects a page of links. After is nil for the first page.
+type ListOptions struct {
+	Limit int
+	After *Cursor
+	// Broken restricts the page to links whose last check failed.
+	Broken bool
+	// Deleted lists soft-deleted links instead of live ones.
+	Deleted bool
+	// Search, when set, matches a case-insensitive substring of the code,
+	// destination, title, description or metadata.
+	Search string
+	// Tags restricts the page to links carrying all of these tags.
+	Tags []string
+	// Campaign restricts the page to the links in this campaign.
+	Campaign string
+	// Metadata restricts the page to links whose metadata has each of
+	// these keys set to the given string.
+	Metadata map[string]string
+	// Workspace restricts the page to the links of this workspace, or to
+	// links outside any workspace when empty, unless AllWorkspaces is set.
+	Workspace     string
+	AllWorkspaces bool
+}
+
+// URLPage is one page of links; NextCursor is empty on the last page.
+type URLPage struct {
+	URLs       []URL  `json:"urls"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CodeCollision lists codes on one domain, the default host when Domain is
+// "", that differ only in case and so cannot coexist once codes are looked
+// up regardless of case.
+type CodeCollision struct {
+	Domain string   `json:"domain"`
+	Codes  []string `json:"codes"`
 }
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook events. Each is written to the outbox in the same transaction as
+// the change it describes. EventLinkClickLimitReached is sent once, with
+// the redirect that uses up a link's max_clicks.
+const (
+	EventLinkCreated           = "link.created"
+	EventLinkUpdated           = "link.updated"
+	EventLinkDeleted           = "link.deleted"
+	EventLinkRestored          = "link.restored"
+	EventLinkClickLimitReached = "link.click_limit_reached"
+)
+
+// Delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a registered endpoint. An empty Events list subscribes to every
+// event. Secret is only returned when the webhook is created.
+type Webhook struct {
+	ID     string   `json:"id" db:"id"`
+	URL    string   `json:"url" db:"url"`
+	Secret string   `json:"secret,omitempty" db:"secret"`
+	Events []string `json:"events" db:"events"`
+	// OwnerUserID is the user who registered the webhook. It receives the
+	// events of links in WorkspaceID while the owner is a member, or of
+	// links outside any workspace when WorkspaceID is nil.
+	OwnerUserID string    `json:"-" db:"owner_user_id"`
+	WorkspaceID *string   `json:"workspace_id,omitempty" db:"workspace_id"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery is one event queued for one webhook, along with the
+// outcome of its most recent attempt.
+type WebhookDelivery struct {
+	ID            int64           `json:"id" db:"id"`
+	WebhookID     string          `json:"webhook_id" db:"webhook_id"`
+	Event         string          `json:"event" db:"event"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	Status        string          `json:"status" db:"status"`
+	ResponseCode  int             `json:"response_code,omitempty" db:"response_code"`
+	Error         string          `json:"error,omitempty" db:"error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+
+	// Target and Secret are joined from the webhook for the delivery worker.
+	Target string `json:"-" db:"url"`
+	Secret string `json:"-" db:"secret"`
+}
@@ -0,0 +1,109 @@
+package model
+
+import "time"
+
+// Roles a user can hold in a workspace, from least to most trusted.
+// Viewers read links and their stats, editors also create, change and
+// delete them, and admins also manage members and invites.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// RoleRank orders roles so that a higher rank may do everything a lower
+// one may. Unknown roles rank 0, below every real role.
+func RoleRank(role string) int {
+	switch role {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// InviteTTL is how long an invite can be accepted.
+const InviteTTL = 7 * 24 * time.Hour
+
+// Caps on account fields, in characters.
+const (
+	MaxUserNameLength      = 100
+	MaxWorkspaceNameLength = 100
+	MaxEmailLength         = 254
+)
+
+// User is a person calling the API with their own key. APIKey is only
+// returned when the user signs up.
+type User struct {
+	ID    string `json:"id" db:"id"`
+	Email string `json:"email" db:"email"`
+	Name  string `json:"name,omitempty" db:"name"`
+	// EmailVerified is set for users the identity provider reports as
+	// owning Email. Only their emails are unique, and only they can accept
+	// invites sent to an address.
+	EmailVerified bool      `json:"email_verified" db:"email_verified"`
+	APIKey        string    `json:"api_key,omitempty" db:"-"`
+	APIKeyHash    string    `json:"-" db:"api_key_hash"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Workspace is a team sharing links. Role is the caller's role in it when
+// workspaces are listed for a user.
+type Workspace struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Role      string    `json:"role,omitempty" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Member is a user's membership of a workspace.
+type Member struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	Name      string    `json:"name,omitempty" db:"name"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Invite lets whoever holds Token join a workspace with Role. An invite
+// with an Email can only be accepted by the user with that email. Token
+// is only returned when the invite is created.
+type Invite struct {
+	ID          string     `json:"id" db:"id"`
+	WorkspaceID string     `json:"workspace_id" db:"workspace_id"`
+	Email       string     `json:"email,omitempty" db:"email"`
+	Role        string     `json:"role" db:"role"`
+	Token       string     `json:"token,omitempty" db:"-"`
+	TokenHash   string     `json:"-" db:"token_hash"`
+	InvitedBy   string     `json:"invited_by,omitempty" db:"invited_by"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SignUpRequest creates a user.
+type SignUpRequest struct {
+	Email string `json:"email" binding:"required"`
+	Name  string `json:"name"`
+}
+
+// WorkspaceRequest creates a workspace.
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// InviteRequest invites someone to a workspace. Email is optional; Role
+// defaults to RoleViewer.
+type InviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// RoleRequest changes a member's role.
+type RoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
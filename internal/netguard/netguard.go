@@ -0,0 +1,72 @@
+// Package netguard keeps the requests the server makes on its users'
+// behalf, to link destinations and webhook targets, off the internal
+// network.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for connections refused by Transport.
+var ErrPrivateAddress = errors.New("address is private")
+
+// Resolver looks up the addresses of a host name.
+// net.DefaultResolver.LookupIPAddr is one.
+type Resolver func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+// Private reports whether ip is a loopback, private, link-local or
+// unspecified address.
+func Private(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+// PrivateHost reports whether host is, or resolves through lookup to, a
+// private address. Names that do not resolve are reported public: it is
+// meant for rejecting URLs early, and Transport checks the address of
+// every connection again, which also covers names repointed later.
+func PrivateHost(ctx context.Context, lookup Resolver, host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return Private(ip)
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if Private(addr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Transport returns an HTTP transport that connects without a proxy and
+// refuses private addresses unless allowPrivate, asked on every dial,
+// reports true. The check runs after DNS resolution, so it also catches
+// public names pointing at private addresses.
+func Transport(allowPrivate func() bool) *http.Transport {
+	control := func(_, address string, _ syscall.RawConn) error {
+		if allowPrivate() {
+			return nil
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip == nil || Private(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
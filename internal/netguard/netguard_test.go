@@ -0,0 +1,70 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrivate(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":       true,
+		"::1":             true,
+		"10.1.2.3":        true,
+		"192.168.1.10":    true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"fe80::1":         true,
+		"203.0.113.7":     false,
+		"2001:db8::1":     false,
+	}
+	for addr, want := range tests {
+		if got := Private(net.ParseIP(addr)); got != want {
+			t.Errorf("Private(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestPrivateHost(t *testing.T) {
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		case "public.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	tests := map[string]bool{
+		"127.0.0.1":            true,
+		"internal.example.com": true,
+		"public.example.com":   false,
+		"unknown.example.com":  false,
+	}
+	for host, want := range tests {
+		if got := PrivateHost(context.Background(), lookup, host); got != want {
+			t.Errorf("PrivateHost(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	allow := false
+	client := &http.Client{Transport: Transport(func() bool { return allow })}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("expected a loopback server to be refused, got %v", err)
+	}
+
+	allow = true
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected private addresses to be allowed, got %v", err)
+	}
+	_ = resp.Body.Close()
+}
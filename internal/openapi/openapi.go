@@ -0,0 +1,107 @@
+// Package openapi embeds the API specification, serves it and validates
+// incoming requests against it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+// Load parses and validates the embedded specification.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(Spec)
+	if err != nil {
+		return nil, fmt.Errorf("loading spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validating spec: %w", err)
+	}
+	return doc, nil
+}
+
+// Handler serves the specification as JSON.
+func Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", Spec)
+}
+
+// Validator rejects requests whose parameters or body do not match doc,
+// attaching an apperr.ErrInvalid for middleware.Errors to render.
+// Requests for paths the spec does not describe, such as static assets,
+// pass through unchecked. Authentication is left to the handlers.
+func Validator(doc *openapi3.T) (gin.HandlerFunc, error) {
+	// Match on path alone so the spec's server list doesn't pin the host.
+	doc.Servers = nil
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("building router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	// Operations marked x-streamed-body take uploads too large to buffer
+	// for validation; only their parameters are checked.
+	streamed := *options
+	streamed.ExcludeRequestBody = true
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+				c.Next()
+				return
+			}
+			_ = c.Error(apperr.Invalid("%v", err))
+			c.Abort()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if v, _ := route.Operation.Extensions["x-streamed-body"].(bool); v {
+			input.Options = &streamed
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			_ = c.Error(apperr.Invalid("%s", validationMessage(err)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// validationMessage trims kin-openapi's error down to what a client needs
+// to fix the request, without echoing the schema back.
+func validationMessage(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		var schemaErr *openapi3.SchemaError
+		if errors.As(reqErr.Err, &schemaErr) {
+			if field := schemaErr.JSONPointer(); len(field) > 0 {
+				return fmt.Sprintf("invalid request: %s: %s", strings.Join(field, "."), schemaErr.Reason)
+			}
+			return "invalid request: " + schemaErr.Reason
+		}
+		return "invalid request: " + reqErr.Error()
+	}
+	return "invalid request"
+}
@@ -0,0 +1,170 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/middleware"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setupRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	validator, err := Validator(doc)
+	if err != nil {
+		t.Fatalf("failed to build validator: %v", err)
+	}
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.Use(validator)
+	router.GET("/openapi.json", Handler)
+	router.POST("/shorten", ok)
+	router.GET("/:code", ok)
+	router.POST("/webhooks", ok)
+	router.POST("/import", ok)
+	router.GET("/static/*filepath", ok)
+	return router
+}
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("expected spec to load, got %v", err)
+	}
+	for _, path := range []string{"/shorten", "/{code}", "/url/{id}", "/health", "/ready"} {
+		if doc.Paths.Find(path) == nil {
+			t.Errorf("expected spec to describe %s", path)
+		}
+	}
+}
+
+func TestHandler_ServesSpec(t *testing.T) {
+	router := setupRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("expected openapi 3.0.3, got %v", doc["openapi"])
+	}
+}
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"valid shorten", http.MethodPost, "/shorten", `{"url": "https://example.com"}`, http.StatusOK},
+		{"missing url", http.MethodPost, "/shorten", `{}`, http.StatusBadRequest},
+		{"wrong type", http.MethodPost, "/shorten", `{"url": "https://example.com", "forward_query": "yes"}`, http.StatusBadRequest},
+		{"bad enum", http.MethodPost, "/shorten", `{"url": "https://example.com", "query_precedence": "sideways"}`, http.StatusBadRequest},
+		{"bad webhook event", http.MethodPost, "/webhooks", `{"url": "https://hooks.example.com", "events": ["nope"]}`, http.StatusBadRequest},
+		{"redirect", http.MethodGet, "/abc1234", "", http.StatusOK},
+		{"undocumented path", http.MethodGet, "/static/style.css", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupRouter(t)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest && w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("expected problem+json, got %s", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestValidator_PreservesBody(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	validator, err := Validator(doc)
+	if err != nil {
+		t.Fatalf("failed to build validator: %v", err)
+	}
+
+	var got struct {
+		URL string `json:"url"`
+	}
+	router := gin.New()
+	router.Use(validator)
+	router.POST("/shorten", func(c *gin.Context) {
+		if err := c.ShouldBindJSON(&got); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(`{"url": "https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || got.URL != "https://example.com" {
+		t.Errorf("expected handler to read the validated body, got %d %q", w.Code, got.URL)
+	}
+}
+
+func TestValidator_StreamedBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"ndjson upload", "/import?format=ndjson", http.StatusOK},
+		{"bad format", "/import?format=xml", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupRouter(t)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{"code": "abc", "original_url": "https://example.com"}`))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
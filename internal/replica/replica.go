@@ -0,0 +1,244 @@
+// Package replica routes reads to PostgreSQL read replicas that are healthy
+// and caught up, falling back to the primary when none is.
+package replica
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// DB is the part of a connection pool reads need; *pgxpool.Pool satisfies
+// it.
+type DB interface {
+	Ping(ctx context.Context) error
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Pool roles.
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// Pool states.
+const (
+	// StateOK pools serve reads.
+	StateOK = "ok"
+	// StateLagging replicas are reachable but further behind the primary
+	// than MaxLag, so reads skip them.
+	StateLagging = "lagging"
+	// StateDisconnected replicas are reachable but not receiving WAL from
+	// the primary, so how far behind they are cannot be told and reads
+	// skip them.
+	StateDisconnected = "disconnected"
+	// StateUnavailable pools could not be reached.
+	StateUnavailable = "unavailable"
+	// StateUnknown replicas have not been checked yet and serve no reads.
+	StateUnknown = "unknown"
+)
+
+// lagQuery measures how far behind the primary a replica is: the time
+// since the last transaction it replayed, or zero when it has replayed
+// everything it received, so an idle primary does not look like lag. That
+// only holds while WAL keeps arriving, so it also reports whether a WAL
+// receiver is streaming from the primary; a replica cut off from it has
+// replayed all it received however far behind it is. The receiver's status
+// is only visible to roles with pg_read_all_stats; without it a running
+// receiver counts as streaming. A server that is not in recovery is never
+// behind.
+const lagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END::float8, NOT pg_is_in_recovery() OR EXISTS (
+	SELECT 1 FROM pg_stat_wal_receiver WHERE COALESCE(status, 'streaming') = 'streaming'
+)`
+
+// PoolStatus is the state of one pool as last checked.
+type PoolStatus struct {
+	Name      string
+	Role      string
+	State     string
+	Lag       time.Duration
+	Error     string
+	CheckedAt time.Time
+}
+
+type member struct {
+	name   string
+	db     DB
+	mu     sync.RWMutex
+	status PoolStatus
+}
+
+func (m *member) get() PoolStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+func (m *member) set(st PoolStatus) (previous string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous = m.status.State
+	m.status = st
+	return previous
+}
+
+// Set is a primary and its replicas. Read spreads reads over the replicas
+// that passed their last check; a replica passes when it answers within
+// Timeout, is streaming from the primary and is no more than MaxLag behind. Replicas are checked every
+// Interval by Run and serve nothing until their first check.
+type Set struct {
+	primary  DB
+	replicas []*member
+	next     atomic.Uint64
+	logger   zerolog.Logger
+
+	Interval time.Duration
+	MaxLag   time.Duration
+	Timeout  time.Duration
+}
+
+func NewSet(primary DB, logger zerolog.Logger) *Set {
+	return &Set{
+		primary:  primary,
+		logger:   logger,
+		Interval: 5 * time.Second,
+		MaxLag:   5 * time.Second,
+		Timeout:  2 * time.Second,
+	}
+}
+
+// Add adds a replica under name, which identifies it in logs and
+// readiness reports. Replicas must be added before Run.
+func (s *Set) Add(name string, db DB) {
+	s.replicas = append(s.replicas, &member{
+		name:   name,
+		db:     db,
+		status: PoolStatus{Name: name, Role: RoleReplica, State: StateUnknown},
+	})
+}
+
+// Primary returns the primary.
+func (s *Set) Primary() DB {
+	return s.primary
+}
+
+// Ping pings the primary, without which nothing can be written.
+func (s *Set) Ping(ctx context.Context) error {
+	return s.primary.Ping(ctx)
+}
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so reads made with it go to the primary, for reads
+// that must see the latest writes, such as the read before an update.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether ctx was marked by WithPrimary.
+func UsesPrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// Read returns the pool a read made with ctx should use: the next healthy
+// replica in turn, or the primary when there is none or ctx was marked by
+// WithPrimary.
+func (s *Set) Read(ctx context.Context) DB {
+	if len(s.replicas) == 0 || UsesPrimary(ctx) {
+		return s.primary
+	}
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		m := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if m.get().State == StateOK {
+			return m.db
+		}
+	}
+	return s.primary
+}
+
+// Pools reports the state of every pool, the primary first. The primary is
+// pinged now; replicas are reported as of their last check.
+func (s *Set) Pools(ctx context.Context) []PoolStatus {
+	primary := PoolStatus{Name: RolePrimary, Role: RolePrimary, State: StateOK, CheckedAt: time.Now().UTC()}
+	if err := s.primary.Ping(ctx); err != nil {
+		primary.State, primary.Error = StateUnavailable, err.Error()
+	}
+	pools := []PoolStatus{primary}
+	for _, m := range s.replicas {
+		pools = append(pools, m.get())
+	}
+	return pools
+}
+
+// Run checks the replicas every Interval until ctx is cancelled.
+func (s *Set) Run(ctx context.Context) {
+	if len(s.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.CheckOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckOnce checks every replica and logs those whose state changed.
+func (s *Set) CheckOnce(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range s.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			st := s.check(ctx, m)
+			if previous := m.set(st); previous != st.State {
+				event := s.logger.Info()
+				if st.State != StateOK {
+					event = s.logger.Warn()
+				}
+				event.Str("replica", m.name).Str("state", st.State).Str("previous", previous).
+					Dur("lag", st.Lag).Str("error", st.Error).Msg("Replica state changed")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Set) check(ctx context.Context, m *member) PoolStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	st := PoolStatus{Name: m.name, Role: RoleReplica, CheckedAt: time.Now().UTC()}
+	var seconds float64
+	var streaming bool
+	if err := m.db.QueryRow(ctx, lagQuery).Scan(&seconds, &streaming); err != nil {
+		st.State, st.Error = StateUnavailable, err.Error()
+		return st
+	}
+	if !streaming {
+		st.State, st.Error = StateDisconnected, "not receiving WAL from the primary"
+		return st
+	}
+	st.Lag = time.Duration(seconds * float64(time.Second))
+	st.State = StateOK
+	if st.Lag > s.MaxLag {
+		st.State = StateLagging
+	}
+	return st
+}
@@ -0,0 +1,156 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// fakeDB answers the lag query with lag, or fails with err. A
+// disconnected fakeDB has no WAL receiver.
+type fakeDB struct {
+	lag          time.Duration
+	disconnected bool
+	err          error
+}
+
+func (f *fakeDB) Ping(context.Context) error { return f.err }
+
+func (f *fakeDB) Query(context.Context, string, ...any) (pgx.Rows, error) { return nil, f.err }
+
+func (f *fakeDB) QueryRow(context.Context, string, ...any) pgx.Row { return fakeRow{f} }
+
+type fakeRow struct{ db *fakeDB }
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.db.err != nil {
+		return r.db.err
+	}
+	*dest[0].(*float64) = r.db.lag.Seconds()
+	*dest[1].(*bool) = !r.db.disconnected
+	return nil
+}
+
+func TestRead_NoReplicasUsesPrimary(t *testing.T) {
+	primary := &fakeDB{}
+	s := NewSet(primary, zerolog.Nop())
+
+	if got := s.Read(context.Background()); got != primary {
+		t.Errorf("expected the primary, got %v", got)
+	}
+}
+
+func TestRead_UncheckedReplicasServeNothing(t *testing.T) {
+	primary := &fakeDB{}
+	s := NewSet(primary, zerolog.Nop())
+	s.Add("replica-1", &fakeDB{})
+
+	if got := s.Read(context.Background()); got != primary {
+		t.Errorf("expected the primary before the first check, got %v", got)
+	}
+}
+
+func TestRead_SkipsUnhealthyReplicas(t *testing.T) {
+	primary := &fakeDB{}
+	healthy := &fakeDB{lag: time.Second}
+	s := NewSet(primary, zerolog.Nop())
+	s.Add("down", &fakeDB{err: errors.New("connection refused")})
+	s.Add("behind", &fakeDB{lag: time.Minute})
+	s.Add("healthy", healthy)
+	s.CheckOnce(context.Background())
+
+	for i := 0; i < 6; i++ {
+		if got := s.Read(context.Background()); got != healthy {
+			t.Fatalf("read %d: expected the healthy replica, got %v", i, got)
+		}
+	}
+	if got := s.Read(WithPrimary(context.Background())); got != primary {
+		t.Errorf("expected the primary for a context marked WithPrimary, got %v", got)
+	}
+}
+
+func TestRead_SkipsDisconnectedReplicas(t *testing.T) {
+	primary := &fakeDB{}
+	s := NewSet(primary, zerolog.Nop())
+	// Having replayed all it received, a replica cut off from the primary
+	// reports no lag however stale it is.
+	s.Add("cut off", &fakeDB{disconnected: true})
+	s.CheckOnce(context.Background())
+
+	if got := s.Read(context.Background()); got != primary {
+		t.Errorf("expected the primary while the replica is disconnected, got %v", got)
+	}
+	if st := s.Pools(context.Background())[1]; st.State != StateDisconnected || st.Error == "" {
+		t.Errorf("expected the replica reported disconnected, got %+v", st)
+	}
+}
+
+func TestRead_SpreadsOverReplicas(t *testing.T) {
+	a, b := &fakeDB{}, &fakeDB{}
+	s := NewSet(&fakeDB{}, zerolog.Nop())
+	s.Add("a", a)
+	s.Add("b", b)
+	s.CheckOnce(context.Background())
+
+	seen := map[DB]int{}
+	for i := 0; i < 4; i++ {
+		seen[s.Read(context.Background())]++
+	}
+	if seen[a] != 2 || seen[b] != 2 {
+		t.Errorf("expected reads spread evenly, got a=%d b=%d", seen[a], seen[b])
+	}
+}
+
+func TestRead_FallsBackWhenAllReplicasUnhealthy(t *testing.T) {
+	primary := &fakeDB{}
+	replica := &fakeDB{}
+	s := NewSet(primary, zerolog.Nop())
+	s.Add("replica-1", replica)
+	s.CheckOnce(context.Background())
+	if got := s.Read(context.Background()); got != replica {
+		t.Fatalf("expected the replica, got %v", got)
+	}
+
+	replica.err = errors.New("connection refused")
+	s.CheckOnce(context.Background())
+	if got := s.Read(context.Background()); got != primary {
+		t.Errorf("expected the primary once the replica failed, got %v", got)
+	}
+}
+
+func TestPools(t *testing.T) {
+	s := NewSet(&fakeDB{err: errors.New("connection refused")}, zerolog.Nop())
+	s.MaxLag = 10 * time.Second
+	s.Add("ok", &fakeDB{lag: 2 * time.Second})
+	s.Add("behind", &fakeDB{lag: time.Minute})
+	s.Add("cut off", &fakeDB{disconnected: true})
+	s.CheckOnce(context.Background())
+	s.Add("unchecked", &fakeDB{})
+
+	pools := s.Pools(context.Background())
+	want := []struct {
+		name, role, state string
+	}{
+		{"primary", RolePrimary, StateUnavailable},
+		{"ok", RoleReplica, StateOK},
+		{"behind", RoleReplica, StateLagging},
+		{"cut off", RoleReplica, StateDisconnected},
+		{"unchecked", RoleReplica, StateUnknown},
+	}
+	if len(pools) != len(want) {
+		t.Fatalf("expected %d pools, got %d", len(want), len(pools))
+	}
+	for i, w := range want {
+		p := pools[i]
+		if p.Name != w.name || p.Role != w.role || p.State != w.state {
+			t.Errorf("pool %d: expected %s %s %s, got %s %s %s", i, w.name, w.role, w.state, p.Name, p.Role, p.State)
+		}
+	}
+	if pools[1].Lag != 2*time.Second {
+		t.Errorf("expected lag 2s, got %v", pools[1].Lag)
+	}
+}
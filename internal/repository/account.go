@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// AccountRepository stores users, the workspaces they share and the
+// invites into them. Methods return errors wrapping the apperr kinds: a
+// missing row is apperr.ErrNotFound and an email another user verified
+// apperr.ErrConflict.
+type AccountRepository interface {
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByKeyHash(ctx context.Context, keyHash string) (*model.User, error)
+	// ExternalUser returns the user the identity provider knows as id's
+	// issuer and subject, creating them on first sign-in. Users are never
+	// linked by email. A verified email marks the user's email verified
+	// unless another user verified it first, which is apperr.ErrConflict
+	// for a new user.
+	ExternalUser(ctx context.Context, id *model.Identity) (*model.User, error)
+	// CreateWorkspace creates workspace with ownerID as its first admin.
+	CreateWorkspace(ctx context.Context, workspace *model.Workspace, ownerID string) error
+	// GetWorkspace returns the workspace with the given ID with Role set to
+	// userID's role in it. Workspaces userID is not a member of are not
+	// found.
+	GetWorkspace(ctx context.Context, id, userID string) (*model.Workspace, error)
+	// ListWorkspaces returns the workspaces userID is a member of, by name,
+	// each with its Role set.
+	ListWorkspaces(ctx context.Context, userID string) ([]model.Workspace, error)
+	ListMembers(ctx context.Context, workspaceID string) ([]model.Member, error)
+	// SetRole changes a member's role and RemoveMember removes them. Both
+	// fail with apperr.ErrConflict rather than leave a workspace without an
+	// admin; changes to one workspace's members are serialised so two
+	// admins cannot demote each other at once.
+	SetRole(ctx context.Context, workspaceID, userID, role string) error
+	RemoveMember(ctx context.Context, workspaceID, userID string) error
+	CreateInvite(ctx context.Context, invite *model.Invite) error
+	GetInviteByTokenHash(ctx context.Context, tokenHash string) (*model.Invite, error)
+	// AcceptInvite marks the invite used by userID and adds them to its
+	// workspace with its role; a user who is already a member keeps their
+	// role. An invite that was already used is apperr.ErrConflict.
+	AcceptInvite(ctx context.Context, inviteID, userID string) error
+}
+
+type postgresAccountRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAccountRepository(pool *pgxpool.Pool) AccountRepository {
+	return &postgresAccountRepository{pool: pool}
+}
+
+// CreateUser stores a user who signed up for an API key. Their email is
+// unverified, so it does not keep anyone else from the address, but an
+// address another user verified is refused.
+func (r *postgresAccountRepository) CreateUser(ctx context.Context, user *model.User) error {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO users (email, name, api_key_hash)
+		 SELECT $1, $2, $3 WHERE NOT EXISTS (SELECT 1 FROM users WHERE email = $1 AND email_verified)
+		 RETURNING id, created_at`,
+		user.Email, user.Name, user.APIKeyHash,
+	).Scan(&user.ID, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperr.Conflict("email %q belongs to another user", user.Email)
+	}
+	return mapError(err, "user")
+}
+
+func (r *postgresAccountRepository) GetUserByKeyHash(ctx context.Context, keyHash string) (*model.User, error) {
+	var u model.User
+	err := r.pool.QueryRow(ctx,
+		"SELECT id, email, name, email_verified, api_key_hash, created_at FROM users WHERE api_key_hash = $1",
+		keyHash,
+	).Scan(&u.ID, &u.Email, &u.Name, &u.EmailVerified, &u.APIKeyHash, &u.CreatedAt)
+	if err != nil {
+		return nil, mapError(err, "user")
+	}
+	return &u, nil
+}
+
+func (r *postgresAccountRepository) ExternalUser(ctx context.Context, id *model.Identity) (*model.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, mapError(err, "user")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var u model.User
+	err = tx.QueryRow(ctx,
+		`SELECT id, email, name, email_verified, created_at FROM users
+		 WHERE oidc_issuer = $1 AND oidc_subject = $2`,
+		id.Issuer, id.Subject,
+	).Scan(&u.ID, &u.Email, &u.Name, &u.EmailVerified, &u.CreatedAt)
+	if err == nil {
+		if u.EmailVerified || !id.EmailVerified || u.Email != id.Email {
+			return &u, nil
+		}
+		// The provider has verified the email since the user was
+		// created. Should another user have verified it first, the
+		// update fails and the email stays unverified.
+		if _, err := tx.Exec(ctx, "UPDATE users SET email_verified = true WHERE id = $1", u.ID); err != nil {
+			return &u, nil
+		}
+		u.EmailVerified = true
+		return &u, mapError(tx.Commit(ctx), "user")
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, mapError(err, "user")
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO users (email, email_verified, name, oidc_issuer, oidc_subject) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (email) WHERE email_verified DO NOTHING
+		 RETURNING id, email, name, email_verified, created_at`,
+		id.Email, id.EmailVerified, id.Name, id.Issuer, id.Subject,
+	).Scan(&u.ID, &u.Email, &u.Name, &u.EmailVerified, &u.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Another identity has verified the email.
+		return nil, apperr.Conflict("email %q belongs to another user", id.Email)
+	}
+	if err != nil {
+		return nil, mapError(err, "user")
+	}
+	return &u, mapError(tx.Commit(ctx), "user")
+}
+
+func (r *postgresAccountRepository) CreateWorkspace(ctx context.Context, workspace *model.Workspace, ownerID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return mapError(err, "workspace")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = tx.QueryRow(ctx,
+		"INSERT INTO workspaces (name) VALUES ($1) RETURNING id, created_at",
+		workspace.Name,
+	).Scan(&workspace.ID, &workspace.CreatedAt)
+	if err != nil {
+		return mapError(err, "workspace")
+	}
+	_, err = tx.Exec(ctx,
+		"INSERT INTO memberships (workspace_id, user_id, role) VALUES ($1, $2, $3)",
+		workspace.ID, ownerID, model.RoleAdmin,
+	)
+	if err != nil {
+		return mapError(err, "workspace")
+	}
+	workspace.Role = model.RoleAdmin
+	return mapError(tx.Commit(ctx), "workspace")
+}
+
+func (r *postgresAccountRepository) GetWorkspace(ctx context.Context, id, userID string) (*model.Workspace, error) {
+	var w model.Workspace
+	err := r.pool.QueryRow(ctx,
+		`SELECT w.id, w.name, m.role, w.created_at
+		 FROM workspaces w JOIN memberships m ON m.workspace_id = w.id
+		 WHERE w.id = $1 AND m.user_id = $2`,
+		id, userID,
+	).Scan(&w.ID, &w.Name, &w.Role, &w.CreatedAt)
+	if err != nil {
+		return nil, mapError(err, "workspace")
+	}
+	return &w, nil
+}
+
+func (r *postgresAccountRepository) ListWorkspaces(ctx context.Context, userID string) ([]model.Workspace, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT w.id, w.name, m.role, w.created_at
+		 FROM workspaces w JOIN memberships m ON m.workspace_id = w.id
+		 WHERE m.user_id = $1 ORDER BY w.name, w.id`,
+		userID,
+	)
+	if err != nil {
+		return nil, mapError(err, "workspace")
+	}
+	defer rows.Close()
+
+	workspaces := []model.Workspace{}
+	for rows.Next() {
+		var w model.Workspace
+		if err := rows.Scan(&w.ID, &w.Name, &w.Role, &w.CreatedAt); err != nil {
+			return nil, mapError(err, "workspace")
+		}
+		workspaces = append(workspaces, w)
+	}
+	return workspaces, mapError(rows.Err(), "workspace")
+}
+
+func (r *postgresAccountRepository) ListMembers(ctx context.Context, workspaceID string) ([]model.Member, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.user_id, u.email, u.name, m.role, m.created_at
+		 FROM memberships m JOIN users u ON u.id = m.user_id
+		 WHERE m.workspace_id = $1 ORDER BY u.email`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, mapError(err, "member")
+	}
+	defer rows.Close()
+
+	members := []model.Member{}
+	for rows.Next() {
+		var m model.Member
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Name, &m.Role, &m.CreatedAt); err != nil {
+			return nil, mapError(err, "member")
+		}
+		members = append(members, m)
+	}
+	return members, mapError(rows.Err(), "member")
+}
+
+func (r *postgresAccountRepository) SetRole(ctx context.Context, workspaceID, userID, role string) error {
+	return r.changeMember(ctx, workspaceID, userID, role != model.RoleAdmin, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			"UPDATE memberships SET role = $3 WHERE workspace_id = $1 AND user_id = $2",
+			workspaceID, userID, role,
+		)
+		return err
+	})
+}
+
+func (r *postgresAccountRepository) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	return r.changeMember(ctx, workspaceID, userID, true, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			"DELETE FROM memberships WHERE workspace_id = $1 AND user_id = $2",
+			workspaceID, userID,
+		)
+		return err
+	})
+}
+
+// changeMember runs change on the membership of userID in workspaceID
+// with the workspace row locked. When demotes is set and the member is
+// the workspace's only admin, change is not run.
+func (r *postgresAccountRepository) changeMember(ctx context.Context, workspaceID, userID string, demotes bool, change func(pgx.Tx) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return mapError(err, "member")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, "SELECT 1 FROM workspaces WHERE id = $1 FOR UPDATE", workspaceID); err != nil {
+		return mapError(err, "workspace")
+	}
+	var role string
+	var admins int
+	err = tx.QueryRow(ctx,
+		`SELECT role, (SELECT COUNT(*) FROM memberships WHERE workspace_id = $1 AND role = 'admin')
+		 FROM memberships WHERE workspace_id = $1 AND user_id = $2`,
+		workspaceID, userID,
+	).Scan(&role, &admins)
+	if err != nil {
+		return mapError(err, "member")
+	}
+	if demotes && role == model.RoleAdmin && admins == 1 {
+		return apperr.Conflict("a workspace needs at least one admin")
+	}
+	if err := change(tx); err != nil {
+		return mapError(err, "member")
+	}
+	return mapError(tx.Commit(ctx), "member")
+}
+
+func (r *postgresAccountRepository) CreateInvite(ctx context.Context, invite *model.Invite) error {
+	var invitedBy *string
+	if invite.InvitedBy != "" {
+		invitedBy = &invite.InvitedBy
+	}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO invites (workspace_id, email, role, token_hash, invited_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		invite.WorkspaceID, invite.Email, invite.Role, invite.TokenHash, invitedBy, invite.ExpiresAt,
+	).Scan(&invite.ID, &invite.CreatedAt)
+	return mapError(err, "invite")
+}
+
+func (r *postgresAccountRepository) GetInviteByTokenHash(ctx context.Context, tokenHash string) (*model.Invite, error) {
+	var inv model.Invite
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, workspace_id, email, role, token_hash, COALESCE(invited_by::text, ''), expires_at, accepted_at, created_at
+		 FROM invites WHERE token_hash = $1`,
+		tokenHash,
+	).Scan(&inv.ID, &inv.WorkspaceID, &inv.Email, &inv.Role, &inv.TokenHash, &inv.InvitedBy,
+		&inv.ExpiresAt, &inv.AcceptedAt, &inv.CreatedAt)
+	if err != nil {
+		return nil, mapError(err, "invite")
+	}
+	return &inv, nil
+}
+
+func (r *postgresAccountRepository) AcceptInvite(ctx context.Context, inviteID, userID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return mapError(err, "invite")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var workspaceID, role string
+	err = tx.QueryRow(ctx,
+		`UPDATE invites SET accepted_at = NOW(), accepted_by = $2
+		 WHERE id = $1 AND accepted_at IS NULL RETURNING workspace_id, role`,
+		inviteID, userID,
+	).Scan(&workspaceID, &role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperr.Conflict("invite has already been used")
+	}
+	if err != nil {
+		return mapError(err, "invite")
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO memberships (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		 ON CONFLICT (workspace_id, user_id) DO NOTHING`,
+		workspaceID, userID, role,
+	)
+	if err != nil {
+		return mapError(err, "invite")
+	}
+	return mapError(tx.Commit(ctx), "invite")
+}
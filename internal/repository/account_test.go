@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func cleanupAccounts(t *testing.T) {
+	t.Helper()
+	cleanupURLs(t)
+	_, err := testPool.Exec(context.Background(), "DELETE FROM invites; DELETE FROM workspaces; DELETE FROM users")
+	if err != nil {
+		t.Fatalf("failed to clean account tables: %v", err)
+	}
+}
+
+func createUser(t *testing.T, repo AccountRepository, email string) *model.User {
+	t.Helper()
+	u := &model.User{Email: email, APIKeyHash: email + "-hash"}
+	if err := repo.CreateUser(context.Background(), u); err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	return u
+}
+
+func TestWorkspace_LastAdmin(t *testing.T) {
+	cleanupAccounts(t)
+	repo := NewPostgresAccountRepository(testPool)
+	ctx := context.Background()
+
+	ada := createUser(t, repo, "ada@example.com")
+	w := &model.Workspace{Name: "Team"}
+	if err := repo.CreateWorkspace(ctx, w, ada.ID); err != nil {
+		t.Fatalf("create workspace failed: %v", err)
+	}
+
+	got, err := repo.GetWorkspace(ctx, w.ID, ada.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Role != model.RoleAdmin {
+		t.Errorf("expected creator to be admin, got %q", got.Role)
+	}
+	if err := repo.SetRole(ctx, w.ID, ada.ID, model.RoleViewer); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected conflict demoting the last admin, got %v", err)
+	}
+	if err := repo.RemoveMember(ctx, w.ID, ada.ID); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected conflict removing the last admin, got %v", err)
+	}
+}
+
+func TestWorkspace_NonMemberNotFound(t *testing.T) {
+	cleanupAccounts(t)
+	repo := NewPostgresAccountRepository(testPool)
+	ctx := context.Background()
+
+	ada := createUser(t, repo, "ada@example.com")
+	grace := createUser(t, repo, "grace@example.com")
+	w := &model.Workspace{Name: "Team"}
+	if err := repo.CreateWorkspace(ctx, w, ada.ID); err != nil {
+		t.Fatalf("create workspace failed: %v", err)
+	}
+
+	if _, err := repo.GetWorkspace(ctx, w.ID, grace.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected not found for a non-member, got %v", err)
+	}
+}
+
+func TestInvite_SingleUse(t *testing.T) {
+	cleanupAccounts(t)
+	repo := NewPostgresAccountRepository(testPool)
+	ctx := context.Background()
+
+	ada := createUser(t, repo, "ada@example.com")
+	grace := createUser(t, repo, "grace@example.com")
+	w := &model.Workspace{Name: "Team"}
+	if err := repo.CreateWorkspace(ctx, w, ada.ID); err != nil {
+		t.Fatalf("create workspace failed: %v", err)
+	}
+	inv := &model.Invite{WorkspaceID: w.ID, Role: model.RoleEditor, TokenHash: "token-hash",
+		InvitedBy: ada.ID, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.CreateInvite(ctx, inv); err != nil {
+		t.Fatalf("create invite failed: %v", err)
+	}
+
+	if err := repo.AcceptInvite(ctx, inv.ID, grace.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, err := repo.GetWorkspace(ctx, w.ID, grace.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Role != model.RoleEditor {
+		t.Errorf("expected editor role, got %q", got.Role)
+	}
+	if err := repo.AcceptInvite(ctx, inv.ID, grace.ID); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected conflict reusing an invite, got %v", err)
+	}
+}
+
+func TestExternalUser(t *testing.T) {
+	cleanupAccounts(t)
+	repo := NewPostgresAccountRepository(testPool)
+	ctx := context.Background()
+
+	ada := createUser(t, repo, "ada@example.com")
+	id := &model.Identity{Issuer: "https://idp.example.com", Subject: "u-1", Email: "grace@example.com", EmailVerified: true, Name: "Grace"}
+
+	created, err := repo.ExternalUser(ctx, id)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.ID == "" || created.ID == ada.ID || created.Name != "Grace" || !created.EmailVerified {
+		t.Errorf("expected a new user, got %+v", created)
+	}
+	again, err := repo.ExternalUser(ctx, id)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("expected the same user on the next sign-in, got %s", again.ID)
+	}
+
+	other := &model.Identity{Issuer: "https://idp.example.com", Subject: "u-2", Email: "grace@example.com", EmailVerified: true}
+	if _, err := repo.ExternalUser(ctx, other); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected conflict for an email another identity verified, got %v", err)
+	}
+	if err := repo.CreateUser(ctx, &model.User{Email: "grace@example.com", APIKeyHash: "grace-hash"}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected conflict signing up with a verified email, got %v", err)
+	}
+	unverified := &model.Identity{Issuer: "https://idp.example.com", Subject: "u-4", Email: "grace@example.com"}
+	if u, err := repo.ExternalUser(ctx, unverified); err != nil || u.ID == created.ID || u.EmailVerified {
+		t.Errorf("expected a separate, unverified user for an unverified email, got %+v, %v", u, err)
+	}
+
+	// Signing up with an API key never proved ada owns the email: an
+	// identity verifying it gets a user of its own rather than ada's
+	// account, and is not kept from the address.
+	claim := &model.Identity{Issuer: "https://idp.example.com", Subject: "u-3", Email: "ada@example.com", EmailVerified: true}
+	owner, err := repo.ExternalUser(ctx, claim)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if owner.ID == ada.ID || !owner.EmailVerified {
+		t.Errorf("expected a new, verified user, got %+v", owner)
+	}
+}
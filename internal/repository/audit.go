@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/audit"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+const auditColumns = "id, actor, actor_email, action, url_id, workspace_id, before, after, client_ip, request_id, created_at"
+
+// AuditRepository reads the audit log. Entries are written by the
+// URLRepository, in the same transaction as the change they record.
+type AuditRepository interface {
+	// List returns up to opts.Limit entries matching opts, newest first,
+	// starting after opts.After.
+	List(ctx context.Context, opts model.AuditOptions) ([]model.AuditEntry, error)
+}
+
+type postgresAuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresAuditRepository(pool *pgxpool.Pool) AuditRepository {
+	return &postgresAuditRepository{pool: pool}
+}
+
+// insertAudit records action on a link as part of tx, attributed to the
+// actor and origin carried by ctx. before and after are the link's
+// snapshots on either side of the change; one of them may be nil.
+func insertAudit(ctx context.Context, tx pgx.Tx, action string, before, after *model.URL) error {
+	link := after
+	if link == nil {
+		link = before
+	}
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+	actor, origin := audit.ActorFrom(ctx), audit.OriginFrom(ctx)
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, actor_email, action, url_id, workspace_id, before, after, client_ip, request_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		actor.ID, actor.Email, action, link.ID, link.WorkspaceID, beforeJSON, afterJSON,
+		origin.ClientIP, origin.RequestID,
+	)
+	return err
+}
+
+func snapshot(url *model.URL) ([]byte, error) {
+	if url == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(url)
+	if err != nil {
+		return nil, fmt.Errorf("encoding audit snapshot: %w", err)
+	}
+	return b, nil
+}
+
+func (r *postgresAuditRepository) List(ctx context.Context, opts model.AuditOptions) ([]model.AuditEntry, error) {
+	query := "SELECT " + auditColumns + " FROM audit_log WHERE TRUE"
+	args := []any{opts.Limit}
+	switch {
+	case opts.AllWorkspaces:
+	case opts.Workspace == "":
+		query += " AND workspace_id IS NULL"
+	default:
+		args = append(args, opts.Workspace)
+		query += fmt.Sprintf(" AND workspace_id = $%d", len(args))
+	}
+	if opts.Actor != "" {
+		args = append(args, opts.Actor)
+		query += fmt.Sprintf(" AND actor = $%d", len(args))
+	}
+	if opts.LinkID != "" {
+		args = append(args, opts.LinkID)
+		query += fmt.Sprintf(" AND url_id = $%d", len(args))
+	}
+	if opts.Since != nil {
+		args = append(args, *opts.Since)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if opts.Until != nil {
+		args = append(args, *opts.Until)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	if opts.After != nil {
+		n := len(args)
+		query += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d::bigint)", n+1, n+2)
+		args = append(args, opts.After.CreatedAt, opts.After.ID)
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT $1"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err, "audit entry")
+	}
+	defer rows.Close()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var e model.AuditEntry
+		var before, after []byte
+		err := rows.Scan(&e.ID, &e.Actor, &e.ActorEmail, &e.Action, &e.LinkID, &e.WorkspaceID,
+			&before, &after, &e.ClientIP, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, mapError(err, "audit entry")
+		}
+		if e.Before, err = readSnapshot(before); err != nil {
+			return nil, err
+		}
+		if e.After, err = readSnapshot(after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, mapError(rows.Err(), "audit entry")
+}
+
+func readSnapshot(b []byte) (*model.URL, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var url model.URL
+	if err := json.Unmarshal(b, &url); err != nil {
+		return nil, fmt.Errorf("decoding audit snapshot: %w", err)
+	}
+	return &url, nil
+}
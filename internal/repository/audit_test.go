@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/audit"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func cleanupAudit(t *testing.T) {
+	t.Helper()
+	cleanupURLs(t)
+	// DELETE is refused on the append-only log; TRUNCATE is not.
+	if _, err := testPool.Exec(context.Background(), "TRUNCATE audit_log"); err != nil {
+		t.Fatalf("failed to clean audit log: %v", err)
+	}
+}
+
+func TestAudit_RecordsChanges(t *testing.T) {
+	cleanupAudit(t)
+	repo := NewPostgresURLRepository(testPool)
+	auditRepo := NewPostgresAuditRepository(testPool)
+	ctx := audit.WithActor(context.Background(), audit.Actor{ID: model.ActorOperator})
+	ctx = audit.WithOrigin(ctx, audit.Origin{ClientIP: "203.0.113.7", RequestID: "req-1"})
+
+	url := &model.URL{Code: "audit1", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	url.OriginalURL = "https://example.org"
+	if err := repo.Update(ctx, url); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := repo.Delete(ctx, url.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := repo.Restore(ctx, url.ID); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	entries, err := auditRepo.List(ctx, model.AuditOptions{Limit: 10, LinkID: url.ID, AllWorkspaces: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	wantActions := []string{model.AuditRestore, model.AuditDelete, model.AuditUpdate, model.AuditCreate}
+	for i, e := range entries {
+		if e.Action != wantActions[i] {
+			t.Errorf("entry %d: expected %s, got %s", i, wantActions[i], e.Action)
+		}
+		if e.Actor != model.ActorOperator || e.ClientIP != "203.0.113.7" || e.RequestID != "req-1" {
+			t.Errorf("entry %d: unexpected attribution %+v", i, e)
+		}
+	}
+	update := entries[2]
+	if update.Before == nil || update.Before.OriginalURL != "https://example.com" ||
+		update.After == nil || update.After.OriginalURL != "https://example.org" {
+		t.Errorf("expected before and after destinations, got %+v, %+v", update.Before, update.After)
+	}
+	if entries[1].After != nil || entries[3].Before != nil {
+		t.Error("expected no after snapshot for the delete and no before snapshot for the create")
+	}
+
+	if _, err := testPool.Exec(ctx, "DELETE FROM audit_log"); err == nil {
+		t.Error("expected deleting from the audit log to fail")
+	}
+}
+
+func TestAudit_ImportsAndDomains(t *testing.T) {
+	cleanupAudit(t)
+	cleanupDomains(t)
+	repo := NewPostgresURLRepository(testPool)
+	auditRepo := NewPostgresAuditRepository(testPool)
+	ctx := audit.WithActor(context.Background(), audit.Actor{ID: model.ActorOperator})
+
+	d := &model.Domain{Host: "go.example.com", OwnerKeyHash: "hash"}
+	if err := NewPostgresDomainRepository(testPool).Create(ctx, d); err != nil {
+		t.Fatalf("create domain failed: %v", err)
+	}
+	conflicts, err := repo.Import(ctx, []model.URL{
+		{Code: "imported", DomainID: &d.ID, OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming, Tags: []string{"mail"}},
+	})
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("import failed: %v, %v", conflicts, err)
+	}
+	url, err := repo.GetByCode(ctx, d.ID, "imported")
+	if err != nil {
+		t.Fatalf("expected the imported link, got %v", err)
+	}
+	if err := repo.Delete(ctx, url.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	entries, err := auditRepo.List(ctx, model.AuditOptions{Limit: 10, LinkID: url.ID, AllWorkspaces: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != model.AuditDelete || entries[1].Action != model.AuditCreate {
+		t.Fatalf("expected a delete after the import's create, got %+v", entries)
+	}
+	if created := entries[1].After; created == nil || created.Domain != "go.example.com" || len(created.Tags) != 1 {
+		t.Errorf("expected the imported link with its domain and tags, got %+v", created)
+	}
+	if deleted := entries[0].Before; deleted == nil || deleted.Domain != "go.example.com" {
+		t.Errorf("expected the deleted link's domain, got %+v", deleted)
+	}
+}
+
+func TestAudit_ListFilters(t *testing.T) {
+	cleanupAudit(t)
+	repo := NewPostgresURLRepository(testPool)
+	auditRepo := NewPostgresAuditRepository(testPool)
+
+	ada := audit.WithActor(context.Background(), audit.Actor{ID: "ada"})
+	for _, code := range []string{"f1", "f2"} {
+		url := &model.URL{Code: code, OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+		if err := repo.Create(ada, url); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+	url := &model.URL{Code: "f3", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	if err := repo.Create(context.Background(), url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	ctx := context.Background()
+	byAda, err := auditRepo.List(ctx, model.AuditOptions{Limit: 10, Actor: "ada"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(byAda) != 2 {
+		t.Errorf("expected 2 entries by ada, got %d", len(byAda))
+	}
+
+	first, err := auditRepo.List(ctx, model.AuditOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(first) != 1 || first[0].Actor != model.ActorAnonymous {
+		t.Fatalf("expected the anonymous create first, got %+v", first)
+	}
+	rest, err := auditRepo.List(ctx, model.AuditOptions{
+		Limit: 10,
+		After: &model.Cursor{CreatedAt: first[0].CreatedAt, ID: strconv.FormatInt(first[0].ID, 10)},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rest) != 2 || rest[0].ID == first[0].ID {
+		t.Errorf("expected the 2 entries after the cursor, got %+v", rest)
+	}
+
+	since := first[0].CreatedAt.Add(1)
+	none, err := auditRepo.List(ctx, model.AuditOptions{Limit: 10, Since: &since})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("expected no entries after the last one, got %d", len(none))
+	}
+}
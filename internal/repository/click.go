@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+type ClickRepository interface {
+	Record(ctx context.Context, click *model.Click) error
+	// Stats summarises the clicks on urlID, with a daily breakdown of the
+	// last days days. Bot clicks are only counted separately unless
+	// includeBots is set.
+	Stats(ctx context.Context, urlID string, days int, includeBots bool) (*model.Stats, error)
+	// GroupStats summarises the clicks on every live link in the tag or
+	// campaign, by model.Group kind, called name, counting them like
+	// Stats. Only links in the workspace with the given ID are counted, or
+	// links outside any workspace when it is "". A group without live
+	// links is not found.
+	GroupStats(ctx context.Context, kind, name, workspaceID string, days int, includeBots bool) (*model.GroupStats, error)
+}
+
+type postgresClickRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresClickRepository(pool *pgxpool.Pool) ClickRepository {
+	return &postgresClickRepository{pool: pool}
+}
+
+func (r *postgresClickRepository) Record(ctx context.Context, click *model.Click) error {
+	err := r.pool.QueryRow(ctx,
+		"INSERT INTO clicks (url_id, ip, user_agent, referrer, bot) VALUES ($1, $2, $3, $4, $5) RETURNING clicked_at",
+		click.URLID, click.IP, click.UserAgent, click.Referrer, click.Bot,
+	).Scan(&click.ClickedAt)
+	return mapError(err, "click")
+}
+
+func (r *postgresClickRepository) Stats(ctx context.Context, urlID string, days int, includeBots bool) (*model.Stats, error) {
+	stats := &model.Stats{URLID: urlID}
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FILTER (WHERE $2 OR NOT bot), COUNT(*) FILTER (WHERE bot),
+		        MAX(clicked_at) FILTER (WHERE $2 OR NOT bot)
+		 FROM clicks WHERE url_id = $1`,
+		urlID, includeBots,
+	).Scan(&stats.TotalClicks, &stats.BotClicks, &stats.LastClickedAt)
+	if err != nil {
+		return nil, mapError(err, "click")
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT (clicked_at AT TIME ZONE 'UTC')::date AS day, COUNT(*)
+		 FROM clicks
+		 WHERE url_id = $1 AND clicked_at >= NOW() - make_interval(days => $2) AND ($3 OR NOT bot)
+		 GROUP BY day ORDER BY day`,
+		urlID, days, includeBots,
+	)
+	if err != nil {
+		return nil, mapError(err, "click")
+	}
+	stats.Daily, err = scanDaily(rows)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// scanDaily reads (day, clicks) rows into a daily breakdown.
+func scanDaily(rows pgx.Rows) ([]model.DailyClicks, error) {
+	defer rows.Close()
+
+	daily := []model.DailyClicks{}
+	for rows.Next() {
+		var day time.Time
+		var d model.DailyClicks
+		if err := rows.Scan(&day, &d.Clicks); err != nil {
+			return nil, mapError(err, "click")
+		}
+		d.Date = day.Format(time.DateOnly)
+		daily = append(daily, d)
+	}
+	return daily, mapError(rows.Err(), "click")
+}
+
+// groupLinks selects the IDs of the live links in a group named $1 in
+// the workspace with ID $2, or outside any workspace when $2 is empty.
+var groupLinks = map[string]string{
+	model.GroupTag: `SELECT ut.url_id AS id FROM url_tags ut
+	                 JOIN tags t ON t.id = ut.tag_id JOIN urls u ON u.id = ut.url_id
+	                 WHERE t.name = $1 AND u.deleted_at IS NULL
+	                   AND u.workspace_id IS NOT DISTINCT FROM NULLIF($2, '')::uuid`,
+	model.GroupCampaign: `SELECT u.id FROM urls u JOIN campaigns c ON c.id = u.campaign_id
+	                      WHERE c.name = $1 AND u.deleted_at IS NULL
+	                        AND u.workspace_id IS NOT DISTINCT FROM NULLIF($2, '')::uuid`,
+}
+
+func (r *postgresClickRepository) GroupStats(ctx context.Context, kind, name, workspaceID string, days int, includeBots bool) (*model.GroupStats, error) {
+	links, ok := groupLinks[kind]
+	if !ok {
+		return nil, apperr.Invalid("invalid group %q", kind)
+	}
+	with := "WITH links AS (" + links + ") "
+
+	stats := &model.GroupStats{Group: kind, Name: name}
+	err := r.pool.QueryRow(ctx, with+
+		`SELECT (SELECT COUNT(*) FROM links),
+		        COUNT(*) FILTER (WHERE $3 OR NOT bot), COUNT(*) FILTER (WHERE bot),
+		        MAX(clicked_at) FILTER (WHERE $3 OR NOT bot)
+		 FROM clicks WHERE url_id IN (SELECT id FROM links)`,
+		name, workspaceID, includeBots,
+	).Scan(&stats.Links, &stats.TotalClicks, &stats.BotClicks, &stats.LastClickedAt)
+	if err != nil {
+		return nil, mapError(err, "click")
+	}
+	if stats.Links == 0 {
+		return nil, apperr.NotFound("%s %q has no links", kind, name)
+	}
+
+	rows, err := r.pool.Query(ctx, with+
+		`SELECT (clicked_at AT TIME ZONE 'UTC')::date AS day, COUNT(*)
+		 FROM clicks
+		 WHERE url_id IN (SELECT id FROM links) AND clicked_at >= NOW() - make_interval(days => $3) AND ($4 OR NOT bot)
+		 GROUP BY day ORDER BY day`,
+		name, workspaceID, days, includeBots,
+	)
+	if err != nil {
+		return nil, mapError(err, "click")
+	}
+	if stats.Daily, err = scanDaily(rows); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.pool.Query(ctx, with+
+		`SELECT u.id, u.code, COALESCE(d.host, ''), COUNT(c.id) AS clicks
+		 FROM links l JOIN urls u ON u.id = l.id
+		 LEFT JOIN domains d ON d.id = u.domain_id
+		 LEFT JOIN clicks c ON c.url_id = u.id AND ($3 OR NOT c.bot)
+		 GROUP BY u.id, u.code, d.host
+		 ORDER BY clicks DESC, u.code LIMIT $4`,
+		name, workspaceID, includeBots, model.GroupTopLinks,
+	)
+	if err != nil {
+		return nil, mapError(err, "click")
+	}
+	defer rows.Close()
+
+	stats.TopLinks = []model.LinkClicks{}
+	for rows.Next() {
+		var l model.LinkClicks
+		if err := rows.Scan(&l.URLID, &l.Code, &l.Domain, &l.Clicks); err != nil {
+			return nil, mapError(err, "click")
+		}
+		stats.TopLinks = append(stats.TopLinks, l)
+	}
+	return stats, mapError(rows.Err(), "click")
+}
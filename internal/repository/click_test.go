@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func TestClickRecord_Stats(t *testing.T) {
+	cleanupURLs(t)
+	urls := NewPostgresURLRepository(testPool)
+	clicks := NewPostgresClickRepository(testPool)
+	ctx := context.Background()
+
+	u := &model.URL{Code: "clicks1", OriginalURL: "https://example.com"}
+	if err := urls.Create(ctx, u); err != nil {
+		t.Fatalf("failed to create url: %v", err)
+	}
+
+	for range 3 {
+		if err := clicks.Record(ctx, &model.Click{URLID: u.ID, IP: "127.0.0.1", UserAgent: "test"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	stats, err := clicks.Stats(ctx, u.ID, model.StatsDays, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats.TotalClicks != 3 {
+		t.Errorf("expected 3 clicks, got %d", stats.TotalClicks)
+	}
+	if stats.LastClickedAt == nil {
+		t.Error("expected LastClickedAt to be set")
+	}
+	if len(stats.Daily) != 1 || stats.Daily[0].Clicks != 3 {
+		t.Errorf("expected one day with 3 clicks, got %+v", stats.Daily)
+	}
+}
+
+func TestClickStats_NoClicks(t *testing.T) {
+	cleanupURLs(t)
+	urls := NewPostgresURLRepository(testPool)
+	clicks := NewPostgresClickRepository(testPool)
+	ctx := context.Background()
+
+	u := &model.URL{Code: "clicks2", OriginalURL: "https://example.com"}
+	if err := urls.Create(ctx, u); err != nil {
+		t.Fatalf("failed to create url: %v", err)
+	}
+
+	stats, err := clicks.Stats(ctx, u.ID, model.StatsDays, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats.TotalClicks != 0 || stats.LastClickedAt != nil || len(stats.Daily) != 0 {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+}
+
+func TestClickStats_Bots(t *testing.T) {
+	cleanupURLs(t)
+	urls := NewPostgresURLRepository(testPool)
+	clicks := NewPostgresClickRepository(testPool)
+	ctx := context.Background()
+
+	u := &model.URL{Code: "clicks3", OriginalURL: "https://example.com"}
+	if err := urls.Create(ctx, u); err != nil {
+		t.Fatalf("failed to create url: %v", err)
+	}
+	for _, bot := range []bool{false, true, true} {
+		if err := clicks.Record(ctx, &model.Click{URLID: u.ID, Bot: bot}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	humans, err := clicks.Stats(ctx, u.ID, model.StatsDays, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if humans.TotalClicks != 1 || humans.BotClicks != 2 || len(humans.Daily) != 1 || humans.Daily[0].Clicks != 1 {
+		t.Errorf("expected 1 human and 2 bot clicks, got %+v", humans)
+	}
+
+	all, err := clicks.Stats(ctx, u.ID, model.StatsDays, true)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if all.TotalClicks != 3 || all.BotClicks != 2 || all.Daily[0].Clicks != 3 {
+		t.Errorf("expected 3 clicks including bots, got %+v", all)
+	}
+}
+
+func TestClickGroupStats(t *testing.T) {
+	cleanupURLs(t)
+	urls := NewPostgresURLRepository(testPool)
+	clicks := NewPostgresClickRepository(testPool)
+	ctx := context.Background()
+
+	a := &model.URL{Code: "camp1", OriginalURL: "https://example.com/a", Campaign: "launch"}
+	b := &model.URL{Code: "camp2", OriginalURL: "https://example.com/b", Campaign: "launch"}
+	for _, u := range []*model.URL{a, b} {
+		if err := urls.Create(ctx, u); err != nil {
+			t.Fatalf("failed to create url: %v", err)
+		}
+	}
+	for _, c := range []model.Click{{URLID: a.ID}, {URLID: b.ID}, {URLID: b.ID}, {URLID: b.ID, Bot: true}} {
+		if err := clicks.Record(ctx, &c); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	stats, err := clicks.GroupStats(ctx, model.GroupCampaign, "launch", "", model.StatsDays, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats.Links != 2 || stats.TotalClicks != 3 || stats.BotClicks != 1 || len(stats.Daily) != 1 {
+		t.Errorf("expected 3 human clicks over 2 links, got %+v", stats)
+	}
+	if len(stats.TopLinks) != 2 || stats.TopLinks[0].URLID != b.ID || stats.TopLinks[0].Clicks != 2 {
+		t.Errorf("expected the busier link first, got %+v", stats.TopLinks)
+	}
+
+	_, err = clicks.GroupStats(ctx, model.GroupTag, "launch", "", model.StatsDays, false)
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unused tag, got %v", err)
+	}
+}
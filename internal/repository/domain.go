@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+type DomainRepository interface {
+	Create(ctx context.Context, domain *model.Domain) error
+	GetByHost(ctx context.Context, host string) (*model.Domain, error)
+	ListByOwner(ctx context.Context, ownerKeyHash string) ([]model.Domain, error)
+}
+
+type postgresDomainRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresDomainRepository(pool *pgxpool.Pool) DomainRepository {
+	return &postgresDomainRepository{pool: pool}
+}
+
+func (r *postgresDomainRepository) Create(ctx context.Context, domain *model.Domain) error {
+	err := r.pool.QueryRow(ctx,
+		"INSERT INTO domains (host, fallback_url, owner_key_hash) VALUES ($1, $2, $3) RETURNING id, created_at",
+		domain.Host, domain.FallbackURL, domain.OwnerKeyHash,
+	).Scan(&domain.ID, &domain.CreatedAt)
+	return mapError(err, "domain")
+}
+
+func (r *postgresDomainRepository) GetByHost(ctx context.Context, host string) (*model.Domain, error) {
+	var d model.Domain
+	err := r.pool.QueryRow(ctx,
+		"SELECT id, host, fallback_url, owner_key_hash, created_at FROM domains WHERE host = $1",
+		host,
+	).Scan(&d.ID, &d.Host, &d.FallbackURL, &d.OwnerKeyHash, &d.CreatedAt)
+	if err != nil {
+		return nil, mapError(err, "domain")
+	}
+	return &d, nil
+}
+
+func (r *postgresDomainRepository) ListByOwner(ctx context.Context, ownerKeyHash string) ([]model.Domain, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT id, host, fallback_url, owner_key_hash, created_at FROM domains WHERE owner_key_hash = $1 ORDER BY host",
+		ownerKeyHash,
+	)
+	if err != nil {
+		return nil, mapError(err, "domain")
+	}
+	defer rows.Close()
+
+	domains := []model.Domain{}
+	for rows.Next() {
+		var d model.Domain
+		if err := rows.Scan(&d.ID, &d.Host, &d.FallbackURL, &d.OwnerKeyHash, &d.CreatedAt); err != nil {
+			return nil, mapError(err, "domain")
+		}
+		domains = append(domains, d)
+	}
+	return domains, mapError(rows.Err(), "domain")
+}
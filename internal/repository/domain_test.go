@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func cleanupDomains(t *testing.T) {
+	t.Helper()
+	cleanupURLs(t)
+	_, err := testPool.Exec(context.Background(), "DELETE FROM domains")
+	if err != nil {
+		t.Fatalf("failed to clean domains table: %v", err)
+	}
+}
+
+func TestDomainCreate_GetByHost(t *testing.T) {
+	cleanupDomains(t)
+	repo := NewPostgresDomainRepository(testPool)
+	ctx := context.Background()
+
+	d := &model.Domain{Host: "go.example.com", FallbackURL: "https://example.com", OwnerKeyHash: "hash"}
+	if err := repo.Create(ctx, d); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if d.ID == "" {
+		t.Error("expected ID to be set")
+	}
+
+	result, err := repo.GetByHost(ctx, "go.example.com")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.ID != d.ID {
+		t.Errorf("expected ID %s, got %s", d.ID, result.ID)
+	}
+	if result.FallbackURL != "https://example.com" {
+		t.Errorf("expected fallback https://example.com, got %s", result.FallbackURL)
+	}
+}
+
+func TestDomainCreate_DuplicateHost(t *testing.T) {
+	cleanupDomains(t)
+	repo := NewPostgresDomainRepository(testPool)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &model.Domain{Host: "dup.example.com", OwnerKeyHash: "a"}); err != nil {
+		t.Fatalf("first create failed: %v", err)
+	}
+	if err := repo.Create(ctx, &model.Domain{Host: "dup.example.com", OwnerKeyHash: "b"}); err == nil {
+		t.Fatal("expected error for duplicate host, got nil")
+	}
+}
+
+func TestDomainListByOwner(t *testing.T) {
+	cleanupDomains(t)
+	repo := NewPostgresDomainRepository(testPool)
+	ctx := context.Background()
+
+	for _, d := range []*model.Domain{
+		{Host: "b.example.com", OwnerKeyHash: "mine"},
+		{Host: "a.example.com", OwnerKeyHash: "mine"},
+		{Host: "c.example.com", OwnerKeyHash: "theirs"},
+	} {
+		if err := repo.Create(ctx, d); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	domains, err := repo.ListByOwner(ctx, "mine")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(domains) != 2 || domains[0].Host != "a.example.com" {
+		t.Errorf("expected two domains starting with a.example.com, got %+v", domains)
+	}
+}
+
+func TestCreate_SameCodeDifferentDomains(t *testing.T) {
+	cleanupDomains(t)
+	domains := NewPostgresDomainRepository(testPool)
+	urls := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	d := &model.Domain{Host: "go.example.com", OwnerKeyHash: "hash"}
+	if err := domains.Create(ctx, d); err != nil {
+		t.Fatalf("create domain failed: %v", err)
+	}
+
+	if err := urls.Create(ctx, &model.URL{Code: "same123", OriginalURL: "https://default.com"}); err != nil {
+		t.Fatalf("create on default domain failed: %v", err)
+	}
+	if err := urls.Create(ctx, &model.URL{Code: "same123", DomainID: &d.ID, OriginalURL: "https://branded.com"}); err != nil {
+		t.Fatalf("create on custom domain failed: %v", err)
+	}
+
+	result, err := urls.GetByCode(ctx, d.ID, "same123")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.OriginalURL != "https://branded.com" {
+		t.Errorf("expected https://branded.com, got %s", result.OriginalURL)
+	}
+	if result.Domain != "go.example.com" {
+		t.Errorf("expected domain go.example.com, got %s", result.Domain)
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+)
+
+// PostgreSQL error codes translated by mapError.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgInvalidTextRepr     = "22P02"
+)
+
+// mapError translates pgx errors into apperr kinds. what names the entity
+// in client-facing messages, e.g. "url". Errors it does not recognise are
+// returned unchanged and surface as internal errors.
+func mapError(err error, what string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperr.NotFound("%s not found", what)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return apperr.Conflict("%s already exists", what)
+		case pgForeignKeyViolation:
+			return apperr.Invalid("%s references a missing record", what)
+		case pgInvalidTextRepr:
+			// Malformed IDs can never match a row.
+			return apperr.NotFound("%s not found", what)
+		}
+		return err
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return apperr.Unavailable(err, "database unavailable")
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"no rows", pgx.ErrNoRows, apperr.ErrNotFound},
+		{"unique violation", &pgconn.PgError{Code: pgUniqueViolation}, apperr.ErrConflict},
+		{"foreign key violation", &pgconn.PgError{Code: pgForeignKeyViolation}, apperr.ErrInvalid},
+		{"malformed uuid", &pgconn.PgError{Code: pgInvalidTextRepr}, apperr.ErrNotFound},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), apperr.ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapError(tt.err, "url"); !errors.Is(got, tt.kind) {
+				t.Errorf("expected %v, got %v", tt.kind, got)
+			}
+		})
+	}
+}
+
+func TestMapError_PassesThroughUnknown(t *testing.T) {
+	raw := &pgconn.PgError{Code: "42P01", Message: "relation \"urls\" does not exist"}
+	if got := mapError(raw, "url"); got != raw {
+		t.Errorf("expected unknown error unchanged, got %v", got)
+	}
+	if mapError(nil, "url") != nil {
+		t.Error("expected nil for nil")
+	}
+}
@@ -0,0 +1,216 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/account.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/account.go -destination=internal/repository/mocks/mock_account.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/kerbatek/url-shortener/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccountRepository is a mock of AccountRepository interface.
+type MockAccountRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAccountRepositoryMockRecorder is the mock recorder for MockAccountRepository.
+type MockAccountRepositoryMockRecorder struct {
+	mock *MockAccountRepository
+}
+
+// NewMockAccountRepository creates a new mock instance.
+func NewMockAccountRepository(ctrl *gomock.Controller) *MockAccountRepository {
+	mock := &MockAccountRepository{ctrl: ctrl}
+	mock.recorder = &MockAccountRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvite mocks base method.
+func (m *MockAccountRepository) AcceptInvite(ctx context.Context, inviteID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvite", ctx, inviteID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptInvite indicates an expected call of AcceptInvite.
+func (mr *MockAccountRepositoryMockRecorder) AcceptInvite(ctx, inviteID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvite", reflect.TypeOf((*MockAccountRepository)(nil).AcceptInvite), ctx, inviteID, userID)
+}
+
+// CreateInvite mocks base method.
+func (m *MockAccountRepository) CreateInvite(ctx context.Context, invite *model.Invite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockAccountRepositoryMockRecorder) CreateInvite(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockAccountRepository)(nil).CreateInvite), ctx, invite)
+}
+
+// CreateUser mocks base method.
+func (m *MockAccountRepository) CreateUser(ctx context.Context, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAccountRepositoryMockRecorder) CreateUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAccountRepository)(nil).CreateUser), ctx, user)
+}
+
+// CreateWorkspace mocks base method.
+func (m *MockAccountRepository) CreateWorkspace(ctx context.Context, workspace *model.Workspace, ownerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWorkspace", ctx, workspace, ownerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWorkspace indicates an expected call of CreateWorkspace.
+func (mr *MockAccountRepositoryMockRecorder) CreateWorkspace(ctx, workspace, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWorkspace", reflect.TypeOf((*MockAccountRepository)(nil).CreateWorkspace), ctx, workspace, ownerID)
+}
+
+// ExternalUser mocks base method.
+func (m *MockAccountRepository) ExternalUser(ctx context.Context, id *model.Identity) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExternalUser", ctx, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExternalUser indicates an expected call of ExternalUser.
+func (mr *MockAccountRepositoryMockRecorder) ExternalUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExternalUser", reflect.TypeOf((*MockAccountRepository)(nil).ExternalUser), ctx, id)
+}
+
+// GetInviteByTokenHash mocks base method.
+func (m *MockAccountRepository) GetInviteByTokenHash(ctx context.Context, tokenHash string) (*model.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*model.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteByTokenHash indicates an expected call of GetInviteByTokenHash.
+func (mr *MockAccountRepositoryMockRecorder) GetInviteByTokenHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteByTokenHash", reflect.TypeOf((*MockAccountRepository)(nil).GetInviteByTokenHash), ctx, tokenHash)
+}
+
+// GetUserByKeyHash mocks base method.
+func (m *MockAccountRepository) GetUserByKeyHash(ctx context.Context, keyHash string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByKeyHash", ctx, keyHash)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByKeyHash indicates an expected call of GetUserByKeyHash.
+func (mr *MockAccountRepositoryMockRecorder) GetUserByKeyHash(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByKeyHash", reflect.TypeOf((*MockAccountRepository)(nil).GetUserByKeyHash), ctx, keyHash)
+}
+
+// GetWorkspace mocks base method.
+func (m *MockAccountRepository) GetWorkspace(ctx context.Context, id, userID string) (*model.Workspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWorkspace", ctx, id, userID)
+	ret0, _ := ret[0].(*model.Workspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWorkspace indicates an expected call of GetWorkspace.
+func (mr *MockAccountRepositoryMockRecorder) GetWorkspace(ctx, id, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkspace", reflect.TypeOf((*MockAccountRepository)(nil).GetWorkspace), ctx, id, userID)
+}
+
+// ListMembers mocks base method.
+func (m *MockAccountRepository) ListMembers(ctx context.Context, workspaceID string) ([]model.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, workspaceID)
+	ret0, _ := ret[0].([]model.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockAccountRepositoryMockRecorder) ListMembers(ctx, workspaceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockAccountRepository)(nil).ListMembers), ctx, workspaceID)
+}
+
+// ListWorkspaces mocks base method.
+func (m *MockAccountRepository) ListWorkspaces(ctx context.Context, userID string) ([]model.Workspace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWorkspaces", ctx, userID)
+	ret0, _ := ret[0].([]model.Workspace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkspaces indicates an expected call of ListWorkspaces.
+func (mr *MockAccountRepositoryMockRecorder) ListWorkspaces(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkspaces", reflect.TypeOf((*MockAccountRepository)(nil).ListWorkspaces), ctx, userID)
+}
+
+// RemoveMember mocks base method.
+func (m *MockAccountRepository) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, workspaceID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockAccountRepositoryMockRecorder) RemoveMember(ctx, workspaceID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockAccountRepository)(nil).RemoveMember), ctx, workspaceID, userID)
+}
+
+// SetRole mocks base method.
+func (m *MockAccountRepository) SetRole(ctx context.Context, workspaceID, userID, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, workspaceID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockAccountRepositoryMockRecorder) SetRole(ctx, workspaceID, userID, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockAccountRepository)(nil).SetRole), ctx, workspaceID, userID, role)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/click.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/click.go -destination=internal/repository/mocks/mock_click.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/kerbatek/url-shortener/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClickRepository is a mock of ClickRepository interface.
+type MockClickRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockClickRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockClickRepositoryMockRecorder is the mock recorder for MockClickRepository.
+type MockClickRepositoryMockRecorder struct {
+	mock *MockClickRepository
+}
+
+// NewMockClickRepository creates a new mock instance.
+func NewMockClickRepository(ctrl *gomock.Controller) *MockClickRepository {
+	mock := &MockClickRepository{ctrl: ctrl}
+	mock.recorder = &MockClickRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClickRepository) EXPECT() *MockClickRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GroupStats mocks base method.
+func (m *MockClickRepository) GroupStats(ctx context.Context, kind, name, workspaceID string, days int, includeBots bool) (*model.GroupStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupStats", ctx, kind, name, workspaceID, days, includeBots)
+	ret0, _ := ret[0].(*model.GroupStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupStats indicates an expected call of GroupStats.
+func (mr *MockClickRepositoryMockRecorder) GroupStats(ctx, kind, name, workspaceID, days, includeBots any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupStats", reflect.TypeOf((*MockClickRepository)(nil).GroupStats), ctx, kind, name, workspaceID, days, includeBots)
+}
+
+// Record mocks base method.
+func (m *MockClickRepository) Record(ctx context.Context, click *model.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, click)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockClickRepositoryMockRecorder) Record(ctx, click any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockClickRepository)(nil).Record), ctx, click)
+}
+
+// Stats mocks base method.
+func (m *MockClickRepository) Stats(ctx context.Context, urlID string, days int, includeBots bool) (*model.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx, urlID, days, includeBots)
+	ret0, _ := ret[0].(*model.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockClickRepositoryMockRecorder) Stats(ctx, urlID, days, includeBots any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockClickRepository)(nil).Stats), ctx, urlID, days, includeBots)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/domain.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/domain.go -destination=internal/repository/mocks/mock_domain.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/kerbatek/url-shortener/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDomainRepository is a mock of DomainRepository interface.
+type MockDomainRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDomainRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDomainRepositoryMockRecorder is the mock recorder for MockDomainRepository.
+type MockDomainRepositoryMockRecorder struct {
+	mock *MockDomainRepository
+}
+
+// NewMockDomainRepository creates a new mock instance.
+func NewMockDomainRepository(ctrl *gomock.Controller) *MockDomainRepository {
+	mock := &MockDomainRepository{ctrl: ctrl}
+	mock.recorder = &MockDomainRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDomainRepository) EXPECT() *MockDomainRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockDomainRepository) Create(ctx context.Context, domain *model.Domain) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, domain)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDomainRepositoryMockRecorder) Create(ctx, domain any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDomainRepository)(nil).Create), ctx, domain)
+}
+
+// GetByHost mocks base method.
+func (m *MockDomainRepository) GetByHost(ctx context.Context, host string) (*model.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHost", ctx, host)
+	ret0, _ := ret[0].(*model.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHost indicates an expected call of GetByHost.
+func (mr *MockDomainRepositoryMockRecorder) GetByHost(ctx, host any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHost", reflect.TypeOf((*MockDomainRepository)(nil).GetByHost), ctx, host)
+}
+
+// ListByOwner mocks base method.
+func (m *MockDomainRepository) ListByOwner(ctx context.Context, ownerKeyHash string) ([]model.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOwner", ctx, ownerKeyHash)
+	ret0, _ := ret[0].([]model.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOwner indicates an expected call of ListByOwner.
+func (mr *MockDomainRepositoryMockRecorder) ListByOwner(ctx, ownerKeyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOwner", reflect.TypeOf((*MockDomainRepository)(nil).ListByOwner), ctx, ownerKeyHash)
+}
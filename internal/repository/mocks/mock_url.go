@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: /Users/kerbatek/development/url-shortener/internal/repository/url.go
+// Source: internal/repository/url.go
 //
 // Generated by this command:
 //
-//	mockgen -source=/Users/kerbatek/development/url-shortener/internal/repository/url.go -destination=/Users/kerbatek/development/url-shortener/internal/repository/mocks/mock_url.go -package=mocks
+//	mockgen -source=internal/repository/url.go -destination=internal/repository/mocks/mock_url.go -package=mocks
 //
 
 // Package mocks is a generated GoMock package.
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/kerbatek/url-shortener/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +42,36 @@ func (m *MockURLRepository) EXPECT() *MockURLRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimDueChecks mocks base method.
+func (m *MockURLRepository) ClaimDueChecks(ctx context.Context, limit int, lease time.Duration) ([]model.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueChecks", ctx, limit, lease)
+	ret0, _ := ret[0].([]model.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueChecks indicates an expected call of ClaimDueChecks.
+func (mr *MockURLRepositoryMockRecorder) ClaimDueChecks(ctx, limit, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueChecks", reflect.TypeOf((*MockURLRepository)(nil).ClaimDueChecks), ctx, limit, lease)
+}
+
+// ConsumeClick mocks base method.
+func (m *MockURLRepository) ConsumeClick(ctx context.Context, id string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeClick", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeClick indicates an expected call of ConsumeClick.
+func (mr *MockURLRepositoryMockRecorder) ConsumeClick(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeClick", reflect.TypeOf((*MockURLRepository)(nil).ConsumeClick), ctx, id)
+}
+
 // Create mocks base method.
 func (m *MockURLRepository) Create(ctx context.Context, url *model.URL) error {
 	m.ctrl.T.Helper()
@@ -70,18 +101,18 @@ func (mr *MockURLRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
 }
 
 // GetByCode mocks base method.
-func (m *MockURLRepository) GetByCode(ctx context.Context, code string) (*model.URL, error) {
+func (m *MockURLRepository) GetByCode(ctx context.Context, domainID, code string) (*model.URL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByCode", ctx, code)
+	ret := m.ctrl.Call(m, "GetByCode", ctx, domainID, code)
 	ret0, _ := ret[0].(*model.URL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByCode indicates an expected call of GetByCode.
-func (mr *MockURLRepositoryMockRecorder) GetByCode(ctx, code any) *gomock.Call {
+func (mr *MockURLRepositoryMockRecorder) GetByCode(ctx, domainID, code any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockURLRepository)(nil).GetByCode), ctx, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockURLRepository)(nil).GetByCode), ctx, domainID, code)
 }
 
 // GetByID mocks base method.
@@ -98,3 +129,135 @@ func (mr *MockURLRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockURLRepository)(nil).GetByID), ctx, id)
 }
+
+// Groups mocks base method.
+func (m *MockURLRepository) Groups(ctx context.Context, kind, workspaceID string) ([]model.LinkGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Groups", ctx, kind, workspaceID)
+	ret0, _ := ret[0].([]model.LinkGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Groups indicates an expected call of Groups.
+func (mr *MockURLRepositoryMockRecorder) Groups(ctx, kind, workspaceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Groups", reflect.TypeOf((*MockURLRepository)(nil).Groups), ctx, kind, workspaceID)
+}
+
+// Import mocks base method.
+func (m *MockURLRepository) Import(ctx context.Context, urls []model.URL) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, urls)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockURLRepositoryMockRecorder) Import(ctx, urls any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockURLRepository)(nil).Import), ctx, urls)
+}
+
+// List mocks base method.
+func (m *MockURLRepository) List(ctx context.Context, opts model.ListOptions) ([]model.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, opts)
+	ret0, _ := ret[0].([]model.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockURLRepositoryMockRecorder) List(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockURLRepository)(nil).List), ctx, opts)
+}
+
+// NextCodeID mocks base method.
+func (m *MockURLRepository) NextCodeID(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NextCodeID", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextCodeID indicates an expected call of NextCodeID.
+func (mr *MockURLRepositoryMockRecorder) NextCodeID(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextCodeID", reflect.TypeOf((*MockURLRepository)(nil).NextCodeID), ctx)
+}
+
+// RecordCheck mocks base method.
+func (m *MockURLRepository) RecordCheck(ctx context.Context, id string, health *model.LinkHealth, next time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordCheck", ctx, id, health, next)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordCheck indicates an expected call of RecordCheck.
+func (mr *MockURLRepositoryMockRecorder) RecordCheck(ctx, id, health, next any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCheck", reflect.TypeOf((*MockURLRepository)(nil).RecordCheck), ctx, id, health, next)
+}
+
+// Restore mocks base method.
+func (m *MockURLRepository) Restore(ctx context.Context, id string) (*model.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(*model.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockURLRepositoryMockRecorder) Restore(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockURLRepository)(nil).Restore), ctx, id)
+}
+
+// SetDisabled mocks base method.
+func (m *MockURLRepository) SetDisabled(ctx context.Context, id, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockURLRepositoryMockRecorder) SetDisabled(ctx, id, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockURLRepository)(nil).SetDisabled), ctx, id, reason)
+}
+
+// Update mocks base method.
+func (m *MockURLRepository) Update(ctx context.Context, url *model.URL) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockURLRepositoryMockRecorder) Update(ctx, url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockURLRepository)(nil).Update), ctx, url)
+}
+
+// WorkspaceOf mocks base method.
+func (m *MockURLRepository) WorkspaceOf(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WorkspaceOf", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WorkspaceOf indicates an expected call of WorkspaceOf.
+func (mr *MockURLRepositoryMockRecorder) WorkspaceOf(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WorkspaceOf", reflect.TypeOf((*MockURLRepository)(nil).WorkspaceOf), ctx, id)
+}
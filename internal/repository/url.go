@@ -2,65 +2,861 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/apperr"
 	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/replica"
 )
 
+const (
+	urlColumns = "u.id, u.code, u.domain_id, COALESCE(d.host, ''), u.original_url, " +
+		"u.forward_query, u.query_precedence, u.utm_params, u.bot_policy, u.created_at, u.updated_at, " +
+		"u.disabled_at, COALESCE(u.disabled_reason, ''), " +
+		"u.checked_at, COALESCE(u.check_status, 0), COALESCE(u.check_latency_ms, 0), COALESCE(u.check_error, ''), u.broken, " +
+		"u.preview_title, u.preview_description, u.preview_image_url, u.title, u.description, u.metadata, " +
+		"u.max_clicks, u.clicks_used, u.workspace_id, " + campaignColumn + ", " + tagsColumn
+	urlFrom = "urls u LEFT JOIN domains d ON d.id = u.domain_id"
+	// noDomain stands in for the default host's missing domain ID in the
+	// unique indexes on codes.
+	noDomain = "'00000000-0000-0000-0000-000000000000'::uuid"
+
+	// campaignColumn and tagsColumn read the groups of the link aliased u.
+	campaignColumn = "COALESCE((SELECT c.name FROM campaigns c WHERE c.id = u.campaign_id), '')"
+	tagsColumn     = "ARRAY(SELECT t.name FROM url_tags ut JOIN tags t ON t.id = ut.tag_id WHERE ut.url_id = u.id ORDER BY t.name)"
+)
+
+// URLRepository methods return errors wrapping the apperr kinds: a missing
+// row is apperr.ErrNotFound and a duplicate code apperr.ErrConflict.
+// Create, Update, Delete, Restore and Import write the change to the audit
+// log in the same transaction, attributed to the actor and origin carried
+// by ctx (see package audit).
 type URLRepository interface {
 	Create(ctx context.Context, url *model.URL) error
-	GetByCode(ctx context.Context, code string) (*model.URL, error)
+	// GetByCode looks up code within a domain; an empty domainID means the
+	// default host.
+	GetByCode(ctx context.Context, domainID, code string) (*model.URL, error)
 	GetByID(ctx context.Context, id string) (*model.URL, error)
+	// WorkspaceOf returns the ID of the workspace the link with the given
+	// ID belongs to, deleted or not, or "" when it belongs to none.
+	WorkspaceOf(ctx context.Context, id string) (string, error)
+	// List returns up to opts.Limit links matching opts, newest first,
+	// starting after opts.After.
+	List(ctx context.Context, opts model.ListOptions) ([]model.URL, error)
+	// Update saves the mutable fields of url and refreshes its UpdatedAt.
+	Update(ctx context.Context, url *model.URL) error
+	// Delete soft-deletes a link: it stops resolving and disappears from
+	// reads, but keeps its code and can be restored.
 	Delete(ctx context.Context, id string) error
+	// Restore undoes Delete. Links that are not deleted are not found.
+	Restore(ctx context.Context, id string) (*model.URL, error)
+	// Import inserts urls as given, keeping their codes and creation
+	// times, and returns the indexes of those whose code was already taken
+	// on their domain. Imports are not published to webhooks, but each
+	// link inserted is audited as a create.
+	Import(ctx context.Context, urls []model.URL) (conflicts []int, err error)
+	// SetDisabled disables a link with the given reason, or re-enables it
+	// when reason is empty. Disabled links still resolve; callers decide
+	// what to serve.
+	SetDisabled(ctx context.Context, id, reason string) error
+	// ClaimDueChecks returns up to limit live, enabled links due for a
+	// destination check and leases them for the given duration so other
+	// checkers skip them.
+	ClaimDueChecks(ctx context.Context, limit int, lease time.Duration) ([]model.URL, error)
+	// RecordCheck stores the outcome of a check and schedules the next one.
+	RecordCheck(ctx context.Context, id string, health *model.LinkHealth, next time.Time) error
+	// ConsumeClick counts one redirect against the click limit of the link
+	// with the given ID and returns how many have been used, failing with
+	// apperr.ErrGone once none are left. Checking and counting are a
+	// single conditional UPDATE, so concurrent redirects cannot overspend
+	// the limit; a cache in front of the repository must always pass this
+	// call through to the database.
+	ConsumeClick(ctx context.Context, id string) (used int64, err error)
+	// NextCodeID returns the next number of the sequence the sequential
+	// and obfuscated code strategies spell codes from.
+	NextCodeID(ctx context.Context) (int64, error)
+	// Groups lists the tags or campaigns, by model.Group kind, that have
+	// live links in the workspace with the given ID, or outside any
+	// workspace when it is "", by name.
+	Groups(ctx context.Context, kind, workspaceID string) ([]model.LinkGroup, error)
 }
 
 type postgresURLRepository struct {
-	pool *pgxpool.Pool
+	pool            *pgxpool.Pool
+	replicas        *replica.Set
+	caseInsensitive bool
 }
 
-func NewPostgresURLRepository(pool *pgxpool.Pool) URLRepository {
-	return &postgresURLRepository{pool: pool}
+// URLRepositoryOption configures a URL repository.
+type URLRepositoryOption func(*postgresURLRepository)
+
+// WithReplicas sends GetByCode, GetByID and List to the read replicas of
+// replicas. A link a replica does not have yet is looked up again on the
+// primary, so one just created can be resolved at once, and so is one the
+// replica failed to answer for, until the next check takes it out of
+// rotation.
+func WithReplicas(replicas *replica.Set) URLRepositoryOption {
+	return func(r *postgresURLRepository) { r.replicas = replicas }
+}
+
+// WithCaseInsensitiveCodes makes GetByCode match codes regardless of case.
+// EnableCaseInsensitiveCodes must have succeeded on the database first.
+func WithCaseInsensitiveCodes() URLRepositoryOption {
+	return func(r *postgresURLRepository) { r.caseInsensitive = true }
+}
+
+func NewPostgresURLRepository(pool *pgxpool.Pool, opts ...URLRepositoryOption) URLRepository {
+	r := &postgresURLRepository{pool: pool}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// reader returns the pool a read made with ctx runs on.
+func (r *postgresURLRepository) reader(ctx context.Context) replica.DB {
+	if r.replicas == nil {
+		return r.pool
+	}
+	return r.replicas.Read(ctx)
+}
+
+// getURL reads a single link on a replica, retrying on the primary when the
+// replica does not have it or cannot be reached.
+func (r *postgresURLRepository) getURL(ctx context.Context, query string, args ...any) (*model.URL, error) {
+	db := r.reader(ctx)
+	u, err := scanURL(db.QueryRow(ctx, query, args...))
+	if (errors.Is(err, apperr.ErrNotFound) || errors.Is(err, apperr.ErrUnavailable)) && db != replica.DB(r.pool) {
+		return scanURL(r.pool.QueryRow(ctx, query, args...))
+	}
+	return u, err
+}
+
+func scanURL(row pgx.Row) (*model.URL, error) {
+	var url model.URL
+	var health model.LinkHealth
+	var checkedAt *time.Time
+	var preview model.LinkPreview
+	err := row.Scan(
+		&url.ID, &url.Code, &url.DomainID, &url.Domain, &url.OriginalURL,
+		&url.ForwardQuery, &url.QueryPrecedence, &url.UTMParams, &url.BotPolicy,
+		&url.CreatedAt, &url.UpdatedAt, &url.DisabledAt, &url.DisabledReason,
+		&checkedAt, &health.StatusCode, &health.LatencyMS, &health.Error, &health.Broken,
+		&preview.Title, &preview.Description, &preview.ImageURL,
+		&url.Title, &url.Description, &url.Metadata, &url.MaxClicks, &url.ClicksUsed,
+		&url.WorkspaceID, &url.Campaign, &url.Tags,
+	)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	if preview != (model.LinkPreview{}) {
+		url.Preview = &preview
+	}
+	clearEmpty(&url)
+	if checkedAt != nil {
+		health.CheckedAt = *checkedAt
+		url.Health = &health
+	}
+	return &url, nil
+}
+
+// clearEmpty sets the empty collections read for url to nil, matching how
+// links are built before they are stored.
+func clearEmpty(url *model.URL) {
+	if len(url.Tags) == 0 {
+		url.Tags = nil
+	}
+	if len(url.Metadata) == 0 {
+		url.Metadata = nil
+	}
+}
+
+func scanURLs(rows pgx.Rows) ([]model.URL, error) {
+	defer rows.Close()
+
+	urls := []model.URL{}
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, *url)
+	}
+	return urls, mapError(rows.Err(), "url")
 }
 
 func (r *postgresURLRepository) Create(ctx context.Context, url *model.URL) error {
-	return r.pool.QueryRow(ctx,
-		"INSERT INTO urls (code, original_url) VALUES ($1, $2) RETURNING id, created_at, updated_at",
-		url.Code, url.OriginalURL,
+	utm := url.UTMParams
+	if utm == nil {
+		utm = map[string]string{}
+	}
+	metadata := metadataOf(url)
+	preview := previewOf(url)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	campaignID, err := upsertCampaign(ctx, tx, url.Campaign)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO urls (code, domain_id, original_url, forward_query, query_precedence, utm_params, bot_policy,
+		                   preview_title, preview_description, preview_image_url, campaign_id,
+		                   title, description, metadata, max_clicks, workspace_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, created_at, updated_at`,
+		url.Code, url.DomainID, url.OriginalURL, url.ForwardQuery, url.QueryPrecedence, utm, botPolicy(url),
+		preview.Title, preview.Description, preview.ImageURL, campaignID,
+		url.Title, url.Description, metadata, url.MaxClicks, url.WorkspaceID,
 	).Scan(&url.ID, &url.CreatedAt, &url.UpdatedAt)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	if err := setTags(ctx, tx, url.ID, url.Tags); err != nil {
+		return mapError(err, "url")
+	}
+	if err := insertOutbox(ctx, tx, model.EventLinkCreated, url); err != nil {
+		return mapError(err, "url")
+	}
+	if err := insertAudit(ctx, tx, model.AuditCreate, nil, url); err != nil {
+		return mapError(err, "url")
+	}
+	return mapError(tx.Commit(ctx), "url")
 }
 
-func (r *postgresURLRepository) GetByCode(ctx context.Context, code string) (*model.URL, error) {
-	var url model.URL
-	err := r.pool.QueryRow(ctx,
-		"SELECT id, code, original_url, created_at, updated_at FROM urls WHERE code = $1",
-		code,
-	).Scan(&url.ID, &url.Code, &url.OriginalURL, &url.CreatedAt, &url.UpdatedAt)
+// botPolicy returns the policy to store for url, defaulting to redirect.
+func botPolicy(url *model.URL) string {
+	if url.BotPolicy == "" {
+		return model.BotPolicyRedirect
+	}
+	return url.BotPolicy
+}
+
+// previewOf returns the preview columns to store for url, all empty when
+// it has none.
+func previewOf(url *model.URL) model.LinkPreview {
+	if url.Preview == nil {
+		return model.LinkPreview{}
+	}
+	return *url.Preview
+}
+
+// metadataOf returns the metadata to store for url, an empty object when it
+// has none.
+func metadataOf(url *model.URL) map[string]any {
+	if url.Metadata == nil {
+		return map[string]any{}
+	}
+	return url.Metadata
+}
+
+// upsertCampaign returns the ID of the campaign called name, creating it
+// if it does not exist yet. An empty name is no campaign.
+func upsertCampaign(ctx context.Context, tx pgx.Tx, name string) (*string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	var id string
+	err := tx.QueryRow(ctx,
+		// The no-op update makes RETURNING yield the existing row too.
+		`INSERT INTO campaigns (name) VALUES ($1)
+		 ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name RETURNING id`,
+		name,
+	).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
-	return &url, nil
+	return &id, nil
+}
+
+// setTags replaces the tags of the link with the given ID, creating any
+// tags that do not exist yet.
+func setTags(ctx context.Context, tx pgx.Tx, urlID string, tags []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM url_tags WHERE url_id = $1", urlID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return insertTags(ctx, tx, []string{urlID}, [][]string{tags})
+}
+
+// insertTags tags each of urlIDs with the tags at the same index, creating
+// any tags that do not exist yet.
+func insertTags(ctx context.Context, tx pgx.Tx, urlIDs []string, tags [][]string) error {
+	var ids, names []string
+	for i, id := range urlIDs {
+		for _, name := range tags[i] {
+			ids, names = append(ids, id), append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx,
+		"INSERT INTO tags (name) SELECT DISTINCT unnest($1::text[]) ON CONFLICT (name) DO NOTHING",
+		names,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO url_tags (url_id, tag_id)
+		 SELECT x.url_id::uuid, t.id FROM unnest($1::text[], $2::text[]) AS x(url_id, name)
+		 JOIN tags t ON t.name = x.name
+		 ON CONFLICT DO NOTHING`,
+		ids, names,
+	)
+	return err
+}
+
+func (r *postgresURLRepository) GetByCode(ctx context.Context, domainID, code string) (*model.URL, error) {
+	var domain *string
+	if domainID != "" {
+		domain = &domainID
+	}
+	where := "u.code = $1 AND u.domain_id IS NOT DISTINCT FROM $2::uuid"
+	if r.caseInsensitive {
+		// Spelled as idx_urls_domain_lower_code is, so the index serves it.
+		where = "lower(u.code) = lower($1) AND COALESCE(u.domain_id, " + noDomain + ") = COALESCE($2::uuid, " + noDomain + ")"
+	}
+	return r.getURL(ctx,
+		"SELECT "+urlColumns+" FROM "+urlFrom+" WHERE "+where+" AND u.deleted_at IS NULL",
+		code, domain,
+	)
 }
 
 func (r *postgresURLRepository) GetByID(ctx context.Context, id string) (*model.URL, error) {
-	var url model.URL
+	return r.getURL(ctx,
+		"SELECT "+urlColumns+" FROM "+urlFrom+" WHERE u.id = $1 AND u.deleted_at IS NULL",
+		id,
+	)
+}
+
+func (r *postgresURLRepository) WorkspaceOf(ctx context.Context, id string) (string, error) {
+	var workspaceID string
 	err := r.pool.QueryRow(ctx,
-		"SELECT id, code, original_url, created_at, updated_at FROM urls WHERE id = $1",
+		"SELECT COALESCE(workspace_id::text, '') FROM urls WHERE id = $1",
 		id,
-	).Scan(&url.ID, &url.Code, &url.OriginalURL, &url.CreatedAt, &url.UpdatedAt)
+	).Scan(&workspaceID)
+	return workspaceID, mapError(err, "url")
+}
+
+func (r *postgresURLRepository) List(ctx context.Context, opts model.ListOptions) ([]model.URL, error) {
+	where := "u.deleted_at IS NULL"
+	if opts.Deleted {
+		where = "u.deleted_at IS NOT NULL"
+	}
+	query := "SELECT " + urlColumns + " FROM " + urlFrom + " WHERE " + where
+	args := []any{opts.Limit}
+	switch {
+	case opts.AllWorkspaces:
+	case opts.Workspace == "":
+		query += " AND u.workspace_id IS NULL"
+	default:
+		args = append(args, opts.Workspace)
+		query += fmt.Sprintf(" AND u.workspace_id = $%d", len(args))
+	}
+	if opts.Broken {
+		query += " AND u.broken"
+	}
+	if opts.Search != "" {
+		args = append(args, "%"+escapeLike(opts.Search)+"%")
+		n := len(args)
+		query += fmt.Sprintf(" AND (u.code ILIKE $%[1]d OR u.original_url ILIKE $%[1]d"+
+			" OR u.title ILIKE $%[1]d OR u.description ILIKE $%[1]d OR u.metadata::text ILIKE $%[1]d)", n)
+	}
+	if len(opts.Metadata) > 0 {
+		args = append(args, opts.Metadata)
+		query += fmt.Sprintf(" AND u.metadata @> $%d::jsonb", len(args))
+	}
+	if len(opts.Tags) > 0 {
+		args = append(args, opts.Tags)
+		n := len(args)
+		query += fmt.Sprintf(" AND (SELECT COUNT(*) FROM url_tags ut JOIN tags t ON t.id = ut.tag_id"+
+			" WHERE ut.url_id = u.id AND t.name = ANY($%d)) = cardinality($%d::text[])", n, n)
+	}
+	if opts.Campaign != "" {
+		args = append(args, opts.Campaign)
+		query += fmt.Sprintf(" AND u.campaign_id = (SELECT id FROM campaigns WHERE name = $%d)", len(args))
+	}
+	if opts.After != nil {
+		n := len(args)
+		query += fmt.Sprintf(" AND (u.created_at, u.id) < ($%d, $%d::uuid)", n+1, n+2)
+		args = append(args, opts.After.CreatedAt, opts.After.ID)
+	}
+	query += " ORDER BY u.created_at DESC, u.id DESC LIMIT $1"
+
+	rows, err := r.reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	return scanURLs(rows)
+}
+
+// likeEscaper escapes the LIKE metacharacters so user input only ever
+// matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func (r *postgresURLRepository) Update(ctx context.Context, url *model.URL) error {
+	utm := url.UTMParams
+	if utm == nil {
+		utm = map[string]string{}
+	}
+	metadata := metadataOf(url)
+	preview := previewOf(url)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Lock the link while it changes, so the audit log's before snapshot
+	// is the state the update replaces.
+	before, err := scanURL(tx.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM "+urlFrom+" WHERE u.id = $1 AND u.deleted_at IS NULL FOR UPDATE OF u",
+		url.ID,
+	))
+	if err != nil {
+		return err
+	}
+	campaignID, err := upsertCampaign(ctx, tx, url.Campaign)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	err = tx.QueryRow(ctx,
+		`UPDATE urls SET original_url = $2, forward_query = $3, query_precedence = $4, utm_params = $5, bot_policy = $6,
+		                 preview_title = $7, preview_description = $8, preview_image_url = $9, campaign_id = $10,
+		                 title = $11, description = $12, metadata = $13, max_clicks = $14, updated_at = NOW(),
+		                 -- A new destination has not been checked yet.
+		                 checked_at = CASE WHEN original_url = $2 THEN checked_at END,
+		                 check_status = CASE WHEN original_url = $2 THEN check_status END,
+		                 check_latency_ms = CASE WHEN original_url = $2 THEN check_latency_ms END,
+		                 check_error = CASE WHEN original_url = $2 THEN check_error END,
+		                 broken = broken AND original_url = $2,
+		                 next_check_at = CASE WHEN original_url = $2 THEN next_check_at END
+		 WHERE id = $1 AND deleted_at IS NULL RETURNING updated_at, clicks_used`,
+		url.ID, url.OriginalURL, url.ForwardQuery, url.QueryPrecedence, utm, botPolicy(url),
+		preview.Title, preview.Description, preview.ImageURL, campaignID,
+		url.Title, url.Description, metadata, url.MaxClicks,
+	).Scan(&url.UpdatedAt, &url.ClicksUsed)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	if err := setTags(ctx, tx, url.ID, url.Tags); err != nil {
+		return mapError(err, "url")
+	}
+	if err := insertOutbox(ctx, tx, model.EventLinkUpdated, url); err != nil {
+		return mapError(err, "url")
+	}
+	if err := insertAudit(ctx, tx, model.AuditUpdate, before, url); err != nil {
+		return mapError(err, "url")
+	}
+	return mapError(tx.Commit(ctx), "url")
+}
+
+// setDeleted flips deleted_at on the link with the given ID when it is
+// currently in the opposite state, recording event in the outbox and
+// action in the audit log.
+func (r *postgresURLRepository) setDeleted(ctx context.Context, id string, deleted bool, event, action string) (*model.URL, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE urls SET deleted_at = CASE WHEN $2::boolean THEN NOW() END
+		 WHERE id = $1 AND (deleted_at IS NULL) = $2`,
+		id, deleted,
+	)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, apperr.NotFound("url not found")
+	}
+	// Read the link back through the join so Domain is in the event and
+	// the audit snapshot.
+	url, err := scanURL(tx.QueryRow(ctx, "SELECT "+urlColumns+" FROM "+urlFrom+" WHERE u.id = $1", id))
 	if err != nil {
 		return nil, err
 	}
-	return &url, nil
+	if err := insertOutbox(ctx, tx, event, url); err != nil {
+		return nil, mapError(err, "url")
+	}
+	before, after := url, (*model.URL)(nil)
+	if !deleted {
+		before, after = after, before
+	}
+	if err := insertAudit(ctx, tx, action, before, after); err != nil {
+		return nil, mapError(err, "url")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, mapError(err, "url")
+	}
+	return url, nil
 }
 
 func (r *postgresURLRepository) Delete(ctx context.Context, id string) error {
-	result, err := r.pool.Exec(ctx, "DELETE FROM urls WHERE id = $1", id)
+	_, err := r.setDeleted(ctx, id, true, model.EventLinkDeleted, model.AuditDelete)
+	return err
+}
+
+func (r *postgresURLRepository) Restore(ctx context.Context, id string) (*model.URL, error) {
+	return r.setDeleted(ctx, id, false, model.EventLinkRestored, model.AuditRestore)
+}
+
+func (r *postgresURLRepository) Import(ctx context.Context, urls []model.URL) ([]int, error) {
+	n := len(urls)
+	codes := make([]string, n)
+	domainIDs := make([]*string, n)
+	originals := make([]string, n)
+	forwards := make([]bool, n)
+	precedences := make([]string, n)
+	utms := make([]string, n)
+	botPolicies := make([]string, n)
+	previewTitles := make([]string, n)
+	previewDescriptions := make([]string, n)
+	previewImages := make([]string, n)
+	campaigns := make([]string, n)
+	titles := make([]string, n)
+	descriptions := make([]string, n)
+	metadatas := make([]string, n)
+	maxClicks := make([]int64, n)
+	clicksUsed := make([]int64, n)
+	createdAts := make([]*time.Time, n)
+	workspaceIDs := make([]*string, n)
+	for i := range urls {
+		u := &urls[i]
+		codes[i], domainIDs[i], originals[i] = u.Code, u.DomainID, u.OriginalURL
+		forwards[i], precedences[i] = u.ForwardQuery, u.QueryPrecedence
+		utm, err := json.Marshal(u.UTMParams)
+		if err != nil || u.UTMParams == nil {
+			utm = []byte("{}")
+		}
+		utms[i] = string(utm)
+		botPolicies[i] = botPolicy(u)
+		preview := previewOf(u)
+		previewTitles[i], previewDescriptions[i], previewImages[i] = preview.Title, preview.Description, preview.ImageURL
+		campaigns[i] = u.Campaign
+		titles[i], descriptions[i] = u.Title, u.Description
+		metadata, err := json.Marshal(metadataOf(u))
+		if err != nil {
+			return nil, apperr.Invalid("invalid metadata: %v", err)
+		}
+		metadatas[i] = string(metadata)
+		maxClicks[i], clicksUsed[i] = u.MaxClicks, u.ClicksUsed
+		if !u.CreatedAt.IsZero() {
+			createdAts[i] = &u.CreatedAt
+		}
+		workspaceIDs[i] = u.WorkspaceID
+	}
+
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, mapError(err, "url")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Campaigns are created first so the insert can look them up by name.
+	_, err = tx.Exec(ctx,
+		`INSERT INTO campaigns (name) SELECT DISTINCT name FROM unnest($1::text[]) AS t(name)
+		 WHERE name <> '' ON CONFLICT (name) DO NOTHING`,
+		campaigns,
+	)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	rows, err := tx.Query(ctx,
+		`INSERT INTO urls (code, domain_id, original_url, forward_query, query_precedence, utm_params, bot_policy,
+		                   preview_title, preview_description, preview_image_url, campaign_id,
+		                   title, description, metadata, max_clicks, clicks_used, workspace_id, created_at, updated_at)
+		 SELECT code, domain_id::uuid, original_url, forward_query, query_precedence, utm_params::jsonb, bot_policy,
+		        preview_title, preview_description, preview_image_url,
+		        (SELECT c.id FROM campaigns c WHERE c.name = t.campaign),
+		        title, description, metadata::jsonb, max_clicks, clicks_used, workspace_id::uuid,
+		        COALESCE(created_at, NOW()), COALESCE(created_at, NOW())
+		 FROM unnest($1::text[], $2::text[], $3::text[], $4::bool[], $5::text[], $6::text[], $7::text[],
+		             $8::text[], $9::text[], $10::text[], $11::text[], $12::text[], $13::text[], $14::text[],
+		             $15::bigint[], $16::bigint[], $17::timestamptz[], $18::text[])
+		   AS t(code, domain_id, original_url, forward_query, query_precedence, utm_params, bot_policy,
+		        preview_title, preview_description, preview_image_url, campaign,
+		        title, description, metadata, max_clicks, clicks_used, created_at, workspace_id)
+		 ON CONFLICT DO NOTHING
+		 RETURNING id, COALESCE(domain_id::text, ''), code`,
+		codes, domainIDs, originals, forwards, precedences, utms, botPolicies,
+		previewTitles, previewDescriptions, previewImages, campaigns,
+		titles, descriptions, metadatas, maxClicks, clicksUsed, createdAts, workspaceIDs,
+	)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+
+	inserted := map[string]string{}
+	for rows.Next() {
+		var id, domainID, code string
+		if err := rows.Scan(&id, &domainID, &code); err != nil {
+			rows.Close()
+			return nil, mapError(err, "url")
+		}
+		inserted[domainID+"/"+code] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err, "url")
+	}
+
+	// A code repeated within the batch is inserted once; the first
+	// occurrence claims it and later ones are conflicts.
+	var conflicts []int
+	var insertedIDs, taggedIDs []string
+	var tags [][]string
+	for i := range urls {
+		key := "/" + codes[i]
+		if domainIDs[i] != nil {
+			key = *domainIDs[i] + key
+		}
+		id, ok := inserted[key]
+		if !ok {
+			conflicts = append(conflicts, i)
+			continue
+		}
+		delete(inserted, key)
+		insertedIDs = append(insertedIDs, id)
+		if len(urls[i].Tags) > 0 {
+			taggedIDs, tags = append(taggedIDs, id), append(tags, urls[i].Tags)
+		}
+	}
+	if err := insertTags(ctx, tx, taggedIDs, tags); err != nil {
+		return nil, mapError(err, "url")
+	}
+
+	// Audit the links as stored, tags and all.
+	rows, err = tx.Query(ctx, "SELECT "+urlColumns+" FROM "+urlFrom+" WHERE u.id = ANY($1)", insertedIDs)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	created, err := scanURLs(rows)
+	if err != nil {
+		return nil, err
 	}
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("url with id %s not found", id)
+	for i := range created {
+		if err := insertAudit(ctx, tx, model.AuditCreate, nil, &created[i]); err != nil {
+			return nil, mapError(err, "url")
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, mapError(err, "url")
+	}
+	return conflicts, nil
+}
+
+func (r *postgresURLRepository) SetDisabled(ctx context.Context, id, reason string) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE urls SET disabled_at = CASE WHEN $2 = '' THEN NULL ELSE COALESCE(disabled_at, NOW()) END,
+		                 disabled_reason = NULLIF($2, '')
+		 WHERE id = $1 AND deleted_at IS NULL`,
+		id, reason,
+	)
+	if err != nil {
+		return mapError(err, "url")
+	}
+	if tag.RowsAffected() == 0 {
+		return apperr.NotFound("url not found")
 	}
 	return nil
 }
+
+func (r *postgresURLRepository) NextCodeID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx, "SELECT nextval('url_code_seq')").Scan(&id)
+	return id, mapError(err, "code sequence")
+}
+
+// EnableCaseInsensitiveCodes creates idx_urls_domain_lower_code, the unique
+// index on lower-cased codes that case-insensitive lookups rely on. Codes
+// that differ only in case would break it, so when there are any it
+// returns them instead and leaves the table as it is; once they are renamed
+// or deleted, calling it again creates the index. Dropping the index turns
+// the check back on, at the next call.
+func EnableCaseInsensitiveCodes(ctx context.Context, pool *pgxpool.Pool) ([]model.CodeCollision, error) {
+	var exists bool
+	err := pool.QueryRow(ctx, "SELECT to_regclass('idx_urls_domain_lower_code') IS NOT NULL").Scan(&exists)
+	if err != nil || exists {
+		return nil, mapError(err, "code index")
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, mapError(err, "code index")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// The lock holds off new links between the check and the index, and
+	// queues other instances starting up behind this one.
+	if _, err := tx.Exec(ctx, "LOCK TABLE urls IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return nil, mapError(err, "code index")
+	}
+	rows, err := tx.Query(ctx,
+		`SELECT COALESCE(d.host, ''), array_agg(u.code ORDER BY u.code)
+		 FROM urls u LEFT JOIN domains d ON d.id = u.domain_id
+		 GROUP BY u.domain_id, d.host, lower(u.code)
+		 HAVING count(*) > 1
+		 ORDER BY 1, 2`,
+	)
+	if err != nil {
+		return nil, mapError(err, "code index")
+	}
+	defer rows.Close()
+
+	var collisions []model.CodeCollision
+	for rows.Next() {
+		var c model.CodeCollision
+		if err := rows.Scan(&c.Domain, &c.Codes); err != nil {
+			return nil, mapError(err, "code index")
+		}
+		collisions = append(collisions, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err, "code index")
+	}
+	if len(collisions) > 0 {
+		return collisions, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_domain_lower_code ON urls (COALESCE(domain_id, "+noDomain+"), lower(code))")
+	if err != nil {
+		return nil, mapError(err, "code index")
+	}
+	return nil, mapError(tx.Commit(ctx), "code index")
+}
+
+func (r *postgresURLRepository) ConsumeClick(ctx context.Context, id string) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, mapError(err, "url")
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var used, limit int64
+	// Concurrent updates of the row queue on its lock and re-check the
+	// condition against the committed count, so no two can take the last
+	// click.
+	err = tx.QueryRow(ctx,
+		`UPDATE urls SET clicks_used = clicks_used + 1
+		 WHERE id = $1 AND deleted_at IS NULL AND (max_clicks = 0 OR clicks_used < max_clicks)
+		 RETURNING clicks_used, max_clicks`,
+		id,
+	).Scan(&used, &limit)
+	if err == nil {
+		// Only the click that takes the last one announces it.
+		if limit > 0 && used == limit {
+			url, err := scanURL(tx.QueryRow(ctx, "SELECT "+urlColumns+" FROM "+urlFrom+" WHERE u.id = $1", id))
+			if err != nil {
+				return 0, err
+			}
+			if err := insertOutbox(ctx, tx, model.EventLinkClickLimitReached, url); err != nil {
+				return 0, mapError(err, "url")
+			}
+		}
+		return used, mapError(tx.Commit(ctx), "url")
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, mapError(err, "url")
+	}
+
+	// Tell a used-up link from one that is gone altogether.
+	var exists bool
+	err = r.pool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM urls WHERE id = $1 AND deleted_at IS NULL)", id,
+	).Scan(&exists)
+	switch {
+	case err != nil:
+		return 0, mapError(err, "url")
+	case !exists:
+		return 0, apperr.NotFound("url not found")
+	default:
+		return 0, apperr.Gone("link has reached its click limit")
+	}
+}
+
+func (r *postgresURLRepository) ClaimDueChecks(ctx context.Context, limit int, lease time.Duration) ([]model.URL, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH due AS (
+			SELECT id FROM urls
+			WHERE deleted_at IS NULL AND disabled_at IS NULL
+			  AND (next_check_at IS NULL OR next_check_at <= NOW())
+			ORDER BY next_check_at NULLS FIRST
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE urls u SET next_check_at = NOW() + $2::interval
+			FROM due WHERE u.id = due.id
+			RETURNING u.*
+		)
+		SELECT `+urlColumns+` FROM claimed u LEFT JOIN domains d ON d.id = u.domain_id`,
+		limit, lease,
+	)
+	if err != nil {
+		return nil, mapError(err, "url")
+	}
+	return scanURLs(rows)
+}
+
+func (r *postgresURLRepository) RecordCheck(ctx context.Context, id string, health *model.LinkHealth, next time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE urls SET checked_at = $2, check_status = $3, check_latency_ms = $4, check_error = NULLIF($5, ''),
+		                 broken = $6, next_check_at = $7
+		 WHERE id = $1`,
+		id, health.CheckedAt, health.StatusCode, health.LatencyMS, health.Error, health.Broken, next,
+	)
+	return mapError(err, "url")
+}
+
+func (r *postgresURLRepository) Groups(ctx context.Context, kind, workspaceID string) ([]model.LinkGroup, error) {
+	var query string
+	switch kind {
+	case model.GroupTag:
+		query = `SELECT t.name, COUNT(*) FROM tags t
+		         JOIN url_tags ut ON ut.tag_id = t.id JOIN urls u ON u.id = ut.url_id
+		         WHERE u.deleted_at IS NULL AND u.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid
+		         GROUP BY t.name ORDER BY t.name`
+	case model.GroupCampaign:
+		query = `SELECT c.name, COUNT(*) FROM campaigns c JOIN urls u ON u.campaign_id = c.id
+		         WHERE u.deleted_at IS NULL AND u.workspace_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid
+		         GROUP BY c.name ORDER BY c.name`
+	default:
+		return nil, apperr.Invalid("invalid group %q", kind)
+	}
+	rows, err := r.pool.Query(ctx, query, workspaceID)
+	if err != nil {
+		return nil, mapError(err, kind)
+	}
+	defer rows.Close()
+
+	groups := []model.LinkGroup{}
+	for rows.Next() {
+		var g model.LinkGroup
+		if err := rows.Scan(&g.Name, &g.Links); err != nil {
+			return nil, mapError(err, kind)
+		}
+		groups = append(groups, g)
+	}
+	return groups, mapError(rows.Err(), kind)
+}
@@ -2,11 +2,22 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"net"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/apperr"
 	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/replica"
+	"github.com/rs/zerolog"
 )
 
 var testPool *pgxpool.Pool
@@ -27,18 +38,19 @@ func TestMain(m *testing.M) {
 	defer testPool.Close()
 
 	// Run migrations
-	_, err = testPool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS urls (
-			id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
-			code        VARCHAR(20)  NOT NULL UNIQUE,
-			original_url TEXT        NOT NULL,
-			created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
-			updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
-		);
-		CREATE INDEX IF NOT EXISTS idx_urls_code ON urls (code);
-	`)
+	files, err := filepath.Glob("../../migrations/*.up.sql")
 	if err != nil {
-		panic("failed to run migrations: " + err.Error())
+		panic("failed to find migrations: " + err.Error())
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			panic("failed to read migration: " + err.Error())
+		}
+		if _, err := testPool.Exec(ctx, string(sql)); err != nil {
+			panic("failed to run migrations: " + err.Error())
+		}
 	}
 
 	os.Exit(m.Run())
@@ -90,8 +102,8 @@ func TestCreate_DuplicateCode(t *testing.T) {
 	}
 
 	err := repo.Create(ctx, url2)
-	if err == nil {
-		t.Fatal("expected error for duplicate code, got nil")
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Fatalf("expected conflict for duplicate code, got %v", err)
 	}
 }
 
@@ -105,7 +117,7 @@ func TestGetByCode_Success(t *testing.T) {
 		t.Fatalf("create failed: %v", err)
 	}
 
-	result, err := repo.GetByCode(ctx, "find123")
+	result, err := repo.GetByCode(ctx, "", "find123")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -120,13 +132,168 @@ func TestGetByCode_Success(t *testing.T) {
 	}
 }
 
+// staleReplica is a replica that has not yet received any link.
+type staleReplica struct{ replica.DB }
+
+type noRow struct{}
+
+func (noRow) Scan(...any) error { return pgx.ErrNoRows }
+
+func (r staleReplica) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "FROM urls") {
+		return noRow{}
+	}
+	return r.DB.QueryRow(ctx, sql, args...)
+}
+
+func TestGetByCode_ReplicaMissFallsBackToPrimary(t *testing.T) {
+	cleanupURLs(t)
+	ctx := context.Background()
+	replicas := replica.NewSet(testPool, zerolog.Nop())
+	replicas.Add("stale", staleReplica{testPool})
+	replicas.CheckOnce(ctx)
+	repo := NewPostgresURLRepository(testPool, WithReplicas(replicas))
+
+	url := &model.URL{Code: "fresh1", OriginalURL: "https://example.com"}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+
+	got, err := repo.GetByCode(ctx, "", "fresh1")
+	if err != nil {
+		t.Fatalf("expected the link from the primary, got %v", err)
+	}
+	if got.ID != url.ID {
+		t.Errorf("expected ID %s, got %s", url.ID, got.ID)
+	}
+	if _, err := repo.GetByCode(ctx, "", "missing"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// downReplica is a replica whose connection broke after its last check.
+type downReplica struct{ replica.DB }
+
+type brokenRow struct{}
+
+func (brokenRow) Scan(...any) error {
+	return &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+}
+
+func (r downReplica) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "FROM urls") {
+		return brokenRow{}
+	}
+	return r.DB.QueryRow(ctx, sql, args...)
+}
+
+func TestGetByCode_ReplicaDownFallsBackToPrimary(t *testing.T) {
+	cleanupURLs(t)
+	ctx := context.Background()
+	replicas := replica.NewSet(testPool, zerolog.Nop())
+	replicas.Add("down", downReplica{testPool})
+	replicas.CheckOnce(ctx)
+	repo := NewPostgresURLRepository(testPool, WithReplicas(replicas))
+
+	url := &model.URL{Code: "down1", OriginalURL: "https://example.com"}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("failed to create: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, url.ID)
+	if err != nil {
+		t.Fatalf("expected the link from the primary, got %v", err)
+	}
+	if got.ID != url.ID {
+		t.Errorf("expected ID %s, got %s", url.ID, got.ID)
+	}
+	if _, err := repo.GetByCode(ctx, "", "missing"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEnableCaseInsensitiveCodes(t *testing.T) {
+	cleanupURLs(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		_, _ = testPool.Exec(ctx, "DROP INDEX IF EXISTS idx_urls_domain_lower_code")
+	})
+	repo := NewPostgresURLRepository(testPool, WithCaseInsensitiveCodes())
+
+	upper := &model.URL{Code: "AbC123", OriginalURL: "https://example.com"}
+	lower := &model.URL{Code: "abc123", OriginalURL: "https://other.com"}
+	for _, u := range []*model.URL{upper, lower} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("failed to create: %v", err)
+		}
+	}
+
+	collisions, err := EnableCaseInsensitiveCodes(ctx, testPool)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(collisions) != 1 || collisions[0].Domain != "" ||
+		strings.Join(collisions[0].Codes, ",") != "AbC123,abc123" {
+		t.Fatalf("expected AbC123 and abc123 to collide, got %+v", collisions)
+	}
+
+	if _, err := testPool.Exec(ctx, "DELETE FROM urls WHERE id = $1", lower.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if collisions, err := EnableCaseInsensitiveCodes(ctx, testPool); err != nil || len(collisions) != 0 {
+		t.Fatalf("expected the index to be created, got %+v, %v", collisions, err)
+	}
+
+	got, err := repo.GetByCode(ctx, "", "ABC123")
+	if err != nil {
+		t.Fatalf("expected a case-insensitive match, got %v", err)
+	}
+	if got.ID != upper.ID {
+		t.Errorf("expected ID %s, got %s", upper.ID, got.ID)
+	}
+	if err := repo.Create(ctx, &model.URL{Code: "abc123", OriginalURL: "https://other.com"}); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected conflict for a code differing in case, got %v", err)
+	}
+}
+
 func TestGetByCode_NotFound(t *testing.T) {
 	cleanupURLs(t)
 	repo := NewPostgresURLRepository(testPool)
 
-	_, err := repo.GetByCode(context.Background(), "nonexist")
-	if err == nil {
-		t.Fatal("expected error for missing code, got nil")
+	_, err := repo.GetByCode(context.Background(), "", "nonexist")
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected not found for missing code, got %v", err)
+	}
+}
+
+func TestGetByCode_QueryPolicy(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	original := &model.URL{
+		Code:            "utm1234",
+		OriginalURL:     "https://example.com",
+		ForwardQuery:    true,
+		QueryPrecedence: model.QueryPrecedenceDestination,
+		UTMParams:       map[string]string{"utm_source": "newsletter"},
+	}
+	if err := repo.Create(ctx, original); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	result, err := repo.GetByCode(ctx, "", "utm1234")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.ForwardQuery {
+		t.Error("expected ForwardQuery to be true")
+	}
+	if result.QueryPrecedence != model.QueryPrecedenceDestination {
+		t.Errorf("expected precedence destination, got %s", result.QueryPrecedence)
+	}
+	if result.UTMParams["utm_source"] != "newsletter" {
+		t.Errorf("expected utm_source newsletter, got %v", result.UTMParams)
 	}
 }
 
@@ -189,7 +356,448 @@ func TestDelete_NotFound(t *testing.T) {
 	repo := NewPostgresURLRepository(testPool)
 
 	err := repo.Delete(context.Background(), "00000000-0000-0000-0000-000000000000")
-	if err == nil {
-		t.Fatal("expected error for missing ID, got nil")
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected not found for missing ID, got %v", err)
+	}
+}
+
+func TestDelete_MalformedID(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+
+	err := repo.Delete(context.Background(), "abc")
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected not found for malformed ID, got %v", err)
+	}
+}
+
+func TestList_Keyset(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	for _, code := range []string{"list1", "list2", "list3"} {
+		if err := repo.Create(ctx, &model.URL{Code: code, OriginalURL: "https://example.com/" + code}); err != nil {
+			t.Fatalf("failed to create %s: %v", code, err)
+		}
+	}
+
+	first, err := repo.List(ctx, model.ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(first) != 2 || first[0].Code != "list3" || first[1].Code != "list2" {
+		t.Fatalf("expected list3, list2 first, got %+v", first)
+	}
+
+	last := first[len(first)-1]
+	rest, err := repo.List(ctx, model.ListOptions{Limit: 2, After: &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rest) != 1 || rest[0].Code != "list1" {
+		t.Errorf("expected list1 on the second page, got %+v", rest)
+	}
+}
+
+func TestList_SearchAndDeleted(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	live := &model.URL{Code: "srch1", OriginalURL: "https://example.com/100%_off"}
+	gone := &model.URL{Code: "srch2", OriginalURL: "https://example.com/other"}
+	for _, u := range []*model.URL{live, gone} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, gone.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	found, err := repo.List(ctx, model.ListOptions{Limit: 10, Search: "100%_OFF"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 1 || found[0].ID != live.ID {
+		t.Errorf("expected the live link, got %+v", found)
+	}
+	if found, _ := repo.List(ctx, model.ListOptions{Limit: 10, Search: "%"}); len(found) != 1 {
+		t.Errorf("expected %% to match literally, got %d links", len(found))
+	}
+
+	deleted, err := repo.List(ctx, model.ListOptions{Limit: 10, Deleted: true, Search: "srch"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != gone.ID {
+		t.Errorf("expected only the deleted link, got %+v", deleted)
+	}
+}
+
+func TestList_TagsAndCampaign(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	both := &model.URL{Code: "grp1", OriginalURL: "https://example.com/1", Campaign: "Spring", Tags: []string{"mail", "promo"}}
+	promo := &model.URL{Code: "grp2", OriginalURL: "https://example.com/2", Campaign: "Spring", Tags: []string{"promo"}}
+	other := &model.URL{Code: "grp3", OriginalURL: "https://example.com/3", Tags: []string{"mail"}}
+	for _, u := range []*model.URL{both, promo, other} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, both.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Campaign != "Spring" || len(got.Tags) != 2 || got.Tags[0] != "mail" || got.Tags[1] != "promo" {
+		t.Errorf("expected campaign and tags to round-trip, got %q %v", got.Campaign, got.Tags)
+	}
+
+	tagged, err := repo.List(ctx, model.ListOptions{Limit: 10, Tags: []string{"mail", "promo"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != both.ID {
+		t.Errorf("expected only the link with both tags, got %+v", tagged)
+	}
+	inCampaign, err := repo.List(ctx, model.ListOptions{Limit: 10, Campaign: "Spring"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(inCampaign) != 2 {
+		t.Errorf("expected 2 links in the campaign, got %d", len(inCampaign))
+	}
+
+	tags, err := repo.Groups(ctx, model.GroupTag, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := []model.LinkGroup{{Name: "mail", Links: 2}, {Name: "promo", Links: 2}}
+	if len(tags) != 2 || tags[0] != want[0] || tags[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+
+	// Leaving the campaign and dropping a tag are reflected straight away.
+	both.Campaign, both.Tags = "", []string{"mail"}
+	if err := repo.Update(ctx, both); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, both.ID)
+	if got.Campaign != "" || len(got.Tags) != 1 || got.Tags[0] != "mail" {
+		t.Errorf("expected the update to replace the groups, got %q %v", got.Campaign, got.Tags)
+	}
+}
+
+func TestList_Notes(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	noted := &model.URL{
+		Code:        "note1",
+		OriginalURL: "https://example.com/1",
+		Title:       "Spring newsletter",
+		Description: "Requested by the growth team",
+		Metadata:    map[string]any{"owner": "alice", "ticket": float64(42)},
+	}
+	plain := &model.URL{Code: "note2", OriginalURL: "https://example.com/2"}
+	for _, u := range []*model.URL{noted, plain} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, noted.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Title != noted.Title || got.Description != noted.Description ||
+		got.Metadata["owner"] != "alice" || got.Metadata["ticket"] != float64(42) {
+		t.Errorf("expected notes to round-trip, got %q %q %v", got.Title, got.Description, got.Metadata)
+	}
+	if got, _ := repo.GetByID(ctx, plain.ID); got.Metadata != nil {
+		t.Errorf("expected nil metadata, got %v", got.Metadata)
+	}
+
+	for _, search := range []string{"NEWSLETTER", "growth team", "alice"} {
+		found, err := repo.List(ctx, model.ListOptions{Limit: 10, Search: search})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 1 || found[0].ID != noted.ID {
+			t.Errorf("search %q: expected the noted link, got %+v", search, found)
+		}
+	}
+	found, err := repo.List(ctx, model.ListOptions{Limit: 10, Metadata: map[string]string{"owner": "alice"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 1 || found[0].ID != noted.ID {
+		t.Errorf("expected the metadata filter to match the noted link, got %+v", found)
+	}
+	if found, _ := repo.List(ctx, model.ListOptions{Limit: 10, Metadata: map[string]string{"owner": "bob"}}); len(found) != 0 {
+		t.Errorf("expected no links for another owner, got %d", len(found))
+	}
+
+	noted.Title, noted.Metadata = "", nil
+	if err := repo.Update(ctx, noted); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, noted.ID)
+	if got.Title != "" || got.Description == "" || got.Metadata != nil {
+		t.Errorf("expected the update to clear title and metadata only, got %q %q %v", got.Title, got.Description, got.Metadata)
+	}
+}
+
+func TestUpdate_Success(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	url := &model.URL{Code: "upd1234", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	if got, _ := repo.GetByID(ctx, url.ID); got == nil || got.BotPolicy != model.BotPolicyRedirect {
+		t.Errorf("expected the redirect bot policy by default, got %+v", got)
+	}
+
+	url.OriginalURL = "https://example.org"
+	url.ForwardQuery = true
+	url.BotPolicy = model.BotPolicyPreview
+	url.Preview = &model.LinkPreview{Title: "Example", ImageURL: "https://example.org/card.png"}
+	if err := repo.Update(ctx, url); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, url.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.OriginalURL != "https://example.org" || !got.ForwardQuery || got.BotPolicy != model.BotPolicyPreview {
+		t.Errorf("expected update to be saved, got %+v", got)
+	}
+	if got.Preview == nil || *got.Preview != *url.Preview {
+		t.Errorf("expected preview %+v, got %+v", url.Preview, got.Preview)
+	}
+}
+
+func TestRestore_Success(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	url := &model.URL{Code: "res1234", OriginalURL: "https://example.com"}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := repo.Restore(ctx, url.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected not found restoring a live link, got %v", err)
+	}
+	if err := repo.Delete(ctx, url.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, url.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected not found deleting twice, got %v", err)
+	}
+
+	restored, err := repo.Restore(ctx, url.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if restored.Code != "res1234" {
+		t.Errorf("expected code res1234, got %s", restored.Code)
+	}
+	if _, err := repo.GetByCode(ctx, "", "res1234"); err != nil {
+		t.Errorf("expected restored link to resolve, got %v", err)
+	}
+}
+
+func TestImport_KeepsCodesAndReportsConflicts(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &model.URL{Code: "taken12", OriginalURL: "https://example.com"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	conflicts, err := repo.Import(ctx, []model.URL{
+		{Code: "imp1234", OriginalURL: "https://example.org", QueryPrecedence: model.QueryPrecedenceIncoming, CreatedAt: created},
+		{Code: "taken12", OriginalURL: "https://example.net", QueryPrecedence: model.QueryPrecedenceIncoming},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0] != 1 {
+		t.Errorf("expected conflict at index 1, got %v", conflicts)
+	}
+
+	got, err := repo.GetByCode(ctx, "", "imp1234")
+	if err != nil {
+		t.Fatalf("expected imported link, got %v", err)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Errorf("expected created_at %v, got %v", created, got.CreatedAt)
+	}
+}
+
+func TestConsumeClick_Concurrent(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	url := &model.URL{Code: "quota1", OriginalURL: "https://example.com", MaxClicks: 5}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	// Twenty concurrent redirects race for five clicks.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	served, gone := 0, 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ConsumeClick(ctx, url.ID)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				served++
+			case errors.Is(err, apperr.ErrGone):
+				gone++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if served != 5 || gone != 15 {
+		t.Errorf("expected 5 served and 15 refused, got %d and %d", served, gone)
+	}
+
+	got, err := repo.GetByID(ctx, url.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.ClicksUsed != 5 || !got.Exhausted() {
+		t.Errorf("expected an exhausted link with 5 clicks used, got %d", got.ClicksUsed)
+	}
+
+	// Raising the limit makes the link work again.
+	got.MaxClicks = 6
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if used, err := repo.ConsumeClick(ctx, url.ID); err != nil || used != 6 {
+		t.Errorf("expected the sixth click to be served, got %d, %v", used, err)
+	}
+
+	if err := repo.Delete(ctx, url.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := repo.ConsumeClick(ctx, url.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a deleted link, got %v", err)
+	}
+}
+
+func TestSetDisabled(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	url := &model.URL{Code: "dis1234", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	if err := repo.SetDisabled(ctx, url.ID, "malware"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, err := repo.GetByCode(ctx, "", "dis1234")
+	if err != nil {
+		t.Fatalf("expected disabled link to resolve, got %v", err)
+	}
+	if got.DisabledAt == nil || got.DisabledReason != "malware" {
+		t.Errorf("expected link disabled as malware, got %+v", got)
+	}
+
+	if err := repo.SetDisabled(ctx, url.ID, ""); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, err = repo.GetByID(ctx, url.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.DisabledAt != nil || got.DisabledReason != "" {
+		t.Errorf("expected link enabled, got %+v", got)
+	}
+
+	if err := repo.SetDisabled(ctx, "550e8400-e29b-41d4-a716-446655440000", "malware"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestLinkChecks(t *testing.T) {
+	cleanupURLs(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	ok := &model.URL{Code: "chk1234", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	dead := &model.URL{Code: "chk5678", OriginalURL: "https://example.org/gone", QueryPrecedence: model.QueryPrecedenceIncoming}
+	for _, u := range []*model.URL{ok, dead} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	due, err := repo.ClaimDueChecks(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("expected both links due, got %d", len(due))
+	}
+	if again, _ := repo.ClaimDueChecks(ctx, 10, time.Minute); len(again) != 0 {
+		t.Errorf("expected claimed links to be leased, got %d", len(again))
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	next := now.Add(time.Hour)
+	if err := repo.RecordCheck(ctx, ok.ID, &model.LinkHealth{StatusCode: 200, LatencyMS: 12, CheckedAt: now}, next); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := repo.RecordCheck(ctx, dead.ID, &model.LinkHealth{StatusCode: 404, Broken: true, CheckedAt: now}, next); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	broken, err := repo.List(ctx, model.ListOptions{Limit: 10, Broken: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(broken) != 1 || broken[0].ID != dead.ID || broken[0].Health == nil || broken[0].Health.StatusCode != 404 {
+		t.Fatalf("expected only the dead link, got %+v", broken)
+	}
+
+	// A new destination clears the old result.
+	dead.OriginalURL = "https://example.org/new"
+	if err := repo.Update(ctx, dead); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	got, err := repo.GetByID(ctx, dead.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Health != nil {
+		t.Errorf("expected health to be reset, got %+v", got.Health)
 	}
 }
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// webhookColumns lists the columns scanned by scanWebhook. Webhooks whose
+// owner's key belonged to no user have no owner.
+const webhookColumns = "id, url, secret, events, COALESCE(owner_user_id::text, ''), workspace_id, created_at"
+
+const deliveryColumns = "d.id, d.webhook_id, d.event, d.payload, d.attempts, d.status, d.response_code, " +
+	"d.error, d.next_attempt_at, d.created_at, d.updated_at, w.url, w.secret"
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	GetByID(ctx context.Context, id string) (*model.Webhook, error)
+	ListByOwner(ctx context.Context, userID string) ([]model.Webhook, error)
+	// Dispatch moves up to limit outbox events into per-webhook deliveries
+	// and returns how many events were dispatched. A webhook only gets the
+	// events of links its owner can read.
+	Dispatch(ctx context.Context, limit int) (int, error)
+	// ClaimDue leases up to limit pending deliveries whose next attempt is
+	// due, hiding them from other workers for lease.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	// RecordAttempt stores the outcome of a delivery attempt.
+	RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error)
+}
+
+type postgresWebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresWebhookRepository(pool *pgxpool.Pool) WebhookRepository {
+	return &postgresWebhookRepository{pool: pool}
+}
+
+// insertOutbox queues event for webhook delivery as part of tx.
+func insertOutbox(ctx context.Context, tx pgx.Tx, event string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s payload: %w", event, err)
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO webhook_outbox (event, payload) VALUES ($1, $2)", event, body)
+	return err
+}
+
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, &w.Events, &w.OwnerUserID, &w.WorkspaceID, &w.CreatedAt)
+	if err != nil {
+		return nil, mapError(err, "webhook")
+	}
+	return &w, nil
+}
+
+func (r *postgresWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO webhooks (url, secret, events, owner_user_id, workspace_id)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		webhook.URL, webhook.Secret, webhook.Events, webhook.OwnerUserID, webhook.WorkspaceID,
+	).Scan(&webhook.ID, &webhook.CreatedAt)
+	return mapError(err, "webhook")
+}
+
+func (r *postgresWebhookRepository) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	return scanWebhook(r.pool.QueryRow(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id))
+}
+
+func (r *postgresWebhookRepository) ListByOwner(ctx context.Context, userID string) ([]model.Webhook, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE owner_user_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return nil, mapError(err, "webhook")
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *w)
+	}
+	return webhooks, mapError(rows.Err(), "webhook")
+}
+
+func (r *postgresWebhookRepository) Dispatch(ctx context.Context, limit int) (int, error) {
+	result, err := r.pool.Exec(ctx, `
+		WITH batch AS (
+			SELECT id, event, payload FROM webhook_outbox
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanout AS (
+			-- Payloads are links, so their workspace_id says who may
+			-- read them: members of that workspace, or everyone when
+			-- there is none. Membership is checked now rather than at
+			-- registration, so owners who leave stop receiving events.
+			INSERT INTO webhook_deliveries (webhook_id, event, payload)
+			SELECT w.id, b.event, b.payload
+			FROM batch b
+			JOIN webhooks w ON (cardinality(w.events) = 0 OR b.event = ANY (w.events))
+				AND w.owner_user_id IS NOT NULL
+				AND w.workspace_id IS NOT DISTINCT FROM (b.payload->>'workspace_id')::uuid
+			WHERE w.workspace_id IS NULL OR EXISTS (
+				SELECT 1 FROM memberships m
+				WHERE m.workspace_id = w.workspace_id AND m.user_id = w.owner_user_id
+			)
+		)
+		UPDATE webhook_outbox o SET dispatched_at = NOW()
+		FROM batch WHERE o.id = batch.id`,
+		limit,
+	)
+	if err != nil {
+		return 0, mapError(err, "webhook delivery")
+	}
+	return int(result.RowsAffected()), nil
+}
+
+func scanDeliveries(rows pgx.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		var d model.WebhookDelivery
+		err := rows.Scan(
+			&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Attempts, &d.Status, &d.ResponseCode,
+			&d.Error, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt, &d.Target, &d.Secret,
+		)
+		if err != nil {
+			return nil, mapError(err, "webhook delivery")
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, mapError(rows.Err(), "webhook delivery")
+}
+
+func (r *postgresWebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $2::interval
+			FROM due WHERE d.id = due.id
+			RETURNING d.*
+		)
+		SELECT `+deliveryColumns+` FROM claimed d JOIN webhooks w ON w.id = d.webhook_id`,
+		limit, lease,
+	)
+	if err != nil {
+		return nil, mapError(err, "webhook delivery")
+	}
+	return scanDeliveries(rows)
+}
+
+func (r *postgresWebhookRepository) RecordAttempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET attempts = $2, status = $3, response_code = $4, error = $5, next_attempt_at = $6, updated_at = NOW()
+		 WHERE id = $1`,
+		delivery.ID, delivery.Attempts, delivery.Status, delivery.ResponseCode, delivery.Error, delivery.NextAttemptAt,
+	)
+	return mapError(err, "webhook delivery")
+}
+
+func (r *postgresWebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]model.WebhookDelivery, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id "+
+			"WHERE d.webhook_id = $1 ORDER BY d.created_at DESC, d.id DESC LIMIT $2",
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, mapError(err, "webhook delivery")
+	}
+	return scanDeliveries(rows)
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func cleanupWebhooks(t *testing.T) {
+	t.Helper()
+	_, err := testPool.Exec(context.Background(), "DELETE FROM webhooks; DELETE FROM webhook_outbox")
+	if err != nil {
+		t.Fatalf("failed to clean webhook tables: %v", err)
+	}
+}
+
+func TestWebhookCreate_GetByID(t *testing.T) {
+	cleanupAccounts(t)
+	cleanupWebhooks(t)
+	repo := NewPostgresWebhookRepository(testPool)
+	ctx := context.Background()
+	owner := createUser(t, NewPostgresAccountRepository(testPool), "ada@example.com")
+
+	w := &model.Webhook{URL: "https://hooks.example.com", Secret: "s", Events: []string{model.EventLinkCreated}, OwnerUserID: owner.ID}
+	if err := repo.Create(ctx, w); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	result, err := repo.GetByID(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.Events) != 1 || result.Events[0] != model.EventLinkCreated {
+		t.Errorf("expected events [link.created], got %v", result.Events)
+	}
+	if result.OwnerUserID != owner.ID || result.WorkspaceID != nil {
+		t.Errorf("expected a webhook of the owner outside any workspace, got %+v", result)
+	}
+}
+
+func TestWebhookOutbox_DispatchAndDeliver(t *testing.T) {
+	cleanupAccounts(t)
+	cleanupWebhooks(t)
+	webhooks := NewPostgresWebhookRepository(testPool)
+	urls := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+	owner := createUser(t, NewPostgresAccountRepository(testPool), "ada@example.com")
+
+	all := &model.Webhook{URL: "https://all.example.com", Secret: "s", Events: []string{}, OwnerUserID: owner.ID}
+	deletes := &model.Webhook{URL: "https://deletes.example.com", Secret: "s", Events: []string{model.EventLinkDeleted}, OwnerUserID: owner.ID}
+	for _, w := range []*model.Webhook{all, deletes} {
+		if err := webhooks.Create(ctx, w); err != nil {
+			t.Fatalf("create webhook failed: %v", err)
+		}
+	}
+
+	url := &model.URL{Code: "hook123", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}
+	if err := urls.Create(ctx, url); err != nil {
+		t.Fatalf("create url failed: %v", err)
+	}
+	if err := urls.Delete(ctx, url.ID); err != nil {
+		t.Fatalf("delete url failed: %v", err)
+	}
+
+	n, err := webhooks.Dispatch(ctx, 10)
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 events dispatched, got %d", n)
+	}
+
+	due, err := webhooks.ClaimDue(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	// all receives both events, deletes only the delete.
+	if len(due) != 3 {
+		t.Fatalf("expected 3 deliveries, got %d", len(due))
+	}
+
+	again, err := webhooks.ClaimDue(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("second claim failed: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("expected leased deliveries to be hidden, got %d", len(again))
+	}
+
+	due[0].Attempts = 1
+	due[0].Status = model.DeliveryDelivered
+	due[0].ResponseCode = 200
+	if err := webhooks.RecordAttempt(ctx, &due[0]); err != nil {
+		t.Fatalf("record attempt failed: %v", err)
+	}
+
+	listed, err := webhooks.ListDeliveries(ctx, due[0].WebhookID, 10)
+	if err != nil {
+		t.Fatalf("list deliveries failed: %v", err)
+	}
+	found := false
+	for _, d := range listed {
+		if d.ID == due[0].ID && d.Status == model.DeliveryDelivered {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected recorded attempt to be listed as delivered")
+	}
+}
+
+func TestWebhookDispatch_OnlyReadableLinks(t *testing.T) {
+	cleanupAccounts(t)
+	cleanupWebhooks(t)
+	webhooks := NewPostgresWebhookRepository(testPool)
+	urls := NewPostgresURLRepository(testPool)
+	accounts := NewPostgresAccountRepository(testPool)
+	ctx := context.Background()
+
+	ada := createUser(t, accounts, "ada@example.com")
+	grace := createUser(t, accounts, "grace@example.com")
+	team := &model.Workspace{Name: "Team"}
+	if err := accounts.CreateWorkspace(ctx, team, ada.ID); err != nil {
+		t.Fatalf("create workspace failed: %v", err)
+	}
+
+	// grace's webhook on the workspace was registered while she was a
+	// member; she has since left.
+	adaTeam := &model.Webhook{URL: "https://ada.example.com", Secret: "s", Events: []string{}, OwnerUserID: ada.ID, WorkspaceID: &team.ID}
+	graceTeam := &model.Webhook{URL: "https://grace.example.com", Secret: "s", Events: []string{}, OwnerUserID: grace.ID, WorkspaceID: &team.ID}
+	graceOpen := &model.Webhook{URL: "https://open.example.com", Secret: "s", Events: []string{}, OwnerUserID: grace.ID}
+	for _, w := range []*model.Webhook{adaTeam, graceTeam, graceOpen} {
+		if err := webhooks.Create(ctx, w); err != nil {
+			t.Fatalf("create webhook failed: %v", err)
+		}
+	}
+
+	inTeam := &model.URL{Code: "team123", OriginalURL: "https://example.com", WorkspaceID: &team.ID}
+	open := &model.URL{Code: "open123", OriginalURL: "https://example.com"}
+	for _, u := range []*model.URL{inTeam, open} {
+		if err := urls.Create(ctx, u); err != nil {
+			t.Fatalf("create url failed: %v", err)
+		}
+	}
+
+	if _, err := webhooks.Dispatch(ctx, 10); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	due, err := webhooks.ClaimDue(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	got := map[string]bool{}
+	for _, d := range due {
+		got[d.WebhookID] = true
+	}
+	if len(due) != 2 || !got[adaTeam.ID] || !got[graceOpen.ID] {
+		t.Errorf("expected one delivery each to ada's workspace webhook and grace's open one, got %+v", due)
+	}
+}
+
+func TestConsumeClick_QueuesLimitReached(t *testing.T) {
+	cleanupURLs(t)
+	cleanupWebhooks(t)
+	repo := NewPostgresURLRepository(testPool)
+	ctx := context.Background()
+
+	url := &model.URL{Code: "limit1", OriginalURL: "https://example.com", MaxClicks: 2}
+	if err := repo.Create(ctx, url); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	// The second click uses the limit up; the third is refused.
+	for range 3 {
+		_, _ = repo.ConsumeClick(ctx, url.ID)
+	}
+
+	var events int
+	var code string
+	err := testPool.QueryRow(ctx,
+		"SELECT count(*), max(payload->>'code') FROM webhook_outbox WHERE event = $1",
+		model.EventLinkClickLimitReached,
+	).Scan(&events, &code)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if events != 1 || code != url.Code {
+		t.Errorf("expected one limit event for %s, got %d for %q", url.Code, events, code)
+	}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository"
+)
+
+// AccountService manages users, their workspaces and the invites into
+// them. Like URLService it authorizes against the caller in the context.
+type AccountService struct {
+	repo     repository.AccountRepository
+	tokens   TokenVerifier
+	adminKey string
+	now      func() time.Time
+}
+
+// TokenVerifier checks a bearer token from the OIDC identity provider and
+// says who it was issued to. sso.Provider implements it.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*model.Identity, error)
+}
+
+type AccountOption func(*AccountService)
+
+// WithTokenVerifier accepts bearer tokens from an identity provider as
+// well as API keys.
+func WithTokenVerifier(tokens TokenVerifier) AccountOption {
+	return func(s *AccountService) { s.tokens = tokens }
+}
+
+// WithAdminKey makes callers presenting adminKey, the key that also signs
+// in to the dashboard, the operator.
+func WithAdminKey(adminKey string) AccountOption {
+	return func(s *AccountService) { s.adminKey = adminKey }
+}
+
+func NewAccountService(repo repository.AccountRepository, opts ...AccountOption) *AccountService {
+	s := &AccountService{repo: repo, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// newToken returns a random hex token for an API key or invite.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Authenticate returns the caller apiKey identifies: the operator for the
+// admin key, or the user it belongs to, who is nil when it belongs to no
+// user.
+func (s *AccountService) Authenticate(ctx context.Context, apiKey string) (Caller, error) {
+	if apiKey == "" {
+		return Caller{}, nil
+	}
+	if s.adminKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(s.adminKey)) == 1 {
+		return Caller{Operator: true}, nil
+	}
+	u, err := s.repo.GetUserByKeyHash(ctx, HashAPIKey(apiKey))
+	if errors.Is(err, apperr.ErrNotFound) {
+		return Caller{}, nil
+	}
+	return Caller{User: u}, err
+}
+
+// AuthenticateToken returns the caller a bearer token from the identity
+// provider identifies, creating their user on first sight. Their groups
+// may make them the operator or grant them workspace roles.
+func (s *AccountService) AuthenticateToken(ctx context.Context, token string) (Caller, error) {
+	if s.tokens == nil {
+		return Caller{}, apperr.Unauthorized("bearer tokens are not accepted, use an API key")
+	}
+	id, err := s.tokens.Verify(ctx, token)
+	if err != nil {
+		return Caller{}, apperr.Unauthorized("invalid bearer token: %v", err)
+	}
+	user, err := s.ExternalUser(ctx, id)
+	if err != nil {
+		return Caller{}, err
+	}
+	return Caller{User: user, Operator: id.Operator, Roles: id.Roles}, nil
+}
+
+// ExternalUser returns the user for an identity from the identity
+// provider, creating them on first sight.
+func (s *AccountService) ExternalUser(ctx context.Context, id *model.Identity) (*model.User, error) {
+	email, err := cleanEmail(id.Email)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(id.Name)
+	if r := []rune(name); len(r) > model.MaxUserNameLength {
+		name = string(r[:model.MaxUserNameLength])
+	}
+	external := *id
+	external.Email, external.Name = email, name
+	return s.repo.ExternalUser(ctx, &external)
+}
+
+// SignUp creates a user. The returned user carries their generated API
+// key, which is not shown again.
+func (s *AccountService) SignUp(ctx context.Context, req model.SignUpRequest) (*model.User, error) {
+	email, err := cleanEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if n := utf8.RuneCountInString(name); n > model.MaxUserNameLength {
+		return nil, apperr.Invalid("name is %d characters, the limit is %d", n, model.MaxUserNameLength)
+	}
+	key, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	u := &model.User{Email: email, Name: name, APIKey: key, APIKeyHash: HashAPIKey(key)}
+	if err := s.repo.CreateUser(ctx, u); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			return nil, apperr.Conflict("a user with email %q already exists", email)
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// cleanEmail lower-cases a bare email address, rejecting anything else.
+func cleanEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > model.MaxEmailLength {
+		return "", apperr.Invalid("invalid email %q", email)
+	}
+	return email, nil
+}
+
+// CreateWorkspace creates a workspace with the caller as its admin.
+func (s *AccountService) CreateWorkspace(ctx context.Context, req model.WorkspaceRequest) (*model.Workspace, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperr.Invalid("name is required")
+	}
+	if n := utf8.RuneCountInString(name); n > model.MaxWorkspaceNameLength {
+		return nil, apperr.Invalid("name is %d characters, the limit is %d", n, model.MaxWorkspaceNameLength)
+	}
+
+	w := &model.Workspace{Name: name}
+	if err := s.repo.CreateWorkspace(ctx, w, user.ID); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Workspaces lists the workspaces the caller is a member of.
+func (s *AccountService) Workspaces(ctx context.Context) ([]model.Workspace, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListWorkspaces(ctx, user.ID)
+}
+
+// Members lists the members of a workspace the caller belongs to.
+func (s *AccountService) Members(ctx context.Context, workspaceID string) ([]model.Member, error) {
+	if _, err := authorize(ctx, s.repo, workspaceID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, workspaceID)
+}
+
+// SetRole changes a member's role, which takes an admin.
+func (s *AccountService) SetRole(ctx context.Context, workspaceID, userID, role string) error {
+	if model.RoleRank(role) == 0 {
+		return apperr.Invalid("invalid role %q", role)
+	}
+	if _, err := authorize(ctx, s.repo, workspaceID, model.RoleAdmin); err != nil {
+		return err
+	}
+	return s.repo.SetRole(ctx, workspaceID, userID, role)
+}
+
+// RemoveMember removes a member from a workspace. Admins may remove anyone
+// and every member may leave.
+func (s *AccountService) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	need := model.RoleAdmin
+	if user := CallerFrom(ctx).User; user != nil && user.ID == userID {
+		need = model.RoleViewer
+	}
+	if _, err := authorize(ctx, s.repo, workspaceID, need); err != nil {
+		return err
+	}
+	return s.repo.RemoveMember(ctx, workspaceID, userID)
+}
+
+// Invite creates an invite into a workspace, which takes an admin. The
+// returned invite carries its token, which is not shown again.
+func (s *AccountService) Invite(ctx context.Context, workspaceID string, req model.InviteRequest) (*model.Invite, error) {
+	inv := &model.Invite{WorkspaceID: workspaceID, Role: req.Role}
+	if inv.Role == "" {
+		inv.Role = model.RoleViewer
+	}
+	if model.RoleRank(inv.Role) == 0 {
+		return nil, apperr.Invalid("invalid role %q", inv.Role)
+	}
+	if req.Email != "" {
+		email, err := cleanEmail(req.Email)
+		if err != nil {
+			return nil, err
+		}
+		inv.Email = email
+	}
+	if _, err := authorize(ctx, s.repo, workspaceID, model.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if user := CallerFrom(ctx).User; user != nil {
+		inv.InvitedBy = user.ID
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	inv.Token, inv.TokenHash = token, HashAPIKey(token)
+	inv.ExpiresAt = s.now().Add(model.InviteTTL).UTC()
+	if err := s.repo.CreateInvite(ctx, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// AcceptInvite adds the caller to the workspace the invite with token is
+// for and returns it. Invites are single use and expire after
+// model.InviteTTL; one that names an email takes a user who verified it.
+func (s *AccountService) AcceptInvite(ctx context.Context, token string) (*model.Workspace, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.repo.GetInviteByTokenHash(ctx, HashAPIKey(token))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case inv.AcceptedAt != nil:
+		return nil, apperr.Conflict("invite has already been used")
+	case !s.now().Before(inv.ExpiresAt):
+		return nil, apperr.Gone("invite has expired")
+	case inv.Email != "" && inv.Email != user.Email:
+		return nil, apperr.Forbidden("invite is for another email address")
+	case inv.Email != "" && !user.EmailVerified:
+		// Anyone can sign up for an API key with any email.
+		return nil, apperr.Forbidden("invite is for %s, sign in through single sign-on to prove it is yours", inv.Email)
+	}
+	if err := s.repo.AcceptInvite(ctx, inv.ID, user.ID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWorkspace(ctx, inv.WorkspaceID, user.ID)
+}
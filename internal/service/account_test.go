@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+const testWorkspace = "11111111-1111-1111-1111-111111111111"
+
+var (
+	ada   = &model.User{ID: "u1", Email: "ada@example.com"}
+	grace = &model.User{ID: "u2", Email: "grace@example.com", EmailVerified: true}
+)
+
+func as(user *model.User) context.Context {
+	return WithCaller(context.Background(), Caller{User: user})
+}
+
+func TestSignUp_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+
+	mockRepo.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.User) error {
+			u.ID = "u1"
+			return nil
+		})
+
+	u, err := svc.SignUp(context.Background(), model.SignUpRequest{Email: " Ada@Example.com ", Name: "Ada"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.Email != "ada@example.com" {
+		t.Errorf("expected normalised email, got %q", u.Email)
+	}
+	if len(u.APIKey) != 64 || u.APIKeyHash != HashAPIKey(u.APIKey) {
+		t.Errorf("expected a 64-char key stored hashed, got %q", u.APIKey)
+	}
+}
+
+func TestSignUp_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{"missing", ""},
+		{"no domain", "ada"},
+		{"display name", "Ada <ada@example.com>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAccountService(mocks.NewMockAccountRepository(ctrl))
+			_, err := svc.SignUp(context.Background(), model.SignUpRequest{Email: tt.email})
+			if !errors.Is(err, apperr.ErrInvalid) {
+				t.Fatalf("expected invalid error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSignUp_EmailTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+
+	mockRepo.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		Return(apperr.Conflict("user already exists"))
+
+	_, err := svc.SignUp(context.Background(), model.SignUpRequest{Email: "ada@example.com"})
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+}
+
+func TestAuthenticate_UnknownKeyIsAnonymous(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+
+	mockRepo.EXPECT().
+		GetUserByKeyHash(gomock.Any(), HashAPIKey("domain-key")).
+		Return(nil, apperr.NotFound("user not found"))
+
+	for _, key := range []string{"", "domain-key"} {
+		caller, err := svc.Authenticate(context.Background(), key)
+		if err != nil || caller.User != nil || caller.Operator {
+			t.Errorf("key %q: expected anonymous caller, got %+v, %v", key, caller, err)
+		}
+	}
+}
+
+func TestAuthenticate_AdminKeyIsOperator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewAccountService(mocks.NewMockAccountRepository(ctrl), WithAdminKey("admin-secret"))
+	caller, err := svc.Authenticate(context.Background(), "admin-secret")
+	if err != nil || !caller.Operator || caller.User != nil {
+		t.Fatalf("expected the operator, got %+v, %v", caller, err)
+	}
+}
+
+func TestCreateWorkspace_RequiresUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewAccountService(mocks.NewMockAccountRepository(ctrl))
+	_, err := svc.CreateWorkspace(context.Background(), model.WorkspaceRequest{Name: "Team"})
+	if !errors.Is(err, apperr.ErrUnauthorized) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestSetRole_RequiresAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+
+	mockRepo.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Name: "Team", Role: model.RoleEditor}, nil)
+
+	err := svc.SetRole(as(ada), testWorkspace, grace.ID, model.RoleAdmin)
+	if !errors.Is(err, apperr.ErrForbidden) {
+		t.Fatalf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestRemoveMember_Self(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+
+	mockRepo.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleViewer}, nil)
+	mockRepo.EXPECT().
+		RemoveMember(gomock.Any(), testWorkspace, ada.ID).
+		Return(nil)
+
+	if err := svc.RemoveMember(as(ada), testWorkspace, ada.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestInvite_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleAdmin}, nil)
+	mockRepo.EXPECT().
+		CreateInvite(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	inv, err := svc.Invite(as(ada), testWorkspace, model.InviteRequest{Email: "Grace@example.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if inv.Role != model.RoleViewer {
+		t.Errorf("expected viewer role by default, got %q", inv.Role)
+	}
+	if inv.Email != "grace@example.com" || inv.InvitedBy != ada.ID {
+		t.Errorf("unexpected invite %+v", inv)
+	}
+	if inv.TokenHash != HashAPIKey(inv.Token) {
+		t.Error("expected the token to be stored hashed")
+	}
+	if !inv.ExpiresAt.Equal(now.Add(model.InviteTTL)) {
+		t.Errorf("expected expiry after %s, got %s", model.InviteTTL, inv.ExpiresAt)
+	}
+}
+
+func TestAcceptInvite(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	used := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		invite  model.Invite
+		wantErr error
+	}{
+		{"success", model.Invite{ExpiresAt: now.Add(time.Hour)}, nil},
+		{"for this email", model.Invite{Email: grace.Email, ExpiresAt: now.Add(time.Hour)}, nil},
+		{"used", model.Invite{ExpiresAt: now.Add(time.Hour), AcceptedAt: &used}, apperr.ErrConflict},
+		{"expired", model.Invite{ExpiresAt: now}, apperr.ErrGone},
+		{"other email", model.Invite{Email: ada.Email, ExpiresAt: now.Add(time.Hour)}, apperr.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockAccountRepository(ctrl)
+			svc := NewAccountService(mockRepo)
+			svc.now = func() time.Time { return now }
+
+			inv := tt.invite
+			inv.ID, inv.WorkspaceID, inv.Role = "i1", testWorkspace, model.RoleEditor
+			mockRepo.EXPECT().
+				GetInviteByTokenHash(gomock.Any(), HashAPIKey("token")).
+				Return(&inv, nil)
+			if tt.wantErr == nil {
+				mockRepo.EXPECT().
+					AcceptInvite(gomock.Any(), "i1", grace.ID).
+					Return(nil)
+				mockRepo.EXPECT().
+					GetWorkspace(gomock.Any(), testWorkspace, grace.ID).
+					Return(&model.Workspace{ID: testWorkspace, Role: model.RoleEditor}, nil)
+			}
+
+			w, err := svc.AcceptInvite(as(grace), "token")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if w.Role != model.RoleEditor {
+				t.Errorf("expected editor role, got %q", w.Role)
+			}
+		})
+	}
+}
+
+func TestAcceptInvite_UnverifiedEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	svc := NewAccountService(mockRepo)
+
+	// Signing up for an API key with grace's email does not make the
+	// invite sent to it theirs.
+	squatter := &model.User{ID: "u3", Email: grace.Email}
+	mockRepo.EXPECT().
+		GetInviteByTokenHash(gomock.Any(), HashAPIKey("token")).
+		Return(&model.Invite{ID: "i1", WorkspaceID: testWorkspace, Email: grace.Email, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+	if _, err := svc.AcceptInvite(as(squatter), "token"); !errors.Is(err, apperr.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+type stubVerifier struct {
+	id  *model.Identity
+	err error
+}
+
+func (s *stubVerifier) Verify(_ context.Context, _ string) (*model.Identity, error) {
+	return s.id, s.err
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	id := &model.Identity{
+		Issuer: "https://idp.example.com", Subject: "u-42", Email: "Ada@Example.com",
+		Operator: true, Roles: map[string]string{testWorkspace: model.RoleEditor},
+	}
+	svc := NewAccountService(mockRepo, WithTokenVerifier(&stubVerifier{id: id}))
+
+	mockRepo.EXPECT().
+		ExternalUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, id *model.Identity) (*model.User, error) {
+			if id.Email != "ada@example.com" || id.Subject != "u-42" {
+				t.Errorf("unexpected identity %+v", id)
+			}
+			return ada, nil
+		})
+
+	caller, err := svc.AuthenticateToken(context.Background(), "jwt")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if caller.User != ada || !caller.Operator || caller.Roles[testWorkspace] != model.RoleEditor {
+		t.Errorf("unexpected caller %+v", caller)
+	}
+}
+
+func TestAuthenticateToken_Rejected(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []AccountOption
+	}{
+		{"not configured", nil},
+		{"invalid token", []AccountOption{WithTokenVerifier(&stubVerifier{err: errors.New("token is expired")})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewAccountService(mocks.NewMockAccountRepository(ctrl), tt.opts...)
+			_, err := svc.AuthenticateToken(context.Background(), "jwt")
+			if !errors.Is(err, apperr.ErrUnauthorized) {
+				t.Fatalf("expected unauthorized error, got %v", err)
+			}
+		})
+	}
+}
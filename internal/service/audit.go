@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// Audit returns a page of the audit log matching q, newest first. A
+// workspace's log takes its admin role. The log of links outside any
+// workspace records every caller's address, so only the operator reads
+// it, along with every workspace's, when q.Workspace is "".
+func (s *URLService) Audit(ctx context.Context, q model.AuditQuery) (_ *model.AuditPage, err error) {
+	ctx, span := startSpan(ctx, "Audit")
+	defer func() { endSpan(span, err) }()
+
+	if s.audit == nil {
+		return nil, apperr.Unavailable(nil, "the audit log is not enabled")
+	}
+	opts := model.AuditOptions{Workspace: q.Workspace}
+	if q.Workspace == "" {
+		caller := CallerFrom(ctx)
+		if !caller.Operator {
+			if caller.User == nil {
+				return nil, apperr.Unauthorized("an API key belonging to a workspace admin is required")
+			}
+			return nil, apperr.Forbidden("only the operator may read the audit log outside workspaces")
+		}
+		opts.AllWorkspaces = true
+	} else if _, err := authorize(ctx, s.accounts, q.Workspace, model.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	opts.Limit = limit
+	opts.Actor = strings.TrimSpace(q.Actor)
+	opts.LinkID = strings.TrimSpace(q.Link)
+	if opts.LinkID != "" && !isUUID(opts.LinkID) {
+		return nil, apperr.Invalid("invalid link ID %q", opts.LinkID)
+	}
+	if q.Since != nil && q.Until != nil && !q.Until.After(*q.Since) {
+		return nil, apperr.Invalid("until must be after since")
+	}
+	opts.Since, opts.Until = q.Since, q.Until
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := strconv.ParseInt(after.ID, 10, 64); err != nil {
+			return nil, apperr.Invalid("invalid cursor")
+		}
+		opts.After = after
+	}
+
+	entries, err := s.audit.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.AuditPage{Entries: entries}
+	if len(entries) == limit {
+		last := entries[len(entries)-1]
+		page.NextCursor = encodeCursor(model.Cursor{CreatedAt: last.CreatedAt, ID: strconv.FormatInt(last.ID, 10)})
+	}
+	return page, nil
+}
+
+// isUUID reports whether s is a UUID in its canonical, hyphenated form,
+// the only one link IDs are shown in.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/audit"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAudit_OperatorReadsEveryWorkspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAudit := mocks.NewMockAuditRepository(ctrl)
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl), WithAudit(mockAudit))
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mockAudit.EXPECT().
+		List(gomock.Any(), model.AuditOptions{Limit: 2, Actor: "u1", AllWorkspaces: true}).
+		Return([]model.AuditEntry{{ID: 9, CreatedAt: at}, {ID: 8, CreatedAt: at}}, nil)
+
+	ctx := WithCaller(context.Background(), Caller{Operator: true})
+	page, err := svc.Audit(ctx, model.AuditQuery{Limit: 2, Actor: " u1 "})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected a next cursor on a full page")
+	}
+
+	after, err := decodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("expected a valid cursor, got %v", err)
+	}
+	mockAudit.EXPECT().
+		List(gomock.Any(), model.AuditOptions{Limit: 2, After: after, AllWorkspaces: true}).
+		Return([]model.AuditEntry{}, nil)
+	if _, err := svc.Audit(ctx, model.AuditQuery{Limit: 2, Cursor: page.NextCursor}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestAudit_Authorization(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		workspace string
+		role      string
+		wantErr   error
+	}{
+		{"anonymous outside workspaces", context.Background(), "", "", apperr.ErrUnauthorized},
+		{"user outside workspaces", as(ada), "", "", apperr.ErrForbidden},
+		{"workspace editor", as(ada), testWorkspace, model.RoleEditor, apperr.ErrForbidden},
+		{"workspace admin", as(ada), testWorkspace, model.RoleAdmin, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAccounts := mocks.NewMockAccountRepository(ctrl)
+			mockAudit := mocks.NewMockAuditRepository(ctrl)
+			svc := NewURLService(mocks.NewMockURLRepository(ctrl), WithAccounts(mockAccounts), WithAudit(mockAudit))
+
+			if tt.role != "" {
+				mockAccounts.EXPECT().
+					GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+					Return(&model.Workspace{ID: testWorkspace, Role: tt.role}, nil)
+			}
+			if tt.wantErr == nil {
+				mockAudit.EXPECT().
+					List(gomock.Any(), model.AuditOptions{Limit: defaultListLimit, Workspace: testWorkspace}).
+					Return([]model.AuditEntry{}, nil)
+			}
+
+			_, err := svc.Audit(tt.ctx, model.AuditQuery{Workspace: tt.workspace})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAudit_Invalid(t *testing.T) {
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		q    model.AuditQuery
+	}{
+		{"link", model.AuditQuery{Link: "abc"}},
+		{"range", model.AuditQuery{Since: &since, Until: &since}},
+		{"cursor", model.AuditQuery{Cursor: encodeCursor(model.Cursor{CreatedAt: since, ID: "not-a-number"})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewURLService(mocks.NewMockURLRepository(ctrl), WithAudit(mocks.NewMockAuditRepository(ctrl)))
+			ctx := WithCaller(context.Background(), Caller{Operator: true})
+			if _, err := svc.Audit(ctx, tt.q); !errors.Is(err, apperr.ErrInvalid) {
+				t.Fatalf("expected invalid error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithCaller_SetsAuditActor(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller Caller
+		want   audit.Actor
+	}{
+		{"user", Caller{User: ada}, audit.Actor{ID: ada.ID, Email: ada.Email}},
+		{"operator user", Caller{User: ada, Operator: true}, audit.Actor{ID: ada.ID, Email: ada.Email}},
+		{"operator", Caller{Operator: true}, audit.Actor{ID: model.ActorOperator}},
+		{"anonymous", Caller{}, audit.Actor{ID: model.ActorAnonymous}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := audit.ActorFrom(WithCaller(context.Background(), tt.caller)); got != tt.want {
+				t.Errorf("expected actor %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
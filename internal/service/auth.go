@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/audit"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository"
+)
+
+// Caller is who a request acts for. The HTTP and gRPC layers identify the
+// caller once per request and attach it to the context with WithCaller;
+// services authorize every workspace operation against it.
+type Caller struct {
+	// User is nil for anonymous callers and for API keys that belong to
+	// no user, such as keys that only own domains and webhooks.
+	User *model.User
+	// Operator is the server's administrator, who may act in every
+	// workspace.
+	Operator bool
+	// Roles are workspace roles granted by the identity provider's groups,
+	// by workspace ID. They count even without a membership.
+	Roles map[string]string
+}
+
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx acting for caller, who is also the
+// actor recorded in the audit log for changes made with it.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	ctx = audit.WithActor(ctx, caller.actor())
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// actor identifies the caller in the audit log: a user by their ID, even
+// when they are also the operator.
+func (c Caller) actor() audit.Actor {
+	switch {
+	case c.User != nil:
+		return audit.Actor{ID: c.User.ID, Email: c.User.Email}
+	case c.Operator:
+		return audit.Actor{ID: model.ActorOperator}
+	default:
+		return audit.Actor{ID: model.ActorAnonymous}
+	}
+}
+
+// CallerFrom returns the caller ctx acts for, anonymous when none was set.
+func CallerFrom(ctx context.Context) Caller {
+	caller, _ := ctx.Value(callerKey{}).(Caller)
+	return caller
+}
+
+// currentUser returns the user ctx acts for, failing when there is none.
+func currentUser(ctx context.Context) (*model.User, error) {
+	user := CallerFrom(ctx).User
+	if user == nil {
+		return nil, apperr.Unauthorized("an API key belonging to a user is required")
+	}
+	return user, nil
+}
+
+// authorize checks that the caller holds at least role need in the
+// workspace with the given ID. An empty ID stands for links outside any
+// workspace, which everyone may use. The operator passes every check
+// without being a member, so the returned workspace is nil for them too.
+// Workspaces the caller is not a member of are not found rather than
+// forbidden, so their IDs cannot be probed. A role granted by the caller's
+// identity provider groups counts as a membership.
+func authorize(ctx context.Context, accounts repository.AccountRepository, workspaceID, need string) (*model.Workspace, error) {
+	caller := CallerFrom(ctx)
+	if workspaceID == "" || caller.Operator {
+		return nil, nil
+	}
+	if accounts == nil {
+		return nil, apperr.Invalid("workspaces are not enabled")
+	}
+	if caller.User == nil {
+		return nil, apperr.Unauthorized("an API key belonging to a workspace member is required")
+	}
+	w, err := accounts.GetWorkspace(ctx, workspaceID, caller.User.ID)
+	if granted := caller.Roles[workspaceID]; granted != "" {
+		if errors.Is(err, apperr.ErrNotFound) {
+			w, err = &model.Workspace{ID: workspaceID}, nil
+		}
+		if err == nil && model.RoleRank(granted) > model.RoleRank(w.Role) {
+			w.Role = granted
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if model.RoleRank(w.Role) < model.RoleRank(need) {
+		name := w.Name
+		if name == "" {
+			name = w.ID
+		}
+		return nil, apperr.Forbidden("this requires the %s role in workspace %q, you are a %s", need, name, w.Role)
+	}
+	return w, nil
+}
+
+// authorizeLink is authorize for a link in workspaceID, reporting the link
+// rather than its workspace as not found to non-members.
+func (s *URLService) authorizeLink(ctx context.Context, workspaceID *string, need string) error {
+	if workspaceID == nil {
+		return nil
+	}
+	_, err := authorize(ctx, s.accounts, *workspaceID, need)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return apperr.NotFound("url not found")
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+const testLink = "550e8400-e29b-41d4-a716-446655440000"
+
+func TestGet_WorkspaceLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		role    string
+		wantErr error
+	}{
+		{"member", as(ada), model.RoleViewer, nil},
+		{"anonymous", context.Background(), "", apperr.ErrUnauthorized},
+		{"non-member", as(ada), "", apperr.ErrNotFound},
+		{"operator", WithCaller(context.Background(), Caller{Operator: true}), "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockURLRepository(ctrl)
+			mockAccounts := mocks.NewMockAccountRepository(ctrl)
+			svc := NewURLService(mockRepo, WithAccounts(mockAccounts))
+
+			workspaceID := testWorkspace
+			mockRepo.EXPECT().
+				GetByID(gomock.Any(), testLink).
+				Return(&model.URL{ID: testLink, WorkspaceID: &workspaceID}, nil)
+			switch {
+			case tt.role != "":
+				mockAccounts.EXPECT().
+					GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+					Return(&model.Workspace{ID: testWorkspace, Role: tt.role}, nil)
+			case tt.wantErr == apperr.ErrNotFound:
+				mockAccounts.EXPECT().
+					GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+					Return(nil, apperr.NotFound("workspace not found"))
+			}
+
+			_, err := svc.Get(tt.ctx, testLink)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUpdate_ViewerForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewURLService(mockRepo, WithAccounts(mockAccounts))
+
+	workspaceID := testWorkspace
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), testLink).
+		Return(&model.URL{ID: testLink, OriginalURL: "https://example.com", WorkspaceID: &workspaceID}, nil)
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleViewer}, nil)
+
+	dest := "https://example.org"
+	_, err := svc.Update(as(ada), testLink, model.UpdateRequest{URL: &dest})
+	if !errors.Is(err, apperr.ErrForbidden) {
+		t.Fatalf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestDelete_WorkspaceEditor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewURLService(mockRepo, WithAccounts(mockAccounts))
+
+	mockRepo.EXPECT().
+		WorkspaceOf(gomock.Any(), testLink).
+		Return(testWorkspace, nil)
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleEditor}, nil)
+	mockRepo.EXPECT().
+		Delete(gomock.Any(), testLink).
+		Return(nil)
+
+	if err := svc.Delete(as(ada), testLink); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestShorten_WorkspaceRequiresEditor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewURLService(mockRepo, WithAccounts(mockAccounts))
+
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleViewer}, nil)
+
+	_, err := svc.Shorten(as(ada), model.ShortenRequest{URL: "https://example.com", Workspace: testWorkspace})
+	if !errors.Is(err, apperr.ErrForbidden) {
+		t.Fatalf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestList_Workspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewURLService(mockRepo, WithAccounts(mockAccounts))
+
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleViewer}, nil)
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: defaultListLimit, Workspace: testWorkspace}).
+		Return([]model.URL{}, nil)
+
+	if _, err := svc.List(as(ada), model.ListQuery{Workspace: testWorkspace}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdate_RoleFromGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewURLService(mockRepo, WithAccounts(mockAccounts))
+
+	workspaceID := testWorkspace
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), testLink).
+		Return(&model.URL{ID: testLink, OriginalURL: "https://example.com",
+			QueryPrecedence: model.QueryPrecedenceIncoming, WorkspaceID: &workspaceID}, nil)
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(nil, apperr.NotFound("workspace not found"))
+	mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	ctx := WithCaller(context.Background(), Caller{User: ada, Roles: map[string]string{testWorkspace: model.RoleEditor}})
+	dest := "https://example.org"
+	if _, err := svc.Update(ctx, testLink, model.UpdateRequest{URL: &dest}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
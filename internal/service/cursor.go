@@ -0,0 +1,32 @@
+package service
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// encodeCursor renders c as an opaque, URL-safe token.
+func encodeCursor(c model.Cursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(token string) (*model.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, apperr.Invalid("invalid cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, apperr.Invalid("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, apperr.Invalid("invalid cursor")
+	}
+	return &model.Cursor{CreatedAt: t, ID: id}, nil
+}
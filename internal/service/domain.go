@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+	"github.com/kerbatek/url-shortener/internal/repository"
+)
+
+type DomainService struct {
+	repo         repository.DomainRepository
+	reserved     map[string]bool
+	screener     Screener
+	allowPrivate bool
+	lookup       netguard.Resolver
+}
+
+type DomainOption func(*DomainService)
+
+// WithReservedHosts refuses to register hosts, the ones the default
+// namespace is served on, as custom domains.
+func WithReservedHosts(hosts ...string) DomainOption {
+	return func(s *DomainService) {
+		for _, h := range hosts {
+			s.reserved[normalizeHost(h)] = true
+		}
+	}
+}
+
+// WithFallbackScreener rejects fallback URLs that screener lists as
+// threats, as links are.
+func WithFallbackScreener(screener Screener) DomainOption {
+	return func(s *DomainService) { s.screener = screener }
+}
+
+// WithPrivateFallbacks accepts fallback URLs on loopback, private and
+// link-local addresses, which are refused by default.
+func WithPrivateFallbacks() DomainOption {
+	return func(s *DomainService) { s.allowPrivate = true }
+}
+
+func NewDomainService(repo repository.DomainRepository, opts ...DomainOption) *DomainService {
+	s := &DomainService{repo: repo, reserved: map[string]bool{}, lookup: net.DefaultResolver.LookupIPAddr}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// HashAPIKey returns the hex SHA-256 digest under which an API key is stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeHost lowercases host and strips any port and trailing dot so that
+// Host headers and stored domains compare equal.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// Register claims host for the given API key, which must be the caller's:
+// only users and the operator may register domains.
+func (s *DomainService) Register(ctx context.Context, host, fallbackURL, apiKey string) (*model.Domain, error) {
+	if apiKey == "" {
+		return nil, apperr.Unauthorized("an API key is required to register a domain")
+	}
+	if caller := CallerFrom(ctx); caller.User == nil && !caller.Operator {
+		return nil, apperr.Unauthorized("an API key belonging to a user is required to register a domain")
+	}
+	host = normalizeHost(host)
+	if host == "" || strings.ContainsAny(host, "/?#@ ") {
+		return nil, apperr.Invalid("invalid domain %q", host)
+	}
+	if s.reserved[host] {
+		return nil, apperr.Forbidden("domain %q is reserved", host)
+	}
+	if fallbackURL != "" {
+		if err := s.checkFallback(ctx, fallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &model.Domain{
+		Host:         host,
+		FallbackURL:  fallbackURL,
+		OwnerKeyHash: HashAPIKey(apiKey),
+	}
+	if err := s.repo.Create(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// checkFallback rejects fallback URLs that are not absolute http or https
+// URLs, are listed as threats or point at private addresses, as unknown
+// codes on the domain redirect to them.
+func (s *DomainService) checkFallback(ctx context.Context, fallbackURL string) error {
+	u, err := url.ParseRequestURI(fallbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return apperr.Invalid("invalid fallback URL %q", fallbackURL)
+	}
+	if s.screener != nil {
+		if threat, listed := s.screener.Check(fallbackURL); listed {
+			return apperr.Invalid("fallback URL is blocked: listed as %s", threat)
+		}
+	}
+	if !s.allowPrivate && netguard.PrivateHost(ctx, s.lookup, u.Hostname()) {
+		return apperr.Invalid("fallback URL %q is on a private address", fallbackURL)
+	}
+	return nil
+}
+
+// List returns the domains owned by apiKey.
+func (s *DomainService) List(ctx context.Context, apiKey string) ([]model.Domain, error) {
+	if apiKey == "" {
+		return nil, apperr.Unauthorized("an API key is required to list domains")
+	}
+	return s.repo.ListByOwner(ctx, HashAPIKey(apiKey))
+}
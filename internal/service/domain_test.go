@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]string{
+		"Go.Example.com":      "go.example.com",
+		"go.example.com:8080": "go.example.com",
+		"go.example.com.":     "go.example.com",
+		"localhost":           "localhost",
+	}
+	for in, want := range tests {
+		if got := normalizeHost(in); got != want {
+			t.Errorf("normalizeHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRegisterDomain_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockDomainRepository(ctrl)
+	svc := NewDomainService(mockRepo)
+	svc.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}}, nil
+	}
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, d *model.Domain) error {
+			d.ID = "11111111-1111-1111-1111-111111111111"
+			return nil
+		})
+
+	d, err := svc.Register(as(ada), "Go.Example.com", "https://example.com", "secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if d.Host != "go.example.com" {
+		t.Errorf("expected host go.example.com, got %s", d.Host)
+	}
+	if d.OwnerKeyHash != HashAPIKey("secret") {
+		t.Error("expected owner hash of the caller's key")
+	}
+}
+
+func TestRegisterDomain_Invalid(t *testing.T) {
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "internal.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}}, nil
+	}
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		host     string
+		fallback string
+		key      string
+		want     error
+	}{
+		{"missing key", as(ada), "go.example.com", "", "", apperr.ErrUnauthorized},
+		{"key of no user", context.Background(), "go.example.com", "", "made-up", apperr.ErrUnauthorized},
+		{"bad host", as(ada), "go.example.com/path", "", "secret", apperr.ErrInvalid},
+		{"default host", as(ada), "Sho.rt:443", "", "secret", apperr.ErrForbidden},
+		{"default host by the operator", WithCaller(context.Background(), Caller{Operator: true}), "sho.rt", "", "admin", apperr.ErrForbidden},
+		{"bad fallback", as(ada), "go.example.com", "not-a-url", "secret", apperr.ErrInvalid},
+		{"script fallback", as(ada), "go.example.com", "javascript:alert(1)", "secret", apperr.ErrInvalid},
+		{"listed fallback", as(ada), "go.example.com", "https://phish.example/login", "secret", apperr.ErrInvalid},
+		{"private fallback", as(ada), "go.example.com", "http://169.254.169.254/latest", "secret", apperr.ErrInvalid},
+		{"internal fallback", as(ada), "go.example.com", "https://internal.example.com", "secret", apperr.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewDomainService(mocks.NewMockDomainRepository(ctrl),
+				WithReservedHosts("sho.rt"), WithFallbackScreener(stubScreener{"https://phish.example/login": "phishing"}))
+			svc.lookup = lookup
+			if _, err := svc.Register(tt.ctx, tt.host, tt.fallback, tt.key); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRegisterDomain_PrivateFallbackAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockDomainRepository(ctrl)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	svc := NewDomainService(mockRepo, WithPrivateFallbacks())
+	if _, err := svc.Register(as(ada), "go.example.com", "http://10.0.0.5/", "secret"); err != nil {
+		t.Fatalf("expected private fallbacks to be allowed, got %v", err)
+	}
+}
+
+func setupDomainURLService(ctrl *gomock.Controller) (*URLService, *mocks.MockURLRepository, *mocks.MockDomainRepository) {
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockDomains := mocks.NewMockDomainRepository(ctrl)
+	return NewURLService(mockRepo, WithDomains(mockDomains)), mockRepo, mockDomains
+}
+
+func TestShorten_OwnedDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockRepo, mockDomains := setupDomainURLService(ctrl)
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "dom-1", Host: "go.example.com", OwnerKeyHash: HashAPIKey("secret")}, nil)
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	u, err := svc.Shorten(context.Background(), model.ShortenRequest{
+		URL:    "https://example.com",
+		Domain: "go.example.com",
+		APIKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.DomainID == nil || *u.DomainID != "dom-1" {
+		t.Errorf("expected domain id dom-1, got %v", u.DomainID)
+	}
+	if u.Domain != "go.example.com" {
+		t.Errorf("expected domain go.example.com, got %s", u.Domain)
+	}
+}
+
+func TestShorten_DomainNotOwned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _, mockDomains := setupDomainURLService(ctrl)
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "dom-1", Host: "go.example.com", OwnerKeyHash: HashAPIKey("secret")}, nil)
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{
+		URL:    "https://example.com",
+		Domain: "go.example.com",
+		APIKey: "other",
+	})
+	if !errors.Is(err, apperr.ErrForbidden) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+}
+
+func TestShorten_DomainsDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com", Domain: "go.example.com"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestResolve_ByHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockRepo, mockDomains := setupDomainURLService(ctrl)
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "dom-1", Host: "go.example.com"}, nil)
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "dom-1", "abc1234").
+		Return(&model.URL{Code: "abc1234", OriginalURL: "https://example.com"}, nil)
+
+	if _, err := svc.Resolve(context.Background(), "go.example.com:443", "abc1234"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestResolve_UnknownHostUsesDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockRepo, mockDomains := setupDomainURLService(ctrl)
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "localhost").
+		Return(nil, apperr.NotFound("domain not found"))
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{Code: "abc1234", OriginalURL: "https://example.com"}, nil)
+
+	if _, err := svc.Resolve(context.Background(), "localhost:8080", "abc1234"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestResolve_DefaultHostIgnoresDomains(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithDomains(mocks.NewMockDomainRepository(ctrl)), WithDefaultHosts("sho.rt"))
+
+	mockRepo.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{Code: "abc1234", OriginalURL: "https://example.com"}, nil)
+
+	if _, err := svc.Resolve(context.Background(), "SHO.RT:443", "abc1234"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := svc.FallbackURL(context.Background(), "sho.rt"); got != "" {
+		t.Errorf("expected no fallback on the default host, got %s", got)
+	}
+}
+
+func TestFallbackURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _, mockDomains := setupDomainURLService(ctrl)
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "dom-1", Host: "go.example.com", FallbackURL: "https://example.com/404"}, nil)
+
+	if got := svc.FallbackURL(context.Background(), "go.example.com"); got != "https://example.com/404" {
+		t.Errorf("expected fallback https://example.com/404, got %s", got)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// cleanTags trims and lower-cases tags, dropping empty and repeated ones,
+// and sorts what is left. It returns nil when no tags remain.
+func cleanTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			out = append(out, t)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// validateGroups checks the tags and campaign of u, which must already be
+// cleaned. Tags are limited to letters, digits and "-_.:/" so they stay
+// usable in query strings and CSV cells.
+func validateGroups(u *model.URL) error {
+	if len(u.Tags) > model.MaxTags {
+		return apperr.Invalid("a link can have at most %d tags", model.MaxTags)
+	}
+	for _, t := range u.Tags {
+		if utf8.RuneCountInString(t) > model.MaxTagLength {
+			return apperr.Invalid("tag %q is longer than %d characters", t, model.MaxTagLength)
+		}
+		if strings.IndexFunc(t, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.:/", r)
+		}) >= 0 {
+			return apperr.Invalid("invalid tag %q", t)
+		}
+	}
+	if n := utf8.RuneCountInString(u.Campaign); n > model.MaxCampaignLength {
+		return apperr.Invalid("campaign is %d characters, the limit is %d", n, model.MaxCampaignLength)
+	}
+	if strings.IndexFunc(u.Campaign, unicode.IsControl) >= 0 {
+		return apperr.Invalid("invalid campaign %q", u.Campaign)
+	}
+	return nil
+}
+
+// groupName normalises the name of a group of the given kind the way
+// links store it.
+func groupName(kind, name string) (string, error) {
+	switch kind {
+	case model.GroupTag:
+		return strings.ToLower(strings.TrimSpace(name)), nil
+	case model.GroupCampaign:
+		return strings.TrimSpace(name), nil
+	default:
+		return "", apperr.Invalid("invalid group %q", kind)
+	}
+}
+
+// Groups lists the tags or campaigns, by model.Group kind, that have live
+// links in workspace, or outside any workspace when it is "".
+func (s *URLService) Groups(ctx context.Context, kind, workspace string) ([]model.LinkGroup, error) {
+	if _, err := groupName(kind, ""); err != nil {
+		return nil, err
+	}
+	if _, err := authorize(ctx, s.accounts, workspace, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.Groups(ctx, kind, workspace)
+}
+
+// GroupStats returns click statistics summed over every live link in a
+// tag or campaign within workspace, or outside any workspace when it is
+// "", counting bot clicks in the totals only when includeBots is set.
+func (s *URLService) GroupStats(ctx context.Context, kind, name, workspace string, includeBots bool) (*model.GroupStats, error) {
+	if s.clicks == nil {
+		return nil, apperr.Unavailable(nil, "click tracking is not enabled")
+	}
+	name, err := groupName(kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, apperr.Invalid("%s name is required", kind)
+	}
+	if _, err := authorize(ctx, s.accounts, workspace, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.clicks.GroupStats(ctx, kind, name, workspace, model.StatsDays, includeBots)
+}
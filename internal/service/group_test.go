@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCleanTags(t *testing.T) {
+	got := cleanTags([]string{" Promo", "mail", "", "promo", "Q3:2025 "})
+	want := []string{"mail", "promo", "q3:2025"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := cleanTags([]string{" ", ""}); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+func TestShorten_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, u *model.URL) error {
+			if u.Campaign != "Spring launch" || !reflect.DeepEqual(u.Tags, []string{"mail", "promo"}) {
+				t.Errorf("expected cleaned groups, got %q %v", u.Campaign, u.Tags)
+			}
+			return nil
+		})
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{
+		URL:      "https://example.com",
+		Campaign: " Spring launch ",
+		Tags:     []string{"Promo", "mail", "promo"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdate_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(&model.URL{
+		ID: "id", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming,
+		Campaign: "old", Tags: []string{"keep"},
+	}, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	campaign := ""
+	u, err := svc.Update(context.Background(), "id", model.UpdateRequest{Campaign: &campaign})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.Campaign != "" || !reflect.DeepEqual(u.Tags, []string{"keep"}) {
+		t.Errorf("expected only the campaign to be cleared, got %q %v", u.Campaign, u.Tags)
+	}
+}
+
+func TestList_Groups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: defaultListLimit, Tags: []string{"promo"}, Campaign: "Spring"}).
+		Return([]model.URL{}, nil)
+
+	if _, err := svc.List(context.Background(), model.ListQuery{Tags: []string{"PROMO "}, Campaign: " Spring"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestGroupStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	svc := NewURLService(mockRepo, WithClicks(mockClicks))
+
+	mockClicks.EXPECT().
+		GroupStats(gomock.Any(), model.GroupTag, "promo", "", model.StatsDays, false).
+		Return(&model.GroupStats{Group: model.GroupTag, Name: "promo", Links: 3}, nil)
+
+	stats, err := svc.GroupStats(context.Background(), model.GroupTag, "Promo", "", false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats.Links != 3 {
+		t.Errorf("expected 3 links, got %d", stats.Links)
+	}
+
+	if _, err := svc.GroupStats(context.Background(), "folder", "x", "", false); !errors.Is(err, apperr.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown group, got %v", err)
+	}
+	if _, err := svc.GroupStats(context.Background(), model.GroupCampaign, " ", "", false); !errors.Is(err, apperr.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an empty name, got %v", err)
+	}
+}
+
+func TestGroupStats_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+	if _, err := svc.GroupStats(context.Background(), model.GroupTag, "promo", "", false); !errors.Is(err, apperr.ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// cleanMetadata returns nil for empty metadata, so links without any store
+// and read back the same way.
+func cleanMetadata(m map[string]any) map[string]any {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// validateNotes checks the title, description and metadata of u, which
+// must already be trimmed.
+func validateNotes(u *model.URL) error {
+	if n := utf8.RuneCountInString(u.Title); n > model.MaxTitleLength {
+		return apperr.Invalid("title is %d characters, the limit is %d", n, model.MaxTitleLength)
+	}
+	if strings.IndexFunc(u.Title, unicode.IsControl) >= 0 {
+		return apperr.Invalid("title must be a single line")
+	}
+	if n := utf8.RuneCountInString(u.Description); n > model.MaxDescriptionLength {
+		return apperr.Invalid("description is %d characters, the limit is %d", n, model.MaxDescriptionLength)
+	}
+	if len(u.Metadata) > model.MaxMetadataKeys {
+		return apperr.Invalid("metadata can have at most %d keys", model.MaxMetadataKeys)
+	}
+	for k := range u.Metadata {
+		if k == "" || utf8.RuneCountInString(k) > model.MaxMetadataKeyLength {
+			return apperr.Invalid("metadata keys must be 1 to %d characters, got %q", model.MaxMetadataKeyLength, k)
+		}
+	}
+	if u.Metadata != nil {
+		b, err := json.Marshal(u.Metadata)
+		if err != nil {
+			return apperr.Invalid("invalid metadata: %v", err)
+		}
+		if len(b) > model.MaxMetadataBytes {
+			return apperr.Invalid("metadata is %d bytes as JSON, the limit is %d", len(b), model.MaxMetadataBytes)
+		}
+	}
+	return nil
+}
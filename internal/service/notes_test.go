@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShorten_Notes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, u *model.URL) error {
+			if u.Title != "Spring newsletter" || u.Description != "Asked for by growth" {
+				t.Errorf("expected trimmed notes, got %q %q", u.Title, u.Description)
+			}
+			if u.Metadata["owner"] != "alice" {
+				t.Errorf("expected metadata to be kept, got %v", u.Metadata)
+			}
+			return nil
+		})
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{
+		URL:         "https://example.com",
+		Title:       " Spring newsletter ",
+		Description: "Asked for by growth\n",
+		Metadata:    map[string]any{"owner": "alice"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestShorten_InvalidNotes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+
+	manyKeys := map[string]any{}
+	for i := 0; i <= model.MaxMetadataKeys; i++ {
+		manyKeys[fmt.Sprintf("key%d", i)] = i
+	}
+	tests := map[string]model.ShortenRequest{
+		"long title":       {Title: strings.Repeat("t", model.MaxTitleLength+1)},
+		"multiline title":  {Title: "one\ntwo"},
+		"long description": {Description: strings.Repeat("d", model.MaxDescriptionLength+1)},
+		"too many keys":    {Metadata: manyKeys},
+		"empty key":        {Metadata: map[string]any{"": "x"}},
+		"long key":         {Metadata: map[string]any{strings.Repeat("k", model.MaxMetadataKeyLength+1): "x"}},
+		"large metadata":   {Metadata: map[string]any{"blob": strings.Repeat("x", model.MaxMetadataBytes)}},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			req.URL = "https://example.com"
+			_, err := svc.Shorten(context.Background(), req)
+			if !errors.Is(err, apperr.ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdate_Notes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(&model.URL{
+		ID: "id", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming,
+		Title: "Old", Description: "Keep me", Metadata: map[string]any{"owner": "alice"},
+	}, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	title, metadata := "New", map[string]any{}
+	u, err := svc.Update(context.Background(), "id", model.UpdateRequest{Title: &title, Metadata: &metadata})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.Title != "New" || u.Description != "Keep me" || u.Metadata != nil {
+		t.Errorf("expected the title replaced and metadata removed, got %q %q %v", u.Title, u.Description, u.Metadata)
+	}
+}
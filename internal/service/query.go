@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// RedirectTarget builds the Location for a redirect to u. The link's UTM
+// parameters fill in any keys the destination does not already carry, and
+// when the link forwards queries the incoming parameters are merged in
+// according to its precedence. The destination's own query is kept byte
+// for byte, in its order and encoding, so signed URLs stay valid: added
+// parameters are appended, and an incoming parameter that takes precedence
+// only removes the pairs it replaces. The destination is returned verbatim
+// when nothing needs to be added.
+func RedirectTarget(u *model.URL, incoming url.Values) (string, error) {
+	if len(u.UTMParams) == 0 && (!u.ForwardQuery || len(incoming) == 0) {
+		return u.OriginalURL, nil
+	}
+
+	dest, err := url.Parse(u.OriginalURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination: %w", err)
+	}
+	q := dest.Query()
+	added := url.Values{}
+	replaced := map[string]bool{}
+
+	for k, v := range u.UTMParams {
+		if !q.Has(k) {
+			added.Set(k, v)
+		}
+	}
+	if u.ForwardQuery {
+		for k, vs := range incoming {
+			if q.Has(k) {
+				if u.QueryPrecedence == model.QueryPrecedenceDestination {
+					continue
+				}
+				replaced[k] = true
+			}
+			added[k] = vs
+		}
+	}
+	if len(added) == 0 {
+		return u.OriginalURL, nil
+	}
+
+	var pairs []string
+	if dest.RawQuery != "" {
+		for _, pair := range strings.Split(dest.RawQuery, "&") {
+			key, _, _ := strings.Cut(pair, "=")
+			if k, err := url.QueryUnescape(key); err == nil && replaced[k] {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	dest.RawQuery = strings.Join(append(pairs, added.Encode()), "&")
+	return dest.String(), nil
+}
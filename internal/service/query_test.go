@@ -0,0 +1,86 @@
+package service
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func TestRedirectTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     model.URL
+		incoming string
+		want     string
+	}{
+		{
+			name:     "query dropped by default",
+			link:     model.URL{OriginalURL: "https://example.com/path?a=1"},
+			incoming: "b=2",
+			want:     "https://example.com/path?a=1",
+		},
+		{
+			name:     "forwarded and merged",
+			link:     model.URL{OriginalURL: "https://example.com/path?a=1", ForwardQuery: true},
+			incoming: "b=2",
+			want:     "https://example.com/path?a=1&b=2",
+		},
+		{
+			name:     "incoming wins",
+			link:     model.URL{OriginalURL: "https://example.com/?a=1", ForwardQuery: true, QueryPrecedence: model.QueryPrecedenceIncoming},
+			incoming: "a=2",
+			want:     "https://example.com/?a=2",
+		},
+		{
+			name:     "destination wins",
+			link:     model.URL{OriginalURL: "https://example.com/?a=1", ForwardQuery: true, QueryPrecedence: model.QueryPrecedenceDestination},
+			incoming: "a=2",
+			want:     "https://example.com/?a=1",
+		},
+		{
+			name:     "destination query kept byte for byte",
+			link:     model.URL{OriginalURL: "https://cdn.example.com/f?z=1&a=%7e&sig=AbC%2Bd&a=2", ForwardQuery: true},
+			incoming: "b=2",
+			want:     "https://cdn.example.com/f?z=1&a=%7e&sig=AbC%2Bd&a=2&b=2",
+		},
+		{
+			name:     "incoming replaces only its own pairs",
+			link:     model.URL{OriginalURL: "https://example.com/?z=1&a=1&y=%7e&a=3", ForwardQuery: true, QueryPrecedence: model.QueryPrecedenceIncoming},
+			incoming: "a=2",
+			want:     "https://example.com/?z=1&y=%7e&a=2",
+		},
+		{
+			name: "utm appended",
+			link: model.URL{OriginalURL: "https://example.com/", UTMParams: map[string]string{"utm_source": "mail", "utm_campaign": "spring sale"}},
+			want: "https://example.com/?utm_campaign=spring+sale&utm_source=mail",
+		},
+		{
+			name: "utm does not override destination",
+			link: model.URL{OriginalURL: "https://example.com/?utm_source=site", UTMParams: map[string]string{"utm_source": "mail"}},
+			want: "https://example.com/?utm_source=site",
+		},
+		{
+			name:     "incoming values are encoded and fragment kept",
+			link:     model.URL{OriginalURL: "https://example.com/p#top", ForwardQuery: true},
+			incoming: "q=a%26b+c",
+			want:     "https://example.com/p?q=a%26b+c#top",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming, err := url.ParseQuery(tt.incoming)
+			if err != nil {
+				t.Fatalf("bad test query: %v", err)
+			}
+			got, err := RedirectTarget(&tt.link, incoming)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// Screener checks destinations against threat lists. threat.Watcher
+// implements it.
+type Screener interface {
+	// Check reports the threat rawURL is listed under, if any.
+	Check(rawURL string) (threat string, listed bool)
+}
+
+// screen rejects rawURL if it is on a threat list.
+func (s *URLService) screen(rawURL string) error {
+	if s.screener == nil {
+		return nil
+	}
+	if threat, listed := s.screener.Check(rawURL); listed {
+		return apperr.Invalid("URL is blocked: listed as %s", threat)
+	}
+	return nil
+}
+
+// Rescan checks every live link against the current threat lists. Newly
+// listed links are disabled and disabled links that are no longer listed,
+// or are listed under a different threat, are updated to match.
+func (s *URLService) Rescan(ctx context.Context) (disabled, enabled int, err error) {
+	if s.screener == nil {
+		return 0, 0, nil
+	}
+	err = s.each(ctx, model.ListOptions{AllWorkspaces: true}, func(u *model.URL) error {
+		threat, _ := s.screener.Check(u.OriginalURL)
+		if threat == u.DisabledReason {
+			return nil
+		}
+		if err := s.repo.SetDisabled(ctx, u.ID, threat); err != nil {
+			return err
+		}
+		if threat == "" {
+			enabled++
+		} else if u.DisabledReason == "" {
+			disabled++
+		}
+		return nil
+	})
+	return disabled, enabled, err
+}
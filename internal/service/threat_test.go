@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// stubScreener lists destinations by exact URL.
+type stubScreener map[string]string
+
+func (s stubScreener) Check(rawURL string) (string, bool) {
+	threat, ok := s[rawURL]
+	return threat, ok
+}
+
+func TestShorten_BlockedURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithScreener(stubScreener{"https://evil.example.com": "malware"}))
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://evil.example.com"})
+	if !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if msg := apperr.Message(err); msg != "URL is blocked: listed as malware" {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestUpdate_ReenablesCleanDestination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithScreener(stubScreener{"https://evil.example.com": "malware"}))
+
+	id := "550e8400-e29b-41d4-a716-446655440000"
+	disabledAt := time.Now()
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), id).
+		Return(&model.URL{ID: id, OriginalURL: "https://evil.example.com", QueryPrecedence: model.QueryPrecedenceIncoming,
+			DisabledAt: &disabledAt, DisabledReason: "malware"}, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().SetDisabled(gomock.Any(), id, "").Return(nil)
+
+	dest := "https://example.com"
+	u, err := svc.Update(context.Background(), id, model.UpdateRequest{URL: &dest})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.DisabledAt != nil || u.DisabledReason != "" {
+		t.Errorf("expected link to be enabled, got %+v", u)
+	}
+}
+
+func TestRescan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithScreener(stubScreener{
+		"https://evil.example.com":  "malware",
+		"https://still.example.com": "phishing",
+	}))
+
+	disabledAt := time.Now()
+	mockRepo.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		Return([]model.URL{
+			{ID: "new-hit", OriginalURL: "https://evil.example.com"},
+			{ID: "clean", OriginalURL: "https://example.com"},
+			{ID: "still-listed", OriginalURL: "https://still.example.com", DisabledAt: &disabledAt, DisabledReason: "phishing"},
+			{ID: "delisted", OriginalURL: "https://example.org", DisabledAt: &disabledAt, DisabledReason: "malware"},
+		}, nil)
+	mockRepo.EXPECT().SetDisabled(gomock.Any(), "new-hit", "malware").Return(nil)
+	mockRepo.EXPECT().SetDisabled(gomock.Any(), "delisted", "").Return(nil)
+
+	disabled, enabled, err := svc.Rescan(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if disabled != 1 || enabled != 1 {
+		t.Errorf("expected 1 disabled and 1 enabled, got %d and %d", disabled, enabled)
+	}
+}
+
+func TestRescan_WithoutScreener(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+	if _, _, err := svc.Rescan(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/telemetry"
+)
+
+// startSpan starts the span of a URLService operation, a child of the
+// request's span when there is one.
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "URLService."+operation, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span with the operation's outcome. Every error is recorded,
+// but only those the caller is not to blame for, which surface as 5xx
+// responses, mark the span failed.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		var appErr *apperr.Error
+		if !errors.As(err, &appErr) || errors.Is(err, apperr.ErrUnavailable) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
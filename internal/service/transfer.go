@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/codegen"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+const (
+	// exportBatchSize is how many links Export reads per repository call.
+	exportBatchSize = 500
+	// importBatchSize is how many links Import inserts per statement.
+	importBatchSize = 500
+	// maxCodeLength is the width of urls.code, wide enough for the
+	// keywords YOURLS allows.
+	maxCodeLength = 200
+)
+
+// importedCode admits the codes other shorteners hand out, which may use
+// '-' and '_' besides our own alphabet.
+var importedCode = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ImportSource yields links to import. Next returns io.EOF once exhausted;
+// an error of kind apperr.ErrInvalid rejects a single record, anything else
+// aborts the import. transfer.Decoder implements it.
+type ImportSource interface {
+	Next() (line int, u *model.URL, err error)
+}
+
+// Export calls fn for every live link in workspace, or outside any
+// workspace when it is "", newest first.
+func (s *URLService) Export(ctx context.Context, workspace string, fn func(*model.URL) error) (err error) {
+	ctx, span := startSpan(ctx, "Export")
+	defer func() { endSpan(span, err) }()
+
+	opts, err := s.scope(ctx, workspace)
+	if err != nil {
+		return err
+	}
+	return s.each(ctx, opts, fn)
+}
+
+// each calls fn for every live link opts selects, newest first. Links are
+// read from the repository a batch at a time with a keyset cursor, so
+// memory use does not grow with the table.
+func (s *URLService) each(ctx context.Context, opts model.ListOptions, fn func(*model.URL) error) error {
+	opts.Limit = exportBatchSize
+	for {
+		urls, err := s.repo.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range urls {
+			if err := fn(&urls[i]); err != nil {
+				return err
+			}
+		}
+		if len(urls) < opts.Limit {
+			return nil
+		}
+		last := urls[len(urls)-1]
+		opts.After = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// Import inserts every link src yields, keeping codes and creation times.
+// Links naming a domain, or every link when domain is set, must be on a
+// domain owned by apiKey. Links are imported into workspace, which takes
+// an editor, or outside any workspace when it is "". Bad records and
+// taken codes are reported rather than failing the import.
+func (s *URLService) Import(ctx context.Context, src ImportSource, domain, workspace, apiKey string) (_ *model.ImportReport, err error) {
+	ctx, span := startSpan(ctx, "Import")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := authorize(ctx, s.accounts, workspace, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	report := &model.ImportReport{Conflicts: []model.ImportProblem{}, Errors: []model.ImportProblem{}}
+	owned := map[string]ownership{}
+
+	batch := make([]model.URL, 0, importBatchSize)
+	lines := make([]int, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		conflicts, err := s.repo.Import(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, i := range conflicts {
+			report.Conflicted++
+			if len(report.Conflicts) < model.MaxImportProblems {
+				report.Conflicts = append(report.Conflicts, model.ImportProblem{
+					Line: lines[i], Code: batch[i].Code, Error: "code already in use",
+				})
+			}
+		}
+		report.Imported += len(batch) - len(conflicts)
+		batch, lines = batch[:0], lines[:0]
+		return nil
+	}
+	fail := func(line int, code string, err error) {
+		report.Failed++
+		if len(report.Errors) < model.MaxImportProblems {
+			report.Errors = append(report.Errors, model.ImportProblem{Line: line, Code: code, Error: apperr.Message(err)})
+		}
+	}
+
+	for {
+		line, u, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, apperr.ErrInvalid) {
+				fail(line, "", err)
+				continue
+			}
+			return nil, err
+		}
+
+		if domain != "" {
+			u.Domain = domain
+		}
+		u.WorkspaceID = nil
+		if workspace != "" {
+			u.WorkspaceID = &workspace
+		}
+		if err := s.prepareImport(ctx, u, apiKey, owned); err != nil {
+			if errors.Is(err, apperr.ErrInvalid) || errors.Is(err, apperr.ErrForbidden) {
+				fail(line, u.Code, err)
+				continue
+			}
+			return nil, err
+		}
+
+		batch = append(batch, *u)
+		lines = append(lines, line)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ownership is the cached outcome of checking one domain during an import.
+type ownership struct {
+	domain *model.Domain
+	err    error
+}
+
+// prepareImport validates an imported link and resolves its domain, caching
+// ownership checks in owned by host.
+func (s *URLService) prepareImport(ctx context.Context, u *model.URL, apiKey string, owned map[string]ownership) error {
+	if u.Code == "" {
+		return apperr.Invalid("code is required")
+	}
+	if len(u.Code) > maxCodeLength || !importedCode.MatchString(u.Code) {
+		return apperr.Invalid("invalid code %q", u.Code)
+	}
+	if codegen.Reserved(u.Code) {
+		return apperr.Invalid("code %q is reserved", u.Code)
+	}
+	if u.QueryPrecedence == "" {
+		u.QueryPrecedence = model.QueryPrecedenceIncoming
+	}
+	if u.BotPolicy == "" {
+		u.BotPolicy = model.BotPolicyRedirect
+	}
+	u.Campaign, u.Tags = strings.TrimSpace(u.Campaign), cleanTags(u.Tags)
+	u.Title, u.Description = strings.TrimSpace(u.Title), strings.TrimSpace(u.Description)
+	u.Metadata = cleanMetadata(u.Metadata)
+	if u.ClicksUsed < 0 {
+		return apperr.Invalid("clicks_used must not be negative")
+	}
+	if err := validateURL(u); err != nil {
+		return err
+	}
+	if err := s.screen(u.OriginalURL); err != nil {
+		return err
+	}
+
+	u.DomainID = nil
+	if u.Domain == "" {
+		return nil
+	}
+	host := normalizeHost(u.Domain)
+	o, ok := owned[host]
+	if !ok {
+		o.domain, o.err = s.ownedDomain(ctx, host, apiKey)
+		if o.err != nil && !errors.Is(o.err, apperr.ErrInvalid) && !errors.Is(o.err, apperr.ErrForbidden) {
+			return o.err
+		}
+		owned[host] = o
+	}
+	if o.err != nil {
+		return o.err
+	}
+	u.DomainID = &o.domain.ID
+	u.Domain = o.domain.Host
+	return nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// sliceSource is an ImportSource over fixed records; a nil URL yields err.
+type sliceSource struct {
+	records []sourceRecord
+	pos     int
+}
+
+type sourceRecord struct {
+	u   *model.URL
+	err error
+}
+
+func (s *sliceSource) Next() (int, *model.URL, error) {
+	if s.pos == len(s.records) {
+		return s.pos + 1, nil, io.EOF
+	}
+	r := s.records[s.pos]
+	s.pos++
+	return s.pos, r.u, r.err
+}
+
+func TestExport_Paginates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	first := make([]model.URL, exportBatchSize)
+	for i := range first {
+		first[i] = model.URL{ID: fmt.Sprintf("id-%d", i), CreatedAt: time.Unix(int64(1000-i), 0)}
+	}
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			List(gomock.Any(), model.ListOptions{Limit: exportBatchSize}).
+			Return(first, nil),
+		mockRepo.EXPECT().
+			List(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, opts model.ListOptions) ([]model.URL, error) {
+				last := first[len(first)-1]
+				if opts.After == nil || opts.After.ID != last.ID || !opts.After.CreatedAt.Equal(last.CreatedAt) {
+					t.Errorf("expected cursor after the last link, got %+v", opts.After)
+				}
+				return []model.URL{{ID: "tail"}}, nil
+			}),
+	)
+
+	n := 0
+	err := svc.Export(context.Background(), "", func(u *model.URL) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n != exportBatchSize+1 {
+		t.Errorf("expected %d links, got %d", exportBatchSize+1, n)
+	}
+}
+
+func TestExport_StopsOnCallbackError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]model.URL{{ID: "a"}, {ID: "b"}}, nil)
+
+	wantErr := errors.New("client went away")
+	err := svc.Export(context.Background(), "", func(u *model.URL) error { return wantErr })
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+}
+
+func TestImport_ReportsProblems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	src := &sliceSource{records: []sourceRecord{
+		{u: &model.URL{Code: "taken", OriginalURL: "https://example.com/a"}},
+		{err: apperr.Invalid("line 2: invalid JSON")},
+		{u: &model.URL{Code: "bad code!", OriginalURL: "https://example.com/b"}},
+		{u: &model.URL{Code: "ok", OriginalURL: "not-a-url"}},
+		{u: &model.URL{Code: "fresh", OriginalURL: "https://example.com/c"}},
+	}}
+
+	mockRepo.EXPECT().
+		Import(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, urls []model.URL) ([]int, error) {
+			if len(urls) != 2 || urls[0].Code != "taken" || urls[1].Code != "fresh" {
+				t.Errorf("unexpected batch %+v", urls)
+			}
+			if urls[1].QueryPrecedence != model.QueryPrecedenceIncoming {
+				t.Errorf("expected default precedence, got %q", urls[1].QueryPrecedence)
+			}
+			return []int{0}, nil
+		})
+
+	report, err := svc.Import(context.Background(), src, "", "", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Imported != 1 || report.Conflicted != 1 || report.Failed != 3 {
+		t.Errorf("unexpected counts %+v", report)
+	}
+	if report.Conflicts[0].Line != 1 || report.Conflicts[0].Code != "taken" {
+		t.Errorf("expected conflict on line 1, got %+v", report.Conflicts[0])
+	}
+	for i, e := range report.Errors {
+		if e.Line != i+2 {
+			t.Errorf("expected error %d on line %d, got %+v", i, i+2, e)
+		}
+	}
+}
+
+func TestImport_FlushesBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	src := &sliceSource{}
+	for i := range importBatchSize + 1 {
+		src.records = append(src.records, sourceRecord{u: &model.URL{Code: fmt.Sprintf("c%d", i), OriginalURL: "https://example.com"}})
+	}
+
+	gomock.InOrder(
+		mockRepo.EXPECT().Import(gomock.Any(), gomock.Len(importBatchSize)).Return(nil, nil),
+		mockRepo.EXPECT().Import(gomock.Any(), gomock.Len(1)).Return(nil, nil),
+	)
+
+	report, err := svc.Import(context.Background(), src, "", "", "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Imported != importBatchSize+1 {
+		t.Errorf("expected %d imported, got %d", importBatchSize+1, report.Imported)
+	}
+}
+
+func TestImport_ChecksDomainOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, mockRepo, mockDomains := setupDomainURLService(ctrl)
+
+	src := &sliceSource{records: []sourceRecord{
+		{u: &model.URL{Code: "a", OriginalURL: "https://example.com/a"}},
+		{u: &model.URL{Code: "b", OriginalURL: "https://example.com/b", Domain: "elsewhere.example.com"}},
+	}}
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "dom-1", Host: "go.example.com", OwnerKeyHash: HashAPIKey("secret")}, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		Import(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, urls []model.URL) ([]int, error) {
+			for _, u := range urls {
+				if u.DomainID == nil || *u.DomainID != "dom-1" {
+					t.Errorf("expected %s on dom-1, got %v", u.Code, u.DomainID)
+				}
+			}
+			return nil, nil
+		})
+
+	report, err := svc.Import(context.Background(), src, "Go.Example.com", "", "secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Imported != 2 {
+		t.Errorf("expected 2 imported, got %d", report.Imported)
+	}
+}
+
+func TestImport_ForeignDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _, mockDomains := setupDomainURLService(ctrl)
+
+	src := &sliceSource{records: []sourceRecord{
+		{u: &model.URL{Code: "a", OriginalURL: "https://example.com/a", Domain: "go.example.com"}},
+		{u: &model.URL{Code: "b", OriginalURL: "https://example.com/b", Domain: "go.example.com"}},
+	}}
+
+	mockDomains.EXPECT().
+		GetByHost(gomock.Any(), "go.example.com").
+		Return(&model.Domain{ID: "dom-1", Host: "go.example.com", OwnerKeyHash: HashAPIKey("secret")}, nil).
+		Times(1)
+
+	report, err := svc.Import(context.Background(), src, "", "", "wrong")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if report.Failed != 2 || report.Imported != 0 {
+		t.Errorf("expected both links rejected, got %+v", report)
+	}
+}
+
+func TestImport_AbortsOnRepoError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	src := &sliceSource{records: []sourceRecord{{u: &model.URL{Code: "a", OriginalURL: "https://example.com"}}}}
+	mockRepo.EXPECT().Import(gomock.Any(), gomock.Any()).Return(nil, apperr.Unavailable(errors.New("conn reset"), "database unavailable"))
+
+	if _, err := svc.Import(context.Background(), src, "", "", ""); !errors.Is(err, apperr.ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestPrepareImport_ReservedCode(t *testing.T) {
+	svc := NewURLService(nil)
+	u := &model.URL{Code: "admin", OriginalURL: "https://example.com"}
+	if err := svc.prepareImport(context.Background(), u, "", map[string]ownership{}); !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestPrepareImport_CodeTooLong(t *testing.T) {
+	svc := NewURLService(nil)
+	u := &model.URL{Code: strings.Repeat("a", 201), OriginalURL: "https://example.com"}
+	if err := svc.prepareImport(context.Background(), u, "", map[string]ownership{}); !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
@@ -2,64 +2,657 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
+	"crypto/subtle"
+	"errors"
 	"fmt"
-	"math/big"
 	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/codegen"
 	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/replica"
 	"github.com/kerbatek/url-shortener/internal/repository"
 )
 
 const (
-	codeLength = 7
-	charset    = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	defaultListLimit = 20
+	maxListLimit     = 100
 )
 
 type URLService struct {
-	repo repository.URLRepository
+	repo     repository.URLRepository
+	domains  repository.DomainRepository
+	clicks   repository.ClickRepository
+	screener Screener
+	events   EventPublisher
+	bots     BotClassifier
+	previews PreviewFetcher
+	accounts repository.AccountRepository
+	audit    repository.AuditRepository
+	// codes holds a generator for each codegen strategy; codeStrategy is
+	// the one used when a request names none.
+	codes        map[string]codegen.Generator
+	codeStrategy string
+	codeConfig   codegen.Config
+	// defaultHosts serve the default namespace whatever domains say.
+	defaultHosts map[string]bool
+}
+
+// BotClassifier recognises bots and crawlers among redirect requests.
+// botdetect.Classifier implements it.
+type BotClassifier interface {
+	// Classify reports whether the client is a bot, naming the pattern or
+	// address range that matched.
+	Classify(userAgent, ip string) (name string, bot bool)
+	// Unfurler reports whether userAgent belongs to a link unfurler, a
+	// bot that renders preview cards for links pasted into chats.
+	Unfurler(userAgent string) bool
+}
+
+// PreviewFetcher reads a destination's own preview metadata.
+// unfurl.Fetcher implements it.
+type PreviewFetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*model.LinkPreview, error)
+}
+
+// EventPublisher streams served redirects to external sinks. Publish is
+// called on the redirect path, so it must not wait on delivery.
+type EventPublisher interface {
+	Publish(ctx context.Context, event model.ClickEvent)
+}
+
+// Option configures optional URLService dependencies.
+type Option func(*URLService)
+
+// WithDomains enables custom short domains backed by repo.
+func WithDomains(repo repository.DomainRepository) Option {
+	return func(s *URLService) { s.domains = repo }
+}
+
+// WithClicks enables click recording and stats backed by repo.
+func WithClicks(repo repository.ClickRepository) Option {
+	return func(s *URLService) { s.clicks = repo }
+}
+
+// WithScreener rejects destinations that screener lists as threats and
+// enables Rescan.
+func WithScreener(screener Screener) Option {
+	return func(s *URLService) { s.screener = screener }
+}
+
+// WithEvents publishes every recorded click to publisher.
+func WithEvents(publisher EventPublisher) Option {
+	return func(s *URLService) { s.events = publisher }
+}
+
+// WithBotClassifier flags clicks from clients classifier recognises as
+// bots, so stats can leave them out and links can serve them previews.
+func WithBotClassifier(classifier BotClassifier) Option {
+	return func(s *URLService) { s.bots = classifier }
+}
+
+// WithPreviewFetcher lets Shorten prefill link previews from their
+// destination.
+func WithPreviewFetcher(fetcher PreviewFetcher) Option {
+	return func(s *URLService) { s.previews = fetcher }
+}
+
+// WithAccounts lets links belong to workspaces, whose members' roles
+// decide who may read and change them.
+func WithAccounts(repo repository.AccountRepository) Option {
+	return func(s *URLService) { s.accounts = repo }
+}
+
+// WithAudit lets callers read the audit log kept in repo.
+func WithAudit(repo repository.AuditRepository) Option {
+	return func(s *URLService) { s.audit = repo }
+}
+
+// WithCodeStrategy makes strategy, one of codegen.Names, the default for
+// new links instead of codegen.Random, with cfg configuring the strategies.
+func WithCodeStrategy(strategy string, cfg codegen.Config) Option {
+	return func(s *URLService) {
+		s.codeStrategy = strategy
+		s.codeConfig = cfg
+	}
 }
 
-func NewURLService(repo repository.URLRepository) *URLService {
-	return &URLService{repo: repo}
+// WithDefaultHosts serves the default namespace on hosts, ignoring any
+// custom domain registered for one of them.
+func WithDefaultHosts(hosts ...string) Option {
+	return func(s *URLService) {
+		if s.defaultHosts == nil {
+			s.defaultHosts = map[string]bool{}
+		}
+		for _, h := range hosts {
+			s.defaultHosts[normalizeHost(h)] = true
+		}
+	}
 }
 
-func generateCode() (string, error) {
-	b := make([]byte, codeLength)
-	for i := range b {
-		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+func NewURLService(repo repository.URLRepository, opts ...Option) *URLService {
+	s := &URLService{repo: repo, codeStrategy: codegen.Random}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.codes = codegen.New(repo, s.codeConfig)
+	return s
+}
+
+// codeGenerator returns the generator of strategy, or of the default
+// strategy when it is "".
+func (s *URLService) codeGenerator(strategy string) (codegen.Generator, error) {
+	if strategy == "" {
+		strategy = s.codeStrategy
+	}
+	g, ok := s.codes[strategy]
+	if !ok {
+		return nil, apperr.Invalid("invalid code_strategy %q", strategy)
+	}
+	return g, nil
+}
+
+func (s *URLService) Shorten(ctx context.Context, req model.ShortenRequest) (_ *model.URL, err error) {
+	ctx, span := startSpan(ctx, "Shorten")
+	defer func() { endSpan(span, err) }()
+
+	precedence := req.QueryPrecedence
+	if precedence == "" {
+		precedence = model.QueryPrecedenceIncoming
+	}
+	botPolicy := req.BotPolicy
+	if botPolicy == "" {
+		botPolicy = model.BotPolicyRedirect
+	}
+	u := &model.URL{
+		OriginalURL:     req.URL,
+		ForwardQuery:    req.ForwardQuery,
+		QueryPrecedence: precedence,
+		UTMParams:       req.UTMParams,
+		BotPolicy:       botPolicy,
+		Campaign:        strings.TrimSpace(req.Campaign),
+		Tags:            cleanTags(req.Tags),
+		Preview:         cleanPreview(req.Preview),
+		Title:           strings.TrimSpace(req.Title),
+		Description:     strings.TrimSpace(req.Description),
+		Metadata:        cleanMetadata(req.Metadata),
+		MaxClicks:       req.MaxClicks,
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+	codes, err := s.codeGenerator(req.CodeStrategy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.screen(u.OriginalURL); err != nil {
+		return nil, err
+	}
+	if req.FetchPreview {
+		if err := s.prefillPreview(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+	if req.Workspace != "" {
+		if _, err := authorize(ctx, s.accounts, req.Workspace, model.RoleEditor); err != nil {
+			return nil, err
+		}
+		u.WorkspaceID = &req.Workspace
+	}
+	if req.Domain != "" {
+		d, err := s.ownedDomain(ctx, req.Domain, req.APIKey)
+		if err != nil {
+			return nil, err
+		}
+		u.DomainID = &d.ID
+		u.Domain = d.Host
+	}
+
+	attempts := codegen.Attempts(codes)
+	for attempt := 1; ; attempt++ {
+		code, err := codes.Generate(ctx)
 		if err != nil {
-			return "", err
+			return nil, fmt.Errorf("failed to generate code: %w", err)
 		}
-		b[i] = charset[n.Int64()]
+		u.Code = code
+
+		err = s.repo.Create(ctx, u)
+		if err == nil {
+			return u, nil
+		}
+		if !errors.Is(err, apperr.ErrConflict) || attempt == attempts {
+			return nil, err
+		}
+	}
+}
+
+// validateURL checks the caller-controlled fields of u.
+func validateURL(u *model.URL) error {
+	if _, err := url.ParseRequestURI(u.OriginalURL); err != nil {
+		return apperr.Invalid("invalid URL: %v", err)
+	}
+	switch u.QueryPrecedence {
+	case model.QueryPrecedenceIncoming, model.QueryPrecedenceDestination:
+	default:
+		return apperr.Invalid("invalid query_precedence %q", u.QueryPrecedence)
+	}
+	for k := range u.UTMParams {
+		if !strings.HasPrefix(k, "utm_") {
+			return apperr.Invalid("invalid UTM parameter %q", k)
+		}
+	}
+	switch u.BotPolicy {
+	case model.BotPolicyRedirect, model.BotPolicyPreview:
+	default:
+		return apperr.Invalid("invalid bot_policy %q", u.BotPolicy)
+	}
+	if err := validateGroups(u); err != nil {
+		return err
+	}
+	if err := validateNotes(u); err != nil {
+		return err
+	}
+	if u.MaxClicks < 0 {
+		return apperr.Invalid("max_clicks must not be negative")
+	}
+	if p := u.Preview; p != nil {
+		if n := utf8.RuneCountInString(p.Title); n > model.MaxPreviewTitle {
+			return apperr.Invalid("preview title is %d characters, the limit is %d", n, model.MaxPreviewTitle)
+		}
+		if n := utf8.RuneCountInString(p.Description); n > model.MaxPreviewDescription {
+			return apperr.Invalid("preview description is %d characters, the limit is %d", n, model.MaxPreviewDescription)
+		}
+		if p.ImageURL != "" {
+			img, err := url.ParseRequestURI(p.ImageURL)
+			if err != nil || (img.Scheme != "http" && img.Scheme != "https") || img.Host == "" {
+				return apperr.Invalid("preview image_url must be an absolute http or https URL")
+			}
+			if len(p.ImageURL) > model.MaxPreviewImageURL {
+				return apperr.Invalid("preview image_url is longer than %d characters", model.MaxPreviewImageURL)
+			}
+		}
+	}
+	return nil
+}
+
+// cleanPreview trims p, returning nil when nothing is left.
+func cleanPreview(p *model.LinkPreview) *model.LinkPreview {
+	if p == nil {
+		return nil
+	}
+	out := model.LinkPreview{
+		Title:       strings.TrimSpace(p.Title),
+		Description: strings.TrimSpace(p.Description),
+		ImageURL:    strings.TrimSpace(p.ImageURL),
+	}
+	if out == (model.LinkPreview{}) {
+		return nil
 	}
-	return string(b), nil
+	return &out
 }
 
-func (s *URLService) Shorten(ctx context.Context, originalURL string) (*model.URL, error) {
-	if _, err := url.ParseRequestURI(originalURL); err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+// prefillPreview fills the preview fields of u left empty from its
+// destination's metadata. A destination that cannot be read leaves the
+// preview as it is rather than failing the link.
+func (s *URLService) prefillPreview(ctx context.Context, u *model.URL) error {
+	if s.previews == nil {
+		return apperr.Invalid("preview fetching is not enabled")
+	}
+	fetched, err := s.previews.Fetch(ctx, u.OriginalURL)
+	if err != nil || fetched == nil {
+		return nil
+	}
+	p := model.LinkPreview{}
+	if u.Preview != nil {
+		p = *u.Preview
+	}
+	if p.Title == "" {
+		p.Title = fetched.Title
 	}
+	if p.Description == "" {
+		p.Description = fetched.Description
+	}
+	if p.ImageURL == "" {
+		p.ImageURL = fetched.ImageURL
+	}
+	u.Preview = cleanPreview(&p)
+	return validateURL(u)
+}
 
-	code, err := generateCode()
+func (s *URLService) ownedDomain(ctx context.Context, host, apiKey string) (*model.Domain, error) {
+	if s.domains == nil {
+		return nil, apperr.Invalid("custom domains are not enabled")
+	}
+	d, err := s.domains.GetByHost(ctx, normalizeHost(host))
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate code: %w", err)
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, apperr.Invalid("unknown domain %q", host)
+		}
+		return nil, err
 	}
+	if apiKey == "" || subtle.ConstantTimeCompare([]byte(HashAPIKey(apiKey)), []byte(d.OwnerKeyHash)) != 1 {
+		return nil, apperr.Forbidden("domain %q is not owned by this API key", d.Host)
+	}
+	return d, nil
+}
 
-	u := &model.URL{
-		Code:        code,
-		OriginalURL: originalURL,
+// domainFor returns the custom domain serving host, or nil when host is not
+// a registered domain and the default namespace applies.
+func (s *URLService) domainFor(ctx context.Context, host string) (*model.Domain, error) {
+	host = normalizeHost(host)
+	if s.domains == nil || s.defaultHosts[host] {
+		return nil, nil
+	}
+	d, err := s.domains.GetByHost(ctx, host)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	if err := s.repo.Create(ctx, u); err != nil {
+	return d, nil
+}
+
+// Resolve looks up code in the namespace of the domain serving host.
+func (s *URLService) Resolve(ctx context.Context, host, code string) (_ *model.URL, err error) {
+	ctx, span := startSpan(ctx, "Resolve", attribute.String("short_code", code))
+	defer func() { endSpan(span, err) }()
+
+	d, err := s.domainFor(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	domainID := ""
+	if d != nil {
+		domainID = d.ID
+	}
+	return s.repo.GetByCode(ctx, domainID, code)
+}
+
+// FallbackURL returns where unknown codes on host should be sent, or "" when
+// the domain has no fallback and a 404 should be served.
+func (s *URLService) FallbackURL(ctx context.Context, host string) string {
+	d, err := s.domainFor(ctx, host)
+	if err != nil || d == nil {
+		return ""
+	}
+	return d.FallbackURL
+}
+
+func (s *URLService) Get(ctx context.Context, id string) (_ *model.URL, err error) {
+	ctx, span := startSpan(ctx, "Get", attribute.String("link.id", id))
+	defer func() { endSpan(span, err) }()
+
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeLink(ctx, u.WorkspaceID, model.RoleViewer); err != nil {
 		return nil, err
 	}
 	return u, nil
 }
 
-func (s *URLService) Resolve(ctx context.Context, code string) (*model.URL, error) {
-	return s.repo.GetByCode(ctx, code)
+// scope returns the list options selecting the links the caller may list
+// from workspace: its links, which takes a viewer, or the links outside
+// any workspace when it is "". The operator lists every link with "".
+func (s *URLService) scope(ctx context.Context, workspace string) (model.ListOptions, error) {
+	if _, err := authorize(ctx, s.accounts, workspace, model.RoleViewer); err != nil {
+		return model.ListOptions{}, err
+	}
+	if workspace == "" && CallerFrom(ctx).Operator {
+		return model.ListOptions{AllWorkspaces: true}, nil
+	}
+	return model.ListOptions{Workspace: workspace}, nil
+}
+
+// List returns a page of links matching q, newest first.
+func (s *URLService) List(ctx context.Context, q model.ListQuery) (_ *model.URLPage, err error) {
+	ctx, span := startSpan(ctx, "List")
+	defer func() { endSpan(span, err) }()
+
+	opts, err := s.scope(ctx, q.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	opts.Limit = limit
+	opts.Search = strings.TrimSpace(q.Search)
+	opts.Tags = cleanTags(q.Tags)
+	opts.Campaign = strings.TrimSpace(q.Campaign)
+	opts.Metadata = q.Metadata
+	switch q.Status {
+	case model.LinkStatusAll:
+	case model.LinkStatusBroken:
+		opts.Broken = true
+	case model.LinkStatusDeleted:
+		opts.Deleted = true
+	default:
+		return nil, apperr.Invalid("invalid status %q", q.Status)
+	}
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		opts.After = after
+	}
+
+	urls, err := s.repo.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.URLPage{URLs: urls}
+	if len(urls) == limit {
+		last := urls[len(urls)-1]
+		page.NextCursor = encodeCursor(model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// IsBot reports whether a request from ip with the given user agent comes
+// from a bot. Without a classifier no request is.
+func (s *URLService) IsBot(userAgent, ip string) bool {
+	if s.bots == nil {
+		return false
+	}
+	_, bot := s.bots.Classify(userAgent, ip)
+	return bot
+}
+
+// ServesPreview reports whether a client should be shown the preview page
+// for u instead of being redirected: every bot when u's bot policy asks
+// for previews, and link unfurlers whenever u carries preview metadata.
+func (s *URLService) ServesPreview(u *model.URL, userAgent string, bot bool) bool {
+	if !bot {
+		return false
+	}
+	if u.BotPolicy == model.BotPolicyPreview {
+		return true
+	}
+	return u.Preview != nil && s.bots != nil && s.bots.Unfurler(userAgent)
 }
 
-func (s *URLService) Delete(ctx context.Context, id string) error {
+// ConsumeClick counts a redirect of u against its click limit, failing
+// with apperr.ErrGone once the limit is used up. The count is kept in the
+// repository, never on u, so every redirect server sees the same limit;
+// links without one are not counted.
+func (s *URLService) ConsumeClick(ctx context.Context, u *model.URL) (err error) {
+	if u.MaxClicks == 0 {
+		return nil
+	}
+	ctx, span := startSpan(ctx, "ConsumeClick", attribute.String("link.id", u.ID))
+	defer func() { endSpan(span, err) }()
+
+	used, err := s.repo.ConsumeClick(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	u.ClicksUsed = used
+	return nil
+}
+
+// RecordClick stores a redirect served for u and publishes it to the event
+// sinks; target is where the client was sent. Either step is skipped when
+// not enabled.
+func (s *URLService) RecordClick(ctx context.Context, u *model.URL, target string, click model.Click) (err error) {
+	ctx, span := startSpan(ctx, "RecordClick", attribute.String("link.id", u.ID))
+	defer func() { endSpan(span, err) }()
+	click.URLID = u.ID
+	click.ClickedAt = time.Now().UTC()
+
+	if s.clicks != nil {
+		err = s.clicks.Record(ctx, &click)
+	}
+	if s.events != nil {
+		s.events.Publish(ctx, model.ClickEvent{
+			Click:       click,
+			Code:        u.Code,
+			Domain:      u.Domain,
+			Destination: u.OriginalURL,
+			Target:      target,
+		})
+	}
+	return err
+}
+
+// Stats returns click statistics for the link with the given ID, counting
+// bot clicks in the totals only when includeBots is set.
+func (s *URLService) Stats(ctx context.Context, id string, includeBots bool) (_ *model.Stats, err error) {
+	ctx, span := startSpan(ctx, "Stats", attribute.String("link.id", id))
+	defer func() { endSpan(span, err) }()
+
+	if s.clicks == nil {
+		return nil, apperr.Unavailable(nil, "click tracking is not enabled")
+	}
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeLink(ctx, u.WorkspaceID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.clicks.Stats(ctx, id, model.StatsDays, includeBots)
+}
+
+// Update applies the non-nil fields of req to the link with the given ID.
+func (s *URLService) Update(ctx context.Context, id string, req model.UpdateRequest) (_ *model.URL, err error) {
+	ctx, span := startSpan(ctx, "Update", attribute.String("link.id", id))
+	defer func() { endSpan(span, err) }()
+
+	// The link is saved whole, so it must not be read from a replica that
+	// has yet to see an earlier change.
+	ctx = replica.WithPrimary(ctx)
+	u, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeLink(ctx, u.WorkspaceID, model.RoleEditor); err != nil {
+		return nil, err
+	}
+	if req.URL != nil && *req.URL != u.OriginalURL {
+		u.OriginalURL = *req.URL
+		u.Health = nil // the repository resets checks for a new destination
+	}
+	if req.ForwardQuery != nil {
+		u.ForwardQuery = *req.ForwardQuery
+	}
+	if req.QueryPrecedence != nil {
+		u.QueryPrecedence = *req.QueryPrecedence
+	}
+	if req.UTMParams != nil {
+		u.UTMParams = *req.UTMParams
+	}
+	if req.BotPolicy != nil {
+		u.BotPolicy = *req.BotPolicy
+	}
+	if req.Campaign != nil {
+		u.Campaign = strings.TrimSpace(*req.Campaign)
+	}
+	if req.Tags != nil {
+		u.Tags = cleanTags(*req.Tags)
+	}
+	if req.Preview != nil {
+		u.Preview = cleanPreview(req.Preview)
+	}
+	if req.Title != nil {
+		u.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		u.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Metadata != nil {
+		u.Metadata = cleanMetadata(*req.Metadata)
+	}
+	if req.MaxClicks != nil {
+		u.MaxClicks = *req.MaxClicks
+	}
+	if u.BotPolicy == "" {
+		u.BotPolicy = model.BotPolicyRedirect
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		if err := s.screen(u.OriginalURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	// A disabled link pointed somewhere clean need not wait for a rescan.
+	if req.URL != nil && u.DisabledAt != nil && s.screener != nil {
+		if err := s.repo.SetDisabled(ctx, u.ID, ""); err != nil {
+			return nil, err
+		}
+		u.DisabledAt, u.DisabledReason = nil, ""
+	}
+	return u, nil
+}
+
+func (s *URLService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "Delete", attribute.String("link.id", id))
+	defer func() { endSpan(span, err) }()
+
+	if err := s.authorizeLinkID(ctx, id); err != nil {
+		return err
+	}
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *URLService) Restore(ctx context.Context, id string) (_ *model.URL, err error) {
+	ctx, span := startSpan(ctx, "Restore", attribute.String("link.id", id))
+	defer func() { endSpan(span, err) }()
+
+	if err := s.authorizeLinkID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.Restore(ctx, id)
+}
+
+// authorizeLinkID checks that the caller may edit the link with the given
+// ID, which may be deleted. Without workspaces, or for the operator, there
+// is nothing to check and the link is not read.
+func (s *URLService) authorizeLinkID(ctx context.Context, id string) error {
+	if s.accounts == nil || CallerFrom(ctx).Operator {
+		return nil
+	}
+	workspaceID, err := s.repo.WorkspaceOf(ctx, id)
+	if err != nil || workspaceID == "" {
+		return err
+	}
+	return s.authorizeLink(ctx, &workspaceID, model.RoleEditor)
+}
@@ -2,11 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/botdetect"
+	"github.com/kerbatek/url-shortener/internal/codegen"
 	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/replica"
 	"github.com/kerbatek/url-shortener/internal/repository/mocks"
 	"go.uber.org/mock/gomock"
 )
@@ -27,15 +33,15 @@ func TestShorten_Success(t *testing.T) {
 			return nil
 		})
 
-	result, err := svc.Shorten(context.Background(), "https://example.com")
+	result, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com"})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 	if result.OriginalURL != "https://example.com" {
 		t.Errorf("expected original URL https://example.com, got %s", result.OriginalURL)
 	}
-	if len(result.Code) != codeLength {
-		t.Errorf("expected code length %d, got %d", codeLength, len(result.Code))
+	if len(result.Code) != 7 {
+		t.Errorf("expected code length 7, got %d", len(result.Code))
 	}
 	if result.ID != "550e8400-e29b-41d4-a716-446655440000" {
 		t.Errorf("expected ID 550e8400-e29b-41d4-a716-446655440000, got %s", result.ID)
@@ -49,7 +55,7 @@ func TestShorten_InvalidURL(t *testing.T) {
 	mockRepo := mocks.NewMockURLRepository(ctrl)
 	svc := NewURLService(mockRepo)
 
-	_, err := svc.Shorten(context.Background(), "not-a-url")
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "not-a-url"})
 	if err == nil {
 		t.Fatal("expected error for invalid URL, got nil")
 	}
@@ -66,12 +72,225 @@ func TestShorten_RepoError(t *testing.T) {
 		Create(gomock.Any(), gomock.Any()).
 		Return(fmt.Errorf("db error"))
 
-	_, err := svc.Shorten(context.Background(), "https://example.com")
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com"})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
 
+func TestShorten_RetriesCodeConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(apperr.Conflict("url already exists")),
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	if _, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com"}); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+}
+
+func TestShorten_GivesUpAfterConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(apperr.Conflict("url already exists")).
+		Times(3)
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com"})
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func TestShorten_CodeStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		request  string
+		want     string
+	}{
+		{"request picks sequential", "", codegen.Sequential, "g8"},
+		{"default sequential", codegen.Sequential, "", "g8"},
+		{"request overrides default", codegen.Sequential, codegen.Obfuscated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockURLRepository(ctrl)
+			var opts []Option
+			if tt.strategy != "" {
+				opts = append(opts, WithCodeStrategy(tt.strategy, codegen.Config{Salt: "test"}))
+			}
+			svc := NewURLService(mockRepo, opts...)
+
+			mockRepo.EXPECT().NextCodeID(gomock.Any()).Return(int64(1000), nil)
+			mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+			u, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com", CodeStrategy: tt.request})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tt.want != "" && u.Code != tt.want {
+				t.Errorf("expected code %q, got %q", tt.want, u.Code)
+			}
+			if tt.want == "" && len(u.Code) != 5 {
+				t.Errorf("expected a 5 character obfuscated code, got %q", u.Code)
+			}
+		})
+	}
+}
+
+func TestShorten_CaseInsensitiveCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithCodeStrategy(codegen.Random, codegen.Config{CaseInsensitive: true}))
+
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(20)
+
+	for i := 0; i < 20; i++ {
+		u, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if u.Code != strings.ToLower(u.Code) {
+			t.Fatalf("expected a lower case code, got %q", u.Code)
+		}
+	}
+}
+
+func TestShorten_SequenceSkipsTakenCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithCodeStrategy(codegen.Sequential, codegen.Config{}))
+
+	gomock.InOrder(
+		mockRepo.EXPECT().NextCodeID(gomock.Any()).Return(int64(1), nil),
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(apperr.Conflict("url already exists")),
+		mockRepo.EXPECT().NextCodeID(gomock.Any()).Return(int64(2), nil),
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(apperr.Conflict("url already exists")),
+		mockRepo.EXPECT().NextCodeID(gomock.Any()).Return(int64(3), nil),
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(apperr.Conflict("url already exists")),
+		mockRepo.EXPECT().NextCodeID(gomock.Any()).Return(int64(4), nil),
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	u, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.Code != "4" {
+		t.Errorf("expected code 4, got %q", u.Code)
+	}
+}
+
+func TestShorten_UnknownCodeStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com", CodeStrategy: "emoji"})
+	if !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestShorten_InvalidURLKind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+
+	_, err := svc.Shorten(context.Background(), model.ShortenRequest{URL: "not-a-url"})
+	if !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected invalid, got %v", err)
+	}
+}
+
+func TestShorten_DefaultsQueryPrecedence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	result, err := svc.Shorten(context.Background(), model.ShortenRequest{
+		URL:          "https://example.com",
+		ForwardQuery: true,
+		UTMParams:    map[string]string{"utm_source": "newsletter"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.QueryPrecedence != model.QueryPrecedenceIncoming {
+		t.Errorf("expected precedence incoming, got %s", result.QueryPrecedence)
+	}
+	if !result.ForwardQuery {
+		t.Error("expected ForwardQuery to be true")
+	}
+	if result.BotPolicy != model.BotPolicyRedirect {
+		t.Errorf("expected bot policy redirect, got %s", result.BotPolicy)
+	}
+}
+
+func TestShorten_InvalidQueryPolicy(t *testing.T) {
+	manyTags := make([]string, model.MaxTags+1)
+	for i := range manyTags {
+		manyTags[i] = fmt.Sprintf("tag%d", i)
+	}
+	tests := []struct {
+		name string
+		req  model.ShortenRequest
+	}{
+		{"unknown precedence", model.ShortenRequest{URL: "https://example.com", QueryPrecedence: "sideways"}},
+		{"non-utm parameter", model.ShortenRequest{URL: "https://example.com", UTMParams: map[string]string{"ref": "x"}}},
+		{"unknown bot policy", model.ShortenRequest{URL: "https://example.com", BotPolicy: "block"}},
+		{"long preview title", model.ShortenRequest{URL: "https://example.com",
+			Preview: &model.LinkPreview{Title: strings.Repeat("x", model.MaxPreviewTitle+1)}}},
+		{"relative preview image", model.ShortenRequest{URL: "https://example.com",
+			Preview: &model.LinkPreview{ImageURL: "/card.png"}}},
+		{"tag with a comma", model.ShortenRequest{URL: "https://example.com", Tags: []string{"a,b"}}},
+		{"too many tags", model.ShortenRequest{URL: "https://example.com", Tags: manyTags}},
+		{"long campaign", model.ShortenRequest{URL: "https://example.com",
+			Campaign: strings.Repeat("c", model.MaxCampaignLength+1)}},
+		{"negative click limit", model.ShortenRequest{URL: "https://example.com", MaxClicks: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+			if _, err := svc.Shorten(context.Background(), tt.req); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
 func TestResolve_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -86,10 +305,10 @@ func TestResolve_Success(t *testing.T) {
 	}
 
 	mockRepo.EXPECT().
-		GetByCode(gomock.Any(), "abc1234").
+		GetByCode(gomock.Any(), "", "abc1234").
 		Return(expected, nil)
 
-	result, err := svc.Resolve(context.Background(), "abc1234")
+	result, err := svc.Resolve(context.Background(), "example.com", "abc1234")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -106,10 +325,10 @@ func TestResolve_NotFound(t *testing.T) {
 	svc := NewURLService(mockRepo)
 
 	mockRepo.EXPECT().
-		GetByCode(gomock.Any(), "missing").
-		Return(nil, fmt.Errorf("not found"))
+		GetByCode(gomock.Any(), "", "missing").
+		Return(nil, apperr.NotFound("url not found"))
 
-	_, err := svc.Resolve(context.Background(), "missing")
+	_, err := svc.Resolve(context.Background(), "example.com", "missing")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -141,7 +360,7 @@ func TestDelete_NotFound(t *testing.T) {
 
 	mockRepo.EXPECT().
 		Delete(gomock.Any(), "00000000-0000-0000-0000-000000000000").
-		Return(fmt.Errorf("url with id 00000000-0000-0000-0000-000000000000 not found"))
+		Return(apperr.NotFound("url not found"))
 
 	err := svc.Delete(context.Background(), "00000000-0000-0000-0000-000000000000")
 	if err == nil {
@@ -149,32 +368,391 @@ func TestDelete_NotFound(t *testing.T) {
 	}
 }
 
-func TestGenerateCode(t *testing.T) {
-	code, err := generateCode()
+func TestList_NextCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	created := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 2}).
+		Return([]model.URL{{ID: "a"}, {ID: "b", CreatedAt: created}}, nil)
+
+	page, err := svc.List(context.Background(), model.ListQuery{Limit: 2})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	if len(code) != codeLength {
-		t.Errorf("expected length %d, got %d", codeLength, len(code))
+	if page.NextCursor == "" {
+		t.Fatal("expected next cursor on a full page")
 	}
 
-	// Verify all characters are in charset
-	for _, c := range code {
-		found := false
-		for _, ch := range charset {
-			if c == ch {
-				found = true
-				break
-			}
+	after := &model.Cursor{CreatedAt: created, ID: "b"}
+	mockRepo.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 2, After: after}).
+		Return([]model.URL{{ID: "c"}}, nil)
+
+	page, err = svc.List(context.Background(), model.ListQuery{Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if page.NextCursor != "" {
+		t.Errorf("expected no cursor on the last page, got %q", page.NextCursor)
+	}
+}
+
+func TestList_ClampsLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().List(gomock.Any(), model.ListOptions{Limit: defaultListLimit}).Return(nil, nil)
+	mockRepo.EXPECT().List(gomock.Any(), model.ListOptions{Limit: maxListLimit}).Return(nil, nil)
+
+	if _, err := svc.List(context.Background(), model.ListQuery{Limit: 0}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := svc.List(context.Background(), model.ListQuery{Limit: 1000}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestList_InvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+
+	for _, cursor := range []string{"!!!", "bm8tc2VwYXJhdG9y", "bm90LWEtdGltZXxpZA"} {
+		if _, err := svc.List(context.Background(), model.ListQuery{Limit: 10, Cursor: cursor}); !errors.Is(err, apperr.ErrInvalid) {
+			t.Errorf("cursor %q: expected ErrInvalid, got %v", cursor, err)
 		}
-		if !found {
-			t.Errorf("character %c not in charset", c)
+	}
+}
+
+func TestList_Status(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().List(gomock.Any(), model.ListOptions{Limit: 10, Broken: true}).Return(nil, nil)
+	mockRepo.EXPECT().List(gomock.Any(), model.ListOptions{Limit: 10, Deleted: true}).Return(nil, nil)
+
+	if _, err := svc.List(context.Background(), model.ListQuery{Limit: 10, Status: model.LinkStatusBroken}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := svc.List(context.Background(), model.ListQuery{Limit: 10, Status: model.LinkStatusDeleted}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := svc.List(context.Background(), model.ListQuery{Limit: 10, Status: "healthy"}); !errors.Is(err, apperr.ErrInvalid) {
+		t.Errorf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestList_Search(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().List(gomock.Any(), model.ListOptions{Limit: 10, Search: "example"}).Return(nil, nil)
+
+	if _, err := svc.List(context.Background(), model.ListQuery{Limit: 10, Search: "  example "}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+// eventRecorder keeps the events published to it.
+// previewStub returns preview, or err, for every destination.
+type previewStub struct {
+	preview *model.LinkPreview
+	err     error
+}
+
+func (p previewStub) Fetch(context.Context, string) (*model.LinkPreview, error) {
+	return p.preview, p.err
+}
+
+func TestShorten_FetchPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	fetched := &model.LinkPreview{Title: "Fetched", Description: "From the page", ImageURL: "https://example.com/card.png"}
+	svc := NewURLService(mockRepo, WithPreviewFetcher(previewStub{preview: fetched}))
+	u, err := svc.Shorten(context.Background(), model.ShortenRequest{
+		URL:          "https://example.com",
+		Preview:      &model.LinkPreview{Title: " Custom "},
+		FetchPreview: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := model.LinkPreview{Title: "Custom", Description: "From the page", ImageURL: "https://example.com/card.png"}
+	if u.Preview == nil || *u.Preview != want {
+		t.Errorf("expected custom fields to win over fetched ones, got %+v", u.Preview)
+	}
+
+	// An unreadable destination still gets its link.
+	svc = NewURLService(mockRepo, WithPreviewFetcher(previewStub{err: errors.New("timeout")}))
+	u, err = svc.Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com", FetchPreview: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.Preview != nil {
+		t.Errorf("expected no preview, got %+v", u.Preview)
+	}
+
+	_, err = NewURLService(mockRepo).Shorten(context.Background(), model.ShortenRequest{URL: "https://example.com", FetchPreview: true})
+	if !errors.Is(err, apperr.ErrInvalid) {
+		t.Errorf("expected ErrInvalid without a fetcher, got %v", err)
+	}
+}
+
+func TestServesPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl), WithBotClassifier(botdetect.New()))
+	const slack = "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+	const google = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	plain := &model.URL{BotPolicy: model.BotPolicyRedirect}
+	custom := &model.URL{BotPolicy: model.BotPolicyRedirect, Preview: &model.LinkPreview{Title: "Sale"}}
+	all := &model.URL{BotPolicy: model.BotPolicyPreview}
+
+	tests := []struct {
+		name      string
+		u         *model.URL
+		userAgent string
+		bot       bool
+		want      bool
+	}{
+		{"human", all, "Mozilla/5.0", false, false},
+		{"unfurler without preview", plain, slack, true, false},
+		{"unfurler with preview", custom, slack, true, true},
+		{"crawler with preview", custom, google, true, false},
+		{"crawler under preview policy", all, google, true, true},
+	}
+	for _, tt := range tests {
+		if got := svc.ServesPreview(tt.u, tt.userAgent, tt.bot); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
 		}
 	}
+}
+
+type eventRecorder []model.ClickEvent
+
+func (r *eventRecorder) Publish(_ context.Context, event model.ClickEvent) {
+	*r = append(*r, event)
+}
+
+func TestRecordClick_PublishesEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	var events eventRecorder
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl), WithClicks(mockClicks), WithEvents(&events))
+
+	// The event goes out even when storing the click fails.
+	mockClicks.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+
+	u := &model.URL{ID: "id", Code: "abc1234", OriginalURL: "https://example.com"}
+	err := svc.RecordClick(context.Background(), u, "https://example.com?utm_source=x", model.Click{IP: "1.2.3.4"})
+	if err == nil {
+		t.Error("expected the storage error")
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	ev := events[0]
+	if ev.URLID != "id" || ev.Code != "abc1234" || ev.Target != "https://example.com?utm_source=x" || ev.IP != "1.2.3.4" || ev.ClickedAt.IsZero() {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestConsumeClick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	// Unlimited links never reach the repository.
+	if err := svc.ConsumeClick(context.Background(), &model.URL{ID: "free"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	mockRepo.EXPECT().ConsumeClick(gomock.Any(), "limited").Return(int64(3), nil)
+	u := &model.URL{ID: "limited", MaxClicks: 3, ClicksUsed: 1}
+	if err := svc.ConsumeClick(context.Background(), u); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.ClicksUsed != 3 || !u.Exhausted() {
+		t.Errorf("expected the count from the repository, got %d", u.ClicksUsed)
+	}
+
+	mockRepo.EXPECT().ConsumeClick(gomock.Any(), "limited").Return(int64(0), apperr.Gone("link has reached its click limit"))
+	if err := svc.ConsumeClick(context.Background(), u); !errors.Is(err, apperr.ErrGone) {
+		t.Errorf("expected ErrGone, got %v", err)
+	}
+}
+
+func TestStats_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	svc := NewURLService(mockRepo, WithClicks(mockClicks))
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(&model.URL{ID: "id"}, nil)
+	mockClicks.EXPECT().
+		Stats(gomock.Any(), "id", model.StatsDays, false).
+		Return(&model.Stats{URLID: "id", TotalClicks: 3}, nil)
+
+	stats, err := svc.Stats(context.Background(), "id", false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats.TotalClicks != 3 {
+		t.Errorf("expected 3 clicks, got %d", stats.TotalClicks)
+	}
+}
+
+func TestStats_IncludeBots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	mockClicks := mocks.NewMockClickRepository(ctrl)
+	svc := NewURLService(mockRepo, WithClicks(mockClicks))
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), "id").Return(&model.URL{ID: "id"}, nil)
+	mockClicks.EXPECT().
+		Stats(gomock.Any(), "id", model.StatsDays, true).
+		Return(&model.Stats{URLID: "id", TotalClicks: 5, BotClicks: 2}, nil)
+
+	if _, err := svc.Stats(context.Background(), "id", true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	if NewURLService(mocks.NewMockURLRepository(ctrl)).IsBot("curl/8.7.1", "") {
+		t.Error("expected no bots without a classifier")
+	}
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl), WithBotClassifier(botdetect.New()))
+	if !svc.IsBot("curl/8.7.1", "") {
+		t.Error("expected curl to be a bot")
+	}
+	if svc.IsBot("Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", "") {
+		t.Error("expected Firefox not to be a bot")
+	}
+}
+
+func TestStats_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo, WithClicks(mocks.NewMockClickRepository(ctrl)))
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), "missing").Return(nil, apperr.NotFound("url not found"))
+
+	if _, err := svc.Stats(context.Background(), "missing", false); !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStats_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewURLService(mocks.NewMockURLRepository(ctrl))
+
+	if _, err := svc.Stats(context.Background(), "id", false); !errors.Is(err, apperr.ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestUpdate_AppliesFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "id").
+		Return(&model.URL{ID: "id", OriginalURL: "https://old.example.com", QueryPrecedence: model.QueryPrecedenceIncoming}, nil)
+	mockRepo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.URL) error {
+			if u.OriginalURL != "https://new.example.com" || !u.ForwardQuery {
+				t.Errorf("unexpected update %+v", u)
+			}
+			if u.QueryPrecedence != model.QueryPrecedenceIncoming {
+				t.Errorf("expected precedence to be kept, got %s", u.QueryPrecedence)
+			}
+			return nil
+		})
+
+	dest, forward := "https://new.example.com", true
+	if _, err := svc.Update(context.Background(), "id", model.UpdateRequest{URL: &dest, ForwardQuery: &forward}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdate_ReadsFromPrimary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "id").
+		DoAndReturn(func(ctx context.Context, id string) (*model.URL, error) {
+			if !replica.UsesPrimary(ctx) {
+				t.Error("expected the link to be read from the primary")
+			}
+			return &model.URL{ID: id, OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}, nil
+		})
+	mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	title := "Example"
+	if _, err := svc.Update(context.Background(), "id", model.UpdateRequest{Title: &title}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestUpdate_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockURLRepository(ctrl)
+	svc := NewURLService(mockRepo)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "id").
+		Return(&model.URL{ID: "id", OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}, nil)
 
-	// Verify uniqueness (two codes should differ)
-	code2, _ := generateCode()
-	if code == code2 {
-		t.Error("expected different codes, got identical")
+	dest := "not-a-url"
+	_, err := svc.Update(context.Background(), "id", model.UpdateRequest{URL: &dest})
+	if !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
 	}
 }
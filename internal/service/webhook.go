@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+	"github.com/kerbatek/url-shortener/internal/repository"
+)
+
+const maxDeliveriesListed = 100
+
+var webhookEvents = []string{
+	model.EventLinkCreated, model.EventLinkUpdated, model.EventLinkDeleted, model.EventLinkRestored,
+	model.EventLinkClickLimitReached,
+}
+
+type WebhookService struct {
+	repo         repository.WebhookRepository
+	accounts     repository.AccountRepository
+	allowPrivate bool
+	lookup       netguard.Resolver
+}
+
+type WebhookOption func(*WebhookService)
+
+// WithPrivateWebhookTargets accepts webhook URLs on loopback, private and
+// link-local addresses, which are refused by default.
+func WithPrivateWebhookTargets() WebhookOption {
+	return func(s *WebhookService) { s.allowPrivate = true }
+}
+
+func NewWebhookService(repo repository.WebhookRepository, accounts repository.AccountRepository, opts ...WebhookOption) *WebhookService {
+	s := &WebhookService{repo: repo, accounts: accounts, lookup: net.DefaultResolver.LookupIPAddr}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// checkTarget refuses webhook URLs whose host is, or resolves to, a
+// non-public address. Names that do not resolve are let through: the
+// dispatcher screens the address of every delivery again as it connects,
+// which also covers names repointed after registration.
+func (s *WebhookService) checkTarget(ctx context.Context, u *url.URL) error {
+	if !s.allowPrivate && netguard.PrivateHost(ctx, s.lookup, u.Hostname()) {
+		return apperr.Invalid("webhook URL %q is on a private address", u)
+	}
+	return nil
+}
+
+// Register adds an endpoint owned by the calling user for the events of
+// links in workspaceID, which the caller must be a member of, or of links
+// outside any workspace when it is empty. The returned webhook carries the
+// generated signing secret, which is not shown again.
+func (s *WebhookService) Register(ctx context.Context, target string, events []string, workspaceID string) (*model.Webhook, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.ParseRequestURI(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, apperr.Invalid("invalid webhook URL %q", target)
+	}
+	if err := s.checkTarget(ctx, u); err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if !slices.Contains(webhookEvents, e) {
+			return nil, apperr.Invalid("unknown event %q", e)
+		}
+	}
+	if events == nil {
+		events = []string{}
+	}
+	var workspace *string
+	if workspaceID != "" {
+		// Deliveries require a membership, so roles granted only by the
+		// identity provider or by being the operator do not count here.
+		if s.accounts == nil {
+			return nil, apperr.Invalid("workspaces are not enabled")
+		}
+		if _, err := s.accounts.GetWorkspace(ctx, workspaceID, user.ID); err != nil {
+			return nil, err
+		}
+		workspace = &workspaceID
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	w := &model.Webhook{
+		URL:         target,
+		Secret:      hex.EncodeToString(secret),
+		Events:      events,
+		OwnerUserID: user.ID,
+		WorkspaceID: workspace,
+	}
+	if err := s.repo.Create(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// List returns the webhooks owned by the calling user without their
+// secrets.
+func (s *WebhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := s.repo.ListByOwner(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Deliveries returns the most recent delivery attempts for a webhook owned
+// by the calling user.
+func (s *WebhookService) Deliveries(ctx context.Context, webhookID string) ([]model.WebhookDelivery, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.repo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if w.OwnerUserID != user.ID {
+		return nil, apperr.NotFound("webhook not found")
+	}
+	return s.repo.ListDeliveries(ctx, webhookID, maxDeliveriesListed)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRegisterWebhook_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	svc := NewWebhookService(mockRepo, nil)
+
+	mockRepo.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, w *model.Webhook) error {
+			w.ID = "22222222-2222-2222-2222-222222222222"
+			return nil
+		})
+
+	w, err := svc.Register(as(ada), "https://hooks.example.com", []string{model.EventLinkCreated}, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(w.Secret) != 64 {
+		t.Errorf("expected 64-char hex secret, got %q", w.Secret)
+	}
+	if w.OwnerUserID != ada.ID || w.WorkspaceID != nil {
+		t.Errorf("expected a webhook of ada's outside any workspace, got %+v", w)
+	}
+}
+
+func TestRegisterWebhook_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		target string
+		events []string
+		want   error
+	}{
+		{"anonymous", context.Background(), "https://hooks.example.com", nil, apperr.ErrUnauthorized},
+		{"key of no user", WithCaller(context.Background(), Caller{}), "https://hooks.example.com", nil, apperr.ErrUnauthorized},
+		{"bad url", as(ada), "hooks.example.com", nil, apperr.ErrInvalid},
+		{"non-http scheme", as(ada), "ftp://hooks.example.com", nil, apperr.ErrInvalid},
+		{"unknown event", as(ada), "https://hooks.example.com", []string{"link.exploded"}, apperr.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewWebhookService(mocks.NewMockWebhookRepository(ctrl), nil)
+			if _, err := svc.Register(tt.ctx, tt.target, tt.events, ""); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRegisterWebhook_Workspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewWebhookService(mockRepo, mockAccounts)
+
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, ada.ID).
+		Return(&model.Workspace{ID: testWorkspace, Role: model.RoleViewer}, nil)
+	mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	w, err := svc.Register(as(ada), "https://hooks.example.com", nil, testWorkspace)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if w.WorkspaceID == nil || *w.WorkspaceID != testWorkspace {
+		t.Errorf("expected the webhook to be scoped to the workspace, got %v", w.WorkspaceID)
+	}
+}
+
+func TestRegisterWebhook_NotAMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	svc := NewWebhookService(mocks.NewMockWebhookRepository(ctrl), mockAccounts)
+
+	mockAccounts.EXPECT().
+		GetWorkspace(gomock.Any(), testWorkspace, grace.ID).
+		Return(nil, apperr.NotFound("workspace not found"))
+
+	// A role granted by the identity provider alone does not count, as
+	// deliveries check the membership.
+	ctx := WithCaller(context.Background(), Caller{User: grace, Roles: map[string]string{testWorkspace: model.RoleAdmin}})
+	if _, err := svc.Register(ctx, "https://hooks.example.com", nil, testWorkspace); !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRegisterWebhook_PrivateTargets(t *testing.T) {
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "internal.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.7")}}, nil
+	}
+	targets := []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://192.168.1.10/hook",
+		"https://internal.example.com/hook",
+	}
+
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewWebhookService(mocks.NewMockWebhookRepository(ctrl), nil)
+			svc.lookup = lookup
+			if _, err := svc.Register(as(ada), target, nil, ""); !errors.Is(err, apperr.ErrInvalid) {
+				t.Fatalf("expected ErrInvalid, got %v", err)
+			}
+
+			mockRepo := mocks.NewMockWebhookRepository(ctrl)
+			mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			svc = NewWebhookService(mockRepo, nil, WithPrivateWebhookTargets())
+			svc.lookup = lookup
+			if _, err := svc.Register(as(ada), target, nil, ""); err != nil {
+				t.Fatalf("expected private targets to be allowed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListWebhooks_HidesSecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	svc := NewWebhookService(mockRepo, nil)
+
+	mockRepo.EXPECT().
+		ListByOwner(gomock.Any(), ada.ID).
+		Return([]model.Webhook{{ID: "w1", Secret: "shh"}}, nil)
+
+	webhooks, err := svc.List(as(ada))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if webhooks[0].Secret != "" {
+		t.Error("expected secret to be cleared")
+	}
+}
+
+func TestWebhookDeliveries_NotOwned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	svc := NewWebhookService(mockRepo, nil)
+
+	mockRepo.EXPECT().
+		GetByID(gomock.Any(), "w1").
+		Return(&model.Webhook{ID: "w1", OwnerUserID: ada.ID}, nil)
+
+	if _, err := svc.Deliveries(as(grace), "w1"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
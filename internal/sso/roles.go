@@ -0,0 +1,63 @@
+package sso
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// OperatorRole in a RoleMap makes a group's members operators.
+const OperatorRole = "operator"
+
+// RoleMap maps identity provider groups to the roles their members get:
+// OperatorRole, or "<workspace id>:<role>" for a role in one workspace.
+type RoleMap map[string][]string
+
+// ParseRoleMap parses comma-separated group=role pairs, as in
+//
+//	platform-team=operator,marketing=11111111-1111-1111-1111-111111111111:editor
+//
+// A group may be listed more than once to grant several roles.
+func ParseRoleMap(s string) (RoleMap, error) {
+	m := RoleMap{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("want group=role, got %q", pair)
+		}
+		if role != OperatorRole {
+			workspace, wsRole, ok := strings.Cut(role, ":")
+			if !ok || workspace == "" || model.RoleRank(wsRole) == 0 {
+				return nil, fmt.Errorf("group %q: want %s or <workspace id>:<viewer|editor|admin>, got %q", group, OperatorRole, role)
+			}
+		}
+		m[group] = append(m[group], role)
+	}
+	return m, nil
+}
+
+// apply grants id the roles of its groups, keeping the highest role when
+// several groups grant one in the same workspace.
+func (m RoleMap) apply(id *model.Identity) {
+	for _, group := range id.Groups {
+		for _, role := range m[group] {
+			if role == OperatorRole {
+				id.Operator = true
+				continue
+			}
+			workspace, wsRole, _ := strings.Cut(role, ":")
+			if model.RoleRank(wsRole) > model.RoleRank(id.Roles[workspace]) {
+				if id.Roles == nil {
+					id.Roles = map[string]string{}
+				}
+				id.Roles[workspace] = wsRole
+			}
+		}
+	}
+}
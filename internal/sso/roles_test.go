@@ -0,0 +1,31 @@
+package sso
+
+import (
+	"testing"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+func TestParseRoleMap(t *testing.T) {
+	m, err := ParseRoleMap(" platform = operator, marketing=ws-1:viewer,marketing=ws-1:admin,,design=ws-2:editor")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	id := &model.Identity{Groups: []string{"marketing", "design", "unmapped"}}
+	m.apply(id)
+	if id.Operator {
+		t.Error("expected no operator role")
+	}
+	if id.Roles["ws-1"] != model.RoleAdmin || id.Roles["ws-2"] != model.RoleEditor {
+		t.Errorf("expected the highest role per workspace, got %v", id.Roles)
+	}
+}
+
+func TestParseRoleMap_Invalid(t *testing.T) {
+	for _, s := range []string{"platform", "=operator", "marketing=ws-1", "marketing=ws-1:owner", "marketing=:editor"} {
+		if _, err := ParseRoleMap(s); err == nil {
+			t.Errorf("%q: expected error, got nil", s)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package sso signs people in through an OpenID Connect identity provider:
+// browsers with the authorization code flow and PKCE, API clients with
+// bearer JWTs issued by the same provider. The provider's groups map to
+// the server's roles through a RoleMap.
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+type Config struct {
+	// Issuer is the provider's URL; its discovery document is fetched
+	// from Issuer/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends browsers back to after they
+	// sign in.
+	RedirectURL string
+	// Audience is the aud bearer tokens must be issued for. ID tokens are
+	// always checked against ClientID; bearer tokens too when Audience is
+	// empty.
+	Audience string
+	// GroupsClaim names the claim listing a user's groups, "groups" when
+	// empty.
+	GroupsClaim string
+	Roles       RoleMap
+}
+
+// Provider is a configured identity provider. Its signing keys are fetched
+// from the provider's JWKS endpoint on first use and cached, and fetched
+// again when a token is signed with a key the cache does not hold, so key
+// rotation needs no restart.
+type Provider struct {
+	oauth       oauth2.Config
+	idTokens    *gooidc.IDTokenVerifier
+	bearer      *gooidc.IDTokenVerifier
+	groupsClaim string
+	roles       RoleMap
+}
+
+// New discovers the provider at cfg.Issuer. An HTTP client set in ctx with
+// oauth2.HTTPClient is used for discovery and for fetching keys.
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, errors.New("issuer and client ID are required")
+	}
+	p, err := gooidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", cfg.Issuer, err)
+	}
+	audience := cfg.Audience
+	if audience == "" {
+		audience = cfg.ClientID
+	}
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+	return &Provider{
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     p.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{gooidc.ScopeOpenID, "email", "profile"},
+		},
+		idTokens:    p.Verifier(&gooidc.Config{ClientID: cfg.ClientID}),
+		bearer:      p.Verifier(&gooidc.Config{ClientID: audience}),
+		groupsClaim: groupsClaim,
+		roles:       cfg.Roles,
+	}, nil
+}
+
+// Login is the state of one browser sign-in, kept by the browser between
+// the redirect to the provider and the callback. State ties the callback
+// to the browser that started the sign-in, Nonce the ID token to it, and
+// Verifier is the PKCE secret whose hash the provider checks the code
+// exchange against.
+type Login struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// NewLogin starts a sign-in with fresh random values.
+func NewLogin() (Login, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Login{}, fmt.Errorf("failed to generate login state: %w", err)
+	}
+	return Login{
+		State:    hex.EncodeToString(b[:16]),
+		Nonce:    hex.EncodeToString(b[16:]),
+		Verifier: oauth2.GenerateVerifier(),
+	}, nil
+}
+
+// AuthCodeURL is where to send the browser to sign in.
+func (p *Provider) AuthCodeURL(login Login) string {
+	return p.oauth.AuthCodeURL(login.State,
+		oauth2.S256ChallengeOption(login.Verifier), gooidc.Nonce(login.Nonce))
+}
+
+// Exchange trades the code the provider sent the browser back with for an
+// ID token, and returns who it identifies.
+func (p *Provider) Exchange(ctx context.Context, login Login, code string) (*model.Identity, error) {
+	tok, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(login.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := p.idTokens.Verify(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("verifying ID token: %w", err)
+	}
+	if idToken.Nonce != login.Nonce {
+		return nil, errors.New("ID token nonce does not match the sign-in")
+	}
+	return p.identity(idToken)
+}
+
+// Verify checks a bearer JWT's signature, issuer, audience and expiry and
+// returns who it identifies.
+func (p *Provider) Verify(ctx context.Context, token string) (*model.Identity, error) {
+	t, err := p.bearer.Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("verifying bearer token: %w", err)
+	}
+	return p.identity(t)
+}
+
+func (p *Provider) identity(t *gooidc.IDToken) (*model.Identity, error) {
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     *bool  `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	var raw map[string]any
+	if err := t.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("decoding claims: %w", err)
+	}
+	if err := t.Claims(&raw); err != nil {
+		return nil, fmt.Errorf("decoding claims: %w", err)
+	}
+	if claims.Email == "" {
+		return nil, errors.New("token has no email claim")
+	}
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		return nil, fmt.Errorf("email %q is not verified", claims.Email)
+	}
+
+	id := &model.Identity{
+		Issuer:        t.Issuer,
+		Subject:       t.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified != nil && *claims.EmailVerified,
+		Name:          claims.Name,
+		Groups:        stringList(raw[p.groupsClaim]),
+	}
+	if id.Name == "" {
+		id.Name = claims.PreferredUsername
+	}
+	p.roles.apply(id)
+	return id, nil
+}
+
+// stringList reads a claim that providers send as either a list of
+// strings or a single string.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
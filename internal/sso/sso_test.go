@@ -0,0 +1,178 @@
+package sso
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/sso/ssotest"
+)
+
+const testWorkspace = "11111111-1111-1111-1111-111111111111"
+
+func setupProvider(t *testing.T) (*Provider, *ssotest.Issuer) {
+	t.Helper()
+	issuer := ssotest.NewIssuer(t, "shortener")
+	roles, err := ParseRoleMap("platform=operator,marketing=" + testWorkspace + ":editor")
+	if err != nil {
+		t.Fatalf("failed to parse roles: %v", err)
+	}
+	p, err := New(context.Background(), Config{
+		Issuer:      issuer.URL,
+		ClientID:    "shortener",
+		RedirectURL: "https://sho.rt/admin/sso/callback",
+		Roles:       roles,
+	})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	return p, issuer
+}
+
+// authorize follows the sign-in redirect to the issuer and returns the
+// query it sends the browser back with.
+func authorize(t *testing.T, p *Provider, login Login) url.Values {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(p.AuthCodeURL(login))
+	if err != nil {
+		t.Fatalf("authorize request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	return loc.Query()
+}
+
+func TestExchange(t *testing.T) {
+	p, issuer := setupProvider(t)
+	issuer.User = map[string]any{
+		"sub": "u-42", "email": "ada@example.com", "name": "Ada",
+		"groups": []string{"platform", "marketing"},
+	}
+
+	login, err := NewLogin()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	back := authorize(t, p, login)
+	if back.Get("state") != login.State {
+		t.Fatalf("expected state %q back, got %q", login.State, back.Get("state"))
+	}
+
+	id, err := p.Exchange(context.Background(), login, back.Get("code"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id.Subject != "u-42" || id.Email != "ada@example.com" || id.Name != "Ada" || id.Issuer != issuer.URL {
+		t.Errorf("unexpected identity %+v", id)
+	}
+	if !id.Operator || id.Roles[testWorkspace] != "editor" {
+		t.Errorf("expected operator and editor roles from groups, got %+v", id)
+	}
+}
+
+func TestExchange_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(*Login)
+	}{
+		{"wrong verifier", func(l *Login) { l.Verifier = "not-the-verifier-that-was-hashed-into-the-challenge" }},
+		{"wrong nonce", func(l *Login) { l.Nonce = "replayed" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := setupProvider(t)
+			login, err := NewLogin()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			back := authorize(t, p, login)
+			tt.tamper(&login)
+			if _, err := p.Exchange(context.Background(), login, back.Get("code")); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	p, issuer := setupProvider(t)
+
+	for range 2 {
+		id, err := p.Verify(context.Background(), issuer.Token(map[string]any{
+			"sub": "svc", "email": "ci@example.com", "groups": "marketing",
+		}))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if id.Operator || id.Roles[testWorkspace] != "editor" {
+			t.Errorf("unexpected identity %+v", id)
+		}
+	}
+	if n := issuer.KeyFetches(); n != 1 {
+		t.Errorf("expected keys to be fetched once and cached, got %d fetches", n)
+	}
+}
+
+func TestVerify_EmailVerified(t *testing.T) {
+	p, issuer := setupProvider(t)
+
+	tests := map[string]struct {
+		claims map[string]any
+		want   bool
+	}{
+		"verified":   {map[string]any{"sub": "u", "email": "a@example.com", "email_verified": true}, true},
+		"unreported": {map[string]any{"sub": "u", "email": "a@example.com"}, false},
+	}
+	for name, tt := range tests {
+		id, err := p.Verify(context.Background(), issuer.Token(tt.claims))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if id.EmailVerified != tt.want {
+			t.Errorf("%s: expected EmailVerified %v, got %v", name, tt.want, id.EmailVerified)
+		}
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]any
+	}{
+		{"expired", map[string]any{"sub": "u", "email": "a@example.com", "exp": time.Now().Add(-time.Minute).Unix()}},
+		{"other audience", map[string]any{"sub": "u", "email": "a@example.com", "aud": "another-app"}},
+		{"other issuer", map[string]any{"sub": "u", "email": "a@example.com", "iss": "https://evil.example.com"}},
+		{"no email", map[string]any{"sub": "u"}},
+		{"unverified email", map[string]any{"sub": "u", "email": "a@example.com", "email_verified": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, issuer := setupProvider(t)
+			if _, err := p.Verify(context.Background(), issuer.Token(tt.claims)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestVerify_ForgedSignature(t *testing.T) {
+	p, _ := setupProvider(t)
+	other := ssotest.NewIssuer(t, "shortener")
+
+	if _, err := p.Verify(context.Background(), other.Token(map[string]any{"sub": "u", "email": "a@example.com"})); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,189 @@
+// Package ssotest runs a mock OpenID Connect issuer for tests. It signs
+// every browser in as its User without asking, checks the PKCE verifier of
+// every code exchange and mints bearer tokens on demand.
+package ssotest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+)
+
+const keyID = "ssotest"
+
+// Issuer is a running mock provider. Its URL is the issuer to configure.
+type Issuer struct {
+	*httptest.Server
+	ClientID string
+	// User holds the claims of whoever signs in, such as sub, email and
+	// groups. Tests may change it between sign-ins.
+	User map[string]any
+
+	key        *rsa.PrivateKey
+	discovery  *oidctest.Server
+	keyFetches atomic.Int64
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+// grant is an authorization code waiting to be exchanged.
+type grant struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// NewIssuer starts an issuer for clientID that is closed when the test
+// ends.
+func NewIssuer(t testing.TB, clientID string) *Issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	i := &Issuer{
+		ClientID: clientID,
+		User:     map[string]any{"sub": "user-1", "email": "ada@example.com", "email_verified": true},
+		key:      key,
+		discovery: &oidctest.Server{PublicKeys: []oidctest.PublicKey{
+			{PublicKey: key.Public(), KeyID: keyID, Algorithm: gooidc.RS256},
+		}},
+		codes: map[string]grant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /.well-known/openid-configuration", i.discovery)
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		i.keyFetches.Add(1)
+		i.discovery.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("GET /auth", i.authorize)
+	mux.HandleFunc("POST /token", i.token)
+	i.Server = httptest.NewServer(mux)
+	i.discovery.SetIssuer(i.URL)
+	t.Cleanup(i.Close)
+	return i
+}
+
+// KeyFetches is how many times the issuer's keys have been fetched.
+func (i *Issuer) KeyFetches() int {
+	return int(i.keyFetches.Load())
+}
+
+// Token signs a JWT carrying claims, filling in iss, aud (the client ID),
+// iat and exp (an hour away) unless claims sets them.
+func (i *Issuer) Token(claims map[string]any) string {
+	now := time.Now()
+	all := map[string]any{
+		"iss": i.URL,
+		"aud": i.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	maps.Copy(all, claims)
+	b, err := json.Marshal(all)
+	if err != nil {
+		panic("ssotest: encoding claims: " + err.Error())
+	}
+	return oidctest.SignIDToken(i.key, keyID, gooidc.RS256, string(b))
+}
+
+// authorize signs the browser in as User and sends it straight back with
+// a code.
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	switch {
+	case q.Get("client_id") != i.ClientID:
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return
+	case err != nil || !redirect.IsAbs():
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	case q.Get("response_type") != "code":
+		http.Error(w, "unsupported response_type", http.StatusBadRequest)
+		return
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	i.mu.Lock()
+	i.codes[code] = grant{redirectURI: redirect.String(), challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	i.mu.Unlock()
+
+	back := redirect.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	redirect.RawQuery = back.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token exchanges a code once, for the redirect URI and PKCE verifier it
+// was issued for.
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	g, found := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code" || clientID != i.ClientID:
+		tokenError(w, "invalid_client")
+		return
+	case !found || r.PostForm.Get("redirect_uri") != g.redirectURI:
+		tokenError(w, "invalid_grant")
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge:
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	claims := maps.Clone(i.User)
+	claims["nonce"] = g.nonce
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": i.Token(i.User),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     i.Token(claims),
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,89 @@
+// Package telemetry sets up OpenTelemetry tracing: the exporter spans are
+// sent to, the propagation of trace context between services and the
+// instrumentation of PostgreSQL queries.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in exported spans unless OTEL_SERVICE_NAME
+// is set, and the tracer of its own spans.
+const ServiceName = "url-shortener"
+
+// Exporters, as named by OTEL_TRACES_EXPORTER.
+const (
+	// ExporterNone records no spans.
+	ExporterNone = "none"
+	// ExporterOTLP sends spans over OTLP/gRPC to the collector configured
+	// by the standard OTEL_EXPORTER_OTLP_* variables, localhost:4317 by
+	// default.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON, for trying tracing locally. The
+	// name is the standard one; where they are written is up to Setup's
+	// caller.
+	ExporterStdout = "stdout"
+)
+
+// Tracer returns the tracer for the service's own spans. It is backed by
+// the global provider, so spans go wherever Setup sends them and nowhere
+// before.
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// Setup installs the global tracer provider, exporting spans through
+// exporter, and the W3C trace context and baggage propagators. ExporterStdout
+// writes to w, which should not be the log stream: spans interleaved with
+// log lines break whatever parses the logs. The returned function flushes
+// pending spans and stops the provider; it must be called before exiting.
+func Setup(ctx context.Context, exporter string, w io.Writer) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var spans sdktrace.SpanExporter
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spans, err = otlptracegrpc.New(ctx)
+	case ExporterStdout:
+		spans, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", exporter, err)
+	}
+
+	// Attributes from OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME,
+	// merged last, win over the defaults.
+	res, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	// The sampler follows OTEL_TRACES_SAMPLER, sampling every trace by
+	// default.
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spans),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
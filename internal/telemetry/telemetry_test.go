@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestOperationName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"\n\t\twith recent AS (SELECT 1) SELECT * FROM recent", "WITH"},
+		{"", "QUERY"},
+	}
+	for _, tt := range tests {
+		if got := operationName(tt.sql); got != tt.want {
+			t.Errorf("operationName(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestSetup_Stdout(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), ExporterStdout, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, span := Tracer().Start(context.Background(), "test-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Name":"test-span"`) {
+		t.Errorf("expected the span to be written, got %q", buf.String())
+	}
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", nil); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
@@ -0,0 +1,112 @@
+package threat
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	// maxHostSuffixes and maxPathPrefixes bound the expressions checked
+	// per URL, as in Safe Browsing.
+	maxHostSuffixes = 5
+	maxPathPrefixes = 4
+)
+
+// canonicalize reduces rawURL to the lowercase host (without port or
+// trailing dots), a cleaned path and the raw query, following the Safe
+// Browsing canonicalization closely enough for locally built lists.
+func canonicalize(rawURL string) (host, path, query string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return "", "", "", false
+	}
+	host = strings.Trim(strings.ToLower(u.Hostname()), ".")
+	for strings.Contains(host, "..") {
+		host = strings.ReplaceAll(host, "..", ".")
+	}
+	if host == "" {
+		return "", "", "", false
+	}
+	return host, cleanPath(u.Path), u.RawQuery, true
+}
+
+// cleanPath resolves "." and ".." segments and collapses repeated slashes,
+// keeping a trailing slash.
+func cleanPath(p string) string {
+	var out []string
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch seg {
+		case "", ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+	if len(out) == 0 {
+		return "/"
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+// hostSuffixes returns host itself and, unless it is an IP address, up to
+// four more hosts formed from its last five components by dropping leading
+// components, stopping before the top-level domain.
+func hostSuffixes(host string) []string {
+	suffixes := []string{host}
+	if net.ParseIP(host) != nil {
+		return suffixes
+	}
+	parts := strings.Split(host, ".")
+	start := max(1, len(parts)-maxHostSuffixes)
+	for i := start; i < len(parts)-1 && len(suffixes) < maxHostSuffixes; i++ {
+		suffixes = append(suffixes, strings.Join(parts[i:], "."))
+	}
+	return suffixes
+}
+
+// pathPrefixes returns the exact path with and without query, then the root
+// and successive directories of path, each with a trailing slash.
+func pathPrefixes(path, query string) []string {
+	var prefixes []string
+	if query != "" {
+		prefixes = append(prefixes, path+"?"+query)
+	}
+	prefixes = append(prefixes, path)
+
+	dirs := strings.Split(strings.Trim(path[:strings.LastIndex(path, "/")+1], "/"), "/")
+	prefix := "/"
+	for i := range maxPathPrefixes {
+		if prefix != path {
+			prefixes = append(prefixes, prefix)
+		}
+		if i == len(dirs) || dirs[i] == "" {
+			break
+		}
+		prefix += dirs[i] + "/"
+	}
+	return prefixes
+}
+
+// expressions returns every host-suffix/path-prefix combination to look up.
+func expressions(host, path, query string) []string {
+	var exprs []string
+	paths := pathPrefixes(path, query)
+	for _, h := range hostSuffixes(host) {
+		for _, p := range paths {
+			exprs = append(exprs, h+p)
+		}
+	}
+	return exprs
+}
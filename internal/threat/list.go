@@ -0,0 +1,165 @@
+// Package threat screens link destinations against locally stored threat
+// lists.
+//
+// A list directory holds two kinds of file, each named after the threat it
+// describes ("malware.hosts", "phishing.prefixes"):
+//
+//   - *.hosts: one host per line. A listed host also matches its
+//     subdomains. Hosts-file lines ("0.0.0.0 evil.example") are accepted.
+//   - *.prefixes: one hex-encoded SHA-256 hash prefix (4 to 32 bytes) per
+//     line, computed over Safe Browsing URL expressions as in the Update
+//     API. Without full-hash confirmation a prefix hit counts as a match,
+//     so lists of full 32-byte hashes avoid false positives.
+//
+// Blank lines and lines starting with '#' are ignored in both.
+package threat
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	hostsExt    = ".hosts"
+	prefixesExt = ".prefixes"
+
+	minPrefixLen = 4
+	maxPrefixLen = sha256.Size
+)
+
+// List is an immutable set of threat entries. The zero value matches
+// nothing.
+type List struct {
+	// hosts maps a host to the threat it is listed under.
+	hosts map[string]string
+	// prefixes maps hash prefixes, keyed by length, to their threat.
+	prefixes map[int]map[string]string
+}
+
+// Len returns the number of entries in l.
+func (l *List) Len() int {
+	n := len(l.hosts)
+	for _, set := range l.prefixes {
+		n += len(set)
+	}
+	return n
+}
+
+// Match reports the threat rawURL is listed under, if any. Host entries are
+// checked first, then hash prefixes of every URL expression.
+func (l *List) Match(rawURL string) (string, bool) {
+	if l.Len() == 0 {
+		return "", false
+	}
+	host, path, query, ok := canonicalize(rawURL)
+	if !ok {
+		return "", false
+	}
+
+	for h := host; h != ""; {
+		if threat, ok := l.hosts[h]; ok {
+			return threat, true
+		}
+		_, h, _ = strings.Cut(h, ".")
+	}
+	if len(l.prefixes) == 0 {
+		return "", false
+	}
+	for _, expr := range expressions(host, path, query) {
+		sum := sha256.Sum256([]byte(expr))
+		for n, set := range l.prefixes {
+			if threat, ok := set[string(sum[:n])]; ok {
+				return threat, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Load reads every list file in dir. Files with other extensions are
+// skipped.
+func Load(dir string) (*List, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := &List{hosts: map[string]string{}, prefixes: map[int]map[string]string{}}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != hostsExt && ext != prefixesExt) {
+			continue
+		}
+		threat := strings.TrimSuffix(e.Name(), ext)
+		path := filepath.Join(dir, e.Name())
+		if ext == hostsExt {
+			err = l.loadHosts(path, threat)
+		} else {
+			err = l.loadPrefixes(path, threat)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *List) loadHosts(path, threat string) error {
+	return eachLine(path, func(line string) error {
+		fields := strings.Fields(line)
+		host := fields[0]
+		// Hosts-file format: an address followed by the host.
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			host = fields[1]
+		}
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if host == "" || strings.ContainsAny(host, "/:") {
+			return fmt.Errorf("invalid host %q", host)
+		}
+		l.hosts[host] = threat
+		return nil
+	})
+}
+
+func (l *List) loadPrefixes(path, threat string) error {
+	return eachLine(path, func(line string) error {
+		prefix, err := hex.DecodeString(line)
+		if err != nil || len(prefix) < minPrefixLen || len(prefix) > maxPrefixLen {
+			return fmt.Errorf("invalid hash prefix %q", line)
+		}
+		set := l.prefixes[len(prefix)]
+		if set == nil {
+			set = map[string]string{}
+			l.prefixes[len(prefix)] = set
+		}
+		set[string(prefix)] = threat
+		return nil
+	})
+}
+
+// eachLine calls fn for every non-blank, non-comment line of the file at
+// path, trimmed of surrounding space.
+func eachLine(path string, fn func(line string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,107 @@
+package threat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeList(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func hashPrefix(expr string, n int) string {
+	sum := sha256.Sum256([]byte(expr))
+	return hex.EncodeToString(sum[:n])
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in, host, path, query string
+	}{
+		{"https://Evil.Example.COM./a/./b/../c?x=1#frag", "evil.example.com", "/a/c", "x=1"},
+		{"http://evil.example.com:8080", "evil.example.com", "/", ""},
+		{"http://evil.example.com//a//b/", "evil.example.com", "/a/b/", ""},
+		{"http://1.2.3.4/x", "1.2.3.4", "/x", ""},
+	}
+	for _, tt := range tests {
+		host, path, query, ok := canonicalize(tt.in)
+		if !ok || host != tt.host || path != tt.path || query != tt.query {
+			t.Errorf("canonicalize(%q) = %q %q %q %v, want %q %q %q", tt.in, host, path, query, ok, tt.host, tt.path, tt.query)
+		}
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	// The example from the Safe Browsing documentation.
+	got := expressions("a.b.c", "/1/2.html", "param=1")
+	want := []string{
+		"a.b.c/1/2.html?param=1", "a.b.c/1/2.html", "a.b.c/", "a.b.c/1/",
+		"b.c/1/2.html?param=1", "b.c/1/2.html", "b.c/", "b.c/1/",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	hosts := hostSuffixes("a.b.c.d.e.f.g")
+	if !slices.Equal(hosts, []string{"a.b.c.d.e.f.g", "c.d.e.f.g", "d.e.f.g", "e.f.g", "f.g"}) {
+		t.Errorf("unexpected host suffixes %v", hosts)
+	}
+	if ip := hostSuffixes("1.2.3.4"); len(ip) != 1 {
+		t.Errorf("expected IP hosts to be used as is, got %v", ip)
+	}
+}
+
+func TestLoad_Match(t *testing.T) {
+	dir := t.TempDir()
+	writeList(t, dir, "malware.hosts", "# comment\nevil.example.com\n0.0.0.0 tracker.example.net\n\n")
+	writeList(t, dir, "phishing.prefixes", hashPrefix("bank.example.org/login/", 4)+"\n")
+	writeList(t, dir, "README.txt", "not a list")
+
+	l, err := Load(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if l.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", l.Len())
+	}
+
+	tests := map[string]string{
+		"https://evil.example.com/anything":             "malware",
+		"https://cdn.evil.example.com/x.exe":            "malware",
+		"http://tracker.example.net":                    "malware",
+		"https://bank.example.org/login/form.php?id=1":  "phishing",
+		"https://www.bank.example.org/login/index.html": "phishing",
+		"https://bank.example.org/about":                "",
+		"https://example.com":                           "",
+		"not a url":                                     "",
+	}
+	for in, want := range tests {
+		got, listed := l.Match(in)
+		if got != want || listed != (want != "") {
+			t.Errorf("Match(%q) = %q %v, want %q", in, got, listed, want)
+		}
+	}
+}
+
+func TestLoad_InvalidEntry(t *testing.T) {
+	dir := t.TempDir()
+	writeList(t, dir, "malware.prefixes", "abc\n")
+
+	if _, err := Load(dir); err == nil {
+		t.Fatal("expected error for a short prefix")
+	}
+}
+
+func TestZeroList(t *testing.T) {
+	var l List
+	if _, listed := l.Match("https://example.com"); listed {
+		t.Error("expected the zero List to match nothing")
+	}
+}
@@ -0,0 +1,63 @@
+package threat
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Scanner re-checks every stored link against the current lists,
+// disabling new matches and re-enabling links no longer listed.
+// service.URLService implements it.
+type Scanner interface {
+	Rescan(ctx context.Context) (disabled, enabled int, err error)
+}
+
+// Rescanner runs Scanner every Interval, and early when Trigger is called.
+type Rescanner struct {
+	scanner Scanner
+	logger  zerolog.Logger
+	trigger chan struct{}
+
+	Interval time.Duration
+}
+
+func NewRescanner(scanner Scanner, logger zerolog.Logger) *Rescanner {
+	return &Rescanner{
+		scanner:  scanner,
+		logger:   logger,
+		trigger:  make(chan struct{}, 1),
+		Interval: time.Hour,
+	}
+}
+
+// Trigger requests a rescan without waiting for the next interval. Calls
+// made while one is already pending are coalesced.
+func (r *Rescanner) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run rescans every Interval, or when triggered, until ctx is cancelled.
+func (r *Rescanner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+		disabled, enabled, err := r.scanner.Rescan(ctx)
+		if err != nil {
+			r.logger.Error().Err(err).Msg("Threat rescan failed")
+			continue
+		}
+		r.logger.Info().Int("disabled", disabled).Int("enabled", enabled).Msg("Threat rescan finished")
+	}
+}
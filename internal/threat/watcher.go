@@ -0,0 +1,110 @@
+package threat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Watcher serves the lists in a directory and reloads them when a list
+// file is added, removed or modified. It is safe for concurrent use.
+type Watcher struct {
+	dir    string
+	logger zerolog.Logger
+	list   atomic.Pointer[List]
+	stamp  string
+
+	// Interval is how often Run checks the directory for changes.
+	Interval time.Duration
+	// OnReload, when set, is called after every successful reload.
+	OnReload func()
+}
+
+// NewWatcher loads the lists in dir. It fails if they cannot be read, so
+// a misconfigured directory is caught at startup.
+func NewWatcher(dir string, logger zerolog.Logger) (*Watcher, error) {
+	w := &Watcher{dir: dir, logger: logger, Interval: 30 * time.Second}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Check reports the threat rawURL is listed under, if any.
+func (w *Watcher) Check(rawURL string) (string, bool) {
+	return w.list.Load().Match(rawURL)
+}
+
+// Reload re-reads the lists if any file changed since the last load and
+// reports whether it did. On error the previous lists stay in use.
+func (w *Watcher) Reload() (bool, error) {
+	stamp, err := w.fingerprint()
+	if err != nil {
+		return false, err
+	}
+	if stamp == w.stamp && w.list.Load() != nil {
+		return false, nil
+	}
+	l, err := Load(w.dir)
+	if err != nil {
+		return false, err
+	}
+	w.list.Store(l)
+	w.stamp = stamp
+	return true, nil
+}
+
+// Run checks for changed lists every Interval until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := w.Reload()
+		if err != nil {
+			w.logger.Error().Err(err).Str("dir", w.dir).Msg("Threat list reload failed")
+			continue
+		}
+		if reloaded {
+			w.logger.Info().Int("entries", w.list.Load().Len()).Msg("Threat lists reloaded")
+			if w.OnReload != nil {
+				w.OnReload()
+			}
+		}
+	}
+}
+
+// fingerprint summarises the name, size and modification time of every
+// list file in the directory.
+func (w *Watcher) fingerprint() (string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != hostsExt && ext != prefixesExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", e.Name(), info.Size(), info.ModTime().UnixNano()))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|"), nil
+}
@@ -0,0 +1,105 @@
+package threat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	writeList(t, dir, "malware.hosts", "evil.example.com\n")
+
+	w, err := NewWatcher(dir, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, listed := w.Check("https://evil.example.com"); !listed {
+		t.Fatal("expected initial list to be loaded")
+	}
+
+	if reloaded, err := w.Reload(); err != nil || reloaded {
+		t.Errorf("expected no reload without changes, got %v %v", reloaded, err)
+	}
+
+	writeList(t, dir, "phishing.hosts", "bank.example.org\n")
+	if reloaded, err := w.Reload(); err != nil || !reloaded {
+		t.Fatalf("expected reload after adding a list, got %v %v", reloaded, err)
+	}
+	if threat, _ := w.Check("https://bank.example.org"); threat != "phishing" {
+		t.Errorf("expected phishing, got %q", threat)
+	}
+
+	// A broken list keeps the previous lists in place.
+	writeList(t, dir, "bad.prefixes", "zz\n")
+	if _, err := w.Reload(); err == nil {
+		t.Fatal("expected error for a broken list")
+	}
+	if _, listed := w.Check("https://bank.example.org"); !listed {
+		t.Error("expected previous lists to stay in use")
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWatcher(dir, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	w.Interval = 10 * time.Millisecond
+	reloaded := make(chan struct{}, 1)
+	w.OnReload = func() { reloaded <- struct{}{} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	writeList(t, dir, "malware.hosts", "evil.example.com\n")
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected OnReload after a list was added")
+	}
+	if _, listed := w.Check("https://evil.example.com"); !listed {
+		t.Error("expected new list to be in use")
+	}
+}
+
+func TestNewWatcher_MissingDir(t *testing.T) {
+	if _, err := NewWatcher(filepath.Join(t.TempDir(), "missing"), zerolog.Nop()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+}
+
+type countingScanner struct{ calls atomic.Int32 }
+
+func (s *countingScanner) Rescan(context.Context) (int, int, error) {
+	s.calls.Add(1)
+	return 0, 0, nil
+}
+
+func TestRescanner_Trigger(t *testing.T) {
+	scanner := &countingScanner{}
+	r := NewRescanner(scanner, zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Trigger()
+	r.Trigger() // coalesced with the first
+	go r.Run(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for scanner.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := scanner.calls.Load(); n != 1 {
+		t.Errorf("expected one rescan, got %d", n)
+	}
+}
@@ -0,0 +1,107 @@
+// Package transfer encodes links for export and decodes them from our own
+// export formats and from other shorteners' exports.
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// csvHeader is the column layout of our CSV export, which the CSV importer
+// reads back.
+// Tags are joined with commas, which tags cannot contain, and metadata is
+// a JSON object.
+var csvHeader = []string{"id", "code", "domain", "original_url", "forward_query", "query_precedence", "utm_params", "created_at",
+	"campaign", "tags", "title", "description", "metadata"}
+
+// Encoder writes links one at a time. Flush must be called once at the end.
+type Encoder interface {
+	Encode(u *model.URL) error
+	Flush() error
+}
+
+// ContentType returns the media type of an export format.
+func ContentType(format string) string {
+	if format == model.FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// NewEncoder returns an encoder for format, which is ndjson or csv.
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	switch format {
+	case model.FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case model.FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, apperr.Invalid("unsupported export format %q", format)
+	}
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(u *model.URL) error { return e.enc.Encode(u) }
+func (e *ndjsonEncoder) Flush() error              { return nil }
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (e *csvEncoder) Encode(u *model.URL) error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+	var metadata string
+	if len(u.Metadata) > 0 {
+		b, err := json.Marshal(u.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(b)
+	}
+	return e.w.Write([]string{
+		u.ID, u.Code, u.Domain, u.OriginalURL,
+		strconv.FormatBool(u.ForwardQuery), u.QueryPrecedence,
+		encodeParams(u.UTMParams), u.CreatedAt.UTC().Format(time.RFC3339Nano),
+		u.Campaign, strings.Join(u.Tags, ","),
+		u.Title, u.Description, metadata,
+	})
+}
+
+// Flush writes the header even when there were no links, so an empty
+// export is still a valid CSV for the importer.
+func (e *csvEncoder) Flush() error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// encodeParams renders UTM parameters as a query string, sorted by key.
+func encodeParams(params map[string]string) string {
+	v := url.Values{}
+	for k, val := range params {
+		v.Set(k, val)
+	}
+	return v.Encode()
+}
@@ -0,0 +1,333 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// Decoder reads links to import. Next returns io.EOF once the input is
+// exhausted. A record that cannot be parsed yields an apperr.ErrInvalid
+// error along with its line; the caller may skip it and keep reading.
+// Any other error means the input itself is unreadable.
+type Decoder interface {
+	Next() (line int, u *model.URL, err error)
+}
+
+// NewDecoder returns a decoder for format: our own ndjson or csv exports,
+// a YOURLS export (keyword, url, title, timestamp, ...) or a Bitly CSV
+// export. CSV formats read their header row here.
+func NewDecoder(r io.Reader, format string) (Decoder, error) {
+	switch format {
+	case model.FormatNDJSON:
+		return &ndjsonDecoder{r: bufio.NewReader(r)}, nil
+	case model.FormatCSV:
+		return newCSVDecoder(r, ownColumns, false)
+	case model.FormatYOURLS:
+		return newCSVDecoder(r, yourlsColumns, true)
+	case model.FormatBitly:
+		return newCSVDecoder(r, bitlyColumns, false)
+	default:
+		return nil, apperr.Invalid("unsupported import format %q", format)
+	}
+}
+
+type ndjsonDecoder struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (d *ndjsonDecoder) Next() (int, *model.URL, error) {
+	for {
+		raw, err := d.r.ReadBytes('\n')
+		if len(raw) == 0 && err != nil {
+			return d.line, nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return d.line, nil, err
+		}
+		d.line++
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		var u model.URL
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return d.line, nil, apperr.Invalid("invalid JSON: %v", err)
+		}
+		u.ID = ""
+		return d.line, &u, nil
+	}
+}
+
+// Fields a CSV column can map to. fieldLink is a full short link, such as
+// Bitly's "bit.ly/abc", whose last path segment becomes the code.
+const (
+	fieldCode            = "code"
+	fieldLink            = "link"
+	fieldDomain          = "domain"
+	fieldOriginalURL     = "original_url"
+	fieldForwardQuery    = "forward_query"
+	fieldQueryPrecedence = "query_precedence"
+	fieldUTMParams       = "utm_params"
+	fieldCreatedAt       = "created_at"
+	fieldCampaign        = "campaign"
+	fieldTags            = "tags"
+	fieldTitle           = "title"
+	fieldDescription     = "description"
+	fieldMetadata        = "metadata"
+)
+
+// columnSet maps fields to the header names that may carry them. The
+// required field must be present, as must original_url.
+type columnSet struct {
+	aliases  map[string][]string
+	required string
+	// positional is the column order assumed when the input has no header.
+	positional []string
+}
+
+var ownColumns = columnSet{
+	aliases: map[string][]string{
+		fieldCode:            {"code"},
+		fieldDomain:          {"domain"},
+		fieldOriginalURL:     {"original_url", "url"},
+		fieldForwardQuery:    {"forward_query"},
+		fieldQueryPrecedence: {"query_precedence"},
+		fieldUTMParams:       {"utm_params"},
+		fieldCreatedAt:       {"created_at"},
+		fieldCampaign:        {"campaign"},
+		fieldTags:            {"tags"},
+		fieldTitle:           {"title"},
+		fieldDescription:     {"description"},
+		fieldMetadata:        {"metadata"},
+	},
+	required: fieldCode,
+}
+
+// yourlsColumns follows the yourls_url table, which is also the column
+// order of header-less exports.
+var yourlsColumns = columnSet{
+	aliases: map[string][]string{
+		fieldCode:        {"keyword"},
+		fieldOriginalURL: {"url"},
+		fieldCreatedAt:   {"timestamp"},
+	},
+	required:   fieldCode,
+	positional: []string{fieldCode, fieldOriginalURL, "", fieldCreatedAt},
+}
+
+var bitlyColumns = columnSet{
+	aliases: map[string][]string{
+		fieldLink:        {"bitlink", "link", "short_link", "short_url"},
+		fieldOriginalURL: {"long_url", "destination", "original_url"},
+		fieldCreatedAt:   {"created", "created_at", "date_created", "creation_date"},
+		fieldTitle:       {"title"},
+	},
+	required: fieldLink,
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+	// pending is a first row that turned out to be data, not a header.
+	pending []string
+	line    int
+}
+
+func newCSVDecoder(r io.Reader, set columnSet, headerOptional bool) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	first, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return &csvDecoder{r: cr}, nil
+	}
+	if err != nil {
+		return nil, apperr.Invalid("invalid CSV: %v", err)
+	}
+
+	d := &csvDecoder{r: cr, columns: map[string]int{}}
+	for i, name := range first {
+		name = normalizeColumn(name)
+		for field, aliases := range set.aliases {
+			if _, seen := d.columns[field]; !seen && slices.Contains(aliases, name) {
+				d.columns[field] = i
+			}
+		}
+	}
+
+	_, hasRequired := d.columns[set.required]
+	_, hasURL := d.columns[fieldOriginalURL]
+	if hasRequired && hasURL {
+		return d, nil
+	}
+	if headerOptional && set.positional != nil {
+		d.columns = map[string]int{}
+		for i, field := range set.positional {
+			if field != "" {
+				d.columns[field] = i
+			}
+		}
+		d.pending = first
+		d.line = 1
+		return d, nil
+	}
+	if !hasURL {
+		return nil, apperr.Invalid("missing %s column", set.aliases[fieldOriginalURL][0])
+	}
+	return nil, apperr.Invalid("missing %s column", set.aliases[set.required][0])
+}
+
+func normalizeColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func (d *csvDecoder) Next() (int, *model.URL, error) {
+	if d.columns == nil {
+		return d.line, nil, io.EOF
+	}
+
+	record := d.pending
+	if record != nil {
+		d.pending = nil
+	} else {
+		var err error
+		record, err = d.r.Read()
+		if errors.Is(err, io.EOF) {
+			return d.line, nil, io.EOF
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				d.line = parseErr.StartLine
+				return d.line, nil, apperr.Invalid("invalid CSV: %v", parseErr.Err)
+			}
+			return d.line, nil, err
+		}
+		d.line, _ = d.r.FieldPos(0)
+	}
+
+	u, err := d.parse(record)
+	return d.line, u, err
+}
+
+func (d *csvDecoder) field(record []string, name string) string {
+	i, ok := d.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func (d *csvDecoder) parse(record []string) (*model.URL, error) {
+	u := &model.URL{
+		Code:            d.field(record, fieldCode),
+		Domain:          d.field(record, fieldDomain),
+		OriginalURL:     d.field(record, fieldOriginalURL),
+		QueryPrecedence: d.field(record, fieldQueryPrecedence),
+		Campaign:        d.field(record, fieldCampaign),
+		Title:           d.field(record, fieldTitle),
+		Description:     d.field(record, fieldDescription),
+	}
+	if v := d.field(record, fieldTags); v != "" {
+		u.Tags = strings.Split(v, ",")
+	}
+	if link := d.field(record, fieldLink); link != "" && u.Code == "" {
+		u.Code = codeFromLink(link)
+	}
+
+	if v := d.field(record, fieldForwardQuery); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, apperr.Invalid("invalid forward_query %q", v)
+		}
+		u.ForwardQuery = b
+	}
+	if v := d.field(record, fieldUTMParams); v != "" {
+		values, err := url.ParseQuery(v)
+		if err != nil {
+			return nil, apperr.Invalid("invalid utm_params %q", v)
+		}
+		u.UTMParams = make(map[string]string, len(values))
+		for k := range values {
+			u.UTMParams[k] = values.Get(k)
+		}
+	}
+	if v := d.field(record, fieldMetadata); v != "" {
+		if err := json.Unmarshal([]byte(v), &u.Metadata); err != nil {
+			return nil, apperr.Invalid("invalid metadata %q: want a JSON object", v)
+		}
+	}
+	if v := d.field(record, fieldCreatedAt); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		u.CreatedAt = t
+	}
+	return u, nil
+}
+
+// codeFromLink returns the last path segment of a short link, which may
+// lack a scheme ("bit.ly/abc").
+func codeFromLink(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	path := strings.Trim(parsed.Path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	return path
+}
+
+// timeLayouts are tried in order by parseTime. Layouts without a zone are
+// read as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"1/2/2006 3:04:05 PM",
+	"1/2/2006",
+}
+
+// parseTime accepts the timestamp formats shorteners commonly export, and
+// Unix seconds.
+func parseTime(v string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Time{}, apperr.Invalid("invalid timestamp %q", v)
+}
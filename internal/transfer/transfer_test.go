@@ -0,0 +1,178 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// readAll drains d, returning the decoded links and the lines of records
+// rejected as invalid.
+func readAll(t *testing.T, d Decoder) ([]model.URL, []int) {
+	t.Helper()
+	var urls []model.URL
+	var bad []int
+	for {
+		line, u, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return urls, bad
+		}
+		if errors.Is(err, apperr.ErrInvalid) {
+			bad = append(bad, line)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		urls = append(urls, *u)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	in := []model.URL{
+		{ID: "1", Code: "abc1234", Domain: "go.example.com", OriginalURL: "https://example.com/?a=1", ForwardQuery: true,
+			QueryPrecedence: model.QueryPrecedenceDestination, UTMParams: map[string]string{"utm_source": "a&b"}, CreatedAt: created,
+			Campaign: "Spring, 2024", Tags: []string{"mail", "promo"},
+			Title: "Launch", Description: "Asked for by \"growth\"", Metadata: map[string]any{"owner": "alice", "ticket": float64(42)}},
+		{ID: "2", Code: "def5678", OriginalURL: "https://example.org", QueryPrecedence: model.QueryPrecedenceIncoming, CreatedAt: created},
+	}
+
+	for _, format := range []string{model.FormatNDJSON, model.FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(&buf, format)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for i := range in {
+				if err := enc.Encode(&in[i]); err != nil {
+					t.Fatalf("encode failed: %v", err)
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatalf("flush failed: %v", err)
+			}
+
+			dec, err := NewDecoder(&buf, format)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			out, bad := readAll(t, dec)
+			if len(bad) != 0 || len(out) != len(in) {
+				t.Fatalf("expected %d links, got %d (bad lines %v)", len(in), len(out), bad)
+			}
+			got := out[0]
+			if got.ID != "" {
+				t.Errorf("expected ID to be dropped, got %q", got.ID)
+			}
+			if got.Code != "abc1234" || got.Domain != "go.example.com" || got.OriginalURL != "https://example.com/?a=1" ||
+				!got.ForwardQuery || got.QueryPrecedence != model.QueryPrecedenceDestination ||
+				got.UTMParams["utm_source"] != "a&b" || !got.CreatedAt.Equal(created) ||
+				got.Campaign != "Spring, 2024" || len(got.Tags) != 2 || got.Tags[1] != "promo" ||
+				got.Title != "Launch" || got.Description != `Asked for by "growth"` ||
+				got.Metadata["owner"] != "alice" || got.Metadata["ticket"] != float64(42) {
+				t.Errorf("round trip changed the link: %+v", got)
+			}
+			if out[1].Metadata != nil {
+				t.Errorf("expected no metadata on the second link, got %v", out[1].Metadata)
+			}
+		})
+	}
+}
+
+func TestDecode_NDJSONReportsBadLines(t *testing.T) {
+	input := `{"code": "a", "original_url": "https://example.com"}
+
+{not json}
+{"code": "b", "original_url": "https://example.org"}`
+
+	dec, err := NewDecoder(strings.NewReader(input), model.FormatNDJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	urls, bad := readAll(t, dec)
+	if len(urls) != 2 {
+		t.Errorf("expected 2 links, got %d", len(urls))
+	}
+	if len(bad) != 1 || bad[0] != 3 {
+		t.Errorf("expected line 3 to be rejected, got %v", bad)
+	}
+}
+
+func TestDecode_YOURLS(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"with header", "keyword,url,title,timestamp,ip,clicks\nozh,https://ozh.org,Ozh,2009-08-12 16:08:46,127.0.0.1,3\n"},
+		{"without header", "ozh,https://ozh.org,Ozh,2009-08-12 16:08:46,127.0.0.1,3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := NewDecoder(strings.NewReader(tt.input), model.FormatYOURLS)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			urls, bad := readAll(t, dec)
+			if len(urls) != 1 || len(bad) != 0 {
+				t.Fatalf("expected one link, got %v (bad %v)", urls, bad)
+			}
+			want := time.Date(2009, 8, 12, 16, 8, 46, 0, time.UTC)
+			if urls[0].Code != "ozh" || urls[0].OriginalURL != "https://ozh.org" || !urls[0].CreatedAt.Equal(want) {
+				t.Errorf("unexpected link %+v", urls[0])
+			}
+		})
+	}
+}
+
+func TestDecode_Bitly(t *testing.T) {
+	input := "\ufeffBitlink,Long URL,Title,Created\n" +
+		"bit.ly/3xYz,https://example.com/a,A,2021-03-04T05:06:07+0000\n" +
+		"https://bit.ly/custom-name,https://example.com/b,B,1614834367\n" +
+		"bit.ly/bad,https://example.com/c,C,yesterday\n"
+
+	dec, err := NewDecoder(strings.NewReader(input), model.FormatBitly)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	urls, bad := readAll(t, dec)
+	if len(urls) != 2 {
+		t.Fatalf("expected 2 links, got %d", len(urls))
+	}
+	if urls[0].Code != "3xYz" || urls[1].Code != "custom-name" {
+		t.Errorf("expected codes from the bitlinks, got %q and %q", urls[0].Code, urls[1].Code)
+	}
+	if urls[0].Title != "A" {
+		t.Errorf("expected the title to be kept, got %q", urls[0].Title)
+	}
+	if !urls[1].CreatedAt.Equal(time.Unix(1614834367, 0)) {
+		t.Errorf("expected Unix timestamp to parse, got %v", urls[1].CreatedAt)
+	}
+	if len(bad) != 1 || bad[0] != 4 {
+		t.Errorf("expected line 4 to be rejected, got %v", bad)
+	}
+}
+
+func TestDecode_MissingColumns(t *testing.T) {
+	_, err := NewDecoder(strings.NewReader("code,title\nabc,Hello\n"), model.FormatCSV)
+	if !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
+
+func TestDecode_UnknownFormat(t *testing.T) {
+	if _, err := NewDecoder(strings.NewReader(""), "xml"); !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if _, err := NewEncoder(io.Discard, "xml"); !errors.Is(err, apperr.ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+}
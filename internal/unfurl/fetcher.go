@@ -0,0 +1,161 @@
+// Package unfurl reads a page's own preview metadata: its Open Graph and
+// Twitter card tags, falling back to the title element and meta
+// description.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+)
+
+// maxBodyRead bounds how much of a page is read looking for its head.
+const maxBodyRead = 512 << 10
+
+var errNotHTML = errors.New("destination is not an HTML page")
+
+// Fetcher requests pages and extracts their preview metadata.
+type Fetcher struct {
+	client *http.Client
+
+	// AllowPrivate permits fetching from loopback, private and link-local
+	// addresses, which are refused by default so links cannot be used to
+	// probe the internal network.
+	AllowPrivate bool
+	UserAgent    string
+}
+
+func NewFetcher() *Fetcher {
+	f := &Fetcher{UserAgent: "url-shortener-unfurl/1.0"}
+	transport := netguard.Transport(func() bool { return f.AllowPrivate })
+	f.client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return f
+}
+
+// Fetch requests rawURL, following redirects, and returns the preview
+// metadata found in its head. Fields the page does not provide are empty;
+// values are cut to the model.MaxPreview limits.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*model.LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("destination responded %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, errNotHTML
+	}
+
+	// Relative image URLs resolve against where redirects ended up.
+	return parse(io.LimitReader(resp.Body, maxBodyRead), resp.Request.URL), nil
+}
+
+// parse reads meta tags and the title from the head of the page in r.
+// Open Graph tags win over Twitter card tags, which win over the title
+// element and meta description.
+func parse(r io.Reader, base *url.URL) *model.LinkPreview {
+	found := map[string]string{}
+	set := func(key, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" && found[key] == "" {
+			found[key] = value
+		}
+	}
+
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for done := false; !done; {
+		switch z.Next() {
+		case html.ErrorToken:
+			done = true
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Body:
+				done = true
+			case atom.Title:
+				inTitle = true
+			case atom.Meta:
+				if !hasAttr {
+					continue
+				}
+				var key, content string
+				for more := true; more; {
+					var k, v []byte
+					k, v, more = z.TagAttr()
+					switch string(k) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = string(v)
+					}
+				}
+				set(key, content)
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Head:
+				done = true
+			case atom.Title:
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle {
+				set("title", string(z.Text()))
+			}
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := found[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	p := &model.LinkPreview{
+		Title:       truncate(first("og:title", "twitter:title", "title"), model.MaxPreviewTitle),
+		Description: truncate(first("og:description", "twitter:description", "description"), model.MaxPreviewDescription),
+	}
+	if image := first("og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		if u, err := base.Parse(image); err == nil && (u.Scheme == "http" || u.Scheme == "https") &&
+			len(u.String()) <= model.MaxPreviewImageURL {
+			p.ImageURL = u.String()
+		}
+	}
+	return p
+}
+
+// truncate cuts s to at most n characters, ending with an ellipsis when
+// anything was cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
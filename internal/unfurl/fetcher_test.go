@@ -0,0 +1,104 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+)
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/posts/1")
+	tests := []struct {
+		name string
+		page string
+		want model.LinkPreview
+	}{
+		{
+			name: "open graph",
+			page: `<html><head>
+				<title>Ignored</title>
+				<meta property="og:title" content="Spring &amp; Summer">
+				<meta property="og:description" content="  New   arrivals ">
+				<meta property="og:image" content="/img/card.png">
+				<meta name="twitter:title" content="Also ignored">
+			</head><body><meta property="og:title" content="In the body"></body></html>`,
+			want: model.LinkPreview{Title: "Spring & Summer", Description: "New arrivals", ImageURL: "https://example.com/img/card.png"},
+		},
+		{
+			name: "twitter card",
+			page: `<head><meta name="twitter:title" content="Card"><meta name="twitter:image" content="https://cdn.example.com/c.jpg"></head>`,
+			want: model.LinkPreview{Title: "Card", ImageURL: "https://cdn.example.com/c.jpg"},
+		},
+		{
+			name: "plain page",
+			page: `<!DOCTYPE html><title>Plain
+				page</title><meta name="description" content="About us"><p>text`,
+			want: model.LinkPreview{Title: "Plain page", Description: "About us"},
+		},
+		{
+			name: "unsafe image",
+			page: `<meta property="og:image" content="javascript:alert(1)">`,
+			want: model.LinkPreview{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parse(strings.NewReader(tt.page), base); *got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestParse_Truncates(t *testing.T) {
+	page := `<title>` + strings.Repeat("é", model.MaxPreviewTitle+10) + `</title>`
+	got := parse(strings.NewReader(page), &url.URL{})
+	if n := utf8.RuneCountInString(got.Title); n != model.MaxPreviewTitle || !strings.HasSuffix(got.Title, "…") {
+		t.Errorf("expected a %d character title ending in an ellipsis, got %d: %q", model.MaxPreviewTitle, n, got.Title)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new/page", http.StatusMovedPermanently)
+		case "/new/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<head><title>New</title><meta property="og:image" content="card.png"></head>`))
+		case "/file":
+			w.Header().Set("Content-Type", "application/pdf")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher()
+	if _, err := f.Fetch(context.Background(), srv.URL+"/old"); !errors.Is(err, netguard.ErrPrivateAddress) {
+		t.Errorf("expected a loopback destination to be refused, got %v", err)
+	}
+
+	f.AllowPrivate = true
+	got, err := f.Fetch(context.Background(), srv.URL+"/old")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Title != "New" || got.ImageURL != srv.URL+"/new/card.png" {
+		t.Errorf("expected metadata from the redirect target, got %+v", got)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/file"); err == nil {
+		t.Error("expected a non-HTML destination to fail")
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/missing"); err == nil {
+		t.Error("expected a 404 to fail")
+	}
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+	"github.com/kerbatek/url-shortener/internal/repository"
+)
+
+// Headers sent with every delivery. The signature is "sha256=" followed by
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Dispatcher fans outbox events out to registered webhooks and delivers
+// them, retrying failures with exponential backoff.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	logger zerolog.Logger
+
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// AllowPrivate permits deliveries to loopback, private and link-local
+	// addresses, which are refused by default so webhooks cannot be used
+	// to probe the internal network.
+	AllowPrivate bool
+}
+
+func NewDispatcher(repo repository.WebhookRepository, logger zerolog.Logger) *Dispatcher {
+	d := &Dispatcher{
+		repo:        repo,
+		logger:      logger,
+		Interval:    2 * time.Second,
+		BatchSize:   100,
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+	}
+	// Targets are checked again as each delivery connects, which catches
+	// names that resolved to public addresses at registration and point
+	// at private ones now.
+	transport := netguard.Transport(func() bool { return d.AllowPrivate })
+	d.client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return d
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before retrying after the given number of
+// failed attempts.
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Run processes the outbox every Interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.RunOnce(ctx); err != nil {
+			d.logger.Error().Err(err).Msg("Webhook dispatch failed")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce dispatches pending outbox events and attempts every due delivery.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	if _, err := d.repo.Dispatch(ctx, d.BatchSize); err != nil {
+		return fmt.Errorf("dispatching outbox: %w", err)
+	}
+
+	deliveries, err := d.repo.ClaimDue(ctx, d.BatchSize, 2*d.client.Timeout)
+	if err != nil {
+		return fmt.Errorf("claiming deliveries: %w", err)
+	}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		d.attempt(ctx, delivery)
+		if err := d.repo.RecordAttempt(ctx, delivery); err != nil {
+			return fmt.Errorf("recording delivery %d: %w", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+// attempt sends delivery once and updates its status, attempt count and
+// next attempt time in place.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	delivery.Error = ""
+
+	err := d.send(ctx, delivery)
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryDelivered
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = model.DeliveryPending
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(d.Backoff(delivery.Attempts))
+	}
+
+	d.logger.Info().
+		Int64("delivery_id", delivery.ID).
+		Str("webhook_id", delivery.WebhookID).
+		Str("event", delivery.Event).
+		Int("attempt", delivery.Attempts).
+		Int("response_code", delivery.ResponseCode).
+		Str("status", delivery.Status).
+		Msg("webhook delivery")
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *model.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Target, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	delivery.ResponseCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/netguard"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("secret", "1700000000", []byte(`{"a":1}`))
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, zerolog.Nop())
+	d.BaseBackoff = time.Second
+	d.MaxBackoff = 10 * time.Second
+
+	tests := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 20: 10 * time.Second}
+	for attempts, want := range tests {
+		if got := d.Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestRunOnce_DeliversSignedPayload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var gotSig, gotTimestamp, gotEvent string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(SignatureHeader)
+		gotTimestamp = r.Header.Get(TimestampHeader)
+		gotEvent = r.Header.Get(EventHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	d := NewDispatcher(mockRepo, zerolog.Nop())
+	d.AllowPrivate = true
+
+	payload := []byte(`{"code":"abc1234"}`)
+	mockRepo.EXPECT().Dispatch(gomock.Any(), d.BatchSize).Return(1, nil)
+	mockRepo.EXPECT().ClaimDue(gomock.Any(), d.BatchSize, gomock.Any()).Return([]model.WebhookDelivery{{
+		ID:      1,
+		Event:   model.EventLinkCreated,
+		Payload: payload,
+		Status:  model.DeliveryPending,
+		Target:  srv.URL,
+		Secret:  "secret",
+	}}, nil)
+	mockRepo.EXPECT().
+		RecordAttempt(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *model.WebhookDelivery) error {
+			if delivery.Status != model.DeliveryDelivered {
+				t.Errorf("expected status delivered, got %s", delivery.Status)
+			}
+			if delivery.Attempts != 1 {
+				t.Errorf("expected 1 attempt, got %d", delivery.Attempts)
+			}
+			if delivery.ResponseCode != http.StatusNoContent {
+				t.Errorf("expected response code 204, got %d", delivery.ResponseCode)
+			}
+			return nil
+		})
+
+	if err := d.RunOnce(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if string(gotBody) != string(payload) {
+		t.Errorf("expected body %s, got %s", payload, gotBody)
+	}
+	if gotEvent != model.EventLinkCreated {
+		t.Errorf("expected event header link.created, got %s", gotEvent)
+	}
+	if gotSig != Sign("secret", gotTimestamp, payload) {
+		t.Errorf("signature %s does not verify", gotSig)
+	}
+}
+
+func TestRunOnce_RetriesWithBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	d := NewDispatcher(mockRepo, zerolog.Nop())
+	d.AllowPrivate = true
+	d.BaseBackoff = time.Minute
+
+	mockRepo.EXPECT().Dispatch(gomock.Any(), gomock.Any()).Return(0, nil)
+	mockRepo.EXPECT().ClaimDue(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.WebhookDelivery{
+		{ID: 1, Attempts: 1, Status: model.DeliveryPending, Target: srv.URL, Payload: []byte(`{}`)},
+		{ID: 2, Attempts: d.MaxAttempts - 1, Status: model.DeliveryPending, Target: srv.URL, Payload: []byte(`{}`)},
+	}, nil)
+
+	before := time.Now()
+	recorded := map[int64]model.WebhookDelivery{}
+	mockRepo.EXPECT().
+		RecordAttempt(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *model.WebhookDelivery) error {
+			recorded[delivery.ID] = *delivery
+			return nil
+		}).
+		Times(2)
+
+	if err := d.RunOnce(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	retry := recorded[1]
+	if retry.Status != model.DeliveryPending || retry.Attempts != 2 {
+		t.Errorf("expected pending after 2 attempts, got %s after %d", retry.Status, retry.Attempts)
+	}
+	if retry.NextAttemptAt.Before(before.Add(2 * time.Minute)) {
+		t.Errorf("expected next attempt at least 2m out, got %s", retry.NextAttemptAt.Sub(before))
+	}
+	if retry.ResponseCode != http.StatusInternalServerError || retry.Error == "" {
+		t.Errorf("expected 500 and an error, got %d %q", retry.ResponseCode, retry.Error)
+	}
+
+	if recorded[2].Status != model.DeliveryFailed {
+		t.Errorf("expected delivery to fail after max attempts, got %s", recorded[2].Status)
+	}
+}
+
+func TestRunOnce_RefusesPrivateAddresses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	mockRepo := mocks.NewMockWebhookRepository(ctrl)
+	d := NewDispatcher(mockRepo, zerolog.Nop())
+
+	mockRepo.EXPECT().Dispatch(gomock.Any(), gomock.Any()).Return(0, nil)
+	mockRepo.EXPECT().ClaimDue(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.WebhookDelivery{
+		{ID: 1, Status: model.DeliveryPending, Target: srv.URL, Payload: []byte(`{}`)},
+	}, nil)
+	var recorded model.WebhookDelivery
+	mockRepo.EXPECT().
+		RecordAttempt(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *model.WebhookDelivery) error {
+			recorded = *delivery
+			return nil
+		})
+
+	if err := d.RunOnce(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if called || !strings.Contains(recorded.Error, netguard.ErrPrivateAddress.Error()) {
+		t.Errorf("expected loopback target to be refused, got %+v", recorded)
+	}
+}
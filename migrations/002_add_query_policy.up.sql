@@ -0,0 +1,3 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS forward_query    BOOLEAN     NOT NULL DEFAULT FALSE;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS query_precedence VARCHAR(16) NOT NULL DEFAULT 'incoming';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS utm_params       JSONB       NOT NULL DEFAULT '{}';
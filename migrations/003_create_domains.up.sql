@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS domains (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    host           VARCHAR(255)  NOT NULL UNIQUE,
+    fallback_url   TEXT          NOT NULL DEFAULT '',
+    owner_key_hash CHAR(64)      NOT NULL,
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain_id UUID REFERENCES domains (id) ON DELETE CASCADE;
+
+-- Codes are unique per domain; links on the default host have no domain.
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_domain_code
+    ON urls (COALESCE(domain_id, '00000000-0000-0000-0000-000000000000'::uuid), code);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id             UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    url            TEXT         NOT NULL,
+    secret         TEXT         NOT NULL,
+    events         TEXT[]       NOT NULL DEFAULT '{}',
+    owner_key_hash CHAR(64)     NOT NULL,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner ON webhooks (owner_key_hash);
+
+-- Events are written here in the same transaction as the change they
+-- describe, then fanned out to webhook_deliveries by the delivery worker.
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id            BIGSERIAL    PRIMARY KEY,
+    event         VARCHAR(64)  NOT NULL,
+    payload       JSONB        NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON webhook_outbox (id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL    PRIMARY KEY,
+    webhook_id      UUID         NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event           VARCHAR(64)  NOT NULL,
+    payload         JSONB        NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    status          VARCHAR(16)  NOT NULL DEFAULT 'pending',
+    response_code   INT          NOT NULL DEFAULT 0,
+    error           TEXT         NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS clicks (
+    id          BIGSERIAL    PRIMARY KEY,
+    url_id      UUID         NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    clicked_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    ip          TEXT         NOT NULL DEFAULT '',
+    user_agent  TEXT         NOT NULL DEFAULT '',
+    referrer    TEXT         NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_url_clicked_at ON clicks (url_id, clicked_at);
+
+-- Keyset pagination for listing links newest first.
+CREATE INDEX IF NOT EXISTS idx_urls_created_at_id ON urls (created_at DESC, id DESC);
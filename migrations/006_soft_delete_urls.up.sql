@@ -0,0 +1,2 @@
+-- Deleted links are kept so they can be restored; their codes stay reserved.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Links whose destination matches a threat list are disabled rather than
+-- deleted: they serve a warning page until a rescan clears them.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS disabled_reason TEXT;
@@ -0,0 +1,14 @@
+-- Outcome of the last destination check; NULL checked_at means never checked.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS checked_at TIMESTAMPTZ;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS check_status INT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS check_latency_ms INT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS check_error TEXT;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS broken BOOLEAN NOT NULL DEFAULT FALSE;
+-- When the link is next due for a check; NULL means now. Claiming a link
+-- pushes this forward as a lease so concurrent checkers skip it.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS next_check_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_urls_next_check_at ON urls (next_check_at NULLS FIRST) WHERE deleted_at IS NULL;
+
+-- Keyset pagination over broken links only.
+CREATE INDEX IF NOT EXISTS idx_urls_broken_created_at_id ON urls (created_at DESC, id DESC) WHERE broken;
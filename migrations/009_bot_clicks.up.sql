@@ -0,0 +1,4 @@
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- What bots following a link are served: the redirect, or a preview page.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS bot_policy VARCHAR(16) NOT NULL DEFAULT 'redirect';
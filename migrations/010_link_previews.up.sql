@@ -0,0 +1,5 @@
+-- Custom preview metadata served to link unfurlers; empty falls back to the
+-- destination.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview_title       TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview_description TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS preview_image_url   TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,24 @@
+-- A link belongs to at most one campaign, which groups links like a folder.
+CREATE TABLE IF NOT EXISTS campaigns (
+    id          UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100)  NOT NULL UNIQUE,
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS campaign_id UUID REFERENCES campaigns (id);
+
+CREATE INDEX IF NOT EXISTS idx_urls_campaign_id ON urls (campaign_id) WHERE campaign_id IS NOT NULL;
+
+-- Tags are lower-cased labels; a link carries any number of them.
+CREATE TABLE IF NOT EXISTS tags (
+    id    UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    name  VARCHAR(64)  NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id  UUID  NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    tag_id  UUID  NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (url_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_tags_tag_id ON url_tags (tag_id);
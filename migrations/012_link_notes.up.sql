@@ -0,0 +1,18 @@
+-- Notes on why a link exists and who asked for it; never shown to visitors.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS title       TEXT  NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS description TEXT  NOT NULL DEFAULT '';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS metadata    JSONB NOT NULL DEFAULT '{}';
+
+-- The list search is a substring match over every column it covers, which
+-- only trigram indexes can serve; all of them are indexed so the planner
+-- can combine the indexes instead of scanning.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_urls_code_trgm         ON urls USING gin (code gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_urls_original_url_trgm ON urls USING gin (original_url gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_urls_title_trgm        ON urls USING gin (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_urls_description_trgm  ON urls USING gin (description gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_urls_metadata_trgm     ON urls USING gin ((metadata::text) gin_trgm_ops);
+
+-- Serves the key=value metadata filter, a containment match.
+CREATE INDEX IF NOT EXISTS idx_urls_metadata ON urls USING gin (metadata jsonb_path_ops);
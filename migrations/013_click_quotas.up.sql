@@ -0,0 +1,5 @@
+-- Links limited to max_clicks redirects; 0 means unlimited. clicks_used is
+-- counted separately from the clicks table so the limit can be enforced by
+-- a single conditional UPDATE, which row locking makes race-free.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks  BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS clicks_used BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,49 @@
+-- Users sign in with an API key sent as X-API-Key, the same header that
+-- owns domains and webhooks; only its hash is stored.
+CREATE TABLE IF NOT EXISTS users (
+    id            UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    email         VARCHAR(254)  NOT NULL UNIQUE,
+    name          VARCHAR(100)  NOT NULL DEFAULT '',
+    api_key_hash  CHAR(64)      NOT NULL UNIQUE,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- A workspace is a team sharing a set of links.
+CREATE TABLE IF NOT EXISTS workspaces (
+    id          UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100)  NOT NULL,
+    created_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+    workspace_id  UUID         NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    user_id       UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role          VARCHAR(16)  NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_memberships_user_id ON memberships (user_id);
+
+-- Invites are single-use tokens, stored hashed like API keys. An invite
+-- with an email can only be accepted by the user with that email.
+CREATE TABLE IF NOT EXISTS invites (
+    id            UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id  UUID          NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    email         VARCHAR(254)  NOT NULL DEFAULT '',
+    role          VARCHAR(16)   NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+    token_hash    CHAR(64)      NOT NULL UNIQUE,
+    invited_by    UUID          REFERENCES users (id) ON DELETE SET NULL,
+    expires_at    TIMESTAMPTZ   NOT NULL,
+    accepted_at   TIMESTAMPTZ,
+    accepted_by   UUID          REFERENCES users (id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invites_workspace_id ON invites (workspace_id, created_at DESC);
+
+-- Links outside any workspace stay open to every caller, as before
+-- workspaces existed.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id);
+
+CREATE INDEX IF NOT EXISTS idx_urls_workspace_id ON urls (workspace_id, created_at DESC, id DESC);
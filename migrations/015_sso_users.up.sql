@@ -0,0 +1,6 @@
+-- Users who sign in through the OIDC provider are keyed by the issuer and
+-- subject of their tokens and need no API key of their own.
+ALTER TABLE users ALTER COLUMN api_key_hash DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer  TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc ON users (oidc_issuer, oidc_subject);
@@ -0,0 +1,34 @@
+-- Every create, update, delete and restore of a link, written in the same
+-- transaction as the change. Snapshots are the link as the API renders it,
+-- so they outlive later schema changes. There is no foreign key to urls:
+-- entries must survive whatever happens to the link.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id            BIGSERIAL     PRIMARY KEY,
+    actor         TEXT          NOT NULL,
+    actor_email   VARCHAR(254)  NOT NULL DEFAULT '',
+    action        VARCHAR(16)   NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    url_id        UUID          NOT NULL,
+    workspace_id  UUID,
+    before        JSONB,
+    after         JSONB,
+    client_ip     TEXT          NOT NULL DEFAULT '',
+    request_id    TEXT          NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_url_id ON audit_log (url_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_workspace_id ON audit_log (workspace_id, created_at DESC);
+
+-- The log is append-only: entries can be added but never changed or
+-- removed, whatever the application does.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
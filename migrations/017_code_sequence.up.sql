@@ -0,0 +1,4 @@
+-- Numbers the codes of links created with the sequential and obfuscated
+-- code strategies. Numbers are never reused, even when the code they make
+-- is taken and the link gets the next one.
+CREATE SEQUENCE IF NOT EXISTS url_code_seq;
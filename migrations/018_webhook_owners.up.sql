@@ -0,0 +1,14 @@
+-- Webhooks belong to a user and receive the events of the links that user
+-- can read: those of one workspace, while they are a member of it, or
+-- those outside any workspace. Webhooks registered with a key that belongs
+-- to a user are given to that user; those with keys that belong to no one
+-- are left without an owner and receive no more events.
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS owner_user_id UUID REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS workspace_id  UUID REFERENCES workspaces (id) ON DELETE CASCADE;
+ALTER TABLE webhooks ALTER COLUMN owner_key_hash DROP NOT NULL;
+
+UPDATE webhooks w SET owner_user_id = u.id
+FROM users u
+WHERE w.owner_user_id IS NULL AND u.api_key_hash = w.owner_key_hash;
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner_user_id ON webhooks (owner_user_id);
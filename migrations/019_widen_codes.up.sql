@@ -0,0 +1,4 @@
+-- Imported links keep the codes other shorteners gave them, which can be
+-- much longer than our own; YOURLS allows 200 characters. Widening a
+-- VARCHAR rewrites neither the table nor its indexes.
+ALTER TABLE urls ALTER COLUMN code TYPE VARCHAR(200);
@@ -0,0 +1,9 @@
+-- An email is only unique among users who proved it is theirs: those the
+-- identity provider reports as having verified it. API-key sign-ups never
+-- prove their email, so they can no longer keep an address, and the
+-- invites sent to it, from its owner.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_verified_email ON users (email) WHERE email_verified;
+CREATE INDEX IF NOT EXISTS idx_users_email ON users (email);
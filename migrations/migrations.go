@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations applied at startup.
+package migrations
+
+import "embed"
+
+// Files holds the *.up.sql migrations at the root of the FS.
+//
+//go:embed *.up.sql
+var Files embed.FS
@@ -0,0 +1,1474 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: shortener/v1/shortener.proto
+
+package shortenerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type QueryPrecedence int32
+
+const (
+	QueryPrecedence_QUERY_PRECEDENCE_UNSPECIFIED QueryPrecedence = 0
+	QueryPrecedence_QUERY_PRECEDENCE_INCOMING    QueryPrecedence = 1
+	QueryPrecedence_QUERY_PRECEDENCE_DESTINATION QueryPrecedence = 2
+)
+
+// Enum value maps for QueryPrecedence.
+var (
+	QueryPrecedence_name = map[int32]string{
+		0: "QUERY_PRECEDENCE_UNSPECIFIED",
+		1: "QUERY_PRECEDENCE_INCOMING",
+		2: "QUERY_PRECEDENCE_DESTINATION",
+	}
+	QueryPrecedence_value = map[string]int32{
+		"QUERY_PRECEDENCE_UNSPECIFIED": 0,
+		"QUERY_PRECEDENCE_INCOMING":    1,
+		"QUERY_PRECEDENCE_DESTINATION": 2,
+	}
+)
+
+func (x QueryPrecedence) Enum() *QueryPrecedence {
+	p := new(QueryPrecedence)
+	*p = x
+	return p
+}
+
+func (x QueryPrecedence) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (QueryPrecedence) Descriptor() protoreflect.EnumDescriptor {
+	return file_shortener_v1_shortener_proto_enumTypes[0].Descriptor()
+}
+
+func (QueryPrecedence) Type() protoreflect.EnumType {
+	return &file_shortener_v1_shortener_proto_enumTypes[0]
+}
+
+func (x QueryPrecedence) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use QueryPrecedence.Descriptor instead.
+func (QueryPrecedence) EnumDescriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{0}
+}
+
+// BotPolicy says what clients recognised as bots are served: the redirect,
+// or a preview page describing the link.
+type BotPolicy int32
+
+const (
+	BotPolicy_BOT_POLICY_UNSPECIFIED BotPolicy = 0
+	BotPolicy_BOT_POLICY_REDIRECT    BotPolicy = 1
+	BotPolicy_BOT_POLICY_PREVIEW     BotPolicy = 2
+)
+
+// Enum value maps for BotPolicy.
+var (
+	BotPolicy_name = map[int32]string{
+		0: "BOT_POLICY_UNSPECIFIED",
+		1: "BOT_POLICY_REDIRECT",
+		2: "BOT_POLICY_PREVIEW",
+	}
+	BotPolicy_value = map[string]int32{
+		"BOT_POLICY_UNSPECIFIED": 0,
+		"BOT_POLICY_REDIRECT":    1,
+		"BOT_POLICY_PREVIEW":     2,
+	}
+)
+
+func (x BotPolicy) Enum() *BotPolicy {
+	p := new(BotPolicy)
+	*p = x
+	return p
+}
+
+func (x BotPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BotPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_shortener_v1_shortener_proto_enumTypes[1].Descriptor()
+}
+
+func (BotPolicy) Type() protoreflect.EnumType {
+	return &file_shortener_v1_shortener_proto_enumTypes[1]
+}
+
+func (x BotPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BotPolicy.Descriptor instead.
+func (BotPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{1}
+}
+
+type URL struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code            string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Domain          string                 `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`
+	OriginalUrl     string                 `protobuf:"bytes,4,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	ForwardQuery    bool                   `protobuf:"varint,5,opt,name=forward_query,json=forwardQuery,proto3" json:"forward_query,omitempty"`
+	QueryPrecedence QueryPrecedence        `protobuf:"varint,6,opt,name=query_precedence,json=queryPrecedence,proto3,enum=shortener.v1.QueryPrecedence" json:"query_precedence,omitempty"`
+	UtmParams       map[string]string      `protobuf:"bytes,7,rep,name=utm_params,json=utmParams,proto3" json:"utm_params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Set while the destination is on a threat list named by disabled_reason.
+	DisabledAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=disabled_at,json=disabledAt,proto3" json:"disabled_at,omitempty"`
+	DisabledReason string                 `protobuf:"bytes,11,opt,name=disabled_reason,json=disabledReason,proto3" json:"disabled_reason,omitempty"`
+	// Unset until the destination has been checked.
+	Health    *LinkHealth `protobuf:"bytes,12,opt,name=health,proto3" json:"health,omitempty"`
+	BotPolicy BotPolicy   `protobuf:"varint,13,opt,name=bot_policy,json=botPolicy,proto3,enum=shortener.v1.BotPolicy" json:"bot_policy,omitempty"`
+	// Unset unless the link carries custom preview metadata.
+	Preview  *LinkPreview `protobuf:"bytes,14,opt,name=preview,proto3" json:"preview,omitempty"`
+	Campaign string       `protobuf:"bytes,15,opt,name=campaign,proto3" json:"campaign,omitempty"`
+	Tags     []string     `protobuf:"bytes,16,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Notes for whoever manages the link; never shown to visitors.
+	Title       string           `protobuf:"bytes,17,opt,name=title,proto3" json:"title,omitempty"`
+	Description string           `protobuf:"bytes,18,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata    *structpb.Struct `protobuf:"bytes,19,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// max_clicks limits the redirects the link serves, 0 meaning no limit;
+	// clicks_used counts those served against it.
+	MaxClicks  int64 `protobuf:"varint,20,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	ClicksUsed int64 `protobuf:"varint,21,opt,name=clicks_used,json=clicksUsed,proto3" json:"clicks_used,omitempty"`
+	// workspace_id is the workspace the link belongs to, empty for links
+	// outside any workspace.
+	WorkspaceId   string `protobuf:"bytes,22,opt,name=workspace_id,json=workspaceId,proto3" json:"workspace_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *URL) Reset() {
+	*x = URL{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URL) ProtoMessage() {}
+
+func (x *URL) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URL.ProtoReflect.Descriptor instead.
+func (*URL) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *URL) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *URL) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *URL) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *URL) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *URL) GetForwardQuery() bool {
+	if x != nil {
+		return x.ForwardQuery
+	}
+	return false
+}
+
+func (x *URL) GetQueryPrecedence() QueryPrecedence {
+	if x != nil {
+		return x.QueryPrecedence
+	}
+	return QueryPrecedence_QUERY_PRECEDENCE_UNSPECIFIED
+}
+
+func (x *URL) GetUtmParams() map[string]string {
+	if x != nil {
+		return x.UtmParams
+	}
+	return nil
+}
+
+func (x *URL) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *URL) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *URL) GetDisabledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DisabledAt
+	}
+	return nil
+}
+
+func (x *URL) GetDisabledReason() string {
+	if x != nil {
+		return x.DisabledReason
+	}
+	return ""
+}
+
+func (x *URL) GetHealth() *LinkHealth {
+	if x != nil {
+		return x.Health
+	}
+	return nil
+}
+
+func (x *URL) GetBotPolicy() BotPolicy {
+	if x != nil {
+		return x.BotPolicy
+	}
+	return BotPolicy_BOT_POLICY_UNSPECIFIED
+}
+
+func (x *URL) GetPreview() *LinkPreview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+func (x *URL) GetCampaign() string {
+	if x != nil {
+		return x.Campaign
+	}
+	return ""
+}
+
+func (x *URL) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *URL) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *URL) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *URL) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *URL) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *URL) GetClicksUsed() int64 {
+	if x != nil {
+		return x.ClicksUsed
+	}
+	return 0
+}
+
+func (x *URL) GetWorkspaceId() string {
+	if x != nil {
+		return x.WorkspaceId
+	}
+	return ""
+}
+
+// LinkPreview is shown to link unfurlers instead of the redirect. Empty
+// fields describe the destination instead.
+type LinkPreview struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	ImageUrl      string                 `protobuf:"bytes,3,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkPreview) Reset() {
+	*x = LinkPreview{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkPreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkPreview) ProtoMessage() {}
+
+func (x *LinkPreview) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkPreview.ProtoReflect.Descriptor instead.
+func (*LinkPreview) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LinkPreview) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+type LinkHealth struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 0 when no response was received; error says why.
+	StatusCode    int32                  `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	LatencyMs     int32                  `protobuf:"varint,2,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Broken        bool                   `protobuf:"varint,4,opt,name=broken,proto3" json:"broken,omitempty"`
+	CheckedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkHealth) Reset() {
+	*x = LinkHealth{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkHealth) ProtoMessage() {}
+
+func (x *LinkHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkHealth.ProtoReflect.Descriptor instead.
+func (*LinkHealth) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LinkHealth) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *LinkHealth) GetLatencyMs() int32 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *LinkHealth) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *LinkHealth) GetBroken() bool {
+	if x != nil {
+		return x.Broken
+	}
+	return false
+}
+
+func (x *LinkHealth) GetCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return nil
+}
+
+type ShortenRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Url             string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	ForwardQuery    bool                   `protobuf:"varint,2,opt,name=forward_query,json=forwardQuery,proto3" json:"forward_query,omitempty"`
+	QueryPrecedence QueryPrecedence        `protobuf:"varint,3,opt,name=query_precedence,json=queryPrecedence,proto3,enum=shortener.v1.QueryPrecedence" json:"query_precedence,omitempty"`
+	UtmParams       map[string]string      `protobuf:"bytes,4,rep,name=utm_params,json=utmParams,proto3" json:"utm_params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Domain          string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	BotPolicy       BotPolicy              `protobuf:"varint,6,opt,name=bot_policy,json=botPolicy,proto3,enum=shortener.v1.BotPolicy" json:"bot_policy,omitempty"`
+	Preview         *LinkPreview           `protobuf:"bytes,7,opt,name=preview,proto3" json:"preview,omitempty"`
+	// fetch_preview fills the preview fields left empty from the
+	// destination's own metadata.
+	FetchPreview bool `protobuf:"varint,8,opt,name=fetch_preview,json=fetchPreview,proto3" json:"fetch_preview,omitempty"`
+	// campaign and tags group the link; both are created on first use.
+	Campaign    string           `protobuf:"bytes,9,opt,name=campaign,proto3" json:"campaign,omitempty"`
+	Tags        []string         `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	Title       string           `protobuf:"bytes,11,opt,name=title,proto3" json:"title,omitempty"`
+	Description string           `protobuf:"bytes,12,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata    *structpb.Struct `protobuf:"bytes,13,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	MaxClicks   int64            `protobuf:"varint,14,opt,name=max_clicks,json=maxClicks,proto3" json:"max_clicks,omitempty"`
+	// workspace is the ID of the workspace to create the link in, which
+	// takes the editor role.
+	Workspace string `protobuf:"bytes,15,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	// code_strategy picks how the code is made: random, friendly,
+	// sequential, obfuscated or words. Empty uses the server's default.
+	CodeStrategy  string `protobuf:"bytes,16,opt,name=code_strategy,json=codeStrategy,proto3" json:"code_strategy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShortenRequest) Reset() {
+	*x = ShortenRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenRequest) ProtoMessage() {}
+
+func (x *ShortenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShortenRequest.ProtoReflect.Descriptor instead.
+func (*ShortenRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ShortenRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *ShortenRequest) GetForwardQuery() bool {
+	if x != nil {
+		return x.ForwardQuery
+	}
+	return false
+}
+
+func (x *ShortenRequest) GetQueryPrecedence() QueryPrecedence {
+	if x != nil {
+		return x.QueryPrecedence
+	}
+	return QueryPrecedence_QUERY_PRECEDENCE_UNSPECIFIED
+}
+
+func (x *ShortenRequest) GetUtmParams() map[string]string {
+	if x != nil {
+		return x.UtmParams
+	}
+	return nil
+}
+
+func (x *ShortenRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ShortenRequest) GetBotPolicy() BotPolicy {
+	if x != nil {
+		return x.BotPolicy
+	}
+	return BotPolicy_BOT_POLICY_UNSPECIFIED
+}
+
+func (x *ShortenRequest) GetPreview() *LinkPreview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+func (x *ShortenRequest) GetFetchPreview() bool {
+	if x != nil {
+		return x.FetchPreview
+	}
+	return false
+}
+
+func (x *ShortenRequest) GetCampaign() string {
+	if x != nil {
+		return x.Campaign
+	}
+	return ""
+}
+
+func (x *ShortenRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ShortenRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ShortenRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ShortenRequest) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ShortenRequest) GetMaxClicks() int64 {
+	if x != nil {
+		return x.MaxClicks
+	}
+	return 0
+}
+
+func (x *ShortenRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+func (x *ShortenRequest) GetCodeStrategy() string {
+	if x != nil {
+		return x.CodeStrategy
+	}
+	return ""
+}
+
+type ResolveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Host the short link was requested on; empty means the default domain.
+	Host string `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	// Raw query string of the incoming request, merged according to the
+	// link's forwarding policy.
+	Query         string `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveRequest) Reset() {
+	*x = ResolveRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveRequest) ProtoMessage() {}
+
+func (x *ResolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveRequest.ProtoReflect.Descriptor instead.
+func (*ResolveRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ResolveRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ResolveRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *ResolveRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type ResolveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           *URL                   `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveResponse) Reset() {
+	*x = ResolveResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveResponse) ProtoMessage() {}
+
+func (x *ResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveResponse.ProtoReflect.Descriptor instead.
+func (*ResolveResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ResolveResponse) GetUrl() *URL {
+	if x != nil {
+		return x.Url
+	}
+	return nil
+}
+
+func (x *ResolveResponse) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{8}
+}
+
+type ListRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Limit  int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// status is empty for every live link, "broken" for links whose last
+	// destination check failed or "deleted" for soft-deleted links.
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// search matches a case-insensitive substring of the code,
+	// destination, title, description or metadata.
+	Search string `protobuf:"bytes,4,opt,name=search,proto3" json:"search,omitempty"`
+	// tags narrows the page to links carrying every one of them, and
+	// campaign to the links in that campaign.
+	Tags     []string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	Campaign string   `protobuf:"bytes,6,opt,name=campaign,proto3" json:"campaign,omitempty"`
+	// metadata narrows the page to links whose metadata has every key set
+	// to the given string.
+	Metadata map[string]string `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// workspace lists the links of that workspace instead of those outside
+	// any.
+	Workspace     string `protobuf:"bytes,8,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *ListRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListRequest) GetCampaign() string {
+	if x != nil {
+		return x.Campaign
+	}
+	return ""
+}
+
+func (x *ListRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ListRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Urls          []*URL                 `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListResponse) GetUrls() []*URL {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *ListResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type StatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// include_bots counts bot clicks in total_clicks, last_clicked_at and
+	// daily, which otherwise cover human clicks only.
+	IncludeBots   bool `protobuf:"varint,2,opt,name=include_bots,json=includeBots,proto3" json:"include_bots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *StatsRequest) GetIncludeBots() bool {
+	if x != nil {
+		return x.IncludeBots
+	}
+	return false
+}
+
+type DailyClicks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          string                 `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Clicks        int64                  `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DailyClicks) Reset() {
+	*x = DailyClicks{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DailyClicks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DailyClicks) ProtoMessage() {}
+
+func (x *DailyClicks) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DailyClicks.ProtoReflect.Descriptor instead.
+func (*DailyClicks) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DailyClicks) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *DailyClicks) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+type StatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UrlId         string                 `protobuf:"bytes,1,opt,name=url_id,json=urlId,proto3" json:"url_id,omitempty"`
+	TotalClicks   int64                  `protobuf:"varint,2,opt,name=total_clicks,json=totalClicks,proto3" json:"total_clicks,omitempty"`
+	LastClickedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_clicked_at,json=lastClickedAt,proto3" json:"last_clicked_at,omitempty"`
+	Daily         []*DailyClicks         `protobuf:"bytes,4,rep,name=daily,proto3" json:"daily,omitempty"`
+	BotClicks     int64                  `protobuf:"varint,5,opt,name=bot_clicks,json=botClicks,proto3" json:"bot_clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_shortener_v1_shortener_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shortener_v1_shortener_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_shortener_v1_shortener_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StatsResponse) GetUrlId() string {
+	if x != nil {
+		return x.UrlId
+	}
+	return ""
+}
+
+func (x *StatsResponse) GetTotalClicks() int64 {
+	if x != nil {
+		return x.TotalClicks
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLastClickedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastClickedAt
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetDaily() []*DailyClicks {
+	if x != nil {
+		return x.Daily
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetBotClicks() int64 {
+	if x != nil {
+		return x.BotClicks
+	}
+	return 0
+}
+
+var File_shortener_v1_shortener_proto protoreflect.FileDescriptor
+
+const file_shortener_v1_shortener_proto_rawDesc = "" +
+	"\n" +
+	"\x1cshortener/v1/shortener.proto\x12\fshortener.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcd\a\n" +
+	"\x03URL\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\x12!\n" +
+	"\foriginal_url\x18\x04 \x01(\tR\voriginalUrl\x12#\n" +
+	"\rforward_query\x18\x05 \x01(\bR\fforwardQuery\x12H\n" +
+	"\x10query_precedence\x18\x06 \x01(\x0e2\x1d.shortener.v1.QueryPrecedenceR\x0fqueryPrecedence\x12?\n" +
+	"\n" +
+	"utm_params\x18\a \x03(\v2 .shortener.v1.URL.UtmParamsEntryR\tutmParams\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12;\n" +
+	"\vdisabled_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"disabledAt\x12'\n" +
+	"\x0fdisabled_reason\x18\v \x01(\tR\x0edisabledReason\x120\n" +
+	"\x06health\x18\f \x01(\v2\x18.shortener.v1.LinkHealthR\x06health\x126\n" +
+	"\n" +
+	"bot_policy\x18\r \x01(\x0e2\x17.shortener.v1.BotPolicyR\tbotPolicy\x123\n" +
+	"\apreview\x18\x0e \x01(\v2\x19.shortener.v1.LinkPreviewR\apreview\x12\x1a\n" +
+	"\bcampaign\x18\x0f \x01(\tR\bcampaign\x12\x12\n" +
+	"\x04tags\x18\x10 \x03(\tR\x04tags\x12\x14\n" +
+	"\x05title\x18\x11 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x12 \x01(\tR\vdescription\x123\n" +
+	"\bmetadata\x18\x13 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x14 \x01(\x03R\tmaxClicks\x12\x1f\n" +
+	"\vclicks_used\x18\x15 \x01(\x03R\n" +
+	"clicksUsed\x12!\n" +
+	"\fworkspace_id\x18\x16 \x01(\tR\vworkspaceId\x1a<\n" +
+	"\x0eUtmParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"b\n" +
+	"\vLinkPreview\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1b\n" +
+	"\timage_url\x18\x03 \x01(\tR\bimageUrl\"\xb5\x01\n" +
+	"\n" +
+	"LinkHealth\x12\x1f\n" +
+	"\vstatus_code\x18\x01 \x01(\x05R\n" +
+	"statusCode\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\x02 \x01(\x05R\tlatencyMs\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x16\n" +
+	"\x06broken\x18\x04 \x01(\bR\x06broken\x129\n" +
+	"\n" +
+	"checked_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\"\xc4\x05\n" +
+	"\x0eShortenRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12#\n" +
+	"\rforward_query\x18\x02 \x01(\bR\fforwardQuery\x12H\n" +
+	"\x10query_precedence\x18\x03 \x01(\x0e2\x1d.shortener.v1.QueryPrecedenceR\x0fqueryPrecedence\x12J\n" +
+	"\n" +
+	"utm_params\x18\x04 \x03(\v2+.shortener.v1.ShortenRequest.UtmParamsEntryR\tutmParams\x12\x16\n" +
+	"\x06domain\x18\x05 \x01(\tR\x06domain\x126\n" +
+	"\n" +
+	"bot_policy\x18\x06 \x01(\x0e2\x17.shortener.v1.BotPolicyR\tbotPolicy\x123\n" +
+	"\apreview\x18\a \x01(\v2\x19.shortener.v1.LinkPreviewR\apreview\x12#\n" +
+	"\rfetch_preview\x18\b \x01(\bR\ffetchPreview\x12\x1a\n" +
+	"\bcampaign\x18\t \x01(\tR\bcampaign\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x14\n" +
+	"\x05title\x18\v \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\f \x01(\tR\vdescription\x123\n" +
+	"\bmetadata\x18\r \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"max_clicks\x18\x0e \x01(\x03R\tmaxClicks\x12\x1c\n" +
+	"\tworkspace\x18\x0f \x01(\tR\tworkspace\x12#\n" +
+	"\rcode_strategy\x18\x10 \x01(\tR\fcodeStrategy\x1a<\n" +
+	"\x0eUtmParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"N\n" +
+	"\x0eResolveRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12\x14\n" +
+	"\x05query\x18\x03 \x01(\tR\x05query\"N\n" +
+	"\x0fResolveResponse\x12#\n" +
+	"\x03url\x18\x01 \x01(\v2\x11.shortener.v1.URLR\x03url\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x10\n" +
+	"\x0eDeleteResponse\"\xbb\x02\n" +
+	"\vListRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x16\n" +
+	"\x06search\x18\x04 \x01(\tR\x06search\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x1a\n" +
+	"\bcampaign\x18\x06 \x01(\tR\bcampaign\x12C\n" +
+	"\bmetadata\x18\a \x03(\v2'.shortener.v1.ListRequest.MetadataEntryR\bmetadata\x12\x1c\n" +
+	"\tworkspace\x18\b \x01(\tR\tworkspace\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"V\n" +
+	"\fListResponse\x12%\n" +
+	"\x04urls\x18\x01 \x03(\v2\x11.shortener.v1.URLR\x04urls\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"A\n" +
+	"\fStatsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\finclude_bots\x18\x02 \x01(\bR\vincludeBots\"9\n" +
+	"\vDailyClicks\x12\x12\n" +
+	"\x04date\x18\x01 \x01(\tR\x04date\x12\x16\n" +
+	"\x06clicks\x18\x02 \x01(\x03R\x06clicks\"\xdd\x01\n" +
+	"\rStatsResponse\x12\x15\n" +
+	"\x06url_id\x18\x01 \x01(\tR\x05urlId\x12!\n" +
+	"\ftotal_clicks\x18\x02 \x01(\x03R\vtotalClicks\x12B\n" +
+	"\x0flast_clicked_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\rlastClickedAt\x12/\n" +
+	"\x05daily\x18\x04 \x03(\v2\x19.shortener.v1.DailyClicksR\x05daily\x12\x1d\n" +
+	"\n" +
+	"bot_clicks\x18\x05 \x01(\x03R\tbotClicks*t\n" +
+	"\x0fQueryPrecedence\x12 \n" +
+	"\x1cQUERY_PRECEDENCE_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19QUERY_PRECEDENCE_INCOMING\x10\x01\x12 \n" +
+	"\x1cQUERY_PRECEDENCE_DESTINATION\x10\x02*X\n" +
+	"\tBotPolicy\x12\x1a\n" +
+	"\x16BOT_POLICY_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13BOT_POLICY_REDIRECT\x10\x01\x12\x16\n" +
+	"\x12BOT_POLICY_PREVIEW\x10\x022\x89\x03\n" +
+	"\tShortener\x12:\n" +
+	"\aShorten\x12\x1c.shortener.v1.ShortenRequest\x1a\x11.shortener.v1.URL\x12F\n" +
+	"\aResolve\x12\x1c.shortener.v1.ResolveRequest\x1a\x1d.shortener.v1.ResolveResponse\x122\n" +
+	"\x03Get\x12\x18.shortener.v1.GetRequest\x1a\x11.shortener.v1.URL\x12C\n" +
+	"\x06Delete\x12\x1b.shortener.v1.DeleteRequest\x1a\x1c.shortener.v1.DeleteResponse\x12=\n" +
+	"\x04List\x12\x19.shortener.v1.ListRequest\x1a\x1a.shortener.v1.ListResponse\x12@\n" +
+	"\x05Stats\x12\x1a.shortener.v1.StatsRequest\x1a\x1b.shortener.v1.StatsResponseBDZBgithub.com/kerbatek/url-shortener/pkg/api/shortener/v1;shortenerv1b\x06proto3"
+
+var (
+	file_shortener_v1_shortener_proto_rawDescOnce sync.Once
+	file_shortener_v1_shortener_proto_rawDescData []byte
+)
+
+func file_shortener_v1_shortener_proto_rawDescGZIP() []byte {
+	file_shortener_v1_shortener_proto_rawDescOnce.Do(func() {
+		file_shortener_v1_shortener_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_shortener_v1_shortener_proto_rawDesc), len(file_shortener_v1_shortener_proto_rawDesc)))
+	})
+	return file_shortener_v1_shortener_proto_rawDescData
+}
+
+var file_shortener_v1_shortener_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_shortener_v1_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_shortener_v1_shortener_proto_goTypes = []any{
+	(QueryPrecedence)(0),          // 0: shortener.v1.QueryPrecedence
+	(BotPolicy)(0),                // 1: shortener.v1.BotPolicy
+	(*URL)(nil),                   // 2: shortener.v1.URL
+	(*LinkPreview)(nil),           // 3: shortener.v1.LinkPreview
+	(*LinkHealth)(nil),            // 4: shortener.v1.LinkHealth
+	(*ShortenRequest)(nil),        // 5: shortener.v1.ShortenRequest
+	(*ResolveRequest)(nil),        // 6: shortener.v1.ResolveRequest
+	(*ResolveResponse)(nil),       // 7: shortener.v1.ResolveResponse
+	(*GetRequest)(nil),            // 8: shortener.v1.GetRequest
+	(*DeleteRequest)(nil),         // 9: shortener.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 10: shortener.v1.DeleteResponse
+	(*ListRequest)(nil),           // 11: shortener.v1.ListRequest
+	(*ListResponse)(nil),          // 12: shortener.v1.ListResponse
+	(*StatsRequest)(nil),          // 13: shortener.v1.StatsRequest
+	(*DailyClicks)(nil),           // 14: shortener.v1.DailyClicks
+	(*StatsResponse)(nil),         // 15: shortener.v1.StatsResponse
+	nil,                           // 16: shortener.v1.URL.UtmParamsEntry
+	nil,                           // 17: shortener.v1.ShortenRequest.UtmParamsEntry
+	nil,                           // 18: shortener.v1.ListRequest.MetadataEntry
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 20: google.protobuf.Struct
+}
+var file_shortener_v1_shortener_proto_depIdxs = []int32{
+	0,  // 0: shortener.v1.URL.query_precedence:type_name -> shortener.v1.QueryPrecedence
+	16, // 1: shortener.v1.URL.utm_params:type_name -> shortener.v1.URL.UtmParamsEntry
+	19, // 2: shortener.v1.URL.created_at:type_name -> google.protobuf.Timestamp
+	19, // 3: shortener.v1.URL.updated_at:type_name -> google.protobuf.Timestamp
+	19, // 4: shortener.v1.URL.disabled_at:type_name -> google.protobuf.Timestamp
+	4,  // 5: shortener.v1.URL.health:type_name -> shortener.v1.LinkHealth
+	1,  // 6: shortener.v1.URL.bot_policy:type_name -> shortener.v1.BotPolicy
+	3,  // 7: shortener.v1.URL.preview:type_name -> shortener.v1.LinkPreview
+	20, // 8: shortener.v1.URL.metadata:type_name -> google.protobuf.Struct
+	19, // 9: shortener.v1.LinkHealth.checked_at:type_name -> google.protobuf.Timestamp
+	0,  // 10: shortener.v1.ShortenRequest.query_precedence:type_name -> shortener.v1.QueryPrecedence
+	17, // 11: shortener.v1.ShortenRequest.utm_params:type_name -> shortener.v1.ShortenRequest.UtmParamsEntry
+	1,  // 12: shortener.v1.ShortenRequest.bot_policy:type_name -> shortener.v1.BotPolicy
+	3,  // 13: shortener.v1.ShortenRequest.preview:type_name -> shortener.v1.LinkPreview
+	20, // 14: shortener.v1.ShortenRequest.metadata:type_name -> google.protobuf.Struct
+	2,  // 15: shortener.v1.ResolveResponse.url:type_name -> shortener.v1.URL
+	18, // 16: shortener.v1.ListRequest.metadata:type_name -> shortener.v1.ListRequest.MetadataEntry
+	2,  // 17: shortener.v1.ListResponse.urls:type_name -> shortener.v1.URL
+	19, // 18: shortener.v1.StatsResponse.last_clicked_at:type_name -> google.protobuf.Timestamp
+	14, // 19: shortener.v1.StatsResponse.daily:type_name -> shortener.v1.DailyClicks
+	5,  // 20: shortener.v1.Shortener.Shorten:input_type -> shortener.v1.ShortenRequest
+	6,  // 21: shortener.v1.Shortener.Resolve:input_type -> shortener.v1.ResolveRequest
+	8,  // 22: shortener.v1.Shortener.Get:input_type -> shortener.v1.GetRequest
+	9,  // 23: shortener.v1.Shortener.Delete:input_type -> shortener.v1.DeleteRequest
+	11, // 24: shortener.v1.Shortener.List:input_type -> shortener.v1.ListRequest
+	13, // 25: shortener.v1.Shortener.Stats:input_type -> shortener.v1.StatsRequest
+	2,  // 26: shortener.v1.Shortener.Shorten:output_type -> shortener.v1.URL
+	7,  // 27: shortener.v1.Shortener.Resolve:output_type -> shortener.v1.ResolveResponse
+	2,  // 28: shortener.v1.Shortener.Get:output_type -> shortener.v1.URL
+	10, // 29: shortener.v1.Shortener.Delete:output_type -> shortener.v1.DeleteResponse
+	12, // 30: shortener.v1.Shortener.List:output_type -> shortener.v1.ListResponse
+	15, // 31: shortener.v1.Shortener.Stats:output_type -> shortener.v1.StatsResponse
+	26, // [26:32] is the sub-list for method output_type
+	20, // [20:26] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
+}
+
+func init() { file_shortener_v1_shortener_proto_init() }
+func file_shortener_v1_shortener_proto_init() {
+	if File_shortener_v1_shortener_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_shortener_v1_shortener_proto_rawDesc), len(file_shortener_v1_shortener_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_shortener_v1_shortener_proto_goTypes,
+		DependencyIndexes: file_shortener_v1_shortener_proto_depIdxs,
+		EnumInfos:         file_shortener_v1_shortener_proto_enumTypes,
+		MessageInfos:      file_shortener_v1_shortener_proto_msgTypes,
+	}.Build()
+	File_shortener_v1_shortener_proto = out.File
+	file_shortener_v1_shortener_proto_goTypes = nil
+	file_shortener_v1_shortener_proto_depIdxs = nil
+}
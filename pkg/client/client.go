@@ -0,0 +1,589 @@
+// Package client is a typed Go client for the URL shortener HTTP API.
+//
+//	c := client.New("https://sho.rt", client.WithAPIKey(key))
+//	u, err := c.Shorten(ctx, client.ShortenRequest{URL: "https://example.com"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIError is returned for any non-2xx response. Message is the detail of
+// the server's problem+json body, when it has one.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api error: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("api error: status %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	token      string
+	httpClient *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey sends key as X-API-Key on every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithBearerToken sends token, an ID token from the server's single sign-on
+// provider, as a bearer token on every request. It takes precedence over
+// WithAPIKey.
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends a request and decodes a JSON response into out, if non-nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.send(ctx, c.httpClient, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// rawBody is sent as-is instead of being encoded as JSON.
+type rawBody struct {
+	r           io.Reader
+	contentType string
+}
+
+func (c *Client) send(ctx context.Context, hc *http.Client, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	var contentType string
+	switch b := body.(type) {
+	case nil:
+	case rawBody:
+		reader, contentType = b.r, b.contentType
+	default:
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+		reader, contentType = bytes.NewReader(buf), "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return hc.Do(req)
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var problem struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err == nil {
+		apiErr.Message = problem.Detail
+		if apiErr.Message == "" {
+			apiErr.Message = problem.Title
+		}
+	}
+	return apiErr
+}
+
+// Shorten creates a short URL.
+func (c *Client) Shorten(ctx context.Context, req ShortenRequest) (*URL, error) {
+	var u URL
+	if err := c.do(ctx, http.MethodPost, "/shorten", req, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Resolve returns the redirect target for code without following it. A
+// link disabled by threat screening fails with a 403 *APIError.
+func (c *Client) Resolve(ctx context.Context, code string) (string, error) {
+	hc := *c.httpClient
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := c.send(ctx, &hc, http.MethodGet, "/"+url.PathEscape(code), nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return "", decodeError(resp)
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+// Get returns the short URL with the given ID.
+func (c *Client) Get(ctx context.Context, id string) (*URL, error) {
+	var u URL
+	if err := c.do(ctx, http.MethodGet, "/url/"+url.PathEscape(id), nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// List returns a page of short URLs, newest first. Pass the previous
+// page's NextCursor to continue; a limit of 0 uses the server default.
+func (c *Client) List(ctx context.Context, limit int, cursor string) (*URLPage, error) {
+	return c.ListFiltered(ctx, ListFilter{}, limit, cursor)
+}
+
+// ListBroken is List restricted to links whose last destination check
+// failed.
+func (c *Client) ListBroken(ctx context.Context, limit int, cursor string) (*URLPage, error) {
+	return c.ListFiltered(ctx, ListFilter{Broken: true}, limit, cursor)
+}
+
+// ListFiltered is List restricted to the links matching f.
+func (c *Client) ListFiltered(ctx context.Context, f ListFilter, limit int, cursor string) (*URLPage, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	if f.Broken {
+		q.Set("status", "broken")
+	}
+	if f.Search != "" {
+		q.Set("q", f.Search)
+	}
+	for _, t := range f.Tags {
+		q.Add("tag", t)
+	}
+	if f.Campaign != "" {
+		q.Set("campaign", f.Campaign)
+	}
+	for _, k := range slices.Sorted(maps.Keys(f.Metadata)) {
+		q.Add("meta", k+"="+f.Metadata[k])
+	}
+	if f.Workspace != "" {
+		q.Set("workspace", f.Workspace)
+	}
+	path := "/urls"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var page URLPage
+	if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Audit returns a page of the audit log matching f, newest first.
+func (c *Client) Audit(ctx context.Context, f AuditFilter, limit int, cursor string) (*AuditPage, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	if f.Actor != "" {
+		q.Set("actor", f.Actor)
+	}
+	if f.Link != "" {
+		q.Set("link", f.Link)
+	}
+	if !f.Since.IsZero() {
+		q.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		q.Set("until", f.Until.Format(time.RFC3339))
+	}
+	if f.Workspace != "" {
+		q.Set("workspace", f.Workspace)
+	}
+	path := "/audit"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var page AuditPage
+	if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Stats returns click statistics for the short URL with the given ID.
+func (c *Client) Stats(ctx context.Context, id string) (*Stats, error) {
+	return c.stats(ctx, id, "")
+}
+
+// StatsWithBots is Stats with bot clicks counted in the totals and daily
+// breakdown.
+func (c *Client) StatsWithBots(ctx context.Context, id string) (*Stats, error) {
+	return c.stats(ctx, id, "?include_bots=true")
+}
+
+func (c *Client) stats(ctx context.Context, id, query string) (*Stats, error) {
+	var s Stats
+	if err := c.do(ctx, http.MethodGet, "/url/"+url.PathEscape(id)+"/stats"+query, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Tags returns the tags in use, by name, with their link counts, among
+// the links of workspace, or of those outside any when it is "".
+func (c *Client) Tags(ctx context.Context, workspace string) ([]LinkGroup, error) {
+	return c.groups(ctx, "/tags", workspace)
+}
+
+// Campaigns returns the campaigns in use, by name, with their link counts,
+// among the links of workspace, or of those outside any when it is "".
+func (c *Client) Campaigns(ctx context.Context, workspace string) ([]LinkGroup, error) {
+	return c.groups(ctx, "/campaigns", workspace)
+}
+
+func (c *Client) groups(ctx context.Context, path, workspace string) ([]LinkGroup, error) {
+	if workspace != "" {
+		path += "?" + url.Values{"workspace": {workspace}}.Encode()
+	}
+	var groups []LinkGroup
+	if err := c.do(ctx, http.MethodGet, path, nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// TagStats returns click statistics summed over every link of workspace
+// ("" for those outside any) with the tag, counting bot clicks in the
+// totals when includeBots is set.
+func (c *Client) TagStats(ctx context.Context, tag, workspace string, includeBots bool) (*GroupStats, error) {
+	return c.groupStats(ctx, "/tags/"+url.PathEscape(tag), workspace, includeBots)
+}
+
+// CampaignStats returns click statistics summed over every link of
+// workspace ("" for those outside any) in the campaign, counting bot
+// clicks in the totals when includeBots is set.
+func (c *Client) CampaignStats(ctx context.Context, campaign, workspace string, includeBots bool) (*GroupStats, error) {
+	return c.groupStats(ctx, "/campaigns/"+url.PathEscape(campaign), workspace, includeBots)
+}
+
+func (c *Client) groupStats(ctx context.Context, path, workspace string, includeBots bool) (*GroupStats, error) {
+	path += "/stats"
+	q := url.Values{}
+	if workspace != "" {
+		q.Set("workspace", workspace)
+	}
+	if includeBots {
+		q.Set("include_bots", "true")
+	}
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var s GroupStats
+	if err := c.do(ctx, http.MethodGet, path, nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Delete removes the short URL with the given ID.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/url/"+url.PathEscape(id), nil, nil)
+}
+
+// Update changes the short URL with the given ID.
+func (c *Client) Update(ctx context.Context, id string, req UpdateRequest) (*URL, error) {
+	var u URL
+	if err := c.do(ctx, http.MethodPatch, "/url/"+url.PathEscape(id), req, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Restore brings back a deleted short URL.
+func (c *Client) Restore(ctx context.Context, id string) (*URL, error) {
+	var u URL
+	if err := c.do(ctx, http.MethodPost, "/url/"+url.PathEscape(id)+"/restore", nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Export streams every short URL of workspace, or outside any when it is
+// "", to w in format, FormatNDJSON or FormatCSV.
+func (c *Client) Export(ctx context.Context, format, workspace string, w io.Writer) error {
+	q := url.Values{"format": {format}}
+	if workspace != "" {
+		q.Set("workspace", workspace)
+	}
+	resp, err := c.send(ctx, c.httpClient, http.MethodGet, "/export?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Import uploads links read from r in format: FormatNDJSON, FormatCSV,
+// FormatYOURLS or FormatBitly. A non-empty domain puts every link on that
+// custom domain, and a non-empty workspace in that workspace.
+func (c *Client) Import(ctx context.Context, format string, r io.Reader, domain, workspace string) (*ImportReport, error) {
+	q := url.Values{"format": {format}}
+	if domain != "" {
+		q.Set("domain", domain)
+	}
+	if workspace != "" {
+		q.Set("workspace", workspace)
+	}
+	contentType := "text/csv"
+	if format == FormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	var report ImportReport
+	if err := c.do(ctx, http.MethodPost, "/import?"+q.Encode(), rawBody{r: r, contentType: contentType}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Health calls the liveness endpoint.
+func (c *Client) Health(ctx context.Context) (*Status, error) {
+	var s Status
+	if err := c.do(ctx, http.MethodGet, "/health", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Ready calls the readiness endpoint. A 503 is returned as an *APIError.
+func (c *Client) Ready(ctx context.Context) (*Status, error) {
+	var s Status
+	if err := c.do(ctx, http.MethodGet, "/ready", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RegisterDomain claims a custom short host for the client's API key.
+func (c *Client) RegisterDomain(ctx context.Context, host, fallbackURL string) (*Domain, error) {
+	req := struct {
+		Host        string `json:"host"`
+		FallbackURL string `json:"fallback_url,omitempty"`
+	}{host, fallbackURL}
+
+	var d Domain
+	if err := c.do(ctx, http.MethodPost, "/domains", req, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDomains returns the domains owned by the client's API key.
+func (c *Client) ListDomains(ctx context.Context) ([]Domain, error) {
+	var domains []Domain
+	if err := c.do(ctx, http.MethodGet, "/domains", nil, &domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// RegisterWebhook adds a webhook endpoint for the events of links outside
+// any workspace. The returned Secret is not available again.
+func (c *Client) RegisterWebhook(ctx context.Context, target string, events ...string) (*Webhook, error) {
+	return c.RegisterWorkspaceWebhook(ctx, "", target, events...)
+}
+
+// RegisterWorkspaceWebhook adds a webhook endpoint for the events of links
+// in a workspace the client's user is a member of. The returned Secret is
+// not available again.
+func (c *Client) RegisterWorkspaceWebhook(ctx context.Context, workspaceID, target string, events ...string) (*Webhook, error) {
+	req := struct {
+		URL       string   `json:"url"`
+		Events    []string `json:"events,omitempty"`
+		Workspace string   `json:"workspace,omitempty"`
+	}{target, events, workspaceID}
+
+	var w Webhook
+	if err := c.do(ctx, http.MethodPost, "/webhooks", req, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWebhooks returns the webhooks owned by the client's user.
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	if err := c.do(ctx, http.MethodGet, "/webhooks", nil, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// ListDeliveries returns recent delivery attempts for a webhook.
+func (c *Client) ListDeliveries(ctx context.Context, webhookID string) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, "/webhooks/"+url.PathEscape(webhookID)+"/deliveries", nil, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// SignUp creates a user. The returned APIKey is not available again; pass
+// it to WithAPIKey to act as the user.
+func (c *Client) SignUp(ctx context.Context, email, name string) (*User, error) {
+	req := struct {
+		Email string `json:"email"`
+		Name  string `json:"name,omitempty"`
+	}{email, name}
+
+	var u User
+	if err := c.do(ctx, http.MethodPost, "/users", req, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateWorkspace creates a workspace with the client's user as its admin.
+func (c *Client) CreateWorkspace(ctx context.Context, name string) (*Workspace, error) {
+	req := struct {
+		Name string `json:"name"`
+	}{name}
+
+	var w Workspace
+	if err := c.do(ctx, http.MethodPost, "/workspaces", req, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWorkspaces returns the workspaces the client's user is a member of.
+func (c *Client) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
+	var workspaces []Workspace
+	if err := c.do(ctx, http.MethodGet, "/workspaces", nil, &workspaces); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// ListMembers returns the members of a workspace.
+func (c *Client) ListMembers(ctx context.Context, workspaceID string) ([]Member, error) {
+	var members []Member
+	if err := c.do(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(workspaceID)+"/members", nil, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SetRole changes a member's role, which takes the admin role.
+func (c *Client) SetRole(ctx context.Context, workspaceID, userID, role string) error {
+	req := struct {
+		Role string `json:"role"`
+	}{role}
+	return c.do(ctx, http.MethodPatch, memberPath(workspaceID, userID), req, nil)
+}
+
+// RemoveMember removes a member from a workspace. Admins may remove
+// anyone; everyone may remove themselves.
+func (c *Client) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	return c.do(ctx, http.MethodDelete, memberPath(workspaceID, userID), nil, nil)
+}
+
+func memberPath(workspaceID, userID string) string {
+	return "/workspaces/" + url.PathEscape(workspaceID) + "/members/" + url.PathEscape(userID)
+}
+
+// Invite creates an invite into a workspace for role, restricted to email
+// unless it is empty. The returned Token is not available again.
+func (c *Client) Invite(ctx context.Context, workspaceID, email, role string) (*Invite, error) {
+	req := struct {
+		Email string `json:"email,omitempty"`
+		Role  string `json:"role,omitempty"`
+	}{email, role}
+
+	var inv Invite
+	if err := c.do(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(workspaceID)+"/invites", req, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// AcceptInvite joins the workspace the invite token is for and returns it.
+func (c *Client) AcceptInvite(ctx context.Context, token string) (*Workspace, error) {
+	req := struct {
+		Token string `json:"token"`
+	}{token}
+
+	var w Workspace
+	if err := c.do(ctx, http.MethodPost, "/invites/accept", req, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
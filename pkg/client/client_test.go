@@ -0,0 +1,524 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kerbatek/url-shortener/internal/apperr"
+	"github.com/kerbatek/url-shortener/internal/handler"
+	"github.com/kerbatek/url-shortener/internal/middleware"
+	"github.com/kerbatek/url-shortener/internal/model"
+	"github.com/kerbatek/url-shortener/internal/openapi"
+	"github.com/kerbatek/url-shortener/internal/repository/mocks"
+	"github.com/kerbatek/url-shortener/internal/service"
+	"github.com/rs/zerolog"
+	"go.uber.org/mock/gomock"
+)
+
+type stubPinger struct{ err error }
+
+func (s *stubPinger) Ping(_ context.Context) error { return s.err }
+
+type testServer struct {
+	*httptest.Server
+	urls     *mocks.MockURLRepository
+	webhooks *mocks.MockWebhookRepository
+	accounts *mocks.MockAccountRepository
+}
+
+// setupServer runs the real handlers behind the spec validator so the
+// client is exercised against the documented API.
+func setupServer(t *testing.T, ctrl *gomock.Controller) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	validator, err := openapi.Validator(doc)
+	if err != nil {
+		t.Fatalf("failed to build validator: %v", err)
+	}
+
+	urls := mocks.NewMockURLRepository(ctrl)
+	webhooks := mocks.NewMockWebhookRepository(ctrl)
+	accounts := mocks.NewMockAccountRepository(ctrl)
+	as := service.NewAccountService(accounts)
+	h := handler.NewURLHandler(service.NewURLService(urls, service.WithAudit(mocks.NewMockAuditRepository(ctrl))))
+	hh := handler.NewHealthHandler(&stubPinger{})
+	wh := handler.NewWebhookHandler(service.NewWebhookService(webhooks, accounts))
+	ah := handler.NewAccountHandler(as)
+
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.Use(validator)
+	router.Use(middleware.Authenticate(as))
+	router.GET("/health", hh.Liveness)
+	router.GET("/ready", hh.Readiness)
+	router.POST("/shorten", h.ShortenURL)
+	router.GET("/:code", h.RedirectURL)
+	router.GET("/urls", h.ListURLs)
+	router.GET("/export", h.ExportURLs)
+	router.POST("/import", h.ImportURLs)
+	router.GET("/url/:id", h.GetURL)
+	router.PATCH("/url/:id", h.UpdateURL)
+	router.DELETE("/url/:id", h.DeleteURL)
+	router.GET("/tags", h.ListTags)
+	router.GET("/audit", h.Audit)
+	router.POST("/webhooks", wh.RegisterWebhook)
+	router.GET("/webhooks/:id/deliveries", wh.ListDeliveries)
+	router.POST("/users", ah.SignUp)
+	router.POST("/workspaces", ah.CreateWorkspace)
+	router.POST("/workspaces/:id/invites", ah.CreateInvite)
+	router.POST("/invites/accept", ah.AcceptInvite)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return &testServer{Server: srv, urls: urls, webhooks: webhooks, accounts: accounts}
+}
+
+func TestShorten(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.urls.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.URL) error {
+			u.ID = "550e8400-e29b-41d4-a716-446655440000"
+			u.CreatedAt = time.Now()
+			return nil
+		})
+
+	c := New(srv.URL)
+	u, err := c.Shorten(context.Background(), ShortenRequest{URL: "https://example.com", ForwardQuery: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.ID != "550e8400-e29b-41d4-a716-446655440000" || u.Code == "" || !u.ForwardQuery {
+		t.Errorf("unexpected url %+v", u)
+	}
+}
+
+func TestShorten_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	c := New(srv.URL)
+	_, err := c.Shorten(context.Background(), ShortenRequest{URL: "https://example.com", QueryPrecedence: "sideways"})
+
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Message == "" {
+		t.Errorf("expected 400 with message, got %d %q", apiErr.StatusCode, apiErr.Message)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.urls.EXPECT().
+		GetByCode(gomock.Any(), "", "abc1234").
+		Return(&model.URL{Code: "abc1234", OriginalURL: "https://example.com"}, nil)
+
+	target, err := New(srv.URL).Resolve(context.Background(), "abc1234")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if target != "https://example.com" {
+		t.Errorf("expected https://example.com, got %s", target)
+	}
+}
+
+func TestDelete_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.urls.EXPECT().
+		Delete(gomock.Any(), "00000000-0000-0000-0000-000000000000").
+		Return(apperr.NotFound("url not found"))
+
+	err := New(srv.URL).Delete(context.Background(), "00000000-0000-0000-0000-000000000000")
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	c := New(srv.URL)
+	for name, call := range map[string]func(context.Context) (*Status, error){"health": c.Health, "ready": c.Ready} {
+		s, err := call(context.Background())
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if s.Status != "ok" {
+			t.Errorf("%s: expected status ok, got %s", name, s.Status)
+		}
+	}
+}
+
+func TestWebhooks_SendAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.webhooks.EXPECT().
+		Create(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, w *model.Webhook) error {
+			w.ID = "w1"
+			return nil
+		})
+	srv.webhooks.EXPECT().
+		GetByID(gomock.Any(), "w1").
+		Return(&model.Webhook{ID: "w1", OwnerUserID: "u1"}, nil)
+	srv.webhooks.EXPECT().
+		ListDeliveries(gomock.Any(), "w1", gomock.Any()).
+		Return([]model.WebhookDelivery{{ID: 1, WebhookID: "w1", Payload: []byte(`{}`), Status: model.DeliveryPending}}, nil)
+	srv.accounts.EXPECT().
+		GetUserByKeyHash(gomock.Any(), service.HashAPIKey("secret")).
+		Return(&model.User{ID: "u1", Email: "ada@example.com"}, nil).
+		Times(2)
+
+	c := New(srv.URL, WithAPIKey("secret"))
+	w, err := c.RegisterWebhook(context.Background(), "https://hooks.example.com", EventLinkCreated)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if w.Secret == "" {
+		t.Error("expected signing secret")
+	}
+
+	deliveries, err := c.ListDeliveries(context.Background(), w.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != model.DeliveryPending {
+		t.Errorf("unexpected deliveries %+v", deliveries)
+	}
+}
+
+func TestBearerToken_Sent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	// The test server accepts no bearer tokens, so sending one is refused
+	// rather than treated as an anonymous call.
+	c := New(srv.URL, WithBearerToken("jwt"), WithAPIKey("secret"))
+	_, err := c.Shorten(context.Background(), ShortenRequest{URL: "https://example.com"})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %v", err)
+	}
+}
+
+func TestAudit_RequiresOperator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.accounts.EXPECT().
+		GetUserByKeyHash(gomock.Any(), service.HashAPIKey("secret")).
+		Return(&model.User{ID: "u1", Email: "ada@example.com"}, nil)
+
+	c := New(srv.URL, WithAPIKey("secret"))
+	_, err := c.Audit(context.Background(), AuditFilter{
+		Actor: "u1",
+		Link:  "550e8400-e29b-41d4-a716-446655440000",
+		Since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, 10, "")
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %v", err)
+	}
+}
+
+func TestGetAndList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	u := model.URL{ID: "550e8400-e29b-41d4-a716-446655440000", Code: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()}
+	srv.urls.EXPECT().GetByID(gomock.Any(), u.ID).Return(&u, nil)
+	srv.urls.EXPECT().List(gomock.Any(), model.ListOptions{Limit: 1}).Return([]model.URL{u}, nil)
+
+	c := New(srv.URL)
+	got, err := c.Get(context.Background(), u.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Code != "abc1234" {
+		t.Errorf("expected code abc1234, got %s", got.Code)
+	}
+
+	page, err := c.List(context.Background(), 1, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.URLs) != 1 || page.NextCursor == "" {
+		t.Errorf("expected one URL and a next cursor, got %+v", page)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	id := "550e8400-e29b-41d4-a716-446655440000"
+	srv.urls.EXPECT().
+		GetByID(gomock.Any(), id).
+		Return(&model.URL{ID: id, OriginalURL: "https://example.com", QueryPrecedence: model.QueryPrecedenceIncoming}, nil)
+	srv.urls.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+
+	dest := "https://example.org"
+	u, err := New(srv.URL).Update(context.Background(), id, UpdateRequest{URL: &dest})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.OriginalURL != dest {
+		t.Errorf("expected original URL %s, got %s", dest, u.OriginalURL)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.urls.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		Return([]model.URL{{ID: "1", Code: "abc1234", OriginalURL: "https://example.com", CreatedAt: time.Now()}}, nil)
+	srv.urls.EXPECT().
+		Import(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, urls []model.URL) ([]int, error) {
+			if len(urls) != 1 || urls[0].Code != "abc1234" {
+				t.Errorf("expected the exported link back, got %+v", urls)
+			}
+			return nil, nil
+		})
+
+	c := New(srv.URL)
+	var buf bytes.Buffer
+	if err := c.Export(context.Background(), FormatNDJSON, "", &buf); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	report, err := c.Import(context.Background(), FormatNDJSON, &buf, "", "")
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if report.Imported != 1 {
+		t.Errorf("expected 1 imported, got %d", report.Imported)
+	}
+}
+
+func TestImport_UnknownFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	_, err := New(srv.URL).Import(context.Background(), "xml", strings.NewReader(""), "", "")
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 APIError, got %v", err)
+	}
+}
+
+func TestListBroken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	checked := time.Now()
+	srv.urls.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 5, Broken: true}).
+		Return([]model.URL{{ID: "550e8400-e29b-41d4-a716-446655440000", Code: "abc1234", OriginalURL: "https://example.com",
+			CreatedAt: time.Now(), Health: &model.LinkHealth{Error: "connection refused", Broken: true, CheckedAt: checked}}}, nil)
+
+	page, err := New(srv.URL).ListBroken(context.Background(), 5, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.URLs) != 1 || page.URLs[0].Health == nil || !page.URLs[0].Health.Broken {
+		t.Errorf("expected one broken URL, got %+v", page)
+	}
+}
+
+func TestListFilteredAndTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	srv.urls.EXPECT().
+		List(gomock.Any(), model.ListOptions{Limit: 5, Search: "sale", Tags: []string{"mail", "promo"}, Campaign: "Spring launch",
+			Metadata: map[string]string{"owner": "alice", "team": "growth"}}).
+		Return([]model.URL{{ID: "550e8400-e29b-41d4-a716-446655440000", Code: "abc1234", OriginalURL: "https://example.com",
+			QueryPrecedence: model.QueryPrecedenceIncoming, BotPolicy: model.BotPolicyRedirect,
+			Campaign: "Spring launch", Tags: []string{"mail", "promo"}, Title: "Spring sale",
+			Metadata: map[string]any{"owner": "alice"}, CreatedAt: time.Now()}}, nil)
+	srv.urls.EXPECT().
+		Groups(gomock.Any(), model.GroupTag, "").
+		Return([]model.LinkGroup{{Name: "mail", Links: 1}, {Name: "promo", Links: 4}}, nil)
+
+	c := New(srv.URL)
+	page, err := c.ListFiltered(context.Background(), ListFilter{
+		Search:   "sale",
+		Tags:     []string{"promo", "mail"},
+		Campaign: "Spring launch",
+		Metadata: map[string]string{"team": "growth", "owner": "alice"},
+	}, 5, "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.URLs) != 1 || page.URLs[0].Campaign != "Spring launch" || len(page.URLs[0].Tags) != 2 {
+		t.Errorf("expected one grouped URL, got %+v", page)
+	}
+	if u := page.URLs[0]; u.Title != "Spring sale" || u.Metadata["owner"] != "alice" {
+		t.Errorf("expected notes to be decoded, got %q %v", u.Title, u.Metadata)
+	}
+
+	tags, err := c.Tags(context.Background(), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(tags) != 2 || tags[1] != (LinkGroup{Name: "promo", Links: 4}) {
+		t.Errorf("unexpected tags %+v", tags)
+	}
+}
+
+func TestWorkspaces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	srv := setupServer(t, ctrl)
+
+	var key string
+	srv.accounts.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, u *model.User) error {
+			u.ID = "u1"
+			key = u.APIKey
+			return nil
+		})
+	user := &model.User{ID: "u1", Email: "ada@example.com"}
+	srv.accounts.EXPECT().
+		GetUserByKeyHash(gomock.Any(), gomock.Any()).
+		Return(user, nil).
+		Times(2)
+	srv.accounts.EXPECT().
+		CreateWorkspace(gomock.Any(), gomock.Any(), "u1").
+		DoAndReturn(func(ctx context.Context, w *model.Workspace, ownerID string) error {
+			w.ID, w.Role = "11111111-1111-1111-1111-111111111111", model.RoleAdmin
+			return nil
+		})
+	srv.accounts.EXPECT().
+		GetWorkspace(gomock.Any(), "11111111-1111-1111-1111-111111111111", "u1").
+		Return(&model.Workspace{ID: "11111111-1111-1111-1111-111111111111", Name: "Team", Role: model.RoleAdmin}, nil)
+	srv.accounts.EXPECT().
+		CreateInvite(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	u, err := New(srv.URL).SignUp(context.Background(), "Ada@example.com", "Ada")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if u.APIKey == "" || u.APIKey != key || u.Email != "ada@example.com" {
+		t.Errorf("unexpected user %+v", u)
+	}
+
+	c := New(srv.URL, WithAPIKey(u.APIKey))
+	w, err := c.CreateWorkspace(context.Background(), "Team")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if w.Role != RoleAdmin {
+		t.Errorf("expected admin role, got %q", w.Role)
+	}
+	inv, err := c.Invite(context.Background(), w.ID, "", RoleEditor)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if inv.Token == "" || inv.Role != RoleEditor {
+		t.Errorf("unexpected invite %+v", inv)
+	}
+}
+
+func TestWorkspaceOption(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const ws = "11111111-1111-1111-1111-111111111111"
+	urls := mocks.NewMockURLRepository(ctrl)
+	clicks := mocks.NewMockClickRepository(ctrl)
+	accounts := mocks.NewMockAccountRepository(ctrl)
+	h := handler.NewURLHandler(service.NewURLService(urls, service.WithClicks(clicks), service.WithAccounts(accounts)))
+	router := gin.New()
+	router.Use(middleware.Errors(zerolog.Nop()))
+	router.Use(middleware.Authenticate(service.NewAccountService(accounts)))
+	router.GET("/export", h.ExportURLs)
+	router.POST("/import", h.ImportURLs)
+	router.GET("/tags", h.ListTags)
+	router.GET("/campaigns/:name/stats", h.CampaignStats)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	accounts.EXPECT().
+		GetUserByKeyHash(gomock.Any(), service.HashAPIKey("ada-key")).
+		Return(&model.User{ID: "u1"}, nil).
+		AnyTimes()
+	accounts.EXPECT().
+		GetWorkspace(gomock.Any(), ws, "u1").
+		Return(&model.Workspace{ID: ws, Role: model.RoleEditor}, nil).
+		AnyTimes()
+	urls.EXPECT().Groups(gomock.Any(), model.GroupTag, ws).Return([]model.LinkGroup{}, nil)
+	clicks.EXPECT().
+		GroupStats(gomock.Any(), model.GroupCampaign, "Spring", ws, model.StatsDays, true).
+		Return(&model.GroupStats{Name: "Spring"}, nil)
+	urls.EXPECT().
+		List(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, opts model.ListOptions) ([]model.URL, error) {
+			if opts.Workspace != ws {
+				t.Errorf("expected the workspace's links exported, got %+v", opts)
+			}
+			return []model.URL{}, nil
+		})
+	urls.EXPECT().
+		Import(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, links []model.URL) ([]int, error) {
+			if len(links) != 1 || links[0].WorkspaceID == nil || *links[0].WorkspaceID != ws {
+				t.Errorf("expected the link imported into the workspace, got %+v", links)
+			}
+			return nil, nil
+		})
+
+	c := New(srv.URL, WithAPIKey("ada-key"))
+	ctx := context.Background()
+	if _, err := c.Tags(ctx, ws); err != nil {
+		t.Fatalf("tags failed: %v", err)
+	}
+	if _, err := c.CampaignStats(ctx, "Spring", ws, true); err != nil {
+		t.Fatalf("campaign stats failed: %v", err)
+	}
+	if err := c.Export(ctx, FormatNDJSON, ws, &bytes.Buffer{}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	in := strings.NewReader(`{"code":"abc1234","original_url":"https://example.com"}` + "\n")
+	if _, err := c.Import(ctx, FormatNDJSON, in, "", ws); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/kerbatek/url-shortener/internal/model"
+)
+
+// The types the API responds with are the server's own models, so the
+// client, and shortctl built on it, cannot drift from what the API sends.
+type (
+	// URL is a link. WorkspaceID is nil for links outside any workspace,
+	// Health nil until the destination has been checked and Preview nil
+	// unless the link carries custom preview metadata.
+	URL         = model.URL
+	LinkPreview = model.LinkPreview
+	LinkHealth  = model.LinkHealth
+	URLPage     = model.URLPage
+	// Stats counts human clicks in TotalClicks, LastClickedAt and Daily,
+	// and bot clicks in BotClicks, unless fetched with StatsWithBots.
+	Stats       = model.Stats
+	DailyClicks = model.DailyClicks
+	// LinkGroup is a tag or campaign with the number of live links in it;
+	// GroupStats sums the clicks on them, most clicked links first.
+	LinkGroup     = model.LinkGroup
+	GroupStats    = model.GroupStats
+	LinkClicks    = model.LinkClicks
+	ImportReport  = model.ImportReport
+	ImportProblem = model.ImportProblem
+	// Workspace is a team sharing links. Role is the client's role in it.
+	Workspace = model.Workspace
+	Member    = model.Member
+)
+
+// Query precedence values for ShortenRequest.QueryPrecedence.
+const (
+	QueryPrecedenceIncoming    = "incoming"
+	QueryPrecedenceDestination = "destination"
+)
+
+// Webhook events.
+const (
+	EventLinkCreated           = "link.created"
+	EventLinkUpdated           = "link.updated"
+	EventLinkDeleted           = "link.deleted"
+	EventLinkRestored          = "link.restored"
+	EventLinkClickLimitReached = "link.click_limit_reached"
+)
+
+type ShortenRequest struct {
+	URL             string            `json:"url"`
+	ForwardQuery    bool              `json:"forward_query,omitempty"`
+	QueryPrecedence string            `json:"query_precedence,omitempty"`
+	UTMParams       map[string]string `json:"utm_params,omitempty"`
+	// BotPolicy is "redirect" (the default) or "preview".
+	BotPolicy string       `json:"bot_policy,omitempty"`
+	Campaign  string       `json:"campaign,omitempty"`
+	Tags      []string     `json:"tags,omitempty"`
+	Preview   *LinkPreview `json:"preview,omitempty"`
+	// FetchPreview fills the preview fields left empty from the
+	// destination's own metadata.
+	FetchPreview bool   `json:"fetch_preview,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	// Title, Description and Metadata are notes for whoever manages the
+	// link; visitors never see them.
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	// MaxClicks makes the link answer 410 Gone after that many redirects.
+	MaxClicks int64 `json:"max_clicks,omitempty"`
+	// Workspace creates the link in the workspace with this ID, which
+	// takes the editor role.
+	Workspace string `json:"workspace,omitempty"`
+	// CodeStrategy is how the code is made: "random", "friendly",
+	// "sequential", "obfuscated" or "words". Empty uses the server's
+	// default.
+	CodeStrategy string `json:"code_strategy,omitempty"`
+}
+
+// UpdateRequest changes a link in place. Nil fields are left unchanged.
+type UpdateRequest struct {
+	URL             *string            `json:"url,omitempty"`
+	ForwardQuery    *bool              `json:"forward_query,omitempty"`
+	QueryPrecedence *string            `json:"query_precedence,omitempty"`
+	UTMParams       *map[string]string `json:"utm_params,omitempty"`
+	BotPolicy       *string            `json:"bot_policy,omitempty"`
+	// Campaign moves the link to another campaign; "" takes it out of its
+	// campaign.
+	Campaign *string `json:"campaign,omitempty"`
+	// Tags replaces all of the link's tags.
+	Tags *[]string `json:"tags,omitempty"`
+	// Title and Description replace the link's notes; "" clears them.
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// Metadata replaces the whole map; an empty one removes it.
+	Metadata *map[string]any `json:"metadata,omitempty"`
+	// MaxClicks replaces the click limit; 0 removes it.
+	MaxClicks *int64 `json:"max_clicks,omitempty"`
+	// Preview replaces the whole preview; an empty one removes it.
+	Preview *LinkPreview `json:"preview,omitempty"`
+}
+
+// ListFilter narrows a listing: Broken to links whose last destination
+// check failed, Tags to links carrying every one of the tags, Campaign
+// to the links in that campaign and Metadata to links whose metadata has
+// every key set to the given string. Search matches a substring of the
+// code, destination, title, description or metadata. Workspace lists the
+// links of that workspace instead of those outside any.
+type ListFilter struct {
+	Broken    bool
+	Search    string
+	Tags      []string
+	Campaign  string
+	Metadata  map[string]string
+	Workspace string
+}
+
+// AuditEntry records one change to a link. Actor is the ID of the user
+// who made it, "operator" or "anonymous". Before is nil for creates and
+// restores, After for deletes.
+type AuditEntry struct {
+	ID          int64     `json:"id"`
+	Actor       string    `json:"actor"`
+	ActorEmail  string    `json:"actor_email,omitempty"`
+	Action      string    `json:"action"`
+	LinkID      string    `json:"link_id"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	Before      *URL      `json:"before,omitempty"`
+	After       *URL      `json:"after,omitempty"`
+	ClientIP    string    `json:"client_ip,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// AuditFilter narrows the audit log; zero fields match everything. Since
+// and Until bound the entries to [Since, Until).
+type AuditFilter struct {
+	Actor     string
+	Link      string
+	Since     time.Time
+	Until     time.Time
+	Workspace string
+}
+
+// Export and import formats.
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatYOURLS = "yourls"
+	FormatBitly  = "bitly"
+)
+
+type Domain struct {
+	ID          string    `json:"id"`
+	Host        string    `json:"host"`
+	FallbackURL string    `json:"fallback_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Webhook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Events      []string  `json:"events"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     string          `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	Status        string          `json:"status"`
+	ResponseCode  int             `json:"response_code,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Workspace roles, from least to most trusted.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// User is a person with their own API key, which is only set when they
+// sign up.
+type User struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name,omitempty"`
+	APIKey    string    `json:"api_key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Invite lets whoever holds Token join a workspace. Token is only set when
+// the invite is created.
+type Invite struct {
+	ID          string     `json:"id"`
+	WorkspaceID string     `json:"workspace_id"`
+	Email       string     `json:"email,omitempty"`
+	Role        string     `json:"role"`
+	Token       string     `json:"token,omitempty"`
+	InvitedBy   string     `json:"invited_by,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Status is the body of the health endpoints.
+type Status struct {
+	Status string `json:"status"`
+}
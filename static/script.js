@@ -15,17 +15,23 @@ form.addEventListener('submit', async (e) => {
 
         if (!res.ok) {
             result.className = 'error';
-            result.textContent = data.error;
+            result.textContent = data.detail || data.title;
             result.style.display = 'block';
             return;
         }
 
         const shortURL = `${location.origin}/${data.code}`;
+        const link = document.createElement('a');
+        link.href = shortURL;
+        link.target = '_blank';
+        link.textContent = shortURL;
+        const del = document.createElement('button');
+        del.className = 'delete-btn';
+        del.textContent = 'Delete';
+        del.addEventListener('click', () => deleteURL(data.id));
+
         result.className = '';
-        result.innerHTML = `
-            Short URL: <a href="${shortURL}" target="_blank">${shortURL}</a>
-            <br><button class="delete-btn" onclick="deleteURL(${data.id})">Delete</button>
-        `;
+        result.replaceChildren('Short URL: ', link, document.createElement('br'), del);
         result.style.display = 'block';
     } catch (err) {
         result.className = 'error';
@@ -35,7 +41,7 @@ form.addEventListener('submit', async (e) => {
 });
 
 async function deleteURL(id) {
-    const res = await fetch(`/url/${id}`, { method: 'DELETE' });
+    const res = await fetch(`/url/${encodeURIComponent(id)}`, { method: 'DELETE' });
     if (res.ok) {
         result.className = '';
         result.textContent = 'Deleted successfully';
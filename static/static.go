@@ -0,0 +1,9 @@
+// Package static embeds the public web page served at /.
+package static
+
+import "embed"
+
+// Files holds the page and its assets at the root of the FS.
+//
+//go:embed *.html *.css *.js
+var Files embed.FS